@@ -0,0 +1,125 @@
+// Command aurameval scores the email analyzer against the golden corpus in
+// internal/eval/testdata and optionally diffs the run against a previous one.
+//
+//	go run ./cmd/aurameval                              # replay recorded replies, no network
+//	go run ./cmd/aurameval -analyzer rules              # offline rules fallback
+//	go run ./cmd/aurameval -analyzer openai -record r.jsonl -out run.json
+//	go run ./cmd/aurameval -baseline run.json -fail-on-regression
+//	go run ./cmd/aurameval -analyzer openai -prompt candidate.tmpl -baseline run.json
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+
+	"github.com/joho/godotenv"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/eval"
+	"github.com/r7rainz/auramail/internal/prompt"
+)
+
+func main() {
+	_ = godotenv.Load()
+
+	if err := run(); err != nil {
+		slog.Error("aurameval failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var (
+		corpusPath    = flag.String("corpus", "internal/eval/testdata/corpus.jsonl", "golden corpus (JSON lines)")
+		analyzerName  = flag.String("analyzer", "replay", "analyzer to evaluate: replay, rules or openai")
+		responsesPath = flag.String("responses", "internal/eval/testdata/responses.jsonl", "recorded model replies for -analyzer replay")
+		recordPath    = flag.String("record", "", "write raw model replies here (openai analyzer only)")
+		outPath       = flag.String("out", "", "write the JSON report here")
+		baselinePath  = flag.String("baseline", "", "previous JSON report to diff against")
+		failOnRegress = flag.Bool("fail-on-regression", false, "exit non-zero when the diff has regressions")
+		promptPath    = flag.String("prompt", "", "analyze with this prompt template file instead of the embedded one")
+	)
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if *promptPath != "" {
+		data, err := os.ReadFile(*promptPath)
+		if err != nil {
+			return err
+		}
+		t, err := prompt.Parse(string(data), prompt.SourceDisk)
+		if err != nil {
+			return err
+		}
+		ai.SetAnalysisTemplate(t)
+	}
+
+	cases, err := eval.LoadCorpus(*corpusPath)
+	if err != nil {
+		return err
+	}
+
+	var analyzer eval.Analyzer
+	switch *analyzerName {
+	case "replay":
+		replies, err := eval.LoadResponses(*responsesPath)
+		if err != nil {
+			return err
+		}
+		analyzer = eval.ReplayAnalyzer{Replies: replies}
+	case "rules":
+		analyzer = eval.RulesAnalyzer{}
+	case "openai":
+		a := eval.OpenAIAnalyzer{}
+		if *recordPath != "" {
+			f, err := os.Create(*recordPath)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			a.Recorder = eval.NewRecorder(f)
+		}
+		analyzer = a
+	default:
+		return fmt.Errorf("unknown analyzer %q", *analyzerName)
+	}
+
+	report := eval.Run(ctx, analyzer, cases)
+	if err := report.WriteText(os.Stdout); err != nil {
+		return err
+	}
+
+	if *outPath != "" {
+		f, err := os.Create(*outPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := report.WriteJSON(f); err != nil {
+			return err
+		}
+	}
+
+	if *baselinePath == "" {
+		return nil
+	}
+	baseline, err := eval.LoadReport(*baselinePath)
+	if err != nil {
+		return err
+	}
+	diff := eval.Compare(baseline, report)
+	fmt.Println()
+	if err := diff.WriteText(os.Stdout); err != nil {
+		return err
+	}
+	if *failOnRegress && diff.HasRegressions() {
+		return fmt.Errorf("%d regressions against %s", len(diff.Regressions), *baselinePath)
+	}
+	return nil
+}
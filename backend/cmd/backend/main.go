@@ -13,10 +13,15 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/joho/godotenv"
+	"github.com/r7rainz/auramail/internal/ai"
 	"github.com/r7rainz/auramail/internal/app"
 	authgoogle "github.com/r7rainz/auramail/internal/auth/google"
+	"github.com/r7rainz/auramail/internal/calendar"
 	"github.com/r7rainz/auramail/internal/config"
+	"github.com/r7rainz/auramail/internal/digest"
 	"github.com/r7rainz/auramail/internal/gmail"
+	"github.com/r7rainz/auramail/internal/prompt"
+	"github.com/r7rainz/auramail/internal/push"
 	"github.com/r7rainz/auramail/internal/scheduler"
 	"github.com/r7rainz/auramail/internal/server"
 	"github.com/r7rainz/auramail/internal/user"
@@ -39,6 +44,9 @@ func run() error {
 	if err != nil {
 		return err
 	}
+	if _, err := push.KeysFromConfig(cfg); err != nil {
+		return err
+	}
 
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
@@ -54,6 +62,13 @@ func run() error {
 	}
 
 	userRepo := user.NewPostgresRepository(db)
+	loadPromptTemplates(ctx, cfg, userRepo)
+	go backfillCompensation(ctx, userRepo)
+	go func() {
+		// Opportunity matching compares companies, so link them first.
+		backfillCompanyLinks(ctx, userRepo)
+		backfillOpportunities(ctx, userRepo)
+	}()
 	syncScheduler := startEmailSyncScheduler(ctx, cfg, userRepo)
 	if syncScheduler != nil {
 		defer syncScheduler.Stop()
@@ -84,6 +99,34 @@ func run() error {
 	}
 }
 
+// promptReloadInterval is how long other instances take to pick up a
+// template activated through /admin/prompts on one of them.
+const promptReloadInterval = time.Minute
+
+// loadPromptTemplates activates the analysis prompt from the database or
+// PROMPT_TEMPLATE_DIR. A broken override is logged and the embedded
+// template stays active.
+func loadPromptTemplates(ctx context.Context, cfg *config.Config, userRepo *user.PostgresRepository) {
+	if err := reloadPromptTemplate(ctx, cfg, userRepo); err != nil {
+		slog.Error("failed to load prompt template, using embedded", "name", prompt.AnalyzeEmail, "err", err)
+	}
+}
+
+// reloadPromptTemplate activates the stored analysis prompt when it differs
+// from the active one. A failed load keeps the active template.
+func reloadPromptTemplate(ctx context.Context, cfg *config.Config, userRepo *user.PostgresRepository) error {
+	t, err := prompt.Load(ctx, prompt.AnalyzeEmail, cfg.PromptTemplateDir, userRepo)
+	if err != nil {
+		return err
+	}
+	if active := ai.AnalysisTemplate(); active.Text == t.Text && active.Source == t.Source {
+		return nil
+	}
+	ai.SetAnalysisTemplate(t)
+	slog.Info("prompt template loaded", "name", t.Name, "version", t.Version, "source", t.Source)
+	return nil
+}
+
 func startEmailSyncScheduler(ctx context.Context, cfg *config.Config, userRepo *user.PostgresRepository) *scheduler.Scheduler {
 	s := scheduler.New()
 	if cfg.SyncEnabled {
@@ -106,6 +149,30 @@ func startEmailSyncScheduler(ctx context.Context, cfg *config.Config, userRepo *
 		slog.Info("old email summaries cleaned up", "deleted", deleted, "retention", retention)
 		return nil
 	})
+	// Every instance serves /admin/prompts, so each one polls for
+	// templates activated elsewhere.
+	s.AddJob("prompt_reload", promptReloadInterval, func(jobCtx context.Context) error {
+		return reloadPromptTemplate(jobCtx, cfg, userRepo)
+	})
+	s.AddJob("calendar_reconcile", time.Hour, func(jobCtx context.Context) error {
+		return reconcileCalendarsForAllUsers(jobCtx, userRepo)
+	})
+	s.AddJob("embedding_index", time.Hour, func(jobCtx context.Context) error {
+		return indexMissingEmbeddings(jobCtx, cfg, userRepo)
+	})
+	if cfg.SMTPHost != "" {
+		// Digests go out at each user's chosen local time, so check often.
+		sender := digest.NewSender(userRepo, digest.NewSMTPMailer(cfg), cfg.SMTPFrom, cfg.DashboardURL())
+		s.AddJob("email_digest", 15*time.Minute, func(jobCtx context.Context) error {
+			sent, err := sender.Run(jobCtx)
+			if sent > 0 {
+				slog.Info("digests sent", "sent", sent)
+			}
+			return err
+		})
+	} else {
+		slog.Info("email digests disabled: SMTP_HOST not set")
+	}
 	s.Start(ctx)
 
 	slog.Info("background scheduler enabled", "syncEnabled", cfg.SyncEnabled, "syncInterval", cfg.SyncInterval, "retention", retention)
@@ -122,6 +189,9 @@ func syncPlacementEmailsForAllUsers(ctx context.Context, cfg *config.Config, use
 		return nil
 	}
 
+	opts := gmail.SyncOptionsFromConfig(cfg)
+	opts.Calendar = calendar.NewAutoScheduler(userRepo, calendar.GoogleClient{})
+	opts.Notifier = gmail.PushNotifier(cfg, userRepo)
 	for _, u := range users {
 		if err := ctx.Err(); err != nil {
 			return err
@@ -133,15 +203,16 @@ func syncPlacementEmailsForAllUsers(ctx context.Context, cfg *config.Config, use
 			continue
 		}
 
-		processed, err := gmail.SyncUserPlacementEmails(ctx, gmailService, userRepo, cfg.DefaultEmailQuery, u.ID, gmail.SyncOptions{
-			MaxResults:            cfg.SyncMaxResults,
-			IncludeThreadMessages: cfg.SyncIncludeThreads,
-		})
+		processed, err := gmail.SyncUserPlacementEmails(ctx, gmailService, userRepo, cfg.DefaultEmailQuery, u.ID, opts)
 		if err != nil {
 			if se, ok := err.(*gmail.SyncError); ok && se.Code == "NO_EMAILS_FOUND" {
 				slog.Debug("scheduled email sync: no matching emails", "userID", u.ID, "query", cfg.DefaultEmailQuery)
 				continue
 			}
+			if se, ok := err.(*gmail.SyncError); ok && se.Code == "AI_BUDGET_EXCEEDED" {
+				slog.Info("scheduled email sync: ai budget exhausted", "userID", u.ID, "processed", len(processed), "message", se.Message)
+				continue
+			}
 			slog.Error("scheduled email sync failed", "userID", u.ID, "err", err, "processed", len(processed))
 			continue
 		}
@@ -151,3 +222,106 @@ func syncPlacementEmailsForAllUsers(ctx context.Context, cfg *config.Config, use
 
 	return nil
 }
+
+// reconcileCalendarsForAllUsers moves automatically created events whose
+// email changed since they were scheduled, e.g. a corrected deadline.
+func reconcileCalendarsForAllUsers(ctx context.Context, userRepo *user.PostgresRepository) error {
+	users, err := userRepo.ListUsersWithGoogleRefreshToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	scheduler := calendar.NewAutoScheduler(userRepo, calendar.GoogleClient{})
+	for _, u := range users {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		changed, err := scheduler.Reconcile(ctx, u.ID)
+		if err != nil {
+			slog.Error("calendar reconcile failed", "userID", u.ID, "err", err, "changed", changed)
+			continue
+		}
+		if changed > 0 {
+			slog.Info("calendar reconciled", "userID", u.ID, "changed", changed)
+		}
+	}
+	return nil
+}
+
+// embeddingIndexBatch caps how many summaries one indexing run embeds.
+const embeddingIndexBatch = 200
+
+// indexMissingEmbeddings embeds summaries that have no vector for the
+// configured provider: those stored before semantic search, deferred while
+// a user was over budget, or stored under a different provider.
+func indexMissingEmbeddings(ctx context.Context, cfg *config.Config, userRepo *user.PostgresRepository) error {
+	opts := gmail.SyncOptionsFromConfig(cfg)
+	pending, err := userRepo.SummariesMissingEmbedding(ctx, opts.Embedder.Name(), embeddingIndexBatch)
+	if err != nil {
+		return err
+	}
+
+	byUser := make(map[string][]*ai.AIResult)
+	var userIDs []string
+	for _, p := range pending {
+		if _, ok := byUser[p.UserID]; !ok {
+			userIDs = append(userIDs, p.UserID)
+		}
+		byUser[p.UserID] = append(byUser[p.UserID], p.Summary)
+	}
+
+	indexed := 0
+	for _, userID := range userIDs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n, err := gmail.IndexSummaries(ctx, userRepo, userID, byUser[userID], opts)
+		indexed += n
+		if err != nil {
+			slog.Warn("embedding index: user skipped", "userID", userID, "err", err)
+		}
+	}
+	if indexed > 0 {
+		slog.Info("embedding index complete", "indexed", indexed, "provider", opts.Embedder.Name())
+	}
+	return nil
+}
+
+// backfillCompensation normalizes compensation for summaries stored before
+// the comp_* columns existed. It is a no-op once every row is filled.
+func backfillCompensation(ctx context.Context, userRepo *user.PostgresRepository) {
+	updated, err := userRepo.BackfillCompensation(ctx)
+	if err != nil {
+		slog.Error("compensation backfill failed", "err", err, "updated", updated)
+		return
+	}
+	if updated > 0 {
+		slog.Info("compensation backfill complete", "updated", updated)
+	}
+}
+
+// backfillCompanyLinks links summaries stored before company resolution
+// existed to their canonical company.
+func backfillCompanyLinks(ctx context.Context, userRepo *user.PostgresRepository) {
+	linked, err := userRepo.BackfillCompanyLinks(ctx)
+	if err != nil {
+		slog.Error("company backfill failed", "err", err, "linked", linked)
+		return
+	}
+	if linked > 0 {
+		slog.Info("company backfill complete", "linked", linked)
+	}
+}
+
+// backfillOpportunities fingerprints summaries stored before duplicate
+// detection and links resends to the opportunity they repeat.
+func backfillOpportunities(ctx context.Context, userRepo *user.PostgresRepository) {
+	updated, err := userRepo.BackfillOpportunities(ctx)
+	if err != nil {
+		slog.Error("opportunity backfill failed", "err", err, "updated", updated)
+		return
+	}
+	if updated > 0 {
+		slog.Info("opportunity backfill complete", "updated", updated)
+	}
+}
@@ -0,0 +1,22 @@
+// Command vapidkeys prints a new VAPID key pair for Web Push, ready to
+// paste into .env.
+//
+//	go run ./cmd/vapidkeys
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/r7rainz/auramail/internal/push"
+)
+
+func main() {
+	public, private, err := push.GenerateKeys()
+	if err != nil {
+		slog.Error("vapidkeys failed", "error", err)
+		os.Exit(1)
+	}
+	fmt.Printf("VAPID_PUBLIC_KEY=%s\nVAPID_PRIVATE_KEY=%s\n", public, private)
+}
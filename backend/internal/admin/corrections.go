@@ -0,0 +1,37 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/r7rainz/auramail/internal/response"
+)
+
+// CorrectionRepository is the subset of the user repository the correction
+// export depends on.
+type CorrectionRepository interface {
+	ListCorrectionCases(ctx context.Context) ([]json.RawMessage, error)
+}
+
+// ExportCorrections streams the evaluation cases recorded from user
+// corrections as JSON lines, ready to pass to aurameval -corpus.
+func (h *Handler) ExportCorrections(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	cases, err := h.repo.ListCorrectionCases(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to list corrections", "err", err)
+		response.InternalError(w, "Failed to export corrections")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="corrections.jsonl"`)
+	for _, c := range cases {
+		if _, err := w.Write(append(c, '\n')); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package admin
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/prompt"
+	"github.com/r7rainz/auramail/internal/redact"
+	"github.com/r7rainz/auramail/internal/response"
+	"github.com/r7rainz/auramail/internal/user"
+)
+
+// UsageRepository is the subset of the user repository the admin endpoints
+// depend on.
+type UsageRepository interface {
+	ListAIUsage(ctx context.Context, from, to time.Time) ([]user.AIUsage, error)
+}
+
+// Repository is everything the admin endpoints need from storage.
+type Repository interface {
+	UsageRepository
+	PromptRepository
+	CorrectionRepository
+}
+
+type Handler struct {
+	repo     Repository
+	redactor *redact.Redactor
+	// analyze runs a prompt preview; tests replace the model call.
+	analyze func(ctx context.Context, t *prompt.Template, subject, snippet, body string, langs ai.Languages) (*ai.AIResult, ai.Usage, error)
+}
+
+// NewHandler returns the admin handler. redactor masks personal data in
+// emails sent to the model for prompt previews.
+func NewHandler(repo Repository, redactor *redact.Redactor) *Handler {
+	return &Handler{repo: repo, redactor: redactor, analyze: ai.AnalyzeEmailWith}
+}
+
+// UsageSummary aggregates AI usage along one dimension (user, query or day).
+type UsageSummary struct {
+	Key              string  `json:"key"`
+	UserID           string  `json:"userId,omitempty"`
+	Email            string  `json:"email,omitempty"`
+	Requests         int64   `json:"requests"`
+	PromptTokens     int64   `json:"promptTokens"`
+	CompletionTokens int64   `json:"completionTokens"`
+	TotalTokens      int64   `json:"totalTokens"`
+	CostUSD          float64 `json:"costUsd"`
+}
+
+func (s *UsageSummary) add(row user.AIUsage) {
+	s.Requests += row.Requests
+	s.PromptTokens += row.PromptTokens
+	s.CompletionTokens += row.CompletionTokens
+	s.TotalTokens += row.PromptTokens + row.CompletionTokens
+	s.CostUSD += row.CostUSD
+}
+
+const dateLayout = "2006-01-02"
+
+// GetUsage reports AI token usage and estimated cost between the optional
+// from/to query params (YYYY-MM-DD, default: the last 7 days), broken down
+// by user, Gmail query and day so the biggest spenders are easy to spot.
+func (h *Handler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	to := time.Now().UTC()
+	from := to.AddDate(0, 0, -6)
+	if v := r.URL.Query().Get("to"); v != "" {
+		parsed, err := time.Parse(dateLayout, v)
+		if err != nil {
+			response.BadRequest(w, "to must be a date in YYYY-MM-DD format", nil)
+			return
+		}
+		to = parsed
+	}
+	if v := r.URL.Query().Get("from"); v != "" {
+		parsed, err := time.Parse(dateLayout, v)
+		if err != nil {
+			response.BadRequest(w, "from must be a date in YYYY-MM-DD format", nil)
+			return
+		}
+		from = parsed
+	}
+	if from.After(to) {
+		response.BadRequest(w, "from must not be after to", nil)
+		return
+	}
+
+	rows, err := h.repo.ListAIUsage(ctx, from, to)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to list ai usage", "err", err)
+		response.InternalError(w, "Failed to load AI usage")
+		return
+	}
+
+	var total UsageSummary
+	byUser := make(map[string]*UsageSummary)
+	byQuery := make(map[string]*UsageSummary)
+	byDay := make(map[string]*UsageSummary)
+	for _, row := range rows {
+		total.add(row)
+		summarize(byUser, row.UserID, row).Email = row.Email
+		byUser[row.UserID].UserID = row.UserID
+		summarize(byQuery, row.Query, row)
+		summarize(byDay, row.Day.Format(dateLayout), row)
+	}
+	total.Key = "total"
+
+	response.Success(w, map[string]any{
+		"from":    from.Format(dateLayout),
+		"to":      to.Format(dateLayout),
+		"total":   total,
+		"users":   sortedByCost(byUser),
+		"queries": sortedByCost(byQuery),
+		"days":    sortedByKey(byDay),
+	})
+}
+
+func summarize(groups map[string]*UsageSummary, key string, row user.AIUsage) *UsageSummary {
+	s, ok := groups[key]
+	if !ok {
+		s = &UsageSummary{Key: key}
+		groups[key] = s
+	}
+	s.add(row)
+	return s
+}
+
+func sortedByCost(groups map[string]*UsageSummary) []*UsageSummary {
+	out := make([]*UsageSummary, 0, len(groups))
+	for _, s := range groups {
+		out = append(out, s)
+	}
+	slices.SortFunc(out, func(a, b *UsageSummary) int {
+		if c := cmp.Compare(b.CostUSD, a.CostUSD); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.TotalTokens, a.TotalTokens)
+	})
+	return out
+}
+
+func sortedByKey(groups map[string]*UsageSummary) []*UsageSummary {
+	out := make([]*UsageSummary, 0, len(groups))
+	for _, s := range groups {
+		out = append(out, s)
+	}
+	slices.SortFunc(out, func(a, b *UsageSummary) int {
+		return cmp.Compare(a.Key, b.Key)
+	})
+	return out
+}
@@ -0,0 +1,152 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/redact"
+	"github.com/r7rainz/auramail/internal/user"
+)
+
+type fakeUsageRepo struct {
+	rows     []user.AIUsage
+	err      error
+	from, to time.Time
+	// summaries, saved and usage back the prompt endpoints.
+	summaries map[string]*ai.AIResult
+	saved     map[string]string
+	usage     []ai.Usage
+	// cases backs the correction export.
+	cases []json.RawMessage
+}
+
+func (f *fakeUsageRepo) ListAIUsage(ctx context.Context, from, to time.Time) ([]user.AIUsage, error) {
+	f.from, f.to = from, to
+	return f.rows, f.err
+}
+
+func (f *fakeUsageRepo) GetSummary(ctx context.Context, gmailID string) (*ai.AIResult, error) {
+	if res, ok := f.summaries[gmailID]; ok {
+		return res, nil
+	}
+	return nil, pgx.ErrNoRows
+}
+
+func (f *fakeUsageRepo) SavePromptTemplate(ctx context.Context, name, version, text string) error {
+	if f.saved == nil {
+		f.saved = make(map[string]string)
+	}
+	if prev, ok := f.saved[version]; ok && prev != text {
+		return user.ErrPromptVersionExists
+	}
+	f.saved[version] = text
+	return nil
+}
+
+func (f *fakeUsageRepo) ListCorrectionCases(ctx context.Context) ([]json.RawMessage, error) {
+	return f.cases, f.err
+}
+
+func (f *fakeUsageRepo) RecordAIUsage(ctx context.Context, userID, query string, usage ai.Usage) error {
+	f.usage = append(f.usage, usage)
+	return nil
+}
+
+func TestGetUsage_AggregatesByUserQueryAndDay(t *testing.T) {
+	day1 := time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)
+	day2 := time.Date(2026, 8, 2, 0, 0, 0, 0, time.UTC)
+	repo := &fakeUsageRepo{rows: []user.AIUsage{
+		{UserID: "1", Email: "a@x.com", Day: day1, Query: "q1", Requests: 2, PromptTokens: 100, CompletionTokens: 10, CostUSD: 0.10},
+		{UserID: "2", Email: "b@x.com", Day: day1, Query: "q2", Requests: 1, PromptTokens: 900, CompletionTokens: 90, CostUSD: 0.90},
+		{UserID: "1", Email: "a@x.com", Day: day2, Query: "q2", Requests: 1, PromptTokens: 50, CompletionTokens: 5, CostUSD: 0.05},
+	}}
+	h := NewHandler(repo, redact.Default())
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/usage?from=2026-08-01&to=2026-08-02", nil)
+	rr := httptest.NewRecorder()
+	h.GetUsage(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if !repo.from.Equal(day1) || !repo.to.Equal(day2) {
+		t.Errorf("repo called with %v..%v", repo.from, repo.to)
+	}
+
+	var body struct {
+		Data struct {
+			Total   UsageSummary   `json:"total"`
+			Users   []UsageSummary `json:"users"`
+			Queries []UsageSummary `json:"queries"`
+			Days    []UsageSummary `json:"days"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if body.Data.Total.Requests != 4 || body.Data.Total.TotalTokens != 1155 {
+		t.Errorf("unexpected total: %+v", body.Data.Total)
+	}
+	if len(body.Data.Users) != 2 || body.Data.Users[0].Email != "b@x.com" {
+		t.Errorf("expected users sorted by cost with b@x.com first, got %+v", body.Data.Users)
+	}
+	if len(body.Data.Queries) != 2 || body.Data.Queries[0].Key != "q2" || body.Data.Queries[0].Requests != 2 {
+		t.Errorf("unexpected queries: %+v", body.Data.Queries)
+	}
+	if len(body.Data.Days) != 2 || body.Data.Days[0].Key != "2026-08-01" {
+		t.Errorf("unexpected days: %+v", body.Data.Days)
+	}
+}
+
+func TestGetUsage_InvalidDates(t *testing.T) {
+	h := NewHandler(&fakeUsageRepo{}, redact.Default())
+	for _, target := range []string{
+		"/admin/usage?from=yesterday",
+		"/admin/usage?to=2026-13-01",
+		"/admin/usage?from=2026-08-05&to=2026-08-01",
+	} {
+		rr := httptest.NewRecorder()
+		h.GetUsage(rr, httptest.NewRequest(http.MethodGet, target, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", target, rr.Code)
+		}
+	}
+}
+
+func TestGetUsage_RepoError(t *testing.T) {
+	h := NewHandler(&fakeUsageRepo{err: errors.New("db down")}, redact.Default())
+	rr := httptest.NewRecorder()
+	h.GetUsage(rr, httptest.NewRequest(http.MethodGet, "/admin/usage", nil))
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", rr.Code)
+	}
+}
+
+func TestExportCorrections_WritesJSONLines(t *testing.T) {
+	repo := &fakeUsageRepo{cases: []json.RawMessage{
+		json.RawMessage(`{"id":"m1","expected":{"category":"exam"}}`),
+		json.RawMessage(`{"id":"m2","expected":{"category":"internship"}}`),
+	}}
+	h := NewHandler(repo, redact.Default())
+
+	rr := httptest.NewRecorder()
+	h.ExportCorrections(rr, httptest.NewRequest(http.MethodGet, "/admin/corrections/export", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	want := `{"id":"m1","expected":{"category":"exam"}}` + "\n" + `{"id":"m2","expected":{"category":"internship"}}` + "\n"
+	if rr.Body.String() != want {
+		t.Errorf("body = %q, want %q", rr.Body.String(), want)
+	}
+}
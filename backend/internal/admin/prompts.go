@@ -0,0 +1,180 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/auth"
+	"github.com/r7rainz/auramail/internal/lang"
+	"github.com/r7rainz/auramail/internal/prompt"
+	"github.com/r7rainz/auramail/internal/response"
+	"github.com/r7rainz/auramail/internal/user"
+)
+
+// previewQuery tags the AI usage of prompt previews in /admin/usage.
+const previewQuery = "admin:prompt-preview"
+
+// PromptRepository is the subset of the user repository the prompt
+// endpoints depend on.
+type PromptRepository interface {
+	GetSummary(ctx context.Context, gmailID string) (*ai.AIResult, error)
+	SavePromptTemplate(ctx context.Context, name, version, text string) error
+	RecordAIUsage(ctx context.Context, userID, query string, usage ai.Usage) error
+}
+
+type promptRequest struct {
+	Template string `json:"template"`
+}
+
+type previewRequest struct {
+	GmailMessageID string `json:"gmailMessageId"`
+	// Template is a candidate template file; empty previews the active
+	// one.
+	Template string `json:"template"`
+}
+
+// GetPrompt returns the active prompt template.
+func (h *Handler) GetPrompt(w http.ResponseWriter, r *http.Request) {
+	if r.PathValue("name") != prompt.AnalyzeEmail {
+		response.NotFound(w, "Unknown prompt template")
+		return
+	}
+	writePrompt(w, ai.AnalysisTemplate())
+}
+
+// UpdatePrompt saves a new template version and makes it active on this
+// instance. Other instances load it on their next scheduled prompt reload,
+// within a minute. Results analyzed under it carry the new version.
+func (h *Handler) UpdatePrompt(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	name := r.PathValue("name")
+	if name != prompt.AnalyzeEmail {
+		response.NotFound(w, "Unknown prompt template")
+		return
+	}
+
+	var req promptRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "Invalid request body", nil)
+		return
+	}
+	t, err := prompt.Parse(req.Template, prompt.SourceDB)
+	if err != nil {
+		response.BadRequest(w, err.Error(), nil)
+		return
+	}
+	if t.Name != name {
+		response.BadRequest(w, "Template name does not match the URL", nil)
+		return
+	}
+
+	if err := h.repo.SavePromptTemplate(ctx, t.Name, t.Version, t.Text); err != nil {
+		if errors.Is(err, user.ErrPromptVersionExists) {
+			response.Conflict(w, "This version already exists with different text; bump the version")
+			return
+		}
+		slog.ErrorContext(ctx, "failed to save prompt template", "err", err)
+		response.InternalError(w, "Failed to save prompt template")
+		return
+	}
+	ai.SetAnalysisTemplate(t)
+	slog.InfoContext(ctx, "prompt template activated", "name", t.Name, "version", t.Version)
+
+	writePrompt(w, t)
+}
+
+// PreviewPrompt analyzes a stored email under a candidate template and
+// returns the result next to the stored one. Nothing is saved.
+func (h *Handler) PreviewPrompt(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req previewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "Invalid request body", nil)
+		return
+	}
+	req.GmailMessageID = strings.TrimSpace(req.GmailMessageID)
+	if req.GmailMessageID == "" {
+		response.BadRequest(w, "gmailMessageId is required", nil)
+		return
+	}
+
+	t := ai.AnalysisTemplate()
+	if req.Template != "" {
+		candidate, err := prompt.Parse(req.Template, "preview")
+		if err != nil {
+			response.BadRequest(w, err.Error(), nil)
+			return
+		}
+		if candidate.Name != prompt.AnalyzeEmail {
+			response.BadRequest(w, "Only the analyze_email template can be previewed", nil)
+			return
+		}
+		t = candidate
+	}
+	rendered, err := t.Render()
+	if err != nil {
+		response.BadRequest(w, err.Error(), nil)
+		return
+	}
+
+	stored, err := h.repo.GetSummary(ctx, req.GmailMessageID)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && stored == nil) {
+		response.NotFound(w, "Email not found")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load summary for preview", "err", err)
+		response.InternalError(w, "Failed to load email")
+		return
+	}
+
+	// Previews follow the same privacy rules as sync.
+	subject, snippet, body := stored.Subject, stored.Snippet, ""
+	if stored.Description != nil {
+		body = *stored.Description
+	}
+	langs := ai.Languages{Email: lang.Detect(subject + "\n" + body)}
+	session := h.redactor.NewSession()
+	subject, snippet, body = session.Redact(subject), session.Redact(snippet), session.Redact(body)
+
+	result, usage, err := h.analyze(ctx, t, subject, snippet, body, langs)
+	if !usage.IsZero() {
+		adminID, _ := ctx.Value(auth.UserIDContextKey).(string)
+		if usageErr := h.repo.RecordAIUsage(ctx, adminID, previewQuery, usage); usageErr != nil {
+			slog.WarnContext(ctx, "failed to record preview usage", "err", usageErr)
+		}
+	}
+	if errors.Is(err, ai.ErrOpenAIKeyMissing) {
+		response.ServiceUnavailable(w, "OpenAI is not configured")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "prompt preview failed", "err", err)
+		response.Error(w, http.StatusBadGateway, response.ErrCodeServiceUnavail, "The model did not return a usable analysis", err.Error())
+		return
+	}
+	result = session.RestoreResult(result)
+
+	response.Success(w, map[string]any{
+		"template":  t,
+		"prompt":    rendered,
+		"stored":    stored,
+		"candidate": result,
+	})
+}
+
+func writePrompt(w http.ResponseWriter, t *prompt.Template) {
+	response.Success(w, map[string]any{
+		"template": t,
+		"text":     t.Text,
+	})
+}
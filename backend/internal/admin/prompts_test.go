@@ -0,0 +1,135 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/prompt"
+	"github.com/r7rainz/auramail/internal/redact"
+)
+
+const candidateTemplate = `{
+  "name": "analyze_email",
+  "version": "candidate-v1",
+  "schemaVersion": 1,
+  "categories": [{"name": "exam", "description": "Assessments"}],
+  "tags": ["urgent"]
+}
+---
+Pick one of:{{range .Categories}} {{.Name}}{{end}}`
+
+func jsonBody(t *testing.T, v any) *strings.Reader {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.NewReader(string(data))
+}
+
+func TestPreviewPrompt_AnalyzesStoredEmailUnderCandidate(t *testing.T) {
+	body := "OA on 14 Aug. Queries: 9876543210"
+	repo := &fakeUsageRepo{summaries: map[string]*ai.AIResult{
+		"m1": {GmailMessageID: "m1", Subject: "Amazon OA", Category: "announcement", Description: &body},
+	}}
+	h := NewHandler(repo, redact.Default())
+
+	var sentBody string
+	var used *prompt.Template
+	h.analyze = func(ctx context.Context, tmpl *prompt.Template, subject, snippet, body string, langs ai.Languages) (*ai.AIResult, ai.Usage, error) {
+		used, sentBody = tmpl, body
+		return &ai.AIResult{Category: "exam", Summary: "Call [PHONE_1]", AnalysisVersion: tmpl.Version}, ai.Usage{Operation: ai.OperationAnalyzeEmail, PromptTokens: 10}, nil
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/prompts/preview", jsonBody(t, map[string]string{
+		"gmailMessageId": "m1",
+		"template":       candidateTemplate,
+	}))
+	rr := httptest.NewRecorder()
+	h.PreviewPrompt(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	if used == nil || used.Version != "candidate-v1" {
+		t.Fatalf("preview did not use the candidate template: %+v", used)
+	}
+	if strings.Contains(sentBody, "9876543210") {
+		t.Errorf("phone number reached the model: %q", sentBody)
+	}
+	if len(repo.usage) != 1 {
+		t.Errorf("expected preview usage to be recorded, got %d", len(repo.usage))
+	}
+	if ai.AnalysisVersion() == "candidate-v1" {
+		t.Error("preview must not activate the candidate")
+	}
+
+	var resp struct {
+		Data struct {
+			Prompt    string      `json:"prompt"`
+			Stored    ai.AIResult `json:"stored"`
+			Candidate ai.AIResult `json:"candidate"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Data.Prompt != "Pick one of: exam" {
+		t.Errorf("prompt = %q", resp.Data.Prompt)
+	}
+	if resp.Data.Stored.Category != "announcement" || resp.Data.Candidate.Category != "exam" {
+		t.Errorf("unexpected comparison: stored %q, candidate %q", resp.Data.Stored.Category, resp.Data.Candidate.Category)
+	}
+	if resp.Data.Candidate.Summary != "Call 9876543210" {
+		t.Errorf("candidate summary not restored: %q", resp.Data.Candidate.Summary)
+	}
+}
+
+func TestPreviewPrompt_Errors(t *testing.T) {
+	h := NewHandler(&fakeUsageRepo{}, redact.Default())
+	for name, tc := range map[string]struct {
+		body map[string]string
+		want int
+	}{
+		"missing id":     {map[string]string{}, http.StatusBadRequest},
+		"bad template":   {map[string]string{"gmailMessageId": "m1", "template": "no header"}, http.StatusBadRequest},
+		"unknown email":  {map[string]string{"gmailMessageId": "nope"}, http.StatusNotFound},
+		"wrong template": {map[string]string{"gmailMessageId": "m1", "template": strings.Replace(candidateTemplate, "analyze_email", "other", 1)}, http.StatusBadRequest},
+	} {
+		rr := httptest.NewRecorder()
+		h.PreviewPrompt(rr, httptest.NewRequest(http.MethodPost, "/admin/prompts/preview", jsonBody(t, tc.body)))
+		if rr.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d", name, tc.want, rr.Code)
+		}
+	}
+}
+
+func TestUpdatePrompt_SavesAndActivates(t *testing.T) {
+	original := ai.AnalysisTemplate()
+	defer ai.SetAnalysisTemplate(original)
+
+	repo := &fakeUsageRepo{}
+	h := NewHandler(repo, redact.Default())
+	update := func(text string) int {
+		req := httptest.NewRequest(http.MethodPut, "/admin/prompts/analyze_email", jsonBody(t, map[string]string{"template": text}))
+		req.SetPathValue("name", prompt.AnalyzeEmail)
+		rr := httptest.NewRecorder()
+		h.UpdatePrompt(rr, req)
+		return rr.Code
+	}
+
+	if code := update(candidateTemplate); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if ai.AnalysisVersion() != "candidate-v1" || repo.saved["candidate-v1"] == "" {
+		t.Errorf("candidate not saved and activated: version %q", ai.AnalysisVersion())
+	}
+	if code := update(candidateTemplate + " changed"); code != http.StatusConflict {
+		t.Errorf("expected 409 for edited text under the same version, got %d", code)
+	}
+}
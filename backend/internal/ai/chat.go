@@ -0,0 +1,71 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// StreamChat sends a single-turn chat to the model and calls onDelta with
+// each piece of the reply as it arrives. An error from onDelta (e.g. the
+// client went away) stops the stream and is returned. The model only
+// reports usage at the end of a stream, so a stream that stops early is
+// charged an estimate of the prompt and of the reply received so far.
+func StreamChat(ctx context.Context, operation, systemPrompt, userPrompt string, onDelta func(string) error) (Usage, error) {
+	c := getClient()
+	if c == nil {
+		return Usage{}, ErrOpenAIKeyMissing
+	}
+
+	model := openai.GPT4oMini
+	stream, err := c.CreateChatCompletionStream(ctx, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
+			{Role: openai.ChatMessageRoleUser, Content: userPrompt},
+		},
+		Temperature:   0.1,
+		StreamOptions: &openai.StreamOptions{IncludeUsage: true},
+	})
+	if err != nil {
+		return Usage{}, fmt.Errorf("openai error: %w", err)
+	}
+	defer stream.Close()
+
+	var (
+		usage    Usage
+		reported bool
+		reply    strings.Builder
+	)
+	charged := func() Usage {
+		if reported {
+			return usage
+		}
+		return estimatedUsage(operation, model, systemPrompt+userPrompt, reply.String())
+	}
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return charged(), nil
+		}
+		if err != nil {
+			return charged(), fmt.Errorf("openai stream error: %w", err)
+		}
+		if resp.Usage != nil {
+			usage, reported = newUsage(operation, model, *resp.Usage), true
+		}
+		for _, choice := range resp.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			reply.WriteString(choice.Delta.Content)
+			if err := onDelta(choice.Delta.Content); err != nil {
+				return charged(), err
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// ReplyRequest is the email and student details DraftReply writes from.
+type ReplyRequest struct {
+	Subject string
+	Sender  string
+	Summary string
+	Body    string
+	// Fields are the "Name: value" lines the email asked for; values the
+	// student has not provided are "[placeholders]".
+	Fields      []string
+	StudentName string
+	FormLinks   []string
+}
+
+// DraftReply asks the model for the body of a short, formal reply to a
+// placement email. The reply is only ever shown to the student or saved as
+// a draft, never sent.
+func DraftReply(ctx context.Context, req ReplyRequest) (string, Usage, error) {
+	c := getClient()
+	if c == nil {
+		return "", Usage{}, ErrOpenAIKeyMissing
+	}
+
+	systemPrompt := `You write replies from a university student to their placement office or a recruiter.
+Return ONLY a valid JSON object of the form {"body": "..."}.
+
+RULES:
+- Plain text, formal and brief: a greeting, one or two sentences, the requested details, and a sign-off with the student's name.
+- Include every detail line you are given exactly as written, one per line, including [placeholders]; never invent values.
+- If registration form links are given, say the student will complete the form; do not paste the links.
+- Do not add a subject line.`
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Email from %s\nSubject: %s\nSummary: %s\n", req.Sender, req.Subject, req.Summary)
+	if body := strings.TrimSpace(req.Body); body != "" {
+		body = truncateBytes(body, 8000)
+		fmt.Fprintf(&b, "Body:\n%s\n", body)
+	}
+	fmt.Fprintf(&b, "\nStudent name: %s\n", req.StudentName)
+	if len(req.Fields) > 0 {
+		b.WriteString("Details to include:\n")
+		for _, f := range req.Fields {
+			b.WriteString(f + "\n")
+		}
+	}
+	if len(req.FormLinks) > 0 {
+		fmt.Fprintf(&b, "Registration form links: %s\n", strings.Join(req.FormLinks, ", "))
+	}
+
+	model := openai.GPT4oMini
+	resp, err := c.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
+			{Role: openai.ChatMessageRoleUser, Content: b.String()},
+		},
+		Temperature: 0.2,
+		ResponseFormat: &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+		},
+	})
+	if err != nil {
+		return "", Usage{}, fmt.Errorf("openai error: %w", err)
+	}
+	usage := newUsage(OperationDraftReply, model, resp.Usage)
+	if len(resp.Choices) == 0 {
+		return "", usage, ErrInvalidModelReply
+	}
+
+	var reply struct {
+		Body string `json:"body"`
+	}
+	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &reply); err != nil || strings.TrimSpace(reply.Body) == "" {
+		return "", usage, ErrInvalidModelReply
+	}
+	return strings.TrimSpace(reply.Body) + "\n", usage, nil
+}
+
+// truncateBytes shortens s to at most n bytes without splitting a UTF-8
+// character.
+func truncateBytes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,26 @@
+package ai
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateBytes(t *testing.T) {
+	tests := []struct {
+		in   string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"abcdef", 3, "abc"},
+		{"₹50000", 2, ""},
+		{"a₹b", 3, "a"},
+		{"a₹b", 4, "a₹"},
+	}
+	for _, tt := range tests {
+		got := truncateBytes(tt.in, tt.n)
+		if got != tt.want || !utf8.ValidString(got) {
+			t.Errorf("truncateBytes(%q, %d) = %q, want %q", tt.in, tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,427 @@
+package ai
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/r7rainz/auramail/internal/lang"
+	"github.com/r7rainz/auramail/internal/utils"
+)
+
+// RulesAnalysisVersion marks results produced by the offline heuristic
+// analyzer so they can be re-analyzed once the LLM is reachable again.
+const RulesAnalysisVersion = "rules-v1"
+
+// categoryRule maps keywords to a category. Rules are checked in order, so
+// more specific categories come first; the subject is checked before the
+// body because it is the strongest signal.
+type categoryRule struct {
+	category string
+	keywords []string
+}
+
+var categoryRules = []categoryRule{
+	{"result", []string{"shortlist", "shortlisted", "selected candidates", "selection list", "result", "results", "congratulations"}},
+	{"interview", []string{"interview", "interviews", "hr round", "technical round"}},
+	{"exam", []string{"online assessment", "online test", "coding round", "aptitude", "assessment", "oa"}},
+	{"ppt", []string{"pre-placement talk", "pre placement talk", "ppt"}},
+	{"workshop", []string{"workshop", "bootcamp", "hackathon", "webinar", "training session"}},
+	{"reminder", []string{"reminder", "last date", "extended", "closing today"}},
+	{"internship", []string{"internship", "internships", "intern", "interns"}},
+	{"job offer", []string{"full time", "full-time", "fte", "job offer", "placement drive", "campus recruitment", "hiring"}},
+	{"registration", []string{"register", "registration", "sign up", "apply now"}},
+}
+
+var tagRules = []struct {
+	tag      string
+	keywords []string
+}{
+	{"urgent", []string{"urgent", "immediately", "asap", "closing today", "today itself"}},
+	{"remote", []string{"remote"}},
+	{"hybrid", []string{"hybrid"}},
+	{"wfh", []string{"work from home", "wfh"}},
+	{"on-campus", []string{"on-campus", "on campus"}},
+	{"off-campus", []string{"off-campus", "off campus"}},
+	{"startup", []string{"startup", "start-up"}},
+	{"mnc", []string{"mnc", "multinational"}},
+	{"govt", []string{"government", "govt"}},
+	{"psu", []string{"psu", "public sector"}},
+	{"mass-hiring", []string{"mass hiring", "mass recruitment", "bulk hiring"}},
+	{"fresher-friendly", []string{"fresher", "freshers"}},
+	{"core", []string{"core company", "mechanical", "electrical", "civil"}},
+	{"it", []string{"software", "developer", "sde", "it services"}},
+}
+
+var dreamCompanies = []string{
+	"google", "microsoft", "amazon", "apple", "meta", "adobe", "atlassian",
+	"goldman sachs", "uber", "salesforce", "nvidia", "flipkart",
+}
+
+var (
+	companyLineRe = regexp.MustCompile(`(?im)^\s*(?:company(?:\s+name)?|organi[sz]ation|employer)\s*[:\-–]\s*(.+)$`)
+	roleLineRe    = regexp.MustCompile(`(?im)^\s*(?:role|designation|position|job\s+title|profile)\s*[:\-–]\s*(.+)$`)
+	subjectCoRe   = regexp.MustCompile(`(?i)^(?:(?:re|fwd?|reminder)\s*:\s*)*(.+?)\s+(?:[-–|:]\s*)?(?:campus\s+recruitment|recruitment\s+drive|hiring|placement\s+drive|internship|off[- ]campus\s+drive)`)
+	deadlineCueRe = regexp.MustCompile(`(?i)(?:last\s+date|deadline|apply\s+by|register\s+by|registration\s+closes|on\s+or\s+before|before)[^\n]{0,40}`)
+	lpaRe         = regexp.MustCompile(`(?i)(\d+(?:\.\d+)?)\s*(?:lpa|lakhs?\s+per\s+annum|lakh)`)
+
+	eligibilityLineRe = regexp.MustCompile(`(?i)(eligib|cgpa|percentage|backlog|batch|pass[- ]?out|branch)`)
+	locationLineRe    = regexp.MustCompile(`(?im)^\s*(?:job\s+)?location\s*[:\-–]\s*(.+)$`)
+	salaryLineRe      = regexp.MustCompile(`(?i)(ctc|stipend|salary|package|lpa)`)
+	timingLineRe      = regexp.MustCompile(`(?i)(date\s+of\s+(?:test|interview|drive)|reporting\s+time|time\s*:|venue|slot)`)
+)
+
+// dateLayouts are the explicit date formats recognised in email bodies,
+// tried in order after ordinal suffixes ("1st", "22nd") are stripped.
+var dateLayouts = []string{
+	"2006-01-02",
+	"02/01/2006",
+	"2/1/2006",
+	"02-01-2006",
+	"2-1-2006",
+	"02.01.2006",
+	"2 January 2006",
+	"2 Jan 2006",
+	"January 2 2006",
+	"Jan 2 2006",
+	"2 January",
+	"2 Jan",
+}
+
+var (
+	ordinalRe   = regexp.MustCompile(`(?i)\b(\d{1,2})(st|nd|rd|th)\b`)
+	dateTokenRe = regexp.MustCompile(`(?i)\b(\d{4}-\d{2}-\d{2}|\d{1,2}[/.\-]\d{1,2}[/.\-]\d{4}|\d{1,2}\s+[a-z]{3,9},?(?:\s+\d{4})?|[a-z]{3,9}\s+\d{1,2},?\s+\d{4})\b`)
+)
+
+// AnalyzeEmailRules is the deterministic fallback used when the LLM is not
+// configured or keeps failing. It fills the same fields as AnalyzeEmail from
+// keyword tables and regexes; links come from utils.ExtractLinkDetails.
+func AnalyzeEmailRules(subject, snippet, body string, links []utils.ExtractedLink, now time.Time) *AIResult {
+	text := subject + "\n" + snippet + "\n" + body
+	lower := strings.ToLower(text)
+
+	res := &AIResult{
+		AnalysisVersion: RulesAnalysisVersion,
+		SummaryLanguage: lang.English,
+		Category:        ruleCategory(subject, lower),
+		Tags:            ruleTags(lower),
+		OtherLinks:      []string{},
+	}
+
+	res.Company = ruleCompany(subject, body)
+	res.Role = firstSubmatch(roleLineRe, body)
+	res.Deadline = ruleDeadline(text, now)
+	res.Eligibility = bulletLines(body, eligibilityLineRe)
+	res.Salary = bulletLines(body, salaryLineRe)
+	res.Timings = bulletLines(body, timingLineRe)
+	if loc := firstSubmatch(locationLineRe, body); loc != nil {
+		res.Location = "• " + *loc
+	}
+
+	if m := lpaRe.FindStringSubmatch(text); m != nil {
+		var lpa float64
+		if _, err := fmt.Sscanf(m[1], "%g", &lpa); err == nil && lpa >= 10 {
+			res.Tags = appendUnique(res.Tags, "high-package")
+		}
+	}
+	if res.Company != nil && isDreamCompany(*res.Company) {
+		res.Tags = appendUnique(res.Tags, "dream-company")
+	}
+
+	if len(links) > 0 {
+		apply := links[0].URL
+		for _, link := range links {
+			if isApplyLink(link) {
+				apply = link.URL
+				break
+			}
+		}
+		res.ApplyLink = &apply
+		for _, link := range links {
+			if link.URL != apply {
+				res.OtherLinks = append(res.OtherLinks, link.URL)
+			}
+		}
+	}
+
+	res.Priority = rulePriority(res, now)
+	res.Summary = ruleSummary(res, subject, snippet, body)
+	res.Confidence = ruleConfidence(res)
+	return res
+}
+
+// ruleConfidence scores the heuristics below the model: keyword and regex
+// hits are plausible but unverified, and "announcement" is also the
+// category used when nothing matched.
+func ruleConfidence(res *AIResult) map[string]float64 {
+	confidence := map[string]float64{FieldCategory: 0.5}
+	if res.Category == "announcement" {
+		confidence[FieldCategory] = 0.2
+	}
+	for _, field := range []string{FieldCompany, FieldRole, FieldDeadline, FieldApplyLink} {
+		if res.FieldValue(field) != nil {
+			confidence[field] = 0.4
+		}
+	}
+	return confidence
+}
+
+func ruleCategory(subject, lowerText string) string {
+	lowerSubject := strings.ToLower(subject)
+	for _, haystack := range []string{lowerSubject, lowerText} {
+		for i, rule := range categoryRules {
+			if categoryPatterns[i].MatchString(haystack) {
+				return rule.category
+			}
+		}
+	}
+	return "announcement"
+}
+
+// Keyword tables are matched on word boundaries so short keywords like "it",
+// "fte" or "psu" do not fire inside longer words.
+var (
+	categoryPatterns = make([]*regexp.Regexp, len(categoryRules))
+	tagPatterns      = make([]*regexp.Regexp, len(tagRules))
+)
+
+func init() {
+	for i, rule := range categoryRules {
+		categoryPatterns[i] = keywordPattern(rule.keywords)
+	}
+	for i, rule := range tagRules {
+		tagPatterns[i] = keywordPattern(rule.keywords)
+	}
+}
+
+func keywordPattern(keywords []string) *regexp.Regexp {
+	quoted := make([]string, len(keywords))
+	for i, kw := range keywords {
+		quoted[i] = regexp.QuoteMeta(kw)
+	}
+	return regexp.MustCompile(`\b(?:` + strings.Join(quoted, "|") + `)\b`)
+}
+
+func ruleTags(lowerText string) []string {
+	tags := make([]string, 0)
+	for i, rule := range tagRules {
+		if tagPatterns[i].MatchString(lowerText) {
+			tags = append(tags, rule.tag)
+		}
+	}
+	return tags
+}
+
+func ruleCompany(subject, body string) *string {
+	if company := firstSubmatch(companyLineRe, body); company != nil {
+		return company
+	}
+	if m := subjectCoRe.FindStringSubmatch(subject); m != nil {
+		company := strings.Trim(strings.TrimSpace(m[1]), "-–|:")
+		company = strings.TrimSpace(company)
+		if company != "" && len(company) <= 60 {
+			return &company
+		}
+	}
+	return nil
+}
+
+func firstSubmatch(re *regexp.Regexp, text string) *string {
+	m := re.FindStringSubmatch(text)
+	if m == nil {
+		return nil
+	}
+	value := strings.TrimSpace(strings.Trim(m[1], "*•-– \t"))
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// ruleDeadline returns the first date that follows a deadline cue such as
+// "last date" or "apply by", formatted YYYY-MM-DD.
+func ruleDeadline(text string, now time.Time) *string {
+	for _, cue := range deadlineCueRe.FindAllString(text, -1) {
+		if date, ok := parseLooseDate(cue, now); ok {
+			formatted := date.Format("2006-01-02")
+			return &formatted
+		}
+	}
+	return nil
+}
+
+// parseLooseDate finds the first recognisable date in s. Dates without a
+// year are assumed to fall within the next twelve months of now.
+func parseLooseDate(s string, now time.Time) (time.Time, bool) {
+	s = ordinalRe.ReplaceAllString(s, "$1")
+	for _, token := range dateTokenRe.FindAllString(s, -1) {
+		token = strings.Join(strings.Fields(strings.ReplaceAll(token, ",", " ")), " ")
+		for _, layout := range dateLayouts {
+			parsed, err := time.Parse(layout, token)
+			if err != nil {
+				continue
+			}
+			if !strings.Contains(layout, "2006") {
+				parsed = parsed.AddDate(now.Year(), 0, 0)
+				if parsed.Before(now.AddDate(0, 0, -1)) {
+					parsed = parsed.AddDate(1, 0, 0)
+				}
+			}
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// clockRe matches a time of day such as "10:30", "2 PM" or "14.00 hrs".
+// A bare number only counts when it has a meridiem or "hrs" suffix.
+var clockRe = regexp.MustCompile(`(?i)\b([01]?\d|2[0-3])(?:[:.]([0-5]\d))?\s*(a\.?m\b\.?|p\.?m\b\.?|hrs\b|hours\b)?`)
+
+// EventTime finds when a test, interview or drive takes place in text, such
+// as the Timings of an AIResult: the first line with a date, at the time of
+// day on that line. timed is false when the line has no time, leaving the
+// hour to the caller. Times are read in loc.
+func EventTime(text string, now time.Time, loc *time.Location) (start time.Time, timed, ok bool) {
+	for _, line := range strings.Split(text, "\n") {
+		date, found := parseLooseDate(line, now)
+		if !found {
+			continue
+		}
+		start = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+		// Drop the dates so their digits are not read as a time.
+		rest := dateTokenRe.ReplaceAllStringFunc(ordinalRe.ReplaceAllString(line, "$1"), func(token string) string {
+			if _, isDate := parseLooseDate(token, now); isDate {
+				return " "
+			}
+			return token
+		})
+		for _, m := range clockRe.FindAllStringSubmatch(rest, -1) {
+			if m[2] == "" && m[3] == "" {
+				continue
+			}
+			hour, _ := strconv.Atoi(m[1])
+			minute, _ := strconv.Atoi(m[2])
+			switch suffix := strings.ToLower(m[3]); {
+			case strings.HasPrefix(suffix, "p") && hour < 12:
+				hour += 12
+			case strings.HasPrefix(suffix, "a") && hour == 12:
+				hour = 0
+			}
+			return start.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute), true, true
+		}
+		return start, false, true
+	}
+	return time.Time{}, false, false
+}
+
+func bulletLines(body string, re *regexp.Regexp) any {
+	lines := make([]string, 0)
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(strings.Trim(line, "*•-– \t"))
+		if line == "" || len(line) > 200 || !re.MatchString(line) {
+			continue
+		}
+		lines = append(lines, "• "+line)
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isApplyLink(link utils.ExtractedLink) bool {
+	lower := strings.ToLower(link.URL + " " + link.Label)
+	for _, kw := range []string{"forms.gle", "docs.google.com/forms", "apply", "register", "registration"} {
+		if strings.Contains(lower, kw) {
+			return true
+		}
+	}
+	return false
+}
+
+func isDreamCompany(company string) bool {
+	lower := strings.ToLower(company)
+	for _, dream := range dreamCompanies {
+		if strings.Contains(lower, dream) {
+			return true
+		}
+	}
+	return false
+}
+
+// rulePriority ranks a rules result by its tags and how close its deadline
+// is. A deadline that has already passed can no longer be acted on, so it
+// ranks low however urgent the wording.
+func rulePriority(res *AIResult, now time.Time) string {
+	var (
+		days        float64
+		hasDeadline bool
+	)
+	if res.Deadline != nil {
+		if deadline, err := time.Parse("2006-01-02", *res.Deadline); err == nil {
+			days = deadline.Sub(now.Truncate(24*time.Hour)).Hours() / 24
+			hasDeadline = true
+		}
+	}
+	if hasDeadline && days < 0 {
+		return "low"
+	}
+
+	for _, tag := range res.Tags {
+		if tag == "urgent" || tag == "dream-company" {
+			return "high"
+		}
+	}
+	if hasDeadline {
+		switch {
+		case days <= 3:
+			return "high"
+		case days <= 7:
+			return "medium"
+		}
+	}
+	return "low"
+}
+
+func ruleSummary(res *AIResult, subject, snippet, body string) string {
+	bullets := make([]string, 0, 8)
+	add := func(label string, value *string) {
+		if value != nil && *value != "" {
+			bullets = append(bullets, fmt.Sprintf("• %s: %s", label, *value))
+		}
+	}
+	if subject != "" {
+		bullets = append(bullets, "• Subject: "+subject)
+	}
+	add("Company", res.Company)
+	add("Role", res.Role)
+	add("Deadline", res.Deadline)
+	add("Apply", res.ApplyLink)
+
+	added := 0
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(strings.Trim(line, "*•-– \t"))
+		if len(line) < 15 || len(line) > 300 {
+			continue
+		}
+		bullets = append(bullets, "• "+line)
+		if added++; added == 8 {
+			break
+		}
+	}
+	if len(bullets) <= 1 && snippet != "" {
+		bullets = append(bullets, "• "+snippet)
+	}
+	return strings.Join(bullets, "\n")
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,196 @@
+package ai
+
+import (
+	"testing"
+	"time"
+
+	"github.com/r7rainz/auramail/internal/prompt"
+	"github.com/r7rainz/auramail/internal/utils"
+)
+
+func TestAnalyzeEmailRules(t *testing.T) {
+	now := time.Date(2026, 8, 10, 9, 0, 0, 0, time.UTC)
+	body := `Dear Students,
+
+Company: Microsoft
+Role: Software Engineer Intern
+Location: Hyderabad
+Stipend: 1,25,000 per month
+Eligibility: B.Tech CSE/IT, 2027 batch, CGPA 7.5 and above
+
+Interested students must register on the portal. Last date to apply: 12th August 2026.`
+	links := []utils.ExtractedLink{
+		{URL: "https://careers.example.com/about", Label: "About us"},
+		{URL: "https://forms.gle/abc123", Label: "Register here"},
+	}
+
+	res := AnalyzeEmailRules("Microsoft Summer Internship 2027", "Microsoft is hiring interns", body, links, now)
+
+	if res.AnalysisVersion != RulesAnalysisVersion {
+		t.Errorf("AnalysisVersion = %q, want %q", res.AnalysisVersion, RulesAnalysisVersion)
+	}
+	if res.Category != "internship" {
+		t.Errorf("Category = %q, want internship", res.Category)
+	}
+	if res.Company == nil || *res.Company != "Microsoft" {
+		t.Errorf("Company = %v, want Microsoft", res.Company)
+	}
+	if res.Role == nil || *res.Role != "Software Engineer Intern" {
+		t.Errorf("Role = %v, want Software Engineer Intern", res.Role)
+	}
+	if res.Deadline == nil || *res.Deadline != "2026-08-12" {
+		t.Errorf("Deadline = %v, want 2026-08-12", res.Deadline)
+	}
+	if res.ApplyLink == nil || *res.ApplyLink != "https://forms.gle/abc123" {
+		t.Errorf("ApplyLink = %v, want the forms link", res.ApplyLink)
+	}
+	if len(res.OtherLinks) != 1 {
+		t.Errorf("OtherLinks = %v, want one link", res.OtherLinks)
+	}
+	if res.Priority != "high" {
+		t.Errorf("Priority = %q, want high", res.Priority)
+	}
+	if !hasTag(res.Tags, "dream-company") || !hasTag(res.Tags, "it") {
+		t.Errorf("Tags = %v, want dream-company and it", res.Tags)
+	}
+	if res.Location != "• Hyderabad" {
+		t.Errorf("Location = %v, want • Hyderabad", res.Location)
+	}
+	if res.Eligibility == nil || res.Salary == nil {
+		t.Errorf("expected eligibility and salary bullets, got %v / %v", res.Eligibility, res.Salary)
+	}
+	if err := res.Validate(); err != nil {
+		t.Errorf("rules result should validate: %v", err)
+	}
+}
+
+func TestAnalyzeEmailRules_Categories(t *testing.T) {
+	now := time.Date(2026, 8, 10, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		subject string
+		want    string
+	}{
+		{"Shortlisted candidates for Amazon SDE", "result"},
+		{"Interview schedule - Deloitte", "interview"},
+		{"Online Assessment for TCS Digital", "exam"},
+		{"Pre-Placement Talk by Infosys", "ppt"},
+		{"Reminder: complete your profile", "reminder"},
+		{"After the break: campus update", "announcement"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.subject, func(t *testing.T) {
+			res := AnalyzeEmailRules(tt.subject, "", "", nil, now)
+			if res.Category != tt.want {
+				t.Errorf("Category = %q, want %q", res.Category, tt.want)
+			}
+		})
+	}
+}
+
+// The rules analyzer keeps its own keyword tables; everything it can
+// assign must still be in the vocabulary of the embedded analysis template.
+func TestRulesVocabularyMatchesTemplate(t *testing.T) {
+	tmpl := prompt.MustEmbedded(prompt.AnalyzeEmail)
+	for _, rule := range categoryRules {
+		if !tmpl.HasCategory(rule.category) {
+			t.Errorf("category %q is not in the %s template", rule.category, tmpl.Name)
+		}
+	}
+	if !tmpl.HasCategory("announcement") {
+		t.Errorf("default category announcement is not in the %s template", tmpl.Name)
+	}
+	for _, rule := range tagRules {
+		if !tmpl.HasTag(rule.tag) {
+			t.Errorf("tag %q is not in the %s template", rule.tag, tmpl.Name)
+		}
+	}
+	for _, tag := range []string{"dream-company", "high-package"} {
+		if !tmpl.HasTag(tag) {
+			t.Errorf("tag %q is not in the %s template", tag, tmpl.Name)
+		}
+	}
+}
+
+func TestRulePriority(t *testing.T) {
+	now := time.Date(2026, 8, 10, 9, 0, 0, 0, time.UTC)
+	date := func(s string) *string { return &s }
+	tests := []struct {
+		name     string
+		deadline *string
+		tags     []string
+		want     string
+	}{
+		{"due soon", date("2026-08-12"), nil, "high"},
+		{"due today", date("2026-08-10"), nil, "high"},
+		{"due next week", date("2026-08-16"), nil, "medium"},
+		{"no deadline", nil, nil, "low"},
+		{"urgent without deadline", nil, []string{"urgent"}, "high"},
+		{"past deadline", date("2026-08-01"), nil, "low"},
+		{"past deadline at a dream company", date("2026-08-09"), []string{"dream-company"}, "low"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := &AIResult{Deadline: tt.deadline, Tags: tt.tags}
+			if got := rulePriority(res, now); got != tt.want {
+				t.Errorf("rulePriority() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLooseDate(t *testing.T) {
+	now := time.Date(2026, 12, 20, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"apply by 2027-01-05", "2027-01-05"},
+		{"last date 05/01/2027", "2027-01-05"},
+		{"deadline: January 5, 2027", "2027-01-05"},
+		{"register by 5th Jan", "2027-01-05"},
+		{"before 22 December", "2026-12-22"},
+	}
+	for _, tt := range tests {
+		got, ok := parseLooseDate(tt.in, now)
+		if !ok {
+			t.Errorf("parseLooseDate(%q) found no date", tt.in)
+			continue
+		}
+		if got.Format("2006-01-02") != tt.want {
+			t.Errorf("parseLooseDate(%q) = %s, want %s", tt.in, got.Format("2006-01-02"), tt.want)
+		}
+	}
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func TestEventTime(t *testing.T) {
+	now := time.Date(2026, 8, 10, 9, 0, 0, 0, time.UTC)
+	ist := time.FixedZone("IST", 5*3600+1800)
+	for name, tc := range map[string]struct {
+		text  string
+		want  time.Time
+		timed bool
+	}{
+		"clock":     {"• Venue: AB1\n• Date of test: 14th Aug 2026, 10:30 AM", time.Date(2026, 8, 14, 10, 30, 0, 0, ist), true},
+		"pm":        {"Interview on 15/08/2026 at 2 p.m.", time.Date(2026, 8, 15, 14, 0, 0, 0, ist), true},
+		"24h":       {"OA: 2026-08-20 14.00 hrs", time.Date(2026, 8, 20, 14, 0, 0, 0, ist), true},
+		"noon":      {"Slot: 21 Aug, 12 PM", time.Date(2026, 8, 21, 12, 0, 0, 0, ist), true},
+		"date only": {"Drive on 22 August 2026 (Round 2)", time.Date(2026, 8, 22, 0, 0, 0, 0, ist), false},
+	} {
+		got, timed, ok := EventTime(tc.text, now, ist)
+		if !ok || timed != tc.timed || !got.Equal(tc.want) {
+			t.Errorf("%s: EventTime() = %v, %v, %v; want %v, %v", name, got, timed, ok, tc.want, tc.timed)
+		}
+	}
+	if _, _, ok := EventTime("Reporting time: 9 AM", now, ist); ok {
+		t.Error("a time without a date should not be an event time")
+	}
+}
@@ -1,15 +1,21 @@
 package ai
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sashabaranov/go-openai"
+
+	"github.com/r7rainz/auramail/internal/lang"
+	"github.com/r7rainz/auramail/internal/prompt"
 )
 
 // Note: ErrOpenAIKeyMissing and ErrAIClientNotInitialized are defined in errors.go
@@ -28,7 +34,30 @@ var (
 )
 
 const CacheTTL = 1 * time.Hour
-const AnalysisVersion = "detailed-v4"
+
+// analysisTemplate is the prompt template used for email analysis.
+var analysisTemplate atomic.Pointer[prompt.Template]
+
+func init() {
+	analysisTemplate.Store(prompt.MustEmbedded(prompt.AnalyzeEmail))
+}
+
+// AnalysisTemplate returns the active email analysis template.
+func AnalysisTemplate() *prompt.Template {
+	return analysisTemplate.Load()
+}
+
+// SetAnalysisTemplate makes t the active email analysis template. Cached
+// results from the previous template are no longer returned, since the
+// cache key includes the version.
+func SetAnalysisTemplate(t *prompt.Template) {
+	analysisTemplate.Store(t)
+}
+
+// AnalysisVersion identifies results produced by the active template.
+func AnalysisVersion() string {
+	return AnalysisTemplate().Version
+}
 
 func getClient() *openai.Client {
 	once.Do(func() {
@@ -40,8 +69,35 @@ func getClient() *openai.Client {
 	return client
 }
 
-func AnalyzeEmail(ctx context.Context, userID string, subject, snippet, body string) (*AIResult, error) {
-	cacheKey := fmt.Sprintf("%s:user:%s:%s:%s", AnalysisVersion, userID, subject, snippet)
+// Available reports whether an OpenAI client is configured. Callers use it to
+// decide whether results from the rules fallback should be re-analyzed.
+func Available() bool {
+	return getClient() != nil
+}
+
+// Languages are the language an email is written in and the language its
+// summary should be written in, as ISO 639-1 codes. Empty means English.
+// Every other field is extracted in English regardless.
+type Languages struct {
+	Email   string
+	Summary string
+}
+
+// note tells the model about non-English emails and summaries.
+func (l Languages) note() string {
+	email, summary := cmp.Or(l.Email, lang.English), cmp.Or(l.Summary, lang.English)
+	if email == lang.English && summary == lang.English {
+		return ""
+	}
+	return fmt.Sprintf("Email language: %s\nSummary language: %s\n", lang.Name(email), lang.Name(summary))
+}
+
+// AnalyzeEmail extracts structured placement details from an email. The
+// returned Usage reports the tokens consumed by the request; it is zero when
+// the result was served from the in-memory cache.
+func AnalyzeEmail(ctx context.Context, userID string, subject, snippet, body string, langs Languages) (*AIResult, Usage, error) {
+	t := AnalysisTemplate()
+	cacheKey := fmt.Sprintf("%s:%s:user:%s:%s:%s", t.Version, langs.Summary, userID, subject, snippet)
 	if len(cacheKey) > 100 {
 		cacheKey = cacheKey[:100]
 	}
@@ -50,53 +106,51 @@ func AnalyzeEmail(ctx context.Context, userID string, subject, snippet, body str
 	cached, exists := aiCache[cacheKey]
 	cacheMu.RUnlock()
 	if exists && time.Since(cached.timestamp) < CacheTTL {
-		return cached.data, nil
+		return cached.data, Usage{}, nil
+	}
+
+	result, usage, err := AnalyzeEmailWith(ctx, t, subject, snippet, body, langs)
+	if err != nil {
+		return nil, usage, err
 	}
 
+	cacheMu.Lock()
+	aiCache[cacheKey] = cacheItem{data: result, timestamp: time.Now()}
+	cacheMu.Unlock()
+
+	return result, usage, nil
+}
+
+// RequestAnalysis sends one email to the model and returns the raw JSON reply
+// without parsing it, so the evaluation harness can record replies and replay
+// them offline through ParseAnalysis.
+func RequestAnalysis(ctx context.Context, subject, snippet, body string, langs Languages) (string, Usage, error) {
+	return RequestAnalysisWith(ctx, AnalysisTemplate(), subject, snippet, body, langs)
+}
+
+// RequestAnalysisWith is RequestAnalysis under a specific prompt template,
+// e.g. a candidate being previewed.
+func RequestAnalysisWith(ctx context.Context, t *prompt.Template, subject, snippet, body string, langs Languages) (string, Usage, error) {
 	truncatedBody := body
 	if len(body) > 24000 {
 		truncatedBody = body[:24000] + "..."
 	}
 
-	systemPrompt := `You are a highly specialized AI assistant for academic and recruitment analysis at VIT (Vellore Institute of Technology).
-Return ONLY a valid JSON object.
-
-CATEGORIZATION RULES (category field - pick the MOST SPECIFIC one):
-- "internship" - Internship opportunities, summer internships, intern positions
-- "job offer" - Full-time job offers, placement offers, FTE positions
-- "ppt" - Pre-Placement Talks, company presentations, PPT schedules
-- "workshop" - Workshops, bootcamps, training sessions, hackathons
-- "exam" - Online assessments, tests, coding rounds, aptitude tests
-- "interview" - Interview schedules, interview calls, HR rounds
-- "result" - Results announcements, shortlists, selection lists
-- "reminder" - Deadline reminders, follow-ups, last date notices
-- "announcement" - General placement announcements, policy updates
-- "registration" - Registration links, sign-up forms, application deadlines
-
-TAGGING RULES (tags field - array of relevant tags):
-Include ALL applicable tags from: ["urgent", "high-package", "dream-company", "mass-hiring", "off-campus", "on-campus", "remote", "hybrid", "wfh", "tier-1", "startup", "mnc", "govt", "psu", "core", "it", "non-tech", "fresher-friendly"]
-
-JSON FIELD RULES:
-- summary: MUST be a detailed bullet list, never a paragraph. Use one bullet per concrete fact and use as many bullets as needed (normally 8-20). Preserve all details from the main body: company, role, location, work mode, eligibility, pass-out year, experience, compensation, deadline, responsibilities, required skills, restrictions, schedule, and application method. Never collapse a list into vague wording.
-- Extract each fact independently into its matching field as well as keeping it in summary when useful. Do not leave a field null when the main body contains that information.
-- Ignore everything beginning with the exact footer phrase "In God bless you mails" and all following footer/signature text, including lnkd.in, patqueries.bhopal@vitbhopal.ac.in, VIT Bhopal websites, and social links. Do not copy footer text into summary, description, requirements, or any link field.
-- deadline: Use YYYY-MM-DD format or null.
-- otherLinks: Must be an array of strings [].
-- tags: Must be an array of strings [].
-- eligibility, timings, salary, location, eventDetails, requirements: Must be a single string with \n• bullet points.
-- company, role, applyLink, description, attachmentSummary: Use a string or null.
-- If data is missing, use null (not empty string).
-- priority: "high" if deadline within 3 days or dream company, "medium" if within a week, "low" otherwise.`
-
-	userPrompt := fmt.Sprintf("Subject: %s\nSnippet: %s\nBody: %s", subject, snippet, truncatedBody)
+	systemPrompt, err := t.Render()
+	if err != nil {
+		return "", Usage{}, err
+	}
+
+	userPrompt := fmt.Sprintf("%sSubject: %s\nSnippet: %s\nBody: %s", langs.note(), subject, snippet, truncatedBody)
 
 	c := getClient()
 	if c == nil {
-		return nil, ErrOpenAIKeyMissing
+		return "", Usage{}, ErrOpenAIKeyMissing
 	}
 
+	model := openai.GPT4oMini
 	resp, err := c.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
-		Model: openai.GPT4oMini,
+		Model: model,
 		Messages: []openai.ChatCompletionMessage{
 			{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
 			{Role: openai.ChatMessageRoleUser, Content: userPrompt},
@@ -107,23 +161,63 @@ JSON FIELD RULES:
 		},
 	})
 	if err != nil {
-		return nil, fmt.Errorf("openai error: %w", err)
+		return "", Usage{}, fmt.Errorf("openai error: %w", err)
 	}
+	usage := newUsage(OperationAnalyzeEmail, model, resp.Usage)
+	if len(resp.Choices) == 0 {
+		return "", usage, ErrInvalidModelReply
+	}
+	return resp.Choices[0].Message.Content, usage, nil
+}
 
-	var result AIResult
-	content := resp.Choices[0].Message.Content
+// ParseAnalysis decodes a raw model reply into an AIResult stamped with the
+// current AnalysisVersion.
+func ParseAnalysis(content string) (*AIResult, error) {
+	return ParseAnalysisWith(AnalysisTemplate(), content)
+}
 
-	// Unmarshal directly into your pointer-ready struct
-	err = json.Unmarshal([]byte(content), &result)
-	if err != nil {
+// ParseAnalysisWith decodes a reply produced under t. Tags outside the
+// template's vocabulary are dropped, and a category outside it becomes
+// "announcement".
+func ParseAnalysisWith(t *prompt.Template, content string) (*AIResult, error) {
+	var result AIResult
+	if err := json.Unmarshal([]byte(content), &result); err != nil {
 		log.Printf("JSON Unmarshal error: %v | Content: %s", err, content)
 		return nil, err
 	}
-	result.AnalysisVersion = AnalysisVersion
-
-	cacheMu.Lock()
-	aiCache[cacheKey] = cacheItem{data: &result, timestamp: time.Now()}
-	cacheMu.Unlock()
-
+	result.AnalysisVersion = t.Version
+	if len(t.Categories) > 0 {
+		result.Category = strings.ToLower(strings.TrimSpace(result.Category))
+		if !t.HasCategory(result.Category) {
+			result.Category = "announcement"
+		}
+	}
+	if len(t.Tags) > 0 {
+		tags := make([]string, 0, len(result.Tags))
+		for _, tag := range result.Tags {
+			if t.HasTag(tag) {
+				tags = append(tags, tag)
+			}
+		}
+		result.Tags = tags
+	}
+	for field, c := range result.Confidence {
+		result.Confidence[field] = min(max(c, 0), 1)
+	}
 	return &result, nil
 }
+
+// AnalyzeEmailWith analyzes an email under t without touching the cache.
+func AnalyzeEmailWith(ctx context.Context, t *prompt.Template, subject, snippet, body string, langs Languages) (*AIResult, Usage, error) {
+	content, usage, err := RequestAnalysisWith(ctx, t, subject, snippet, body, langs)
+	if err != nil {
+		return nil, usage, err
+	}
+	result, err := ParseAnalysisWith(t, content)
+	if err != nil {
+		return nil, usage, err
+	}
+	result.Language = cmp.Or(langs.Email, lang.English)
+	result.SummaryLanguage = cmp.Or(langs.Summary, lang.English)
+	return result, usage, nil
+}
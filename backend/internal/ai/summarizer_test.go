@@ -0,0 +1,32 @@
+package ai
+
+import (
+	"testing"
+
+	"github.com/r7rainz/auramail/internal/prompt"
+)
+
+func TestParseAnalysisWith_Vocabulary(t *testing.T) {
+	tmpl := prompt.MustEmbedded(prompt.AnalyzeEmail)
+	tests := []struct {
+		content      string
+		wantCategory string
+		wantTags     []string
+	}{
+		{`{"category":" Job Offer ","tags":["urgent","unicorn"]}`, "job offer", []string{"urgent"}},
+		{`{"category":"hackathon","tags":[]}`, "announcement", []string{}},
+		{`{"category":"","tags":["remote"]}`, "announcement", []string{"remote"}},
+	}
+	for _, tt := range tests {
+		res, err := ParseAnalysisWith(tmpl, tt.content)
+		if err != nil {
+			t.Fatalf("ParseAnalysisWith(%s) error = %v", tt.content, err)
+		}
+		if res.Category != tt.wantCategory {
+			t.Errorf("%s: category = %q, want %q", tt.content, res.Category, tt.wantCategory)
+		}
+		if len(res.Tags) != len(tt.wantTags) || (len(res.Tags) > 0 && res.Tags[0] != tt.wantTags[0]) {
+			t.Errorf("%s: tags = %v, want %v", tt.content, res.Tags, tt.wantTags)
+		}
+	}
+}
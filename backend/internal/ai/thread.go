@@ -0,0 +1,112 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	// maxThreadPrompt caps the user prompt of SummarizeThread, in bytes.
+	maxThreadPrompt = 24000
+	// maxThreadSummary caps each message summary, in bytes.
+	maxThreadSummary = 4000
+)
+
+// ThreadMessage is one message of a thread passed to SummarizeThread.
+type ThreadMessage struct {
+	ReceivedAt string
+	Sender     string
+	Subject    string
+	Summary    string
+}
+
+// SummarizeThread asks the model for a short "what changed" rollup of a
+// thread, oldest message first. changes are the field differences already
+// detected between messages; the model is asked to explain them rather
+// than rediscover them. It returns one line per change.
+func SummarizeThread(ctx context.Context, messages []ThreadMessage, changes []string) ([]string, Usage, error) {
+	c := getClient()
+	if c == nil {
+		return nil, Usage{}, ErrOpenAIKeyMissing
+	}
+
+	systemPrompt := `You summarize how a placement email thread evolved.
+Return ONLY a valid JSON object of the form {"changes": ["..."]}.
+
+RULES:
+- One short sentence per change, oldest first, e.g. "Deadline extended from 12 Aug to 15 Aug", "Venue moved to Seminar Hall 2", "Shortlist attached".
+- Cover every detected change you are given; add others only if the messages clearly state them.
+- Do not repeat details that stayed the same. If nothing changed, return an empty array.`
+
+	userPrompt := threadPrompt(messages, changes)
+
+	model := openai.GPT4oMini
+	resp, err := c.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
+			{Role: openai.ChatMessageRoleUser, Content: userPrompt},
+		},
+		Temperature: 0.1,
+		ResponseFormat: &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+		},
+	})
+	if err != nil {
+		return nil, Usage{}, fmt.Errorf("openai error: %w", err)
+	}
+	usage := newUsage(OperationSummarizeThread, model, resp.Usage)
+	if len(resp.Choices) == 0 {
+		return nil, usage, ErrInvalidModelReply
+	}
+
+	var reply struct {
+		Changes []string `json:"changes"`
+	}
+	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &reply); err != nil {
+		return nil, usage, ErrInvalidModelReply
+	}
+	lines := make([]string, 0, len(reply.Changes))
+	for _, line := range reply.Changes {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, usage, nil
+}
+
+// threadPrompt lists the messages and the detected changes. When they do
+// not fit in maxThreadPrompt, whole messages are dropped oldest first; the
+// latest message and the detected changes are always kept.
+func threadPrompt(messages []ThreadMessage, changes []string) string {
+	var detected strings.Builder
+	detected.WriteString("Detected changes:\n")
+	for _, change := range changes {
+		detected.WriteString("- " + change + "\n")
+	}
+
+	blocks := make([]string, len(messages))
+	for i, m := range messages {
+		blocks[i] = fmt.Sprintf("Message %d (%s, from %s)\nSubject: %s\n%s\n\n", i+1, m.ReceivedAt, m.Sender, m.Subject, truncateBytes(m.Summary, maxThreadSummary))
+	}
+	room := maxThreadPrompt - detected.Len()
+	first := len(blocks)
+	for first > 0 && (first == len(blocks) || len(blocks[first-1]) <= room) {
+		room -= len(blocks[first-1])
+		first--
+	}
+
+	var b strings.Builder
+	if first > 0 {
+		fmt.Fprintf(&b, "(%d earlier messages omitted)\n\n", first)
+	}
+	for _, block := range blocks[first:] {
+		b.WriteString(block)
+	}
+	b.WriteString(detected.String())
+	return b.String()
+}
@@ -0,0 +1,32 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestThreadPrompt_DropsOldestMessagesWhole(t *testing.T) {
+	long := strings.Repeat("₹ stipend revised ", 400)
+	messages := make([]ThreadMessage, 10)
+	for i := range messages {
+		messages[i] = ThreadMessage{ReceivedAt: "2026-08-01", Sender: "placements", Subject: "Drive update", Summary: long}
+	}
+
+	got := threadPrompt(messages, []string{"Deadline extended from 12 Aug to 15 Aug"})
+	if len(got) > maxThreadPrompt || !utf8.ValidString(got) {
+		t.Fatalf("prompt is %d bytes, valid UTF-8 %v", len(got), utf8.ValidString(got))
+	}
+	if !strings.HasPrefix(got, "(") || !strings.Contains(got, "earlier messages omitted") {
+		t.Errorf("prompt does not say messages were omitted: %.80q", got)
+	}
+	if !strings.Contains(got, "Message 10 (") || strings.Contains(got, "Message 1 (") {
+		t.Error("expected the latest messages to be kept and the oldest dropped")
+	}
+	if strings.Count(got, "Message ") != strings.Count(got, "Subject: Drive update") {
+		t.Error("a message was cut through its header")
+	}
+	if !strings.HasSuffix(got, "- Deadline extended from 12 Aug to 15 Aug\n") {
+		t.Error("detected changes must always be kept")
+	}
+}
@@ -2,40 +2,135 @@ package ai
 
 import (
 	"encoding/json"
+	"fmt"
 	"strings"
 
+	"github.com/r7rainz/auramail/internal/compensation"
 	"github.com/r7rainz/auramail/internal/utils"
 )
 
 // AIResult is the structured output from email analysis (persisted and returned to clients).
 type AIResult struct {
-	AnalysisVersion   string                 `json:"analysisVersion,omitempty"`
-	GmailMessageID    string                 `json:"gmailMessageId"`
-	ThreadID          string                 `json:"threadId"`
-	Subject           string                 `json:"subject"`
-	Sender            string                 `json:"sender"`
-	ReceiverAt        string                 `json:"receiverAt"`
-	Snippet           string                 `json:"snippet"`
-	Summary           string                 `json:"summary"`
-	Category          string                 `json:"category"`
-	Tags              []string               `json:"tags"`
-	Priority          string                 `json:"priority"`
-	Company           *string                `json:"company"`
-	Role              *string                `json:"role"`
-	Deadline          *string                `json:"deadline"`
-	ApplyLink         *string                `json:"applyLink"`
-	OtherLinks        []string               `json:"otherLinks"`
-	LinkLabels        []string               `json:"linkLabels,omitempty"`
-	Eligibility       any                    `json:"eligibility"`
-	Timings           any                    `json:"timings"`
-	Salary            any                    `json:"salary"`
-	Location          any                    `json:"location"`
-	EventDetails      any                    `json:"eventDetails"`
-	Requirements      any                    `json:"requirements"`
-	Description       *string                `json:"description"`
-	AttachmentSummary *string                `json:"attachmentSummary"`
-	Attachments       []utils.AttachmentMeta `json:"attachments"`
-	Important         bool                   `json:"important"`
+	AnalysisVersion   string                     `json:"analysisVersion,omitempty"`
+	GmailMessageID    string                     `json:"gmailMessageId"`
+	ThreadID          string                     `json:"threadId"`
+	Subject           string                     `json:"subject"`
+	Sender            string                     `json:"sender"`
+	ReceiverAt        string                     `json:"receiverAt"`
+	Snippet           string                     `json:"snippet"`
+	Summary           string                     `json:"summary"`
+	Category          string                     `json:"category"`
+	Tags              []string                   `json:"tags"`
+	Priority          string                     `json:"priority"`
+	Company           *string                    `json:"company"`
+	CompanyID         *int64                     `json:"companyId,omitempty"`
+	OpportunityID     string                     `json:"opportunityId,omitempty"`
+	Role              *string                    `json:"role"`
+	Deadline          *string                    `json:"deadline"`
+	ApplyLink         *string                    `json:"applyLink"`
+	OtherLinks        []string                   `json:"otherLinks"`
+	LinkLabels        []string                   `json:"linkLabels,omitempty"`
+	Eligibility       any                        `json:"eligibility"`
+	Timings           any                        `json:"timings"`
+	Salary            any                        `json:"salary"`
+	Location          any                        `json:"location"`
+	EventDetails      any                        `json:"eventDetails"`
+	Requirements      any                        `json:"requirements"`
+	Description       *string                    `json:"description"`
+	AttachmentSummary *string                    `json:"attachmentSummary"`
+	Attachments       []utils.AttachmentMeta     `json:"attachments"`
+	Important         bool                       `json:"important"`
+	Compensation      *compensation.Compensation `json:"compensation,omitempty"`
+	// Language is the detected language of the email (ISO 639-1); the
+	// original text is kept in Description. SummaryLanguage is the language
+	// Summary was written in. Every other field is in English.
+	Language        string `json:"language,omitempty"`
+	SummaryLanguage string `json:"summaryLanguage,omitempty"`
+	// Confidence maps extracted fields (by JSON name) to how sure the
+	// analyzer is of them, from 0 to 1. User corrections score 1.
+	Confidence map[string]float64 `json:"confidence,omitempty"`
+	// Overrides are the user's corrections, keyed by JSON field name. They
+	// win over every later analysis of the email.
+	Overrides map[string]*string `json:"overrides,omitempty"`
+}
+
+// Fields users can correct, by JSON name.
+const (
+	FieldCategory  = "category"
+	FieldCompany   = "company"
+	FieldRole      = "role"
+	FieldDeadline  = "deadline"
+	FieldApplyLink = "applyLink"
+)
+
+// CorrectableFields lists the fields accepted in Overrides.
+var CorrectableFields = []string{FieldCategory, FieldCompany, FieldRole, FieldDeadline, FieldApplyLink}
+
+// FieldValue returns one of the correctable fields, or nil when it is
+// empty.
+func (r *AIResult) FieldValue(field string) *string {
+	var v *string
+	switch field {
+	case FieldCategory:
+		v = &r.Category
+	case FieldCompany:
+		v = r.Company
+	case FieldRole:
+		v = r.Role
+	case FieldDeadline:
+		v = r.Deadline
+	case FieldApplyLink:
+		v = r.ApplyLink
+	}
+	if v == nil || *v == "" {
+		return nil
+	}
+	value := *v
+	return &value
+}
+
+// Title names the email by company and role, falling back to its subject.
+func (r *AIResult) Title() string {
+	var parts []string
+	if r.Company != nil && *r.Company != "" {
+		parts = append(parts, *r.Company)
+	}
+	if r.Role != nil && *r.Role != "" {
+		parts = append(parts, *r.Role)
+	}
+	if len(parts) > 0 {
+		return strings.Join(parts, " – ")
+	}
+	if r.Subject != "" {
+		return r.Subject
+	}
+	return "Placement email"
+}
+
+// ApplyOverrides copies the user's corrections onto the extracted fields.
+func (r *AIResult) ApplyOverrides() {
+	for field, value := range r.Overrides {
+		switch field {
+		case FieldCategory:
+			if value != nil {
+				r.Category = *value
+			}
+		case FieldCompany:
+			r.Company = value
+		case FieldRole:
+			r.Role = value
+		case FieldDeadline:
+			r.Deadline = value
+		case FieldApplyLink:
+			r.ApplyLink = value
+		default:
+			continue
+		}
+		if r.Confidence == nil {
+			r.Confidence = make(map[string]float64)
+		}
+		r.Confidence[field] = 1
+	}
 }
 
 func (r *AIResult) UnmarshalJSON(data []byte) error {
@@ -73,3 +168,25 @@ func (r *AIResult) UnmarshalJSON(data []byte) error {
 	r.Summary = strings.Join(lines, "\n")
 	return nil
 }
+
+// FieldText flattens one of AIResult's free-form fields (eligibility,
+// salary, ...), which the model returns as a bullet string but older rows
+// may hold as a JSON array.
+func FieldText(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case []string:
+		return strings.Join(t, "\n")
+	case []any:
+		lines := make([]string, 0, len(t))
+		for _, item := range t {
+			lines = append(lines, fmt.Sprint(item))
+		}
+		return strings.Join(lines, "\n")
+	default:
+		return fmt.Sprint(t)
+	}
+}
@@ -24,3 +24,15 @@ func TestAIResultUnmarshalAcceptsStringSummary(t *testing.T) {
 		t.Fatalf("unexpected summary: %q", result.Summary)
 	}
 }
+
+func TestLanguagesNote(t *testing.T) {
+	if note := (Languages{}).note(); note != "" {
+		t.Errorf("English email and summary should add no note, got %q", note)
+	}
+	if note := (Languages{Email: "hi", Summary: "en"}).note(); note != "Email language: Hindi\nSummary language: English\n" {
+		t.Errorf("note = %q", note)
+	}
+	if note := (Languages{Summary: "ta"}).note(); note != "Email language: English\nSummary language: Tamil\n" {
+		t.Errorf("note = %q", note)
+	}
+}
@@ -0,0 +1,84 @@
+package ai
+
+import (
+	"unicode/utf8"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Operations recorded against a user's AI usage.
+const (
+	OperationAnalyzeEmail    = "analyze_email"
+	OperationSummarizeThread = "summarize_thread"
+	OperationEmbed           = "embed"
+	OperationAssistant       = "assistant"
+	OperationDraftReply      = "draft_reply"
+)
+
+// Usage is the token accounting for a single chat completion. A zero Usage
+// means no request was made (e.g. the result came from the cache).
+type Usage struct {
+	Operation        string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	CostUSD          float64
+}
+
+// modelPrice is the USD price per one million tokens.
+type modelPrice struct {
+	Prompt     float64
+	Completion float64
+}
+
+// modelPrices lists published per-token prices for the models we call. Models
+// missing from this table are recorded with a zero cost estimate.
+var modelPrices = map[string]modelPrice{
+	openai.GPT4oMini: {Prompt: 0.15, Completion: 0.60},
+	openai.GPT4o:     {Prompt: 2.50, Completion: 10.00},
+
+	string(openai.SmallEmbedding3): {Prompt: 0.02},
+}
+
+// TotalTokens returns prompt plus completion tokens.
+func (u Usage) TotalTokens() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// IsZero reports whether no tokens were consumed.
+func (u Usage) IsZero() bool {
+	return u.TotalTokens() == 0
+}
+
+// EstimateCost returns the estimated USD cost of a completion.
+func EstimateCost(model string, promptTokens, completionTokens int) float64 {
+	price, ok := modelPrices[model]
+	if !ok {
+		return 0
+	}
+	return (float64(promptTokens)*price.Prompt + float64(completionTokens)*price.Completion) / 1_000_000
+}
+
+// estimateTokens approximates how many tokens text is, at about four
+// characters per token, for requests the model never reported usage for.
+func estimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// estimatedUsage is the Usage of a request charged by estimateTokens.
+func estimatedUsage(operation, model, prompt, completion string) Usage {
+	return newUsage(operation, model, openai.Usage{
+		PromptTokens:     estimateTokens(prompt),
+		CompletionTokens: estimateTokens(completion),
+	})
+}
+
+func newUsage(operation, model string, u openai.Usage) Usage {
+	return Usage{
+		Operation:        operation,
+		Model:            model,
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		CostUSD:          EstimateCost(model, u.PromptTokens, u.CompletionTokens),
+	}
+}
@@ -0,0 +1,35 @@
+package ai
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestEstimateCost(t *testing.T) {
+	got := EstimateCost(openai.GPT4oMini, 1_000_000, 1_000_000)
+	if math.Abs(got-0.75) > 1e-9 {
+		t.Fatalf("EstimateCost() = %v, want 0.75", got)
+	}
+	if got := EstimateCost("unknown-model", 1000, 1000); got != 0 {
+		t.Fatalf("EstimateCost(unknown) = %v, want 0", got)
+	}
+}
+
+func TestNewUsage(t *testing.T) {
+	u := newUsage(OperationAnalyzeEmail, openai.GPT4oMini, openai.Usage{PromptTokens: 2000, CompletionTokens: 500})
+	if u.TotalTokens() != 2500 || u.IsZero() {
+		t.Fatalf("unexpected usage: %+v", u)
+	}
+	if u.CostUSD <= 0 {
+		t.Fatalf("expected a positive cost estimate, got %v", u.CostUSD)
+	}
+}
+
+func TestEstimatedUsage(t *testing.T) {
+	u := estimatedUsage(OperationAssistant, openai.GPT4oMini, "twelve chars", "₹₹₹₹₹")
+	if u.PromptTokens != 3 || u.CompletionTokens != 2 || u.CostUSD <= 0 {
+		t.Fatalf("unexpected usage: %+v", u)
+	}
+}
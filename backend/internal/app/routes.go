@@ -5,11 +5,16 @@ import (
 
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/r7rainz/auramail/internal/admin"
 	"github.com/r7rainz/auramail/internal/auth"
 	authgoogle "github.com/r7rainz/auramail/internal/auth/google"
 	"github.com/r7rainz/auramail/internal/calendar"
 	"github.com/r7rainz/auramail/internal/config"
+	"github.com/r7rainz/auramail/internal/digest"
 	"github.com/r7rainz/auramail/internal/gmail"
+	"github.com/r7rainz/auramail/internal/notification"
+	"github.com/r7rainz/auramail/internal/push"
+	"github.com/r7rainz/auramail/internal/redact"
 	"github.com/r7rainz/auramail/internal/user"
 )
 
@@ -20,24 +25,72 @@ func RegisterRoutes(mux *http.ServeMux, cfg *config.Config, db *pgxpool.Pool) {
 	googleHandler := authgoogle.NewHandler(googleCfg, userRepo, cfg.FrontendURL)
 	authHandler := auth.NewHandler(googleCfg, userRepo)
 	gmailHandler := gmail.NewHandler(cfg, userRepo)
-	calendarHandler := calendar.NewHandler(userRepo)
+	calendarHandler := calendar.NewHandler(userRepo, calendar.GoogleClient{})
+	notificationHandler := notification.NewHandler(userRepo)
+	digestHandler := digest.NewHandler(userRepo, cfg.DashboardURL())
+	// main checks the VAPID keys at startup; push stays off without them.
+	pushKeys, _ := push.KeysFromConfig(cfg)
+	pushHandler := push.NewHandler(userRepo, pushKeys, cfg.PushServiceHosts)
+	adminHandler := admin.NewHandler(userRepo, redact.LoadOrDefault(cfg.RedactionProfile))
 
 	mux.HandleFunc("/health", healthHandler(db))
 
 	mux.HandleFunc("/auth/google", googleHandler.GoogleAuth)
 	mux.HandleFunc("/auth/google/callback", googleHandler.GoogleCallback)
+	mux.Handle("POST /auth/google/scopes/{scope}", auth.AuthMiddleware(http.HandlerFunc(googleHandler.RequestScope)))
+	mux.Handle("DELETE /auth/google/scopes/{scope}", auth.AuthMiddleware(http.HandlerFunc(googleHandler.RevokeScope)))
 	mux.HandleFunc("POST /auth/refresh", authHandler.Refresh)
 	mux.Handle("GET /auth/me", auth.AuthMiddleware(http.HandlerFunc(authHandler.Me)))
 	mux.Handle("POST /auth/logout", auth.AuthMiddleware(http.HandlerFunc(authHandler.Logout)))
 	mux.Handle("PATCH /auth/me/notifications", auth.AuthMiddleware(http.HandlerFunc(authHandler.UpdateNotifications)))
+	mux.Handle("GET /auth/me/academic-profile", auth.AuthMiddleware(http.HandlerFunc(authHandler.GetAcademicProfile)))
+	mux.Handle("PUT /auth/me/academic-profile", auth.AuthMiddleware(http.HandlerFunc(authHandler.UpdateAcademicProfile)))
+	mux.Handle("GET /auth/me/preferences", auth.AuthMiddleware(http.HandlerFunc(authHandler.GetPreferences)))
+	mux.Handle("PATCH /auth/me/preferences", auth.AuthMiddleware(http.HandlerFunc(authHandler.UpdatePreferences)))
 
 	mux.Handle("GET /emails", auth.AuthMiddleware(http.HandlerFunc(gmailHandler.GetEmails)))
 	mux.Handle("GET /emails/sync", auth.AuthMiddleware(http.HandlerFunc(gmailHandler.SyncPlacementEmails)))
 	mux.Handle("GET /emails/stream", auth.AuthMiddleware(http.HandlerFunc(gmailHandler.StreamPlacementEmails)))
+	mux.Handle("GET /emails/search", auth.AuthMiddleware(http.HandlerFunc(gmailHandler.SearchEmails)))
+	mux.Handle("GET /emails/{gmailMessageId}/similar", auth.AuthMiddleware(http.HandlerFunc(gmailHandler.SimilarEmails)))
 	mux.Handle("GET /emails/{gmailMessageId}/attachments/{attachmentId}", auth.AuthMiddleware(http.HandlerFunc(gmailHandler.GetAttachment)))
+	mux.Handle("PATCH /emails/{gmailMessageId}", auth.AuthMiddleware(http.HandlerFunc(gmailHandler.CorrectEmail)))
 	mux.Handle("PATCH /emails/{gmailMessageId}/important", auth.AuthMiddleware(http.HandlerFunc(gmailHandler.SetImportant)))
+	mux.Handle("POST /emails/{gmailMessageId}/reply-draft", auth.AuthMiddleware(http.HandlerFunc(gmailHandler.DraftReply)))
+	mux.Handle("GET /stats/compensation", auth.AuthMiddleware(http.HandlerFunc(gmailHandler.GetCompensationStats)))
+	mux.Handle("GET /companies", auth.AuthMiddleware(http.HandlerFunc(gmailHandler.GetCompanies)))
+	mux.Handle("GET /companies/{id}", auth.AuthMiddleware(http.HandlerFunc(gmailHandler.GetCompany)))
+	mux.Handle("GET /threads/{threadId}", auth.AuthMiddleware(http.HandlerFunc(gmailHandler.GetThread)))
+	mux.Handle("POST /assistant/ask", auth.AuthMiddleware(http.HandlerFunc(gmailHandler.Ask)))
 
 	mux.Handle("GET /calendar/events", auth.AuthMiddleware(http.HandlerFunc(calendarHandler.GetEvents)))
 	mux.Handle("POST /calendar/events", auth.AuthMiddleware(http.HandlerFunc(calendarHandler.AddEvent)))
+	mux.Handle("PATCH /calendar/events/{id}", auth.AuthMiddleware(http.HandlerFunc(calendarHandler.UpdateEvent)))
 	mux.Handle("DELETE /calendar/events", auth.AuthMiddleware(http.HandlerFunc(calendarHandler.DeleteEvent)))
+	mux.Handle("GET /calendar/calendars", auth.AuthMiddleware(http.HandlerFunc(calendarHandler.ListCalendars)))
+	mux.Handle("POST /calendar/calendars", auth.AuthMiddleware(http.HandlerFunc(calendarHandler.CreateCalendar)))
+	mux.Handle("GET /calendar/conflicts", auth.AuthMiddleware(http.HandlerFunc(calendarHandler.GetConflicts)))
+	mux.Handle("GET /emails/{gmailMessageId}/calendar", auth.AuthMiddleware(http.HandlerFunc(calendarHandler.EmailEvents)))
+	mux.Handle("GET /calendar/rules", auth.AuthMiddleware(http.HandlerFunc(calendarHandler.GetRules)))
+	mux.Handle("PUT /calendar/rules", auth.AuthMiddleware(http.HandlerFunc(calendarHandler.UpdateRules)))
+	mux.Handle("GET /calendar/feed", auth.AuthMiddleware(http.HandlerFunc(calendarHandler.GetFeed)))
+	mux.Handle("POST /calendar/feed", auth.AuthMiddleware(http.HandlerFunc(calendarHandler.CreateFeed)))
+	mux.Handle("DELETE /calendar/feed", auth.AuthMiddleware(http.HandlerFunc(calendarHandler.DeleteFeed)))
+	// Calendar apps fetch the feed without signing in; the token is the secret.
+	mux.HandleFunc("GET /calendar/feed/{file}", calendarHandler.Feed)
+
+	mux.Handle("GET /notifications", auth.AuthMiddleware(http.HandlerFunc(notificationHandler.List)))
+	mux.Handle("POST /notifications/read", auth.AuthMiddleware(http.HandlerFunc(notificationHandler.MarkRead)))
+	mux.Handle("GET /digest/settings", auth.AuthMiddleware(http.HandlerFunc(digestHandler.GetSettings)))
+	mux.Handle("PUT /digest/settings", auth.AuthMiddleware(http.HandlerFunc(digestHandler.UpdateSettings)))
+	mux.Handle("GET /digest/preview", auth.AuthMiddleware(http.HandlerFunc(digestHandler.Preview)))
+	mux.Handle("GET /push/key", auth.AuthMiddleware(http.HandlerFunc(pushHandler.GetKey)))
+	mux.Handle("POST /push/subscriptions", auth.AuthMiddleware(http.HandlerFunc(pushHandler.Subscribe)))
+	mux.Handle("DELETE /push/subscriptions", auth.AuthMiddleware(http.HandlerFunc(pushHandler.Unsubscribe)))
+
+	mux.Handle("GET /admin/usage", auth.AdminMiddleware(cfg.AdminEmails, http.HandlerFunc(adminHandler.GetUsage)))
+	mux.Handle("GET /admin/prompts/{name}", auth.AdminMiddleware(cfg.AdminEmails, http.HandlerFunc(adminHandler.GetPrompt)))
+	mux.Handle("PUT /admin/prompts/{name}", auth.AdminMiddleware(cfg.AdminEmails, http.HandlerFunc(adminHandler.UpdatePrompt)))
+	mux.Handle("POST /admin/prompts/preview", auth.AdminMiddleware(cfg.AdminEmails, http.HandlerFunc(adminHandler.PreviewPrompt)))
+	mux.Handle("GET /admin/corrections/export", auth.AdminMiddleware(cfg.AdminEmails, http.HandlerFunc(adminHandler.ExportCorrections)))
 }
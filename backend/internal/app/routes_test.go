@@ -22,13 +22,51 @@ func TestRegisterRoutes_ProtectedWithoutAuth(t *testing.T) {
 		{http.MethodGet, "/emails"},
 		{http.MethodGet, "/emails/sync"},
 		{http.MethodGet, "/emails/stream"},
+		{http.MethodGet, "/emails/search?semantic=remote"},
+		{http.MethodGet, "/emails/msg-1/similar"},
 		{http.MethodGet, "/emails/msg-1/attachments/att-1"},
+		{http.MethodPost, "/emails/msg-1/reply-draft"},
+		{http.MethodPatch, "/emails/msg-1"},
+		{http.MethodPost, "/auth/google/scopes/gmail.compose"},
+		{http.MethodDelete, "/auth/google/scopes/gmail.compose"},
 		{http.MethodGet, "/calendar/events"},
 		{http.MethodPost, "/calendar/events"},
+		{http.MethodPatch, "/calendar/events/ev1"},
 		{http.MethodDelete, "/calendar/events"},
+		{http.MethodGet, "/calendar/calendars"},
+		{http.MethodPost, "/calendar/calendars"},
+		{http.MethodGet, "/calendar/conflicts"},
+		{http.MethodGet, "/emails/msg-1/calendar"},
+		{http.MethodGet, "/calendar/rules"},
+		{http.MethodPut, "/calendar/rules"},
+		{http.MethodGet, "/calendar/feed"},
+		{http.MethodPost, "/calendar/feed"},
+		{http.MethodDelete, "/calendar/feed"},
+		{http.MethodGet, "/notifications"},
+		{http.MethodPost, "/notifications/read"},
+		{http.MethodGet, "/digest/settings"},
+		{http.MethodPut, "/digest/settings"},
+		{http.MethodGet, "/digest/preview"},
+		{http.MethodGet, "/push/key"},
+		{http.MethodPost, "/push/subscriptions"},
+		{http.MethodDelete, "/push/subscriptions"},
 		{http.MethodGet, "/auth/me"},
 		{http.MethodPost, "/auth/logout"},
 		{http.MethodPatch, "/auth/me/notifications"},
+		{http.MethodGet, "/auth/me/academic-profile"},
+		{http.MethodPut, "/auth/me/academic-profile"},
+		{http.MethodGet, "/auth/me/preferences"},
+		{http.MethodPatch, "/auth/me/preferences"},
+		{http.MethodGet, "/stats/compensation"},
+		{http.MethodGet, "/companies"},
+		{http.MethodGet, "/companies/1"},
+		{http.MethodGet, "/threads/t-1"},
+		{http.MethodPost, "/assistant/ask"},
+		{http.MethodGet, "/admin/usage"},
+		{http.MethodGet, "/admin/prompts/analyze_email"},
+		{http.MethodPut, "/admin/prompts/analyze_email"},
+		{http.MethodPost, "/admin/prompts/preview"},
+		{http.MethodGet, "/admin/corrections/export"},
 	}
 	for _, p := range paths {
 		req := httptest.NewRequest(p.method, p.path, nil)
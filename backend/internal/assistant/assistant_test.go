@@ -0,0 +1,116 @@
+package assistant
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/eligibility"
+)
+
+// wednesday is 2026-08-12; its week runs to Sunday 2026-08-16.
+var wednesday = time.Date(2026, 8, 12, 15, 0, 0, 0, time.UTC)
+
+func strPtr(s string) *string { return &s }
+
+func TestParseQuestion(t *testing.T) {
+	q := ParseQuestion("Which drives close this week that I'm eligible for?", wednesday)
+	if !q.EligibleOnly {
+		t.Error("expected EligibleOnly")
+	}
+	if q.DeadlineFrom == nil || q.DeadlineFrom.Format(time.DateOnly) != "2026-08-12" || q.DeadlineTo.Format(time.DateOnly) != "2026-08-16" {
+		t.Errorf("unexpected window: %v – %v", q.DeadlineFrom, q.DeadlineTo)
+	}
+	if len(q.Keywords) != 0 {
+		t.Errorf("expected no keywords, got %v", q.Keywords)
+	}
+
+	q = ParseQuestion("When is the Amazon OA?", wednesday)
+	if !reflect.DeepEqual(q.Keywords, []string{"amazon", "oa"}) || !reflect.DeepEqual(q.Categories, []string{"exam"}) {
+		t.Errorf("keywords = %v, categories = %v", q.Keywords, q.Categories)
+	}
+	if q.HasFilters() {
+		t.Error("expected no structured filters")
+	}
+
+	q = ParseQuestion("internships due next week", wednesday)
+	if q.DeadlineFrom.Format(time.DateOnly) != "2026-08-17" || q.DeadlineTo.Format(time.DateOnly) != "2026-08-23" {
+		t.Errorf("unexpected next-week window: %v – %v", q.DeadlineFrom, q.DeadlineTo)
+	}
+	if !reflect.DeepEqual(q.Keywords, []string{"internship"}) {
+		t.Errorf("keywords = %v", q.Keywords)
+	}
+}
+
+func TestRetrieve_StructuredFilters(t *testing.T) {
+	summaries := []*ai.AIResult{
+		{GmailMessageID: "late", Subject: "Infosys drive", Deadline: strPtr("2026-08-20")},
+		{GmailMessageID: "cgpa", Subject: "Goldman drive", Deadline: strPtr("2026-08-13"), Eligibility: "Minimum CGPA 9.0"},
+		{GmailMessageID: "soon", Subject: "TCS drive", Deadline: strPtr("2026-08-15")},
+		{GmailMessageID: "first", Subject: "Wipro drive", Deadline: strPtr("2026-08-14")},
+		{GmailMessageID: "none", Subject: "Newsletter"},
+	}
+	cgpa := 8.1
+	profile := &eligibility.Profile{CGPA: &cgpa}
+
+	q := ParseQuestion("which drives close this week that I'm eligible for?", wednesday)
+	var got []string
+	for _, s := range Retrieve(q, summaries, profile, 0) {
+		got = append(got, s.GmailMessageID)
+	}
+	if want := []string{"first", "soon"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Retrieve() = %v, want %v", got, want)
+	}
+}
+
+func TestRetrieve_KeywordsRankAndRequireAMatch(t *testing.T) {
+	summaries := []*ai.AIResult{
+		{GmailMessageID: "drive", Subject: "Amazon SDE drive", Company: strPtr("Amazon"), Category: "job offer"},
+		{GmailMessageID: "oa", Subject: "Online assessment link", Company: strPtr("Amazon"), Category: "exam", Timings: "OA on 14 Aug, 10 AM"},
+		{GmailMessageID: "other", Subject: "Flipkart OA", Company: strPtr("Flipkart"), Category: "exam"},
+		{GmailMessageID: "unrelated", Subject: "Hackathon", Category: "workshop"},
+	}
+
+	q := ParseQuestion("When is the Amazon OA?", wednesday)
+	var got []string
+	for _, s := range Retrieve(q, summaries, nil, 0) {
+		got = append(got, s.GmailMessageID)
+	}
+	if want := []string{"oa", "other", "drive"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Retrieve() = %v, want %v", got, want)
+	}
+}
+
+func TestFakeAnswerCitesSources(t *testing.T) {
+	sources := []Source{
+		{GmailMessageID: "m1", Company: "Amazon", Role: "SDE", Deadline: "2026-08-14", Timings: "OA on 14 Aug"},
+		{GmailMessageID: "m2", Subject: "Wipro drive", Eligible: eligibility.No},
+	}
+	var b strings.Builder
+	usage, err := Fake{}.Answer(context.Background(), Request{Question: "q", Sources: sources}, func(d string) error {
+		b.WriteString(d)
+		return nil
+	})
+	if err != nil || !usage.IsZero() {
+		t.Fatalf("Answer() usage = %+v, err = %v", usage, err)
+	}
+	answer := b.String()
+	if !strings.Contains(answer, "- Amazon – SDE: deadline 2026-08-14; OA on 14 Aug [m1]") ||
+		!strings.Contains(answer, "- Wipro drive: you are not eligible [m2]") {
+		t.Errorf("unexpected answer:\n%s", answer)
+	}
+	if got := Citations(answer, sources); !reflect.DeepEqual(got, []string{"m1", "m2"}) {
+		t.Errorf("Citations() = %v", got)
+	}
+}
+
+func TestCitationsIgnoresUnknownIDs(t *testing.T) {
+	sources := []Source{{GmailMessageID: "m1"}, {GmailMessageID: "m2"}}
+	got := Citations("Deadline is 14 Aug [m2]. See [note] and [m2], also [m1].", sources)
+	if want := []string{"m2", "m1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Citations() = %v, want %v", got, want)
+	}
+}
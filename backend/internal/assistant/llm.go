@@ -0,0 +1,176 @@
+package assistant
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/eligibility"
+)
+
+// LLM modes accepted by New.
+const (
+	ModeOpenAI = "openai"
+	ModeFake   = "fake"
+)
+
+// Request is one question with the sources retrieved for it.
+type Request struct {
+	Question string
+	Sources  []Source
+	Now      time.Time
+}
+
+// LLM answers a Request, streaming the answer through onDelta. An error
+// from onDelta stops the answer and is returned.
+type LLM interface {
+	Name() string
+	Answer(ctx context.Context, req Request, onDelta func(string) error) (ai.Usage, error)
+}
+
+// New returns the LLM for mode. An empty mode uses OpenAI when a key is
+// configured and the fake otherwise, so the endpoint still answers
+// (extractively) without one.
+func New(mode string) (LLM, error) {
+	switch mode {
+	case "":
+		if ai.Available() {
+			return OpenAI{}, nil
+		}
+		return Fake{}, nil
+	case ModeOpenAI:
+		if !ai.Available() {
+			return nil, ai.ErrOpenAIKeyMissing
+		}
+		return OpenAI{}, nil
+	case ModeFake:
+		return Fake{}, nil
+	}
+	return nil, fmt.Errorf("unknown assistant mode %q", mode)
+}
+
+// OpenAI answers with the configured OpenAI chat model.
+type OpenAI struct{}
+
+// Name implements LLM.
+func (OpenAI) Name() string { return ModeOpenAI }
+
+// Answer implements LLM.
+func (OpenAI) Answer(ctx context.Context, req Request, onDelta func(string) error) (ai.Usage, error) {
+	system, user := Prompt(req)
+	return ai.StreamChat(ctx, ai.OperationAssistant, system, user, onDelta)
+}
+
+// Prompt builds the system and user prompts for req.
+func Prompt(req Request) (system, user string) {
+	system = fmt.Sprintf(`You answer a student's questions about their campus placement emails.
+Today is %s.
+
+RULES:
+- Use ONLY the emails provided. If they do not answer the question, say you could not find it in their inbox.
+- Cite every email you rely on by its ID in square brackets right after the fact, e.g. "The OA is on 14 Aug [18c2f0a1b]".
+- Be brief: a sentence or a short bullet list. Give dates as written in the emails.
+- An email marked "eligible: no" is one the student does not qualify for; mention that when relevant.`, req.Now.Format("Monday, 2 January 2006"))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Question: %s\n\nEmails:\n", req.Question)
+	if len(req.Sources) == 0 {
+		b.WriteString("(none matched)\n")
+	}
+	for _, s := range req.Sources {
+		fmt.Fprintf(&b, "\n[%s]\nSubject: %s\n", s.GmailMessageID, s.Subject)
+		field := func(label, value string) {
+			if value != "" {
+				fmt.Fprintf(&b, "%s: %s\n", label, value)
+			}
+		}
+		field("Company", s.Company)
+		field("Role", s.Role)
+		field("Category", s.Category)
+		field("Received", s.ReceivedAt)
+		field("Deadline", s.Deadline)
+		field("Timings", s.Timings)
+		field("Location", s.Location)
+		field("Eligible", string(s.Eligible))
+		field("Summary", s.Summary)
+	}
+	return system, b.String()
+}
+
+// Fake answers without a model by listing the retrieved sources. It is
+// deterministic, so tests and offline deployments get a stable, cited
+// answer.
+type Fake struct{}
+
+// Name implements LLM.
+func (Fake) Name() string { return ModeFake }
+
+// Answer implements LLM. It streams one line at a time and reports no
+// usage.
+func (Fake) Answer(ctx context.Context, req Request, onDelta func(string) error) (ai.Usage, error) {
+	lines := []string{"I couldn't find anything in your placement emails that answers this."}
+	if len(req.Sources) > 0 {
+		lines = []string{"Here is what I found in your placement emails:\n"}
+		for _, s := range req.Sources {
+			lines = append(lines, fakeLine(s))
+		}
+	}
+	for _, line := range lines {
+		if err := ctx.Err(); err != nil {
+			return ai.Usage{}, err
+		}
+		if err := onDelta(line); err != nil {
+			return ai.Usage{}, err
+		}
+	}
+	return ai.Usage{}, nil
+}
+
+func fakeLine(s Source) string {
+	title := s.Subject
+	if s.Company != "" {
+		title = s.Company
+		if s.Role != "" {
+			title += " – " + s.Role
+		}
+	}
+	var details []string
+	if s.Deadline != "" {
+		details = append(details, "deadline "+s.Deadline)
+	}
+	if s.Timings != "" {
+		details = append(details, s.Timings)
+	}
+	if s.Eligible == eligibility.No {
+		details = append(details, "you are not eligible")
+	}
+	line := "- " + title
+	if len(details) > 0 {
+		line += ": " + strings.Join(details, "; ")
+	}
+	return line + " [" + s.GmailMessageID + "]\n"
+}
+
+var citationPattern = regexp.MustCompile(`\[([A-Za-z0-9_-]+)\]`)
+
+// Citations returns the IDs of sources cited in answer, in order of first
+// citation. Bracketed text that is not a source ID is ignored.
+func Citations(answer string, sources []Source) []string {
+	known := make(map[string]bool, len(sources))
+	for _, s := range sources {
+		known[s.GmailMessageID] = true
+	}
+	cited := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, m := range citationPattern.FindAllStringSubmatch(answer, -1) {
+		id := m[1]
+		if known[id] && !seen[id] {
+			seen[id] = true
+			cited = append(cited, id)
+		}
+	}
+	return cited
+}
@@ -0,0 +1,156 @@
+// Package assistant answers questions about a user's placement emails
+// ("which drives close this week that I'm eligible for?"). It narrows the
+// stored summaries with keyword and structured filters, then has an LLM
+// answer from those summaries alone, citing gmail message IDs.
+package assistant
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Query is a question reduced to retrieval filters.
+type Query struct {
+	Question string
+	// Keywords are the question's content words, matched against subject,
+	// company, role, tags and summary.
+	Keywords []string
+	// Categories are analysis categories the question asks about; matching
+	// summaries rank higher but others are not excluded.
+	Categories []string
+	// DeadlineFrom and DeadlineTo, when set, keep only summaries whose
+	// deadline falls within the inclusive date range.
+	DeadlineFrom *time.Time
+	DeadlineTo   *time.Time
+	// EligibleOnly drops summaries the user's profile is known to fail.
+	EligibleOnly bool
+}
+
+// HasFilters reports whether the query restricts summaries beyond keywords.
+func (q Query) HasFilters() bool {
+	return q.DeadlineFrom != nil || q.EligibleOnly
+}
+
+// stopWords are question and filler words that carry nothing to match on.
+// Generic nouns like "drive" and "deadline" are included because the
+// structured filters already cover them.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "and": true, "or": true, "of": true, "for": true,
+	"to": true, "in": true, "on": true, "at": true, "by": true, "with": true, "from": true,
+	"is": true, "are": true, "was": true, "were": true, "be": true, "do": true, "does": true,
+	"did": true, "have": true, "has": true, "i": true, "im": true, "me": true, "my": true,
+	"am": true, "it": true, "that": true, "this": true, "these": true, "those": true,
+	"which": true, "what": true, "when": true, "where": true, "who": true, "how": true,
+	"any": true, "all": true, "there": true, "can": true, "should": true, "will": true,
+	"next": true, "week": true, "month": true, "day": true, "today": true, "tomorrow": true,
+	"tonight": true, "upcoming": true, "eligible": true, "qualify": true, "drive": true,
+	"email": true, "mail": true, "opportunity": true, "opening": true, "close": true,
+	"closing": true, "due": true, "deadline": true, "apply": true, "application": true,
+	"tell": true, "show": true, "list": true, "about": true, "please": true, "company": true,
+}
+
+// categoryTerms map question words onto analysis categories.
+var categoryTerms = map[string]string{
+	"oa":           "exam",
+	"assessment":   "exam",
+	"test":         "exam",
+	"exam":         "exam",
+	"aptitude":     "exam",
+	"coding":       "exam",
+	"interview":    "interview",
+	"internship":   "internship",
+	"intern":       "internship",
+	"job":          "job offer",
+	"fte":          "job offer",
+	"result":       "result",
+	"shortlist":    "result",
+	"shortlisted":  "result",
+	"ppt":          "ppt",
+	"talk":         "ppt",
+	"workshop":     "workshop",
+	"hackathon":    "workshop",
+	"webinar":      "workshop",
+	"registration": "registration",
+	"register":     "registration",
+}
+
+var nextDaysPattern = regexp.MustCompile(`\b(?:next|coming)\s+(\d{1,2})\s+days?\b`)
+
+// ParseQuestion extracts filters from a natural-language question. Relative
+// dates ("this week", "tomorrow") are resolved against now.
+func ParseQuestion(question string, now time.Time) Query {
+	q := Query{Question: strings.TrimSpace(question)}
+	lower := strings.ToLower(q.Question)
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	window := func(from, to time.Time) {
+		q.DeadlineFrom, q.DeadlineTo = &from, &to
+	}
+	// Weeks run Monday to Sunday.
+	daysToSunday := (7 - int(today.Weekday())) % 7
+	switch {
+	case nextDaysPattern.MatchString(lower):
+		n, _ := strconv.Atoi(nextDaysPattern.FindStringSubmatch(lower)[1])
+		window(today, today.AddDate(0, 0, n))
+	case strings.Contains(lower, "today") || strings.Contains(lower, "tonight"):
+		window(today, today)
+	case strings.Contains(lower, "tomorrow"):
+		window(today.AddDate(0, 0, 1), today.AddDate(0, 0, 1))
+	case strings.Contains(lower, "next week"):
+		monday := today.AddDate(0, 0, daysToSunday+1)
+		window(monday, monday.AddDate(0, 0, 6))
+	case strings.Contains(lower, "this week"):
+		window(today, today.AddDate(0, 0, daysToSunday))
+	case strings.Contains(lower, "this month"):
+		window(today, time.Date(today.Year(), today.Month()+1, 0, 0, 0, 0, 0, time.UTC))
+	}
+
+	q.EligibleOnly = strings.Contains(lower, "eligible") || strings.Contains(lower, "qualify")
+
+	seen := make(map[string]bool)
+	for _, word := range words(lower) {
+		tok := singular(word)
+		if category, ok := categoryTerms[tok]; ok && !seen["c:"+category] {
+			seen["c:"+category] = true
+			q.Categories = append(q.Categories, category)
+		}
+		if len(tok) < 2 || stopWords[word] || stopWords[tok] || seen[tok] {
+			continue
+		}
+		if _, err := strconv.Atoi(tok); err == nil {
+			continue
+		}
+		seen[tok] = true
+		q.Keywords = append(q.Keywords, tok)
+	}
+	return q
+}
+
+// Tokens lower-cases text into words with plurals reduced to the singular,
+// so "internships" matches "internship" and "companies" matches "company".
+func Tokens(text string) []string {
+	ws := words(text)
+	for i, w := range ws {
+		ws[i] = singular(w)
+	}
+	return ws
+}
+
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func singular(w string) string {
+	switch {
+	case len(w) > 4 && strings.HasSuffix(w, "ies"):
+		return strings.TrimSuffix(w, "ies") + "y"
+	case len(w) > 3 && strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss"):
+		return strings.TrimSuffix(w, "s")
+	}
+	return w
+}
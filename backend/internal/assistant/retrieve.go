@@ -0,0 +1,159 @@
+package assistant
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/eligibility"
+)
+
+// DefaultSources is how many summaries an answer is grounded on by default.
+const DefaultSources = 8
+
+// Source is a summary the answer may draw on and cite.
+type Source struct {
+	GmailMessageID string              `json:"gmailMessageId"`
+	Subject        string              `json:"subject"`
+	Company        string              `json:"company,omitempty"`
+	Role           string              `json:"role,omitempty"`
+	Category       string              `json:"category"`
+	Deadline       string              `json:"deadline,omitempty"`
+	Timings        string              `json:"timings,omitempty"`
+	Location       string              `json:"location,omitempty"`
+	ReceivedAt     string              `json:"receivedAt"`
+	Summary        string              `json:"summary"`
+	Eligible       eligibility.Verdict `json:"eligible"`
+}
+
+// Retrieve picks the summaries that best answer q, at most limit of them.
+// Structured filters (deadline window, eligibility) exclude summaries;
+// keywords and categories rank them. Without filters, a summary must match
+// at least one keyword or category to be kept. summaries are expected
+// newest first, which breaks ties among equally relevant ones.
+func Retrieve(q Query, summaries []*ai.AIResult, profile *eligibility.Profile, limit int) []Source {
+	if limit <= 0 {
+		limit = DefaultSources
+	}
+
+	type candidate struct {
+		source   Source
+		score    int
+		deadline time.Time
+	}
+	var candidates []candidate
+	for _, s := range summaries {
+		deadline := parseDeadline(s.Deadline)
+		if q.DeadlineFrom != nil {
+			if deadline.IsZero() || deadline.Before(*q.DeadlineFrom) || deadline.After(*q.DeadlineTo) {
+				continue
+			}
+		}
+		verdict := eligibility.Match(eligibility.Parse(ai.FieldText(s.Eligibility)), profile)
+		if q.EligibleOnly && verdict.Verdict == eligibility.No {
+			continue
+		}
+
+		score := relevance(q, s)
+		if score == 0 && !q.HasFilters() {
+			continue
+		}
+		candidates = append(candidates, candidate{source: sourceOf(s, verdict.Verdict), score: score, deadline: deadline})
+	}
+
+	// Most relevant first; among equals, the soonest deadline.
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		if a.deadline.IsZero() || b.deadline.IsZero() {
+			return !a.deadline.IsZero() && b.deadline.IsZero()
+		}
+		return a.deadline.Before(b.deadline)
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	sources := make([]Source, 0, len(candidates))
+	for _, c := range candidates {
+		sources = append(sources, c.source)
+	}
+	return sources
+}
+
+// relevance scores how well s matches the query's keywords and categories.
+// Company and role matches count most, then the subject, then the rest.
+func relevance(q Query, s *ai.AIResult) int {
+	fields := []struct {
+		text   string
+		weight int
+	}{
+		{deref(s.Company) + " " + deref(s.Role), 3},
+		{s.Subject, 2},
+		{strings.Join([]string{s.Summary, s.Category, strings.Join(s.Tags, " "), ai.FieldText(s.Timings), ai.FieldText(s.Location), ai.FieldText(s.Salary)}, " "), 1},
+	}
+	tokenSets := make([]map[string]bool, len(fields))
+	for i, f := range fields {
+		tokenSets[i] = make(map[string]bool)
+		for _, tok := range Tokens(f.text) {
+			tokenSets[i][tok] = true
+		}
+	}
+
+	score := 0
+	for _, kw := range q.Keywords {
+		for i, f := range fields {
+			if tokenSets[i][kw] {
+				score += f.weight
+				break
+			}
+		}
+	}
+	for _, c := range q.Categories {
+		if s.Category == c {
+			score += 2
+		}
+	}
+	return score
+}
+
+func sourceOf(s *ai.AIResult, verdict eligibility.Verdict) Source {
+	subject := s.Subject
+	if subject == "" {
+		subject = s.Summary
+	}
+	return Source{
+		GmailMessageID: s.GmailMessageID,
+		Subject:        subject,
+		Company:        deref(s.Company),
+		Role:           deref(s.Role),
+		Category:       s.Category,
+		Deadline:       deref(s.Deadline),
+		Timings:        ai.FieldText(s.Timings),
+		Location:       ai.FieldText(s.Location),
+		ReceivedAt:     s.ReceiverAt,
+		Summary:        s.Summary,
+		Eligible:       verdict,
+	}
+}
+
+func parseDeadline(d *string) time.Time {
+	if d == nil {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.DateOnly, *d)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
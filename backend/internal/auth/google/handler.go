@@ -1,14 +1,19 @@
 package google
 
 import (
+	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/r7rainz/auramail/internal/auth"
+	"github.com/r7rainz/auramail/internal/response"
 	"github.com/r7rainz/auramail/internal/user"
 	"golang.org/x/oauth2"
 )
@@ -38,6 +43,25 @@ func NewHandler(cfg *oauth2.Config, userRepo user.Repository, frontendURL string
 	}
 }
 
+// userInfoURL is Google's OpenID Connect userinfo endpoint; tests point it
+// at a fake.
+var userInfoURL = "https://www.googleapis.com/oauth2/v3/userinfo"
+
+// errInvalidUserInfo means Google rejected the token or sent an unusable
+// profile.
+var errInvalidUserInfo = errors.New("invalid google userinfo response")
+
+// grantCookie holds the binding secret of a pending scope grant. Its path
+// covers both the grant request and the OAuth callback.
+const (
+	grantCookie     = "auramail_grant"
+	grantCookiePath = "/auth/google"
+)
+
+// includeGrantedScopes asks Google for a token covering the scopes the user
+// already granted as well as the requested ones.
+var includeGrantedScopes = oauth2.SetAuthURLParam("include_granted_scopes", "true")
+
 // GoogleAuth starts the OAuth flow by redirecting the user to Google's consent screen
 func (h *Handler) GoogleAuth(w http.ResponseWriter, r *http.Request) {
 	// 1. Generate a secure, random state string
@@ -53,6 +77,7 @@ func (h *Handler) GoogleAuth(w http.ResponseWriter, r *http.Request) {
 		state,
 		oauth2.AccessTypeOffline, // Request a refresh token from Google
 		oauth2.ApprovalForce,     // Force consent screen to ensure we get the refresh token
+		includeGrantedScopes,     // Keep optional scopes granted earlier in the new token
 	)
 
 	// 3. Send the user to Google
@@ -63,10 +88,15 @@ func (h *Handler) GoogleAuth(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) GoogleCallback(w http.ResponseWriter, r *http.Request) {
 	// 1. Verify the state matches what we generated (prevents CSRF attacks)
 	state := r.URL.Query().Get("state")
-	if !h.stateManager.Validate(state) {
+	grant, ok := h.stateManager.Consume(state)
+	if !ok {
 		http.Error(w, "invalid state", http.StatusBadRequest)
 		return
 	}
+	if grant.UserID != "" {
+		h.completeGrant(w, r, grant)
+		return
+	}
 
 	// 2. Grab the authorization code Google sent back
 	codeStr := r.URL.Query().Get("code")
@@ -86,30 +116,14 @@ func (h *Handler) GoogleCallback(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 4. Use Google's access token to fetch the user's profile info
-	client := h.oauthConfig.Client(ctx, googleToken)
-	resp, err := client.Get("https://www.googleapis.com/oauth2/v3/userinfo")
-	if err != nil {
-		slog.Error("userinfo request failed", "err", err)
-		http.Error(w, "failed to fetch user info", http.StatusInternalServerError)
-		return
-	}
-	defer func() { _ = resp.Body.Close() }()
-
-	if resp.StatusCode != http.StatusOK {
+	googleUser, err := h.fetchGoogleUser(ctx, googleToken)
+	if errors.Is(err, errInvalidUserInfo) {
 		http.Error(w, "invalid google response", http.StatusUnauthorized)
 		return
 	}
-
-	// 5. Parse the user info
-	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		http.Error(w, "failed to read response", http.StatusInternalServerError)
-		return
-	}
-
-	var googleUser GoogleUser
-	if err := json.Unmarshal(body, &googleUser); err != nil {
-		http.Error(w, "invalid google user payload", http.StatusInternalServerError)
+		slog.Error("userinfo request failed", "err", err)
+		http.Error(w, "failed to fetch user info", http.StatusInternalServerError)
 		return
 	}
 
@@ -168,6 +182,200 @@ func (h *Handler) GoogleCallback(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, redirectURL, http.StatusTemporaryRedirect)
 }
 
+// RequestScope starts an incremental grant of an optional scope for the
+// signed-in user. It responds with the consent URL for the frontend to
+// open; the callback records the grant and redirects back to the frontend.
+// The request must be sent with credentials so the browser keeps the grant
+// cookie the callback checks.
+func (h *Handler) RequestScope(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(auth.UserIDContextKey).(string)
+	if !ok {
+		response.Unauthorized(w, "No UserID found in context")
+		return
+	}
+	scope, ok := OptionalScopes[r.PathValue("scope")]
+	if !ok {
+		response.BadRequest(w, "unknown scope", nil)
+		return
+	}
+
+	u, err := h.userRepo.FindByID(r.Context(), userID)
+	if err != nil {
+		response.NotFound(w, "User not found")
+		return
+	}
+
+	state, binding, err := h.stateManager.GenerateGrant(userID, scope)
+	if err != nil {
+		slog.Error("failed to generate oauth state", "err", err)
+		response.InternalError(w, "Failed to start authorization")
+		return
+	}
+	// The consent URL only completes in this browser, so it is useless to
+	// anyone it is forwarded to.
+	http.SetCookie(w, &http.Cookie{
+		Name:     grantCookie,
+		Value:    binding,
+		Path:     grantCookiePath,
+		MaxAge:   int(stateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	// Only the new scope is listed; include_granted_scopes keeps the
+	// login scopes in the resulting token. login_hint preselects the
+	// signed-in user's Google account.
+	grantCfg := *h.oauthConfig
+	grantCfg.Scopes = []string{scope}
+	authURL := grantCfg.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.ApprovalForce, includeGrantedScopes,
+		oauth2.SetAuthURLParam("login_hint", u.Email))
+
+	response.Success(w, map[string]string{"url": authURL})
+}
+
+// RevokeScope stops the app from using an optional scope. Access remains
+// on the Google token until the user removes it from their Google account.
+func (h *Handler) RevokeScope(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(auth.UserIDContextKey).(string)
+	if !ok {
+		response.Unauthorized(w, "No UserID found in context")
+		return
+	}
+	scope, ok := OptionalScopes[r.PathValue("scope")]
+	if !ok {
+		response.BadRequest(w, "unknown scope", nil)
+		return
+	}
+
+	if err := h.userRepo.RevokeGoogleScope(r.Context(), userID, scope); err != nil {
+		slog.Error("failed to revoke scope", "userID", userID, "err", err)
+		response.InternalError(w, "Failed to revoke authorization")
+		return
+	}
+	response.NoContent(w)
+}
+
+// completeGrant finishes an incremental scope request. It must come back
+// to the browser that asked for the grant, and the consenting Google
+// account must be the user's own; otherwise anyone who opened a forwarded
+// consent URL would attach their Gmail to the requester's account. The
+// grant is only recorded when Google reports the scope in the token, since
+// users can untick it on the consent screen.
+func (h *Handler) completeGrant(w http.ResponseWriter, r *http.Request, grant Grant) {
+	ctx := r.Context()
+	redirect := func(status string) {
+		http.Redirect(w, r, fmt.Sprintf("%s/settings?scope=%s&status=%s", h.frontendURL, url.QueryEscape(scopeName(grant.Scope)), status), http.StatusTemporaryRedirect)
+	}
+
+	cookie, err := r.Cookie(grantCookie)
+	http.SetCookie(w, &http.Cookie{Name: grantCookie, Path: grantCookiePath, MaxAge: -1, HttpOnly: true, Secure: isHTTPS(r), SameSite: http.SameSiteLaxMode})
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(grant.Binding)) != 1 {
+		slog.Warn("scope grant completed from another browser", "userID", grant.UserID, "scope", grant.Scope)
+		redirect("error")
+		return
+	}
+
+	codeStr := r.URL.Query().Get("code")
+	if codeStr == "" {
+		redirect("denied")
+		return
+	}
+
+	token, err := h.oauthConfig.Exchange(ctx, codeStr)
+	if err != nil {
+		slog.Error("oauth exchange failed", "err", err)
+		redirect("error")
+		return
+	}
+	if !tokenHasScope(token, grant.Scope) {
+		slog.Info("optional scope not granted", "userID", grant.UserID, "scope", grant.Scope)
+		redirect("denied")
+		return
+	}
+
+	u, err := h.userRepo.FindByID(ctx, grant.UserID)
+	if err != nil {
+		slog.Error("scope grant for unknown user", "userID", grant.UserID, "err", err)
+		redirect("error")
+		return
+	}
+	googleUser, err := h.fetchGoogleUser(ctx, token)
+	if err != nil {
+		slog.Error("userinfo request failed", "err", err)
+		redirect("error")
+		return
+	}
+	if googleUser.Sub == "" || googleUser.Sub != u.ProviderID {
+		slog.Warn("scope granted by a different google account", "userID", grant.UserID, "scope", grant.Scope)
+		redirect("wrong_account")
+		return
+	}
+
+	if token.RefreshToken != "" {
+		if err := h.userRepo.UpdateGoogleRefreshToken(ctx, grant.UserID, token.RefreshToken); err != nil {
+			slog.Error("failed to save google refresh token", "err", err)
+			redirect("error")
+			return
+		}
+	}
+	if err := h.userRepo.GrantGoogleScope(ctx, grant.UserID, grant.Scope); err != nil {
+		slog.Error("failed to record scope grant", "userID", grant.UserID, "err", err)
+		redirect("error")
+		return
+	}
+	slog.Info("optional scope granted", "userID", grant.UserID, "scope", grant.Scope)
+	redirect("granted")
+}
+
+// fetchGoogleUser returns the Google account token was issued for.
+func (h *Handler) fetchGoogleUser(ctx context.Context, token *oauth2.Token) (*GoogleUser, error) {
+	resp, err := h.oauthConfig.Client(ctx, token).Get(userInfoURL)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: status %d", errInvalidUserInfo, resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var googleUser GoogleUser
+	if err := json.Unmarshal(body, &googleUser); err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidUserInfo, err)
+	}
+	return &googleUser, nil
+}
+
+// isHTTPS reports whether the client reached the backend over TLS,
+// directly or through a proxy.
+func isHTTPS(r *http.Request) bool {
+	return r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+}
+
+// tokenHasScope reports whether Google listed scope among those granted.
+func tokenHasScope(token *oauth2.Token, scope string) bool {
+	granted, _ := token.Extra("scope").(string)
+	for _, s := range strings.Fields(granted) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func scopeName(scope string) string {
+	for name, s := range OptionalScopes {
+		if s == scope {
+			return name
+		}
+	}
+	return scope
+}
+
 // RegisterRoutes binds the handlers to the provided mux
 func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/auth/google", h.GoogleAuth)
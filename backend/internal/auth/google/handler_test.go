@@ -0,0 +1,107 @@
+package google
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/r7rainz/auramail/internal/user"
+	"golang.org/x/oauth2"
+)
+
+type fakeUserRepo struct {
+	user.Repository
+	u       *user.User
+	granted []string
+}
+
+func (f *fakeUserRepo) FindByID(ctx context.Context, id string) (*user.User, error) {
+	return f.u, nil
+}
+
+func (f *fakeUserRepo) UpdateGoogleRefreshToken(ctx context.Context, userID, token string) error {
+	return nil
+}
+
+func (f *fakeUserRepo) GrantGoogleScope(ctx context.Context, userID, scope string) error {
+	f.granted = append(f.granted, scope)
+	return nil
+}
+
+// newGrantHandler returns a handler whose token and userinfo endpoints
+// report the Google account sub.
+func newGrantHandler(t *testing.T, sub string) (*Handler, *fakeUserRepo) {
+	t.Helper()
+	google := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/token" {
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"access_token":  "access",
+				"refresh_token": "refresh",
+				"token_type":    "Bearer",
+				"scope":         ScopeGmailCompose,
+			})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(GoogleUser{Sub: sub, Email: "someone@example.com"})
+	}))
+	t.Cleanup(google.Close)
+
+	prev := userInfoURL
+	userInfoURL = google.URL + "/userinfo"
+	t.Cleanup(func() { userInfoURL = prev })
+
+	repo := &fakeUserRepo{u: &user.User{ID: "7", ProviderID: "google-a", Email: "a@example.com"}}
+	cfg := &oauth2.Config{
+		ClientID: "client",
+		Endpoint: oauth2.Endpoint{AuthURL: google.URL + "/auth", TokenURL: google.URL + "/token"},
+	}
+	return NewHandler(cfg, repo, "http://frontend"), repo
+}
+
+func callback(h *Handler, state string, cookie *http.Cookie) string {
+	req := httptest.NewRequest(http.MethodGet, "/auth/google/callback?code=c&state="+url.QueryEscape(state), nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	h.GoogleCallback(rec, req)
+	loc, _ := url.Parse(rec.Header().Get("Location"))
+	return loc.Query().Get("status")
+}
+
+func TestCompleteGrant(t *testing.T) {
+	tests := []struct {
+		name       string
+		sub        string
+		withCookie bool
+		want       string
+	}{
+		{"own account", "google-a", true, "granted"},
+		{"another google account", "google-b", true, "wrong_account"},
+		{"another browser", "google-a", false, "error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, repo := newGrantHandler(t, tt.sub)
+			state, binding, err := h.stateManager.GenerateGrant("7", ScopeGmailCompose)
+			if err != nil {
+				t.Fatalf("GenerateGrant() error = %v", err)
+			}
+			var cookie *http.Cookie
+			if tt.withCookie {
+				cookie = &http.Cookie{Name: grantCookie, Value: binding}
+			}
+
+			if got := callback(h, state, cookie); got != tt.want {
+				t.Errorf("status = %q, want %q", got, tt.want)
+			}
+			if granted := len(repo.granted) == 1; granted != (tt.want == "granted") {
+				t.Errorf("granted scopes = %v", repo.granted)
+			}
+		})
+	}
+}
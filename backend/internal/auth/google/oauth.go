@@ -13,6 +13,16 @@ import (
 	"google.golang.org/api/option"
 )
 
+// ScopeGmailCompose lets the app create Gmail drafts. It is requested
+// incrementally, only when a user opts into saving reply drafts.
+const ScopeGmailCompose = "https://www.googleapis.com/auth/gmail.compose"
+
+// OptionalScopes maps the short names used in the API to the optional
+// scopes a user can grant after login.
+var OptionalScopes = map[string]string{
+	"gmail.compose": ScopeGmailCompose,
+}
+
 // Common errors for OAuth operations
 var (
 	ErrEmptyRefreshToken  = errors.New("refresh token is empty or invalid")
@@ -7,26 +7,70 @@ import (
 	"time"
 )
 
+// Grant identifies an incremental scope request carried through the OAuth
+// round trip. It is zero for a login.
+type Grant struct {
+	UserID string
+	Scope  string
+	// Binding is a secret also stored in a cookie on the browser that
+	// asked for the grant; the callback must come from that browser.
+	Binding string
+}
+
+// stateTTL is how long an OAuth round trip may take.
+const stateTTL = 10 * time.Minute
+
+type stateEntry struct {
+	expiry time.Time
+	grant  Grant
+}
+
 // StateManager issues and validates short-lived OAuth state tokens.
 type StateManager struct {
-	states map[string]time.Time
+	states map[string]stateEntry
 	mu     sync.RWMutex
 }
 
 func NewStateManager() *StateManager {
-	return &StateManager{states: make(map[string]time.Time)}
+	return &StateManager{states: make(map[string]stateEntry)}
 }
 
 // Generate returns a cryptographically random state string valid for 10 minutes.
 func (sm *StateManager) Generate() (string, error) {
+	return sm.generate(Grant{})
+}
+
+// GenerateGrant returns a state for an incremental scope request by an
+// already signed-in user, so the callback knows whose grant it is, and the
+// binding secret to hand to the user's browser.
+func (sm *StateManager) GenerateGrant(userID, scope string) (state, binding string, err error) {
+	binding, err = randomToken()
+	if err != nil {
+		return "", "", err
+	}
+	state, err = sm.generate(Grant{UserID: userID, Scope: scope, Binding: binding})
+	if err != nil {
+		return "", "", err
+	}
+	return state, binding, nil
+}
+
+func randomToken() (string, error) {
 	buf := make([]byte, 32)
 	if _, err := rand.Read(buf); err != nil {
 		return "", err
 	}
-	state := base64.URLEncoding.EncodeToString(buf)
+	return base64.URLEncoding.EncodeToString(buf), nil
+}
+
+func (sm *StateManager) generate(grant Grant) (string, error) {
+	state, err := randomToken()
+	if err != nil {
+		return "", err
+	}
 
 	sm.mu.Lock()
-	sm.states[state] = time.Now().Add(10 * time.Minute)
+	sm.states[state] = stateEntry{expiry: time.Now().Add(stateTTL), grant: grant}
 	sm.mu.Unlock()
 
 	return state, nil
@@ -34,17 +78,23 @@ func (sm *StateManager) Generate() (string, error) {
 
 // Validate checks that the state exists and is not expired; it removes one-time tokens.
 func (sm *StateManager) Validate(state string) bool {
+	_, ok := sm.Consume(state)
+	return ok
+}
+
+// Consume validates state like Validate and returns the grant it was
+// issued for.
+func (sm *StateManager) Consume(state string) (Grant, bool) {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
-	expiry, ok := sm.states[state]
+	entry, ok := sm.states[state]
 	if !ok {
-		return false
-	}
-	if time.Now().After(expiry) {
-		delete(sm.states, state)
-		return false
+		return Grant{}, false
 	}
 	delete(sm.states, state)
-	return true
+	if time.Now().After(entry.expiry) {
+		return Grant{}, false
+	}
+	return entry.grant, true
 }
@@ -0,0 +1,42 @@
+package google
+
+import (
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestStateManager_GrantStatesCarryTheUser(t *testing.T) {
+	sm := NewStateManager()
+
+	login, err := sm.Generate()
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	grantState, binding, err := sm.GenerateGrant("7", ScopeGmailCompose)
+	if err != nil {
+		t.Fatalf("GenerateGrant() error = %v", err)
+	}
+
+	if g, ok := sm.Consume(login); !ok || g != (Grant{}) {
+		t.Errorf("login state: grant = %+v, ok = %v", g, ok)
+	}
+	if g, ok := sm.Consume(grantState); !ok || g.UserID != "7" || g.Scope != ScopeGmailCompose || g.Binding != binding || binding == "" {
+		t.Errorf("grant state: grant = %+v, ok = %v", g, ok)
+	}
+	if sm.Validate(grantState) {
+		t.Error("states must be single use")
+	}
+}
+
+func TestTokenHasScope(t *testing.T) {
+	token := (&oauth2.Token{AccessToken: "x"}).WithExtra(map[string]any{
+		"scope": "openid https://www.googleapis.com/auth/gmail.readonly " + ScopeGmailCompose,
+	})
+	if !tokenHasScope(token, ScopeGmailCompose) {
+		t.Error("expected compose scope to be found")
+	}
+	if tokenHasScope(&oauth2.Token{AccessToken: "x"}, ScopeGmailCompose) {
+		t.Error("token without scope list should not report the scope")
+	}
+}
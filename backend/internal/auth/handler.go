@@ -2,11 +2,15 @@ package auth
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
+	"github.com/jackc/pgx/v5"
 	"golang.org/x/oauth2"
 
+	"github.com/r7rainz/auramail/internal/eligibility"
+	"github.com/r7rainz/auramail/internal/lang"
 	"github.com/r7rainz/auramail/internal/user"
 )
 
@@ -32,6 +36,12 @@ type updateNotificationsRequest struct {
 	Enabled bool `json:"enabled"`
 }
 
+// updatePreferencesRequest changes only the preferences that are present.
+type updatePreferencesRequest struct {
+	RedactPII       *bool   `json:"redactPii"`
+	SummaryLanguage *string `json:"summaryLanguage"`
+}
+
 func NewHandler(cfg *oauth2.Config, userRepo user.Repository) *Handler {
 	return &Handler{
 		oauthConfig: cfg,
@@ -117,6 +127,89 @@ func (h *Handler) UpdateNotifications(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// GetAcademicProfile returns the authenticated user's academic profile, or
+// null when none has been saved yet.
+func (h *Handler) GetAcademicProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(UserIDContextKey).(string)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+
+	profile, err := h.userRepo.GetAcademicProfile(r.Context(), userID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"success": false,
+			"error":   "failed to load academic profile",
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"success": true,
+		"profile": profile,
+	})
+}
+
+// UpdateAcademicProfile replaces the authenticated user's academic profile.
+// Degree and branch are normalised to the codes eligibility matching uses.
+func (h *Handler) UpdateAcademicProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(UserIDContextKey).(string)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+
+	var profile eligibility.Profile
+	if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"success": false,
+			"error":   "invalid request format",
+		})
+		return
+	}
+	if err := profile.Validate(); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := h.userRepo.SaveAcademicProfile(r.Context(), userID, &profile); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"success": false,
+			"error":   "failed to save academic profile",
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"success": true,
+		"profile": profile,
+	})
+}
+
 func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
 	var req refreshRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -181,3 +274,105 @@ func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
 		"success": true,
 	})
 }
+
+// GetPreferences returns the authenticated user's processing preferences.
+func (h *Handler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(UserIDContextKey).(string)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+
+	prefs, err := h.userRepo.GetPreferences(r.Context(), userID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"success": false,
+			"error":   "failed to load preferences",
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"success":     true,
+		"preferences": prefs,
+	})
+}
+
+// UpdatePreferences changes the authenticated user's processing
+// preferences. Fields left out of the request keep their current value.
+func (h *Handler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(UserIDContextKey).(string)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+
+	var req updatePreferencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"success": false,
+			"error":   "invalid request format",
+		})
+		return
+	}
+	if req.SummaryLanguage != nil {
+		if _, ok := lang.Lookup(*req.SummaryLanguage); !ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"success":   false,
+				"error":     "unsupported summary language",
+				"languages": lang.Supported,
+			})
+			return
+		}
+	}
+
+	prefs, err := h.userRepo.GetPreferences(r.Context(), userID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"success": false,
+			"error":   "failed to load preferences",
+		})
+		return
+	}
+	if req.RedactPII != nil {
+		prefs.RedactPII = *req.RedactPII
+	}
+	if req.SummaryLanguage != nil {
+		prefs.SummaryLanguage = *req.SummaryLanguage
+	}
+
+	if err := h.userRepo.SavePreferences(r.Context(), userID, prefs); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"success": false,
+			"error":   "failed to save preferences",
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"success":     true,
+		"preferences": prefs,
+	})
+}
@@ -4,31 +4,39 @@ package auth
 import (
 	"context"
 	"net/http"
+	"slices"
 	"strings"
 )
 
 type contextKey string
+
 const UserIDContextKey contextKey = "userID"
 
 func AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		claims, ok := authenticate(w, r)
+		if !ok {
 			return
 		}
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		ctx := context.WithValue(r.Context(), UserIDContextKey, claims.UserID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// AdminMiddleware authenticates like AuthMiddleware and additionally requires
+// the token's email to be listed in admins. An empty list denies everyone.
+func AdminMiddleware(admins []string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := authenticate(w, r)
+		if !ok {
 			return
 		}
 
-		tokenString := parts[1]
-
-		claims, err := ValidateAccessToken(tokenString)
-		if err != nil {
-			http.Error(w, "invalid or expired token", http.StatusUnauthorized)
+		if !slices.ContainsFunc(admins, func(email string) bool {
+			return strings.EqualFold(email, claims.Email)
+		}) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
 			return
 		}
 
@@ -36,3 +44,28 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// authenticate validates the bearer token on r, writing a 401 and returning
+// false when it is missing or invalid.
+func authenticate(w http.ResponseWriter, r *http.Request) (*AccessTokenClaims, bool) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	tokenString := parts[1]
+
+	claims, err := ValidateAccessToken(tokenString)
+	if err != nil {
+		http.Error(w, "invalid or expired token", http.StatusUnauthorized)
+		return nil, false
+	}
+	return claims, true
+}
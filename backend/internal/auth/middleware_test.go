@@ -57,3 +57,39 @@ func TestAuthMiddleware_MalformedHeaderUsesJSON(t *testing.T) {
 		t.Fatalf("got %d", rr.Code)
 	}
 }
+
+func TestAdminMiddleware(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret-for-middleware-32chars!ok")
+	tok, err := GenerateAccessToken("user-1", "Admin@Example.com", "A")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		admins []string
+		header string
+		want   int
+	}{
+		{"no token", []string{"admin@example.com"}, "", http.StatusUnauthorized},
+		{"not an admin", []string{"someone@example.com"}, "Bearer " + tok, http.StatusForbidden},
+		{"empty admin list", nil, "Bearer " + tok, http.StatusForbidden},
+		{"admin", []string{"admin@example.com"}, "Bearer " + tok, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := AdminMiddleware(tt.admins, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/admin/usage", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+			if rr.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rr.Code, tt.want)
+			}
+		})
+	}
+}
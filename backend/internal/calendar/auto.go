@@ -0,0 +1,455 @@
+package calendar
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	gcalendar "google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/notification"
+	"github.com/r7rainz/auramail/internal/user"
+)
+
+// Event types. Automatically scheduled events are deadlines, or test and
+// interview slots, which use the email's category as their kind.
+const (
+	KindDeadline  = "deadline"
+	KindExam      = "exam"
+	KindInterview = "interview"
+	KindEvent     = "event"
+)
+
+// eventLabels name the kinds of automatically scheduled events.
+var eventLabels = map[string]string{
+	KindDeadline:  "Deadline",
+	KindExam:      "Test",
+	KindInterview: "Interview",
+}
+
+// AutoRepository is what automatic scheduling needs from storage.
+type AutoRepository interface {
+	FindByID(ctx context.Context, id string) (*user.User, error)
+	GetCalendarRules(ctx context.Context, userID string) (*user.CalendarRules, error)
+	GetCalendarLink(ctx context.Context, userID, key, kind string) (*user.CalendarLink, error)
+	SaveCalendarLink(ctx context.Context, userID string, link *user.CalendarLink) error
+	ListCalendarLinks(ctx context.Context, userID string, from time.Time) ([]user.CalendarLink, error)
+	GetUserSummary(ctx context.Context, userID, gmailID string) (*ai.AIResult, error)
+	AddNotification(ctx context.Context, userID string, n *user.Notification) error
+}
+
+// AutoScheduler creates and updates calendar events for the deadlines,
+// tests and interviews found in analyzed emails.
+type AutoScheduler struct {
+	repo   AutoRepository
+	client Client
+	now    func() time.Time
+}
+
+func NewAutoScheduler(repo AutoRepository, client Client) *AutoScheduler {
+	return &AutoScheduler{
+		repo:   repo,
+		client: client,
+		now:    time.Now,
+	}
+}
+
+// plannedEvent is a calendar event derived from one email.
+type plannedEvent struct {
+	key, kind, gmailID string
+	title              string
+	description        string
+	location           string
+	company            string
+	start              time.Time
+	// allDay is set for deadlines known only by date.
+	allDay bool
+}
+
+// Schedule puts the events found in summaries on the user's calendar when
+// they opted in. An email about an opportunity that already has an event of
+// the same kind updates that event. It returns how many events were created
+// or changed.
+func (s *AutoScheduler) Schedule(ctx context.Context, userID string, summaries []*ai.AIResult) (int, error) {
+	rules, err := s.repo.GetCalendarRules(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if !rules.AutoSchedule {
+		return 0, nil
+	}
+
+	// Oldest first, so a later reminder mail has the final say.
+	ordered := slices.Clone(summaries)
+	slices.SortStableFunc(ordered, func(a, b *ai.AIResult) int {
+		return cmp.Compare(a.ReceiverAt, b.ReceiverAt)
+	})
+	now := s.now()
+	var planned []plannedEvent
+	for _, res := range ordered {
+		if !slices.Contains(rules.Categories, res.Category) {
+			continue
+		}
+		for _, p := range planEvents(res, rules, now) {
+			if p.span(rules).upcoming(now) {
+				planned = append(planned, p)
+			}
+		}
+	}
+	if len(planned) == 0 {
+		return 0, nil
+	}
+
+	u, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	svc, err := s.client.Service(ctx, u.GoogleRefreshToken)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create calendar service: %w", err)
+	}
+
+	changed := 0
+	var errs []error
+	for _, p := range planned {
+		ok, err := s.upsert(ctx, svc, userID, p, rules)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s event for %s: %w", p.kind, p.gmailID, err))
+			continue
+		}
+		if ok {
+			changed++
+		}
+	}
+	return changed, errors.Join(errs...)
+}
+
+// Reconcile brings the user's upcoming automatically created events in line
+// with their emails as they are now, e.g. after a deadline was corrected or
+// an email was re-analyzed. Events whose email no longer has a date are
+// left alone. It returns how many events were changed.
+func (s *AutoScheduler) Reconcile(ctx context.Context, userID string) (int, error) {
+	now := s.now()
+	// All-day events are stored from midnight, so look back a day for
+	// those still running.
+	links, err := s.repo.ListCalendarLinks(ctx, userID, now.AddDate(0, 0, -1))
+	if err != nil || len(links) == 0 {
+		return 0, err
+	}
+	rules, err := s.repo.GetCalendarRules(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	var stale []plannedEvent
+	for _, link := range links {
+		res, err := s.repo.GetUserSummary(ctx, userID, link.GmailID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		for _, p := range planEvents(res, rules, now) {
+			if p.kind != link.Kind {
+				continue
+			}
+			// The opportunity may have been linked after the event was made.
+			p.key = link.Key
+			if !p.start.Equal(link.StartsAt) || p.title != link.Title {
+				stale = append(stale, p)
+			}
+		}
+	}
+	if len(stale) == 0 {
+		return 0, nil
+	}
+
+	u, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	svc, err := s.client.Service(ctx, u.GoogleRefreshToken)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create calendar service: %w", err)
+	}
+
+	changed := 0
+	var errs []error
+	for _, p := range stale {
+		ok, err := s.upsert(ctx, svc, userID, p, rules)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s event for %s: %w", p.kind, p.gmailID, err))
+			continue
+		}
+		if ok {
+			changed++
+		}
+	}
+	return changed, errors.Join(errs...)
+}
+
+// upsert creates p's event, or updates the one already linked to it. It
+// reports whether Google Calendar was changed.
+func (s *AutoScheduler) upsert(ctx context.Context, svc *gcalendar.Service, userID string, p plannedEvent, rules *user.CalendarRules) (bool, error) {
+	link, err := s.repo.GetCalendarLink(ctx, userID, p.key, p.kind)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return false, err
+	}
+	if link != nil && link.StartsAt.Equal(p.start) && link.Title == p.title {
+		return false, nil
+	}
+
+	eventID := ""
+	if link != nil {
+		eventID = link.EventID
+	} else {
+		// The user may already have added this event from the email.
+		existing, err := emailEvents(ctx, svc, rules.Calendar(), p.gmailID, p.kind)
+		if err != nil {
+			return false, err
+		}
+		if len(existing) > 0 {
+			eventID = existing[0].Id
+		}
+	}
+
+	event := p.event(rules)
+	var saved *gcalendar.Event
+	if eventID != "" {
+		saved, err = svc.Events.Patch(rules.Calendar(), eventID, event).Context(ctx).Do()
+		if isGone(err) {
+			// The event was deleted, or is on the calendar used before the
+			// user chose another one; add it again.
+			slog.InfoContext(ctx, "linked calendar event is gone, recreating", "eventId", eventID, "userID", userID)
+			saved, err = nil, nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+	if saved == nil {
+		if saved, err = svc.Events.Insert(rules.Calendar(), event).Context(ctx).Do(); err != nil {
+			return false, err
+		}
+	}
+
+	err = s.repo.SaveCalendarLink(ctx, userID, &user.CalendarLink{
+		Key:      p.key,
+		Kind:     p.kind,
+		GmailID:  p.gmailID,
+		EventID:  saved.Id,
+		Title:    p.title,
+		StartsAt: p.start,
+	})
+	if err != nil {
+		return false, err
+	}
+	slog.InfoContext(ctx, "calendar event scheduled", "kind", p.kind, "eventId", saved.Id, "gmailId", p.gmailID, "userID", userID)
+	if link != nil {
+		s.notifyMoved(ctx, userID, link, p, saved.Id, rules.Location())
+	}
+	return true, nil
+}
+
+// eventChange is one field of an event that an email changed.
+type eventChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// notifyMoved tells the user that an email changed an event they already
+// had, recording what changed. Failing to notify does not fail scheduling.
+func (s *AutoScheduler) notifyMoved(ctx context.Context, userID string, link *user.CalendarLink, p plannedEvent, eventID string, loc *time.Location) {
+	var changes []eventChange
+	if !link.StartsAt.Equal(p.start) {
+		changes = append(changes, eventChange{Field: "start", From: link.StartsAt.Format(time.RFC3339), To: p.start.Format(time.RFC3339)})
+	}
+	if link.Title != p.title {
+		changes = append(changes, eventChange{Field: "title", From: link.Title, To: p.title})
+	}
+	if len(changes) == 0 {
+		return
+	}
+
+	body := "Updated from an email in your inbox."
+	if !link.StartsAt.Equal(p.start) {
+		layout := "Mon 2 Jan, 3:04 PM"
+		if p.allDay {
+			layout = "Mon 2 Jan"
+		}
+		body = fmt.Sprintf("Moved from %s to %s.", link.StartsAt.In(loc).Format(layout), p.start.In(loc).Format(layout))
+	}
+	details, err := json.Marshal(map[string]any{"eventId": eventID, "kind": p.kind, "changes": changes})
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to encode event changes", "err", err)
+		return
+	}
+	err = s.repo.AddNotification(ctx, userID, &user.Notification{
+		Kind:    notification.KindCalendarUpdated,
+		Title:   p.title,
+		Body:    body,
+		GmailID: p.gmailID,
+		Details: details,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to notify about calendar change", "err", err, "eventId", eventID, "userID", userID)
+	}
+}
+
+func isGone(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && (apiErr.Code == http.StatusNotFound || apiErr.Code == http.StatusGone)
+}
+
+// planEvents returns the events res calls for: one for its deadline and,
+// for tests and interviews, one for the slot itself.
+func planEvents(res *ai.AIResult, rules *user.CalendarRules, now time.Time) []plannedEvent {
+	loc := rules.Location()
+	base := plannedEvent{
+		key:         cmp.Or(res.OpportunityID, res.GmailMessageID),
+		gmailID:     res.GmailMessageID,
+		description: eventDescription(res),
+		location:    strings.TrimSpace(ai.FieldText(res.Location)),
+	}
+	if res.Company != nil {
+		base.company = *res.Company
+	}
+	var planned []plannedEvent
+
+	if res.Deadline != nil {
+		if date, err := time.ParseInLocation(time.DateOnly, *res.Deadline, loc); err == nil {
+			p := base
+			p.kind = KindDeadline
+			p.title = eventTitle(eventLabels[KindDeadline], res)
+			p.start = date
+			p.allDay = rules.AllDayDeadlines
+			if !p.allDay {
+				p.start = date.Add(defaultHour * time.Hour)
+			}
+			planned = append(planned, p)
+		}
+	}
+
+	if res.Category == KindExam || res.Category == KindInterview {
+		text := ai.FieldText(res.Timings) + "\n" + ai.FieldText(res.EventDetails)
+		if start, timed, ok := ai.EventTime(text, now.In(loc), loc); ok {
+			if !timed {
+				start = start.Add(defaultHour * time.Hour)
+			}
+			p := base
+			p.kind = res.Category
+			p.title = eventTitle(eventLabels[res.Category], res)
+			p.start = start
+			planned = append(planned, p)
+		}
+	}
+	return planned
+}
+
+// opportunityEvent is the event one opportunity calls for, as planned from
+// its latest email.
+type opportunityEvent struct {
+	plannedEvent
+	// res is the latest email about the opportunity.
+	res *ai.AIResult
+	// firstGmailID is the earliest email that called for the event.
+	firstGmailID string
+}
+
+// opportunityEvents plans the events in summaries. Emails about the same
+// opportunity share events, and the latest email decides their details.
+// Events are returned in the order they were first called for.
+func opportunityEvents(summaries []*ai.AIResult, rules *user.CalendarRules, now time.Time) []opportunityEvent {
+	ordered := slices.Clone(summaries)
+	slices.SortStableFunc(ordered, func(a, b *ai.AIResult) int {
+		return cmp.Compare(a.ReceiverAt, b.ReceiverAt)
+	})
+
+	var keys []string
+	events := make(map[string]opportunityEvent)
+	for _, res := range ordered {
+		for _, p := range planEvents(res, rules, now) {
+			key := p.key + "/" + p.kind
+			first := p.gmailID
+			if prev, ok := events[key]; ok {
+				first = prev.firstGmailID
+			} else {
+				keys = append(keys, key)
+			}
+			events[key] = opportunityEvent{plannedEvent: p, res: res, firstGmailID: first}
+		}
+	}
+
+	planned := make([]opportunityEvent, 0, len(keys))
+	for _, key := range keys {
+		planned = append(planned, events[key])
+	}
+	return planned
+}
+
+// eventTitle is e.g. "Deadline: Microsoft – SDE Intern", falling back to
+// the email subject when company and role are unknown.
+func eventTitle(label string, res *ai.AIResult) string {
+	var parts []string
+	for _, v := range []*string{res.Company, res.Role} {
+		if v != nil && strings.TrimSpace(*v) != "" {
+			parts = append(parts, strings.TrimSpace(*v))
+		}
+	}
+	if len(parts) == 0 {
+		return label + ": " + res.Subject
+	}
+	return label + ": " + strings.Join(parts, " – ")
+}
+
+func eventDescription(res *ai.AIResult) string {
+	var b strings.Builder
+	if res.Summary != "" {
+		b.WriteString(res.Summary)
+		b.WriteString("\n\n")
+	}
+	if res.ApplyLink != nil && *res.ApplyLink != "" {
+		b.WriteString("Apply: " + *res.ApplyLink + "\n")
+	}
+	b.WriteString("Email: " + gmailLink(res.GmailMessageID))
+	b.WriteString("\n\n---\n" + auraMailFooter)
+	return b.String()
+}
+
+// gmailLink opens an email in Gmail.
+func gmailLink(gmailID string) string {
+	return "https://mail.google.com/mail/u/0/#all/" + gmailID
+}
+
+func (p plannedEvent) span(rules *user.CalendarRules) span {
+	if p.allDay {
+		return span{start: p.start, end: p.start.AddDate(0, 0, 1), allDay: true}
+	}
+	return span{start: p.start, end: p.start.Add(rules.EventLength())}
+}
+
+func (p plannedEvent) event(rules *user.CalendarRules) *gcalendar.Event {
+	start, end := p.span(rules).eventTimes(rules.TimeZone)
+	return &gcalendar.Event{
+		Summary:            p.title,
+		Description:        p.description,
+		Location:           p.location,
+		Start:              start,
+		End:                end,
+		Reminders:          popupReminders(rules.RemindersFor(p.kind)),
+		ColorId:            getColorForEventType(p.kind),
+		ExtendedProperties: auraMailProperties(p.gmailID, p.kind, p.company),
+	}
+}
@@ -0,0 +1,192 @@
+package calendar
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/calendar/calendartest"
+	"github.com/r7rainz/auramail/internal/user"
+)
+
+func newTestScheduler(repo *fakeUserRepo, cal *calendartest.Server, now time.Time) *AutoScheduler {
+	s := NewAutoScheduler(repo, cal)
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func strPtr(s string) *string { return &s }
+
+func TestAutoScheduler_DisabledByDefault(t *testing.T) {
+	cal := newFakeCalendar(t)
+	s := newTestScheduler(&fakeUserRepo{}, cal, time.Now())
+
+	n, err := s.Schedule(context.Background(), "1", []*ai.AIResult{{GmailMessageID: "m1", Category: "exam", Deadline: strPtr("2099-01-01")}})
+	if err != nil || n != 0 || len(cal.Inserts()) != 0 {
+		t.Fatalf("expected nothing scheduled, got %d events, %d inserts, %v", n, len(cal.Inserts()), err)
+	}
+}
+
+func TestAutoScheduler_CreatesThenUpdatesLinkedEvents(t *testing.T) {
+	now := time.Date(2026, 8, 10, 9, 0, 0, 0, time.UTC)
+	cal := newFakeCalendar(t)
+	rules := user.DefaultCalendarRules()
+	rules.AutoSchedule = true
+	rules.ReminderMinutes = []int{30}
+	rules.ReminderSets = map[string][]int{KindExam: {15, 120}}
+	repo := &fakeUserRepo{
+		rules: &rules,
+		findByIDFunc: func(ctx context.Context, id string) (*user.User, error) {
+			return &user.User{ID: id, GoogleRefreshToken: "token"}, nil
+		},
+	}
+	s := newTestScheduler(repo, cal, now)
+	ctx := context.Background()
+
+	oa := &ai.AIResult{
+		GmailMessageID: "m1",
+		OpportunityID:  "opp-1",
+		ReceiverAt:     "2026-08-09T10:00:00Z",
+		Category:       "exam",
+		Company:        strPtr("Globex"),
+		Role:           strPtr("SDE Intern"),
+		Deadline:       strPtr("2026-08-12"),
+		Timings:        "• Date of test: 14 Aug 2026, 2:30 PM",
+	}
+	n, err := s.Schedule(ctx, "1", []*ai.AIResult{
+		oa,
+		{GmailMessageID: "m2", Category: "workshop", Deadline: strPtr("2026-08-20")},
+		{GmailMessageID: "m3", Category: "internship", Deadline: strPtr("2026-08-01")},
+	})
+	if err != nil || n != 2 {
+		t.Fatalf("Schedule = %d, %v; want the deadline and the test slot", n, err)
+	}
+
+	deadline := cal.Event(repo.links["opp-1/deadline"].EventID)
+	if deadline.Summary != "Deadline: Globex – SDE Intern" || deadline.Start.Date != "2026-08-12" || deadline.End.Date != "2026-08-13" {
+		t.Errorf("expected an all-day deadline, got %s on %+v", deadline.Summary, deadline.Start)
+	}
+	if len(deadline.Reminders.Overrides) != 1 || deadline.Reminders.Overrides[0].Minutes != 30 {
+		t.Errorf("reminder rules not applied: %+v", deadline.Reminders)
+	}
+	slot := cal.Event(repo.links["opp-1/exam"].EventID)
+	if slot.Summary != "Test: Globex – SDE Intern" || slot.Start.DateTime != "2026-08-14T14:30:00+05:30" || slot.End.DateTime != "2026-08-14T15:30:00+05:30" {
+		t.Errorf("unexpected test event: %s at %s", slot.Summary, slot.Start.DateTime)
+	}
+	if len(slot.Reminders.Overrides) != 2 || slot.Reminders.Overrides[0].Minutes != 15 {
+		t.Errorf("test reminders not applied: %+v", slot.Reminders)
+	}
+
+	// Running again with nothing new touches nothing.
+	if n, err = s.Schedule(ctx, "1", []*ai.AIResult{oa}); err != nil || n != 0 {
+		t.Fatalf("re-running changed %d events: %v", n, err)
+	}
+
+	// A reminder mail about the same opportunity moves the deadline.
+	reminder := *oa
+	reminder.GmailMessageID = "m4"
+	reminder.ReceiverAt = "2026-08-10T08:00:00Z"
+	reminder.Deadline = strPtr("2026-08-13")
+	if n, err = s.Schedule(ctx, "1", []*ai.AIResult{&reminder}); err != nil || n != 1 {
+		t.Fatalf("Schedule(reminder) = %d, %v", n, err)
+	}
+	if len(cal.Inserts()) != 2 || len(cal.Patches()) != 1 {
+		t.Errorf("expected the existing event to be updated, got %d inserts, %d patches", len(cal.Inserts()), len(cal.Patches()))
+	}
+	if link := repo.links["opp-1/deadline"]; link.GmailID != "m4" || cal.Event(link.EventID).Start.Date != "2026-08-13" {
+		t.Errorf("deadline not moved: %+v", link)
+	}
+	if len(repo.notifications) != 1 || repo.notifications[0].GmailID != "m4" || !strings.Contains(string(repo.notifications[0].Details), `"field":"start"`) {
+		t.Errorf("expected a notification about the moved deadline, got %+v", repo.notifications)
+	}
+
+	// An event deleted from the calendar is recreated.
+	cal.RemoveEvent(repo.links["opp-1/deadline"].EventID)
+	reminder.Deadline = strPtr("2026-08-15")
+	if n, err = s.Schedule(ctx, "1", []*ai.AIResult{&reminder}); err != nil || n != 1 || len(cal.Inserts()) != 3 {
+		t.Fatalf("expected a new event after deletion, got %d changed, %d inserts, %v", n, len(cal.Inserts()), err)
+	}
+}
+
+func TestAutoScheduler_ReconcileFollowsCorrections(t *testing.T) {
+	now := time.Date(2026, 8, 10, 9, 0, 0, 0, time.UTC)
+	cal := newFakeCalendar(t)
+	rules := user.DefaultCalendarRules()
+	rules.AutoSchedule = true
+	rules.AllDayDeadlines = false
+	rules.EventMinutes = 30
+	res := &ai.AIResult{GmailMessageID: "m1", Category: "internship", Company: strPtr("Globex"), Deadline: strPtr("2026-08-12")}
+	repo := &fakeUserRepo{
+		rules:     &rules,
+		summaries: map[string]*ai.AIResult{"m1": res},
+		findByIDFunc: func(ctx context.Context, id string) (*user.User, error) {
+			return &user.User{ID: id, GoogleRefreshToken: "token"}, nil
+		},
+	}
+	s := newTestScheduler(repo, cal, now)
+	ctx := context.Background()
+
+	if n, err := s.Schedule(ctx, "1", []*ai.AIResult{res}); err != nil || n != 1 {
+		t.Fatalf("Schedule = %d, %v", n, err)
+	}
+	if n, err := s.Reconcile(ctx, "1"); err != nil || n != 0 {
+		t.Fatalf("Reconcile with nothing changed = %d, %v", n, err)
+	}
+
+	// The user corrects the deadline.
+	res.Deadline = strPtr("2026-08-20")
+	if n, err := s.Reconcile(ctx, "1"); err != nil || n != 1 {
+		t.Fatalf("Reconcile = %d, %v", n, err)
+	}
+	link := repo.links["m1/deadline"]
+	if ev := cal.Event(link.EventID); len(cal.Patches()) != 1 || ev.Start.DateTime != "2026-08-20T10:00:00+05:30" || ev.End.DateTime != "2026-08-20T10:30:00+05:30" {
+		t.Errorf("event not moved to the corrected deadline: %+v", ev.Start)
+	}
+	if len(repo.notifications) != 1 || !strings.Contains(repo.notifications[0].Body, "Thu 20 Aug") {
+		t.Errorf("unexpected notifications: %+v", repo.notifications)
+	}
+
+	// A deadline removed from the email leaves the event alone.
+	res.Deadline = nil
+	if n, err := s.Reconcile(ctx, "1"); err != nil || n != 0 || len(cal.Patches()) != 1 {
+		t.Errorf("Reconcile without a deadline = %d, %v", n, err)
+	}
+}
+
+func TestUpdateRules(t *testing.T) {
+	repo := &fakeUserRepo{}
+	h := NewHandler(repo, GoogleClient{})
+
+	for name, tc := range map[string]struct {
+		body string
+		want int
+	}{
+		"unknown category": {`{"categories":["spam"]}`, http.StatusBadRequest},
+		"too many":         {`{"reminderMinutes":[1,2,3,4,5,6]}`, http.StatusBadRequest},
+		"too early":        {`{"reminderMinutes":[50000]}`, http.StatusBadRequest},
+		"unknown type":     {`{"reminderSets":{"party":[10]}}`, http.StatusBadRequest},
+		"bad time zone":    {`{"timeZone":"Mars/Olympus"}`, http.StatusBadRequest},
+		"too short":        {`{"eventMinutes":1}`, http.StatusBadRequest},
+		"empty calendar":   {`{"calendarId":""}`, http.StatusBadRequest},
+		"valid":            {`{"autoSchedule":true,"categories":["exam","interview"]}`, http.StatusOK},
+		"event settings":   {`{"timeZone":"Europe/London","eventMinutes":45,"allDayDeadlines":false,"reminderSets":{"interview":[30]}}`, http.StatusOK},
+	} {
+		req := withUserID(httptest.NewRequest(http.MethodPut, "/calendar/rules", strings.NewReader(tc.body)), "1")
+		rr := httptest.NewRecorder()
+		h.UpdateRules(rr, req)
+		if rr.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d", name, tc.want, rr.Code)
+		}
+	}
+
+	if repo.rules == nil || !repo.rules.AutoSchedule || len(repo.rules.Categories) != 2 || len(repo.rules.ReminderMinutes) != 2 {
+		t.Errorf("rules not saved as a partial update: %+v", repo.rules)
+	}
+	if repo.rules.TimeZone != "Europe/London" || repo.rules.EventMinutes != 45 || repo.rules.AllDayDeadlines || len(repo.rules.RemindersFor(KindInterview)) != 1 {
+		t.Errorf("event settings not saved: %+v", repo.rules)
+	}
+}
@@ -0,0 +1,158 @@
+package calendar
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	gcalendar "google.golang.org/api/calendar/v3"
+
+	"github.com/r7rainz/auramail/internal/auth"
+	"github.com/r7rainz/auramail/internal/response"
+	"github.com/r7rainz/auramail/internal/user"
+)
+
+// defaultCalendarName is the summary of the dedicated calendar AuraMail
+// creates when the user does not choose one.
+const defaultCalendarName = "Placements"
+
+// CalendarInfo is one of the user's Google calendars.
+type CalendarInfo struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Primary bool   `json:"primary"`
+	// Selected is true for the calendar AuraMail adds events to.
+	Selected bool `json:"selected"`
+}
+
+// createCalendarRequest names the dedicated calendar.
+type createCalendarRequest struct {
+	Name string `json:"name"`
+}
+
+// ListCalendars returns the calendars the user can add events to, for
+// choosing the one AuraMail uses.
+func (h *Handler) ListCalendars(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(auth.UserIDContextKey).(string)
+	if !ok {
+		response.Unauthorized(w, "No UserID found in context")
+		return
+	}
+
+	u, err := h.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		response.NotFound(w, "User not found")
+		return
+	}
+
+	calSvc, err := h.client.Service(ctx, u.GoogleRefreshToken)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to create calendar service", "err", err)
+		response.InternalError(w, "Failed to connect to Google Calendar")
+		return
+	}
+
+	list, err := calSvc.CalendarList.List().MinAccessRole("writer").Context(ctx).Do()
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to list calendars", "err", err, "userID", userID)
+		response.InternalError(w, "Failed to list calendars")
+		return
+	}
+
+	selected := h.calendarRules(ctx, userID).Calendar()
+	calendars := make([]CalendarInfo, 0, len(list.Items))
+	for _, item := range list.Items {
+		calendars = append(calendars, CalendarInfo{
+			ID:       item.Id,
+			Name:     cmp.Or(item.SummaryOverride, item.Summary),
+			Primary:  item.Primary,
+			Selected: item.Id == selected || (item.Primary && selected == user.PrimaryCalendar),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"success":   true,
+		"calendars": calendars,
+		"total":     len(calendars),
+	})
+}
+
+// CreateCalendar creates a dedicated calendar for AuraMail's events and
+// makes it the one events are added to. It is safe to call again: while
+// the dedicated calendar exists it is returned unchanged.
+func (h *Handler) CreateCalendar(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(auth.UserIDContextKey).(string)
+	if !ok {
+		response.Unauthorized(w, "No UserID found in context")
+		return
+	}
+
+	var req createCalendarRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(w, "Invalid request body", nil)
+		return
+	}
+
+	rules, err := h.userRepo.GetCalendarRules(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load calendar rules", "err", err)
+		response.InternalError(w, "Failed to load calendar rules")
+		return
+	}
+
+	u, err := h.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		response.NotFound(w, "User not found")
+		return
+	}
+
+	calSvc, err := h.client.Service(ctx, u.GoogleRefreshToken)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to create calendar service", "err", err)
+		response.InternalError(w, "Failed to connect to Google Calendar")
+		return
+	}
+
+	if id := rules.Calendar(); id != user.PrimaryCalendar {
+		existing, err := calSvc.Calendars.Get(id).Context(ctx).Do()
+		if err == nil {
+			response.Success(w, map[string]any{"calendar": CalendarInfo{ID: existing.Id, Name: existing.Summary, Selected: true}, "created": false})
+			return
+		}
+		if !isGone(err) {
+			slog.ErrorContext(ctx, "failed to fetch calendar", "err", err, "calendarId", id)
+			response.InternalError(w, "Failed to fetch calendar")
+			return
+		}
+		// The user deleted it; make a new one.
+	}
+
+	created, err := calSvc.Calendars.Insert(&gcalendar.Calendar{
+		Summary:     cmp.Or(req.Name, defaultCalendarName),
+		Description: "Placement deadlines, tests and interviews.\n\n" + auraMailFooter,
+		TimeZone:    rules.Location().String(),
+	}).Context(ctx).Do()
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to create calendar", "err", err, "userID", userID)
+		response.InternalError(w, "Failed to create calendar")
+		return
+	}
+
+	rules.CalendarID = created.Id
+	if err := h.userRepo.SaveCalendarRules(ctx, userID, rules); err != nil {
+		slog.ErrorContext(ctx, "failed to save calendar rules", "err", err)
+		response.InternalError(w, "Failed to save calendar rules")
+		return
+	}
+
+	slog.Info("dedicated calendar created", "calendarId", created.Id, "userId", userID)
+	response.Success(w, map[string]any{"calendar": CalendarInfo{ID: created.Id, Name: created.Summary, Selected: true}, "created": true})
+}
@@ -0,0 +1,67 @@
+package calendar
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCreateCalendar_UsedForNewEvents(t *testing.T) {
+	cal := newFakeCalendar(t)
+	h := newCalendarTestHandler(cal)
+	repo := h.userRepo.(*fakeUserRepo)
+
+	create := func() (CalendarInfo, bool) {
+		rr := httptest.NewRecorder()
+		h.CreateCalendar(rr, withUserID(httptest.NewRequest(http.MethodPost, "/calendar/calendars", strings.NewReader(`{"name":"Placements 2026"}`)), "1"))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var resp struct {
+			Data struct {
+				Calendar CalendarInfo `json:"calendar"`
+				Created  bool         `json:"created"`
+			} `json:"data"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return resp.Data.Calendar, resp.Data.Created
+	}
+
+	first, created := create()
+	if !created || first.Name != "Placements 2026" || repo.rules == nil || repo.rules.Calendar() != first.ID {
+		t.Fatalf("calendar not created and selected: %+v, rules %+v", first, repo.rules)
+	}
+	if again, created := create(); created || again.ID != first.ID || cal.Calendars() != 1 {
+		t.Errorf("expected the existing calendar to be reused, got %+v", again)
+	}
+
+	body, _ := json.Marshal(AddEventRequest{Title: "Globex Interview", StartTime: "2099-08-14T10:00:00+05:30", EmailID: "m1", EventType: KindInterview})
+	rr := httptest.NewRecorder()
+	h.AddEvent(rr, withUserID(httptest.NewRequest(http.MethodPost, "/calendar/events", bytes.NewBuffer(body)), "1"))
+	var added AddEventResponse
+	_ = json.NewDecoder(rr.Body).Decode(&added)
+	if cal.EventCalendar(added.EventID) != first.ID {
+		t.Errorf("event added to %q, want the dedicated calendar", cal.EventCalendar(added.EventID))
+	}
+
+	rr = httptest.NewRecorder()
+	h.ListCalendars(rr, withUserID(httptest.NewRequest(http.MethodGet, "/calendar/calendars", nil), "1"))
+	var listed struct {
+		Calendars []CalendarInfo `json:"calendars"`
+	}
+	_ = json.NewDecoder(rr.Body).Decode(&listed)
+	if len(listed.Calendars) != 2 || listed.Calendars[0].Selected || !listed.Calendars[1].Selected {
+		t.Errorf("unexpected calendars: %+v", listed.Calendars)
+	}
+
+	// A deleted dedicated calendar is created again.
+	cal.RemoveCalendar(first.ID)
+	if second, created := create(); !created || cal.Calendar(second.ID) == nil {
+		t.Errorf("expected a new calendar, got %+v", second)
+	}
+}
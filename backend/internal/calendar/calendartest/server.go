@@ -0,0 +1,338 @@
+// Package calendartest provides an in-memory fake of the Google Calendar
+// API for tests. It serves the event, free/busy and calendar endpoints
+// AuraMail uses and records every event inserted, patched or deleted, so
+// tests can assert the payloads the app sends.
+package calendartest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	gcalendar "google.golang.org/api/calendar/v3"
+	"google.golang.org/api/option"
+)
+
+// Primary is the calendar events are on unless added to another one.
+const Primary = "primary"
+
+// Patch is one events.patch request.
+type Patch struct {
+	CalendarID string
+	EventID    string
+	// Event holds only the fields sent in the request.
+	Event *gcalendar.Event
+}
+
+// Server is a fake Google Calendar API backed by httptest.
+type Server struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	events map[string]*gcalendar.Event
+	// eventCalendars maps event IDs to the calendar they are on.
+	eventCalendars map[string]string
+	calendars      map[string]*gcalendar.Calendar
+	inserts        []*gcalendar.Event
+	patches        []Patch
+	deletes        []string
+	nextID         int
+}
+
+// NewServer starts a fake Calendar API. Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		events:         make(map[string]*gcalendar.Event),
+		eventCalendars: make(map[string]string),
+		calendars:      make(map[string]*gcalendar.Calendar),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/me/calendarList", s.calendarList)
+	mux.HandleFunc("GET /calendars/{calendarId}", s.getCalendar)
+	mux.HandleFunc("POST /calendars", s.insertCalendar)
+	mux.HandleFunc("GET /calendars/{calendarId}/events", s.list)
+	mux.HandleFunc("GET /calendars/{calendarId}/events/{eventId}", s.get)
+	mux.HandleFunc("POST /calendars/{calendarId}/events", s.insert)
+	mux.HandleFunc("PATCH /calendars/{calendarId}/events/{eventId}", s.patch)
+	mux.HandleFunc("DELETE /calendars/{calendarId}/events/{eventId}", s.delete)
+	mux.HandleFunc("POST /freeBusy", s.freeBusy)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Service returns a Calendar client that talks to the fake. The refresh
+// token is ignored.
+func (s *Server) Service(ctx context.Context, refreshToken string) (*gcalendar.Service, error) {
+	return gcalendar.NewService(ctx, option.WithEndpoint(s.URL+"/"), option.WithHTTPClient(s.Client()))
+}
+
+// AddEvent puts ev on calendarID as if the user had created it.
+func (s *Server) AddEvent(calendarID string, ev *gcalendar.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events[ev.Id] = ev
+	s.eventCalendars[ev.Id] = calendarID
+}
+
+// RemoveEvent deletes an event as if the user had deleted it.
+func (s *Server) RemoveEvent(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.events, id)
+	delete(s.eventCalendars, id)
+}
+
+// Event returns the current state of an event, or nil.
+func (s *Server) Event(id string) *gcalendar.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.events[id]
+}
+
+// EventCalendar returns the calendar an event is on, or "".
+func (s *Server) EventCalendar(id string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.eventCalendars[id]
+}
+
+// Inserts returns the events inserted so far, as sent.
+func (s *Server) Inserts() []*gcalendar.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.inserts)
+}
+
+// Patches returns the patch requests so far.
+func (s *Server) Patches() []Patch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.patches)
+}
+
+// Deletes returns the IDs of the events deleted so far.
+func (s *Server) Deletes() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.deletes)
+}
+
+// Calendar returns a calendar created through the API, or nil.
+func (s *Server) Calendar(id string) *gcalendar.Calendar {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calendars[id]
+}
+
+// Calendars returns how many calendars were created through the API and
+// still exist.
+func (s *Server) Calendars() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.calendars)
+}
+
+// RemoveCalendar deletes a calendar as if the user had deleted it.
+func (s *Server) RemoveCalendar(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.calendars, id)
+}
+
+// list supports the privateExtendedProperty filter and pages of maxResults
+// events ordered by start; the time range is ignored.
+func (s *Server) list(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := make([]*gcalendar.Event, 0)
+	for id, ev := range s.events {
+		if s.eventCalendars[id] != r.PathValue("calendarId") {
+			continue
+		}
+		match := true
+		for _, filter := range r.URL.Query()["privateExtendedProperty"] {
+			key, value, _ := strings.Cut(filter, "=")
+			if ev.ExtendedProperties == nil || ev.ExtendedProperties.Private[key] != value {
+				match = false
+			}
+		}
+		if match {
+			items = append(items, ev)
+		}
+	}
+	slices.SortFunc(items, func(a, b *gcalendar.Event) int {
+		return strings.Compare(a.Start.DateTime+a.Start.Date, b.Start.DateTime+b.Start.Date)
+	})
+
+	page := &gcalendar.Events{Items: items}
+	offset, _ := strconv.Atoi(r.URL.Query().Get("pageToken"))
+	if size, err := strconv.Atoi(r.URL.Query().Get("maxResults")); err == nil {
+		page.Items = items[offset:min(offset+size, len(items))]
+		if offset+size < len(items) {
+			page.NextPageToken = strconv.Itoa(offset + size)
+		}
+	}
+	writeJSON(w, page)
+}
+
+func (s *Server) get(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ev, ok := s.events[r.PathValue("eventId")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	writeJSON(w, ev)
+}
+
+func (s *Server) insert(w http.ResponseWriter, r *http.Request) {
+	var sent, ev gcalendar.Event
+	if err := decodeTwice(r.Body, &sent, &ev); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	ev.Id = fmt.Sprintf("ev%d", s.nextID)
+	s.events[ev.Id] = &ev
+	s.eventCalendars[ev.Id] = r.PathValue("calendarId")
+	s.inserts = append(s.inserts, &sent)
+	writeJSON(w, &ev)
+}
+
+func (s *Server) patch(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ev, ok := s.events[r.PathValue("eventId")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	var sent gcalendar.Event
+	if err := decodeTwice(r.Body, &sent, ev); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.patches = append(s.patches, Patch{CalendarID: r.PathValue("calendarId"), EventID: ev.Id, Event: &sent})
+	writeJSON(w, ev)
+}
+
+func (s *Server) delete(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := r.PathValue("eventId")
+	if _, ok := s.events[id]; !ok {
+		writeError(w, http.StatusGone, "Resource has been deleted")
+		return
+	}
+	delete(s.events, id)
+	delete(s.eventCalendars, id)
+	s.deletes = append(s.deletes, id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// freeBusy reports every timed event in the requested range as busy on
+// its calendar.
+func (s *Server) freeBusy(w http.ResponseWriter, r *http.Request) {
+	var req gcalendar.FreeBusyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	from, _ := time.Parse(time.RFC3339, req.TimeMin)
+	to, _ := time.Parse(time.RFC3339, req.TimeMax)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	resp := &gcalendar.FreeBusyResponse{Calendars: make(map[string]gcalendar.FreeBusyCalendar)}
+	for _, item := range req.Items {
+		busy := make([]*gcalendar.TimePeriod, 0)
+		for id, ev := range s.events {
+			start, err1 := time.Parse(time.RFC3339, ev.Start.DateTime)
+			end, err2 := time.Parse(time.RFC3339, ev.End.DateTime)
+			if err1 != nil || err2 != nil || s.eventCalendars[id] != item.Id || !start.Before(to) || !end.After(from) {
+				continue
+			}
+			busy = append(busy, &gcalendar.TimePeriod{Start: ev.Start.DateTime, End: ev.End.DateTime})
+		}
+		resp.Calendars[item.Id] = gcalendar.FreeBusyCalendar{Busy: busy}
+	}
+	writeJSON(w, resp)
+}
+
+// calendarList lists the user's primary calendar and those created
+// through the API.
+func (s *Server) calendarList(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items := []*gcalendar.CalendarListEntry{{Id: "student@example.com", Summary: "student@example.com", Primary: true}}
+	for _, c := range s.calendars {
+		items = append(items, &gcalendar.CalendarListEntry{Id: c.Id, Summary: c.Summary})
+	}
+	slices.SortFunc(items[1:], func(a, b *gcalendar.CalendarListEntry) int { return strings.Compare(a.Id, b.Id) })
+	writeJSON(w, &gcalendar.CalendarList{Items: items})
+}
+
+func (s *Server) getCalendar(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.calendars[r.PathValue("calendarId")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	writeJSON(w, c)
+}
+
+func (s *Server) insertCalendar(w http.ResponseWriter, r *http.Request) {
+	var c gcalendar.Calendar
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	c.Id = fmt.Sprintf("cal%d@group.calendar.google.com", s.nextID)
+	s.calendars[c.Id] = &c
+	writeJSON(w, &c)
+}
+
+// decodeTwice decodes the JSON body into both sent, which records the
+// request as received, and into, which may already hold data.
+func decodeTwice(body io.Reader, sent, into any) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, sent); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, into)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]any{"code": status, "message": message},
+	})
+}
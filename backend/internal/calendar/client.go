@@ -0,0 +1,23 @@
+package calendar
+
+import (
+	"context"
+
+	gcalendar "google.golang.org/api/calendar/v3"
+
+	"github.com/r7rainz/auramail/internal/auth/google"
+)
+
+// Client connects to a user's Google Calendar. Tests use a
+// calendartest.Server, which serves the Calendar API locally.
+type Client interface {
+	Service(ctx context.Context, refreshToken string) (*gcalendar.Service, error)
+}
+
+// GoogleClient connects to Google Calendar with the app's OAuth
+// credentials and the user's refresh token.
+type GoogleClient struct{}
+
+func (GoogleClient) Service(ctx context.Context, refreshToken string) (*gcalendar.Service, error) {
+	return google.CreateCalendarService(ctx, refreshToken)
+}
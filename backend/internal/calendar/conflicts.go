@@ -0,0 +1,243 @@
+package calendar
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	gcalendar "google.golang.org/api/calendar/v3"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/auth"
+	"github.com/r7rainz/auramail/internal/response"
+	"github.com/r7rainz/auramail/internal/user"
+)
+
+// Conflict types.
+const (
+	ConflictOverlap    = "overlap"
+	ConflictBackToBack = "back_to_back"
+)
+
+// SlotBusy is the kind of a busy period from the user's Google Calendar.
+const SlotBusy = "busy"
+
+const (
+	// backToBackGap is the least time needed between two slots, e.g. to
+	// get from one test centre or video call to the next.
+	backToBackGap = 30 * time.Minute
+	// conflictWindow is how far ahead the user's calendar is checked.
+	conflictWindow = 30 * 24 * time.Hour
+)
+
+// Slot is a time the user is expected somewhere: a test or interview from
+// an email, or a busy period on their Google Calendar.
+type Slot struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// Kind is exam, interview or busy.
+	Kind    string `json:"kind"`
+	Title   string `json:"title,omitempty"`
+	GmailID string `json:"gmailId,omitempty"`
+}
+
+// Conflict is two slots that overlap or leave less than backToBackGap
+// between them. First starts no later than Second.
+type Conflict struct {
+	Type   string `json:"type"`
+	First  Slot   `json:"first"`
+	Second Slot   `json:"second"`
+}
+
+// Involves reports whether the conflict concerns the email gmailID.
+func (c Conflict) Involves(gmailID string) bool {
+	return c.First.GmailID == gmailID || c.Second.GmailID == gmailID
+}
+
+// EmailSlots returns the upcoming tests and interviews with a time of day
+// found in summaries.
+func EmailSlots(summaries []*ai.AIResult, rules *user.CalendarRules, now time.Time) []Slot {
+	var slots []Slot
+	for _, p := range opportunityEvents(summaries, rules, now) {
+		if p.kind != KindExam && p.kind != KindInterview {
+			continue
+		}
+		when := p.span(rules)
+		if when.allDay || !when.upcoming(now) {
+			continue
+		}
+		slots = append(slots, Slot{Start: when.start, End: when.end, Kind: p.kind, Title: p.title, GmailID: p.gmailID})
+	}
+	return slots
+}
+
+// FindConflicts returns the email slots that collide with each other or
+// with a busy period. A busy period exactly matching an email slot is taken
+// to be that slot's own calendar event.
+func FindConflicts(emailSlots, busy []Slot) []Conflict {
+	busy = slices.DeleteFunc(slices.Clone(busy), func(b Slot) bool {
+		return slices.ContainsFunc(emailSlots, func(a Slot) bool { return sameTime(a, b) })
+	})
+	conflicts := make([]Conflict, 0)
+	for i, a := range emailSlots {
+		for _, b := range emailSlots[i+1:] {
+			if c, ok := collide(a, b); ok {
+				conflicts = append(conflicts, c)
+			}
+		}
+		for _, b := range busy {
+			if c, ok := collide(a, b); ok {
+				conflicts = append(conflicts, c)
+			}
+		}
+	}
+	slices.SortStableFunc(conflicts, func(x, y Conflict) int {
+		return x.First.Start.Compare(y.First.Start)
+	})
+	return conflicts
+}
+
+// collide reports whether a and b overlap or are back to back.
+func collide(a, b Slot) (Conflict, bool) {
+	if b.Start.Before(a.Start) {
+		a, b = b, a
+	}
+	switch {
+	case b.Start.Before(a.End):
+		return Conflict{Type: ConflictOverlap, First: a, Second: b}, true
+	case b.Start.Sub(a.End) < backToBackGap:
+		return Conflict{Type: ConflictBackToBack, First: a, Second: b}, true
+	}
+	return Conflict{}, false
+}
+
+func sameTime(a, b Slot) bool {
+	return a.Start.Sub(b.Start).Abs() < time.Minute && a.End.Sub(b.End).Abs() < time.Minute
+}
+
+// busyCalendars are the calendars checked for conflicts: the user's
+// primary calendar and the one AuraMail adds events to.
+func busyCalendars(rules *user.CalendarRules) []string {
+	if id := rules.Calendar(); id != user.PrimaryCalendar {
+		return []string{user.PrimaryCalendar, id}
+	}
+	return []string{user.PrimaryCalendar}
+}
+
+// busySlots returns the busy periods on calendars between from and to.
+func busySlots(ctx context.Context, svc *gcalendar.Service, calendars []string, from, to time.Time) ([]Slot, error) {
+	items := make([]*gcalendar.FreeBusyRequestItem, 0, len(calendars))
+	for _, id := range calendars {
+		items = append(items, &gcalendar.FreeBusyRequestItem{Id: id})
+	}
+	resp, err := svc.Freebusy.Query(&gcalendar.FreeBusyRequest{
+		TimeMin: from.Format(time.RFC3339),
+		TimeMax: to.Format(time.RFC3339),
+		Items:   items,
+	}).Context(ctx).Do()
+	if err != nil {
+		return nil, err
+	}
+
+	var slots []Slot
+	for _, id := range calendars {
+		cal, ok := resp.Calendars[id]
+		if !ok {
+			return nil, fmt.Errorf("free/busy response has no calendar %q", id)
+		}
+		if len(cal.Errors) > 0 {
+			return nil, fmt.Errorf("free/busy for calendar %q: %s", id, cal.Errors[0].Reason)
+		}
+		for _, p := range cal.Busy {
+			start, err := time.Parse(time.RFC3339, p.Start)
+			if err != nil {
+				continue
+			}
+			end, err := time.Parse(time.RFC3339, p.End)
+			if err != nil {
+				continue
+			}
+			slots = append(slots, Slot{Start: start, End: end, Kind: SlotBusy})
+		}
+	}
+	return slots, nil
+}
+
+// eventConflicts returns the busy periods that collide with a new event.
+// ignore is the span of an event being replaced, which is not a conflict.
+func eventConflicts(ctx context.Context, svc *gcalendar.Service, calendars []string, event Slot, ignore *Slot) ([]Conflict, error) {
+	busy, err := busySlots(ctx, svc, calendars, event.Start.Add(-backToBackGap), event.End.Add(backToBackGap))
+	if err != nil {
+		return nil, err
+	}
+	var conflicts []Conflict
+	for _, b := range busy {
+		if ignore != nil && sameTime(*ignore, b) {
+			continue
+		}
+		if c, ok := collide(event, b); ok {
+			conflicts = append(conflicts, c)
+		}
+	}
+	return conflicts, nil
+}
+
+// GetConflicts lists upcoming tests and interviews that overlap or are back
+// to back, with each other or with the user's Google Calendar. Without
+// calendar access only the emails are compared.
+func (h *Handler) GetConflicts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(auth.UserIDContextKey).(string)
+	if !ok {
+		response.Unauthorized(w, "No UserID found in context")
+		return
+	}
+
+	summaries, err := h.userRepo.ListSummaries(ctx, userID, user.SummaryFilter{Limit: feedSummaryLimit})
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load summaries for conflicts", "err", err, "userID", userID)
+		response.InternalError(w, "Failed to check for conflicts")
+		return
+	}
+	now := time.Now()
+	rules := h.calendarRules(ctx, userID)
+	slots := EmailSlots(summaries, rules, now)
+
+	var busy []Slot
+	calendarChecked := false
+	if len(slots) > 0 {
+		busy, err = h.userBusySlots(ctx, userID, busyCalendars(rules), now, now.Add(conflictWindow))
+		if err != nil {
+			slog.WarnContext(ctx, "conflicts: calendar not checked", "err", err, "userID", userID)
+		} else {
+			calendarChecked = true
+		}
+	}
+
+	conflicts := FindConflicts(slots, busy)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"success":         true,
+		"conflicts":       conflicts,
+		"total":           len(conflicts),
+		"calendarChecked": calendarChecked,
+	})
+}
+
+// userBusySlots returns the busy periods on the user's Google calendars.
+func (h *Handler) userBusySlots(ctx context.Context, userID string, calendars []string, from, to time.Time) ([]Slot, error) {
+	u, err := h.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	svc, err := h.client.Service(ctx, u.GoogleRefreshToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create calendar service: %w", err)
+	}
+	return busySlots(ctx, svc, calendars, from, to)
+}
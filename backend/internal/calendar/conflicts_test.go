@@ -0,0 +1,105 @@
+package calendar
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	gcalendar "google.golang.org/api/calendar/v3"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/calendar/calendartest"
+)
+
+func TestFindConflicts(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2026, 8, 14, h, m, 0, 0, time.UTC) }
+	oa := Slot{Start: at(9, 0), End: at(10, 0), Kind: KindExam, GmailID: "m1"}
+	interview := Slot{Start: at(9, 30), End: at(10, 30), Kind: KindInterview, GmailID: "m2"}
+	later := Slot{Start: at(10, 45), End: at(11, 45), Kind: KindInterview, GmailID: "m3"}
+	evening := Slot{Start: at(18, 0), End: at(19, 0), Kind: KindExam, GmailID: "m4"}
+	busy := []Slot{
+		{Start: at(9, 0), End: at(10, 0), Kind: SlotBusy},   // m1's own event
+		{Start: at(18, 0), End: at(18, 30), Kind: SlotBusy}, // a class
+	}
+
+	conflicts := FindConflicts([]Slot{interview, oa, later, evening}, busy)
+	want := []struct {
+		typ           string
+		first, second string
+	}{
+		{ConflictOverlap, "m1", "m2"},
+		{ConflictBackToBack, "m2", "m3"},
+		{ConflictOverlap, "m4", ""},
+	}
+	if len(conflicts) != len(want) {
+		t.Fatalf("expected %d conflicts, got %+v", len(want), conflicts)
+	}
+	for i, w := range want {
+		c := conflicts[i]
+		if c.Type != w.typ || c.First.GmailID != w.first || c.Second.GmailID != w.second {
+			t.Errorf("conflict %d = %s %s/%s, want %s %s/%s", i, c.Type, c.First.GmailID, c.Second.GmailID, w.typ, w.first, w.second)
+		}
+	}
+	if !conflicts[0].Involves("m2") || conflicts[0].Involves("m3") {
+		t.Errorf("Involves is wrong for %+v", conflicts[0])
+	}
+}
+
+func TestAddEvent_WarnsAboutConflicts(t *testing.T) {
+	cal := newFakeCalendar(t)
+	h := newCalendarTestHandler(cal)
+	cal.AddEvent(calendartest.Primary, &gcalendar.Event{
+		Id:    "class",
+		Start: &gcalendar.EventDateTime{DateTime: "2099-08-14T10:00:00+05:30"},
+		End:   &gcalendar.EventDateTime{DateTime: "2099-08-14T11:00:00+05:30"},
+	})
+
+	add := func(start string) AddEventResponse {
+		body, _ := json.Marshal(AddEventRequest{Title: "Globex Interview", StartTime: start, EmailID: "m1", EventType: KindInterview})
+		rr := httptest.NewRecorder()
+		h.AddEvent(rr, withUserID(httptest.NewRequest(http.MethodPost, "/calendar/events", bytes.NewBuffer(body)), "1"))
+		var resp AddEventResponse
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil || rr.Code != http.StatusOK {
+			t.Fatalf("AddEvent: %d %v", rr.Code, err)
+		}
+		return resp
+	}
+
+	resp := add("2099-08-14T11:15:00+05:30")
+	if len(resp.Conflicts) != 1 || resp.Conflicts[0].Type != ConflictBackToBack || resp.Warning == "" {
+		t.Fatalf("expected a back-to-back warning, got %+v", resp)
+	}
+
+	// Moving the same event later is clear of the class; its old time is
+	// not a conflict with itself.
+	resp = add("2099-08-14T15:00:00+05:30")
+	if !resp.Updated || len(resp.Conflicts) != 0 || resp.Warning != "" {
+		t.Errorf("expected no conflicts, got %+v", resp)
+	}
+}
+
+func TestGetConflicts(t *testing.T) {
+	cal := newFakeCalendar(t)
+	h := newCalendarTestHandler(cal)
+	repo := h.userRepo.(*fakeUserRepo)
+	repo.summaries = map[string]*ai.AIResult{
+		"m1": {GmailMessageID: "m1", Category: "exam", Company: strPtr("Globex"), Timings: "Online test on 14 Aug 2099 at 2:00 PM"},
+		"m2": {GmailMessageID: "m2", Category: "interview", Company: strPtr("Initech"), Timings: "Interview on 14 Aug 2099 at 2:30 PM"},
+	}
+
+	rr := httptest.NewRecorder()
+	h.GetConflicts(rr, withUserID(httptest.NewRequest(http.MethodGet, "/calendar/conflicts", nil), "1"))
+	var resp struct {
+		Conflicts       []Conflict `json:"conflicts"`
+		CalendarChecked bool       `json:"calendarChecked"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !resp.CalendarChecked || len(resp.Conflicts) != 1 || resp.Conflicts[0].Type != ConflictOverlap {
+		t.Errorf("expected the test and interview to overlap, got %+v", resp)
+	}
+}
@@ -0,0 +1,218 @@
+package calendar
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/auth"
+	"github.com/r7rainz/auramail/internal/ical"
+	"github.com/r7rainz/auramail/internal/response"
+	"github.com/r7rainz/auramail/internal/user"
+)
+
+const (
+	// feedTokenBytes is how much randomness a feed token carries.
+	feedTokenBytes = 32
+	// feedSummaryLimit caps how many summaries one feed is built from.
+	feedSummaryLimit = 500
+	// feedRefresh is how often subscribed clients are asked to poll.
+	feedRefresh = time.Hour
+)
+
+// hashFeedToken is what is stored for a feed token, so the database alone
+// does not reveal feed URLs.
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// feedPath is the path of the feed for token.
+func feedPath(token string) string {
+	return "/calendar/feed/" + token + ".ics"
+}
+
+// baseURL is the scheme and host the request reached the API on.
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host
+}
+
+// GetFeed reports whether the user has a calendar feed. The feed URL is
+// only shown when it is created.
+func (h *Handler) GetFeed(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(auth.UserIDContextKey).(string)
+	if !ok {
+		response.Unauthorized(w, "No UserID found in context")
+		return
+	}
+
+	created, err := h.userRepo.GetCalendarFeedToken(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.Success(w, map[string]any{"enabled": false})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load calendar feed", "err", err, "userID", userID)
+		response.InternalError(w, "Failed to load calendar feed")
+		return
+	}
+	response.Success(w, map[string]any{"enabled": true, "createdAt": created})
+}
+
+// CreateFeed creates the user's secret iCalendar feed URL. Calling it again
+// regenerates the URL and revokes the old one.
+func (h *Handler) CreateFeed(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(auth.UserIDContextKey).(string)
+	if !ok {
+		response.Unauthorized(w, "No UserID found in context")
+		return
+	}
+
+	b := make([]byte, feedTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		slog.ErrorContext(ctx, "failed to generate calendar feed token", "err", err)
+		response.InternalError(w, "Failed to create calendar feed")
+		return
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	created, err := h.userRepo.SaveCalendarFeedToken(ctx, userID, hashFeedToken(token))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to save calendar feed token", "err", err, "userID", userID)
+		response.InternalError(w, "Failed to create calendar feed")
+		return
+	}
+
+	slog.InfoContext(ctx, "calendar feed created", "userID", userID)
+
+	feedURL := baseURL(r) + feedPath(token)
+	response.Success(w, map[string]any{
+		"enabled":   true,
+		"createdAt": created,
+		"path":      feedPath(token),
+		"url":       feedURL,
+		// webcal:// opens the subscription dialog in Apple Calendar and
+		// Outlook.
+		"webcalUrl": "webcal://" + strings.TrimPrefix(strings.TrimPrefix(feedURL, "https://"), "http://"),
+	})
+}
+
+// DeleteFeed revokes the user's calendar feed URL.
+func (h *Handler) DeleteFeed(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(auth.UserIDContextKey).(string)
+	if !ok {
+		response.Unauthorized(w, "No UserID found in context")
+		return
+	}
+
+	if err := h.userRepo.DeleteCalendarFeedToken(ctx, userID); err != nil {
+		slog.ErrorContext(ctx, "failed to delete calendar feed token", "err", err, "userID", userID)
+		response.InternalError(w, "Failed to revoke calendar feed")
+		return
+	}
+
+	slog.InfoContext(ctx, "calendar feed revoked", "userID", userID)
+	response.Success(w, map[string]any{"enabled": false})
+}
+
+// Feed serves a user's deadlines, tests and interviews as an iCalendar
+// feed. It is public: the secret token in the URL identifies the user, so
+// calendar apps can subscribe without signing in.
+func (h *Handler) Feed(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	token, ok := strings.CutSuffix(r.PathValue("file"), ".ics")
+	if !ok || token == "" {
+		response.NotFound(w, "Calendar feed not found")
+		return
+	}
+	userID, err := h.userRepo.FindCalendarFeedUser(ctx, hashFeedToken(token))
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.NotFound(w, "Calendar feed not found")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to look up calendar feed", "err", err)
+		response.InternalError(w, "Failed to load calendar feed")
+		return
+	}
+
+	summaries, err := h.userRepo.ListSummaries(ctx, userID, user.SummaryFilter{Limit: feedSummaryLimit})
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load summaries for calendar feed", "err", err, "userID", userID)
+		response.InternalError(w, "Failed to load calendar feed")
+		return
+	}
+	rules := h.calendarRules(ctx, userID)
+
+	cal := &ical.Calendar{
+		ProdID:          "-//AuraMail//Placement Calendar//EN",
+		Name:            "AuraMail placements",
+		TimeZone:        rules.TimeZone,
+		RefreshInterval: feedRefresh,
+		Events:          feedEvents(summaries, rules, time.Now()),
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="auramail.ics"`)
+	w.Header().Set("Cache-Control", "private, max-age=900")
+	if err := cal.Encode(w); err != nil {
+		slog.ErrorContext(ctx, "failed to write calendar feed", "err", err, "userID", userID)
+	}
+}
+
+// feedEvents turns summaries into feed events. Each event keeps the UID of
+// the first email that called for it, so subscribed calendars update the
+// event they have when a reminder mail changes it.
+func feedEvents(summaries []*ai.AIResult, rules *user.CalendarRules, now time.Time) []ical.Event {
+	planned := opportunityEvents(summaries, rules, now)
+	feed := make([]ical.Event, 0, len(planned))
+	for _, p := range planned {
+		stamp, err := time.Parse(time.RFC3339, p.res.ReceiverAt)
+		if err != nil {
+			stamp = now
+		}
+		when := p.span(rules)
+		var alarms []time.Duration
+		for _, m := range rules.RemindersFor(p.kind) {
+			alarms = append(alarms, time.Duration(m)*time.Minute)
+		}
+		feed = append(feed, ical.Event{
+			UID:         p.firstGmailID + "-" + p.kind + "@auramail",
+			Summary:     p.title,
+			Description: p.description,
+			Location:    p.location,
+			URL:         gmailLink(p.gmailID),
+			Start:       when.start,
+			End:         when.end,
+			AllDay:      when.allDay,
+			Categories:  slices.DeleteFunc([]string{"AuraMail", eventLabels[p.kind], p.res.Category}, func(c string) bool { return c == "" }),
+			Alarms:      alarms,
+			Stamp:       stamp,
+		})
+	}
+	return feed
+}
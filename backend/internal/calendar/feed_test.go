@@ -0,0 +1,85 @@
+package calendar
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/user"
+)
+
+func TestFeedEvents_OpportunityKeepsFirstUID(t *testing.T) {
+	now := time.Date(2026, 8, 10, 9, 0, 0, 0, time.UTC)
+	rules := user.DefaultCalendarRules()
+	first := &ai.AIResult{GmailMessageID: "m1", OpportunityID: "opp-1", ReceiverAt: "2026-08-01T10:00:00Z", Category: "exam", Company: strPtr("Globex"), Deadline: strPtr("2026-08-12")}
+	reminder := &ai.AIResult{GmailMessageID: "m2", OpportunityID: "opp-1", ReceiverAt: "2026-08-05T10:00:00Z", Category: "exam", Company: strPtr("Globex"), Deadline: strPtr("2026-08-13"), Timings: "Test on 14 Aug 2026, 2:30 PM"}
+
+	events := feedEvents([]*ai.AIResult{reminder, first}, &rules, now)
+	if len(events) != 2 {
+		t.Fatalf("expected a deadline and a test, got %+v", events)
+	}
+	deadline, test := events[0], events[1]
+	if deadline.UID != "m1-deadline@auramail" || !deadline.AllDay || deadline.Start.Day() != 13 {
+		t.Errorf("deadline should keep the first email's UID and take the reminder's date: %+v", deadline)
+	}
+	if test.UID != "m2-exam@auramail" || test.AllDay || test.Start.UTC().Format(time.RFC3339) != "2026-08-14T09:00:00Z" {
+		t.Errorf("unexpected test slot: %+v", test)
+	}
+	if strings.Join(test.Categories, ",") != "AuraMail,Test,exam" || len(test.Alarms) != 2 {
+		t.Errorf("unexpected categories or alarms: %v, %v", test.Categories, test.Alarms)
+	}
+}
+
+func TestFeed_CreateServeRevoke(t *testing.T) {
+	repo := &fakeUserRepo{summaries: map[string]*ai.AIResult{
+		"m1": {GmailMessageID: "m1", ReceiverAt: "2026-08-01T10:00:00Z", Category: "internship", Company: strPtr("Globex"), Deadline: strPtr("2099-08-12")},
+	}}
+	h := NewHandler(repo, GoogleClient{})
+
+	rr := httptest.NewRecorder()
+	h.CreateFeed(rr, withUserID(httptest.NewRequest(http.MethodPost, "/calendar/feed", nil), "1"))
+	var created struct {
+		Data struct {
+			Path      string `json:"path"`
+			WebcalURL string `json:"webcalUrl"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&created); err != nil || created.Data.Path == "" {
+		t.Fatalf("CreateFeed: %d %v", rr.Code, err)
+	}
+	if !strings.HasPrefix(created.Data.WebcalURL, "webcal://example.com/calendar/feed/") {
+		t.Errorf("unexpected webcal URL %q", created.Data.WebcalURL)
+	}
+	if strings.Contains(created.Data.Path, repo.feedHash) {
+		t.Errorf("the stored hash must differ from the token")
+	}
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.SetPathValue("file", strings.TrimPrefix(path, "/calendar/feed/"))
+		rr := httptest.NewRecorder()
+		h.Feed(rr, req)
+		return rr
+	}
+
+	rr = serve(created.Data.Path)
+	if rr.Code != http.StatusOK || !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/calendar") {
+		t.Fatalf("Feed: %d %s", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	if body := rr.Body.String(); !strings.Contains(body, "UID:m1-deadline@auramail") || !strings.Contains(body, "DTSTART;VALUE=DATE:20990812") {
+		t.Errorf("deadline missing from feed:\n%s", body)
+	}
+	if rr := serve("/calendar/feed/wrong.ics"); rr.Code != http.StatusNotFound {
+		t.Errorf("unknown token: expected 404, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	h.DeleteFeed(rr, withUserID(httptest.NewRequest(http.MethodDelete, "/calendar/feed", nil), "1"))
+	if rr := serve(created.Data.Path); rr.Code != http.StatusNotFound {
+		t.Errorf("revoked token: expected 404, got %d", rr.Code)
+	}
+}
@@ -1,17 +1,19 @@
 package calendar
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/r7rainz/auramail/internal/ai"
 	"github.com/r7rainz/auramail/internal/auth"
-	"github.com/r7rainz/auramail/internal/auth/google"
 	"github.com/r7rainz/auramail/internal/response"
 	"github.com/r7rainz/auramail/internal/user"
 	gcalendar "google.golang.org/api/calendar/v3"
@@ -22,18 +24,45 @@ import (
 // needing a real database.
 type UserRepository interface {
 	FindByID(ctx context.Context, id string) (*user.User, error)
+	GetCalendarRules(ctx context.Context, userID string) (*user.CalendarRules, error)
+	SaveCalendarRules(ctx context.Context, userID string, rules *user.CalendarRules) error
+	ListSummaries(ctx context.Context, userID string, filter user.SummaryFilter) ([]*ai.AIResult, error)
+	SaveCalendarFeedToken(ctx context.Context, userID, tokenHash string) (time.Time, error)
+	GetCalendarFeedToken(ctx context.Context, userID string) (time.Time, error)
+	DeleteCalendarFeedToken(ctx context.Context, userID string) error
+	FindCalendarFeedUser(ctx context.Context, tokenHash string) (string, error)
 }
 
 type Handler struct {
 	userRepo UserRepository
+	client   Client
 }
 
-func NewHandler(repo UserRepository) *Handler {
+func NewHandler(repo UserRepository, client Client) *Handler {
 	return &Handler{
 		userRepo: repo,
+		client:   client,
 	}
 }
 
+// calendarRules returns the user's calendar rules, or the defaults when
+// they cannot be loaded.
+func (h *Handler) calendarRules(ctx context.Context, userID string) *user.CalendarRules {
+	rules, err := h.userRepo.GetCalendarRules(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load calendar rules, using defaults", "err", err, "userID", userID)
+		defaults := user.DefaultCalendarRules()
+		return &defaults
+	}
+	return rules
+}
+
+// requestCalendar returns the calendar a request is about: the calendarId
+// query parameter, or the one in the user's rules.
+func requestCalendar(r *http.Request, rules *user.CalendarRules) string {
+	return cmp.Or(r.URL.Query().Get("calendarId"), rules.Calendar())
+}
+
 // AddEventRequest represents the request body for adding a calendar event
 type AddEventRequest struct {
 	Title       string `json:"title"`       // Event title (e.g., "Microsoft Interview")
@@ -41,7 +70,7 @@ type AddEventRequest struct {
 	StartTime   string `json:"startTime"`   // ISO 8601 format: "2026-02-15T10:00:00"
 	EndTime     string `json:"endTime"`     // ISO 8601 format: "2026-02-15T11:00:00"
 	Location    string `json:"location"`    // Optional location
-	EmailID     string `json:"emailId"`     // Reference to the email summary
+	EmailID     string `json:"emailId"`     // Email the event is for; adding it again updates the event
 	Company     string `json:"company"`     // Company name for context
 	Role        string `json:"role"`        // Role for context
 	EventType   string `json:"eventType"`   // "deadline", "interview", "exam", "event"
@@ -53,6 +82,13 @@ type AddEventResponse struct {
 	EventID   string `json:"eventId"`
 	EventLink string `json:"eventLink"`
 	Message   string `json:"message"`
+	// Updated is true when an event already added for the same email and
+	// event type was updated instead of creating a duplicate.
+	Updated bool `json:"updated"`
+	// Conflicts are events already on the calendar that the new event
+	// overlaps or leaves too little time after or before.
+	Conflicts []Conflict `json:"conflicts,omitempty"`
+	Warning   string     `json:"warning,omitempty"`
 }
 
 // AddEvent adds a placement-related event to the user's Google Calendar
@@ -96,7 +132,7 @@ func (h *Handler) AddEvent(w http.ResponseWriter, r *http.Request) {
 	slog.Info("Found user, creating calendar service", "email", u.Email, "hasGoogleRefreshToken", u.GoogleRefreshToken != "")
 
 	// Create calendar service
-	calSvc, err := google.CreateCalendarService(ctx, u.GoogleRefreshToken)
+	calSvc, err := h.client.Service(ctx, u.GoogleRefreshToken)
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to create calendar service",
 			"err", err,
@@ -109,42 +145,35 @@ func (h *Handler) AddEvent(w http.ResponseWriter, r *http.Request) {
 
 	slog.Info("Calendar service created successfully")
 
-	// Parse start time
-	startTime, err := time.Parse(time.RFC3339, req.StartTime)
+	// Times are read in the user's time zone; a deadline known only by
+	// date becomes an all-day event when the user prefers that.
+	rules := h.calendarRules(ctx, userID)
+	eventType := cmp.Or(req.EventType, KindEvent)
+	loc := rules.Location()
+
+	startTime, startDate, err := parseEventTime(req.StartTime, loc)
 	if err != nil {
-		slog.Info("Trying alternative date format", "original", req.StartTime)
-		// Try parsing without timezone
-		startTime, err = time.Parse("2006-01-02T15:04:05", req.StartTime)
-		if err != nil {
-			// Try date only format
-			startTime, err = time.Parse("2006-01-02", req.StartTime)
-			if err != nil {
-				slog.Error("Failed to parse startTime", "startTime", req.StartTime, "err", err)
-				response.BadRequest(w, "Invalid startTime format. Use ISO 8601 format (e.g., 2026-02-15T10:00:00 or 2026-02-15)", nil)
-				return
-			}
-			// If date only, set time to 10:00 AM
-			startTime = startTime.Add(10 * time.Hour)
-		}
+		slog.Error("Failed to parse startTime", "startTime", req.StartTime, "err", err)
+		response.BadRequest(w, "Invalid startTime format. Use ISO 8601 format (e.g., 2026-02-15T10:00:00 or 2026-02-15)", nil)
+		return
 	}
-
-	slog.Info("Parsed start time", "startTime", startTime)
-
-	// Set end time (default to 1 hour after start if not provided)
 	var endTime time.Time
+	var endDate bool
 	if req.EndTime != "" {
-		endTime, err = time.Parse(time.RFC3339, req.EndTime)
-		if err != nil {
-			endTime, err = time.Parse("2006-01-02T15:04:05", req.EndTime)
-			if err != nil {
-				response.BadRequest(w, "Invalid endTime format. Use ISO 8601 format", nil)
-				return
-			}
+		if endTime, endDate, err = parseEventTime(req.EndTime, loc); err != nil {
+			response.BadRequest(w, "Invalid endTime format. Use ISO 8601 format", nil)
+			return
 		}
-	} else {
-		endTime = startTime.Add(1 * time.Hour)
+	}
+	allDay := eventType == KindDeadline && rules.AllDayDeadlines
+	when, err := newSpan(startTime, startDate, endTime, endDate, allDay, rules.EventLength())
+	if err != nil {
+		response.BadRequest(w, err.Error(), nil)
+		return
 	}
 
+	slog.Info("Parsed event time", "start", when.start, "end", when.end, "allDay", when.allDay)
+
 	// Build description with context
 	description := req.Description
 	if req.Company != "" || req.Role != "" {
@@ -154,41 +183,64 @@ func (h *Handler) AddEvent(w http.ResponseWriter, r *http.Request) {
 		}
 		description += "\n" + req.Description
 	}
-	description += "\n\n---\nAdded via AuraMail"
+	description += "\n\n---\n" + auraMailFooter
 
 	// Create the calendar event
+	start, end := when.eventTimes(rules.TimeZone)
 	event := &gcalendar.Event{
 		Summary:     req.Title,
 		Description: description,
 		Location:    req.Location,
-		Start: &gcalendar.EventDateTime{
-			DateTime: startTime.Format(time.RFC3339),
-			TimeZone: "Asia/Kolkata", // Indian timezone for VIT students
-		},
-		End: &gcalendar.EventDateTime{
-			DateTime: endTime.Format(time.RFC3339),
-			TimeZone: "Asia/Kolkata",
-		},
-		Reminders: &gcalendar.EventReminders{
-			UseDefault:      false,
-			ForceSendFields: []string{"UseDefault"},
-			Overrides: []*gcalendar.EventReminder{
-				{Method: "popup", Minutes: 30},   // 30 min before
-				{Method: "popup", Minutes: 60},   // 1 hour before
-				{Method: "email", Minutes: 1440}, // 1 day before
-			},
-		},
+		Start:       start,
+		End:         end,
+		Reminders:   popupReminders(rules.RemindersFor(eventType)),
 		// Add color based on event type
 		ColorId: getColorForEventType(req.EventType),
 	}
 
+	// Events for an email are tagged with it, so adding the same email and
+	// event type again updates the existing event.
+	var existingID string
+	var ignore *Slot
+	if req.EmailID != "" {
+		event.ExtendedProperties = auraMailProperties(req.EmailID, eventType, req.Company)
+		existing, err := emailEvents(ctx, calSvc, rules.Calendar(), req.EmailID, eventType)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to look up events for email", "err", err, "emailId", req.EmailID)
+			response.InternalError(w, "Failed to check your Google Calendar for this email")
+			return
+		}
+		if len(existing) > 0 {
+			existingID = existing[0].Id
+			current := eventSpan(existing[0], loc)
+			ignore = &Slot{Start: current.start, End: current.end}
+		}
+	}
+
+	// Check for clashes before saving, while the event is not yet busy
+	// time itself. Failing to check does not stop the event being added.
+	var conflicts []Conflict
+	if !when.allDay {
+		slot := Slot{Start: when.start, End: when.end, Kind: eventType, Title: req.Title, GmailID: req.EmailID}
+		if conflicts, err = eventConflicts(ctx, calSvc, busyCalendars(rules), slot, ignore); err != nil {
+			slog.WarnContext(ctx, "failed to check calendar for conflicts", "err", err, "userID", userID)
+			conflicts = nil
+		}
+	}
+
 	// Insert the event
 	slog.Info("Inserting calendar event",
 		"summary", event.Summary,
-		"startDateTime", event.Start.DateTime,
-		"endDateTime", event.End.DateTime,
+		"start", when.start,
+		"end", when.end,
+		"existingEventId", existingID,
 	)
-	createdEvent, err := calSvc.Events.Insert("primary", event).Do()
+	var createdEvent *gcalendar.Event
+	if existingID != "" {
+		createdEvent, err = calSvc.Events.Patch(rules.Calendar(), existingID, event).Context(ctx).Do()
+	} else {
+		createdEvent, err = calSvc.Events.Insert(rules.Calendar(), event).Context(ctx).Do()
+	}
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to create calendar event",
 			"err", err,
@@ -200,18 +252,163 @@ func (h *Handler) AddEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	slog.Info("calendar event created",
+	slog.Info("calendar event saved",
 		"eventId", createdEvent.Id,
 		"title", req.Title,
 		"userId", userID,
+		"updated", existingID != "",
 	)
 
+	message := "Event added to your Google Calendar with reminders"
+	if existingID != "" {
+		message = "Event updated in your Google Calendar"
+	}
+	var warning string
+	if len(conflicts) > 0 {
+		warning = fmt.Sprintf("This event clashes with %d other event(s) on your calendar", len(conflicts))
+	}
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(AddEventResponse{
 		Success:   true,
 		EventID:   createdEvent.Id,
 		EventLink: createdEvent.HtmlLink,
-		Message:   "Event added to your Google Calendar with reminders",
+		Message:   message,
+		Updated:   existingID != "",
+		Conflicts: conflicts,
+		Warning:   warning,
+	})
+}
+
+// UpdateEventRequest changes only the fields that are present.
+type UpdateEventRequest struct {
+	Title     *string `json:"title"`
+	StartTime *string `json:"startTime"` // ISO 8601; the event keeps its length unless endTime is also given
+	EndTime   *string `json:"endTime"`
+	Location  *string `json:"location"`
+	// ReminderMinutes replaces the event's popup reminders; an empty list
+	// falls back to the calendar's default reminders.
+	ReminderMinutes *[]int `json:"reminderMinutes"`
+}
+
+// UpdateEvent edits the title, time, location or reminders of an event on
+// the user's Google Calendar.
+func (h *Handler) UpdateEvent(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(auth.UserIDContextKey).(string)
+	if !ok {
+		response.Unauthorized(w, "No UserID found in context")
+		return
+	}
+
+	eventID := r.PathValue("id")
+	if eventID == "" {
+		response.BadRequest(w, "event id is required", nil)
+		return
+	}
+
+	var req UpdateEventRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "Invalid request body", nil)
+		return
+	}
+	if req.Title != nil && *req.Title == "" {
+		response.BadRequest(w, "Title cannot be empty", nil)
+		return
+	}
+	rules := h.calendarRules(ctx, userID)
+	loc := rules.Location()
+	var start, end time.Time
+	var startDate, endDate bool
+	var err error
+	if req.StartTime != nil {
+		if start, startDate, err = parseEventTime(*req.StartTime, loc); err != nil {
+			response.BadRequest(w, "Invalid startTime format. Use ISO 8601 format (e.g., 2026-02-15T10:00:00 or 2026-02-15)", nil)
+			return
+		}
+	}
+	if req.EndTime != nil {
+		if end, endDate, err = parseEventTime(*req.EndTime, loc); err != nil {
+			response.BadRequest(w, "Invalid endTime format. Use ISO 8601 format", nil)
+			return
+		}
+	}
+	if req.ReminderMinutes != nil {
+		if msg := validateReminders(*req.ReminderMinutes); msg != "" {
+			response.BadRequest(w, msg, nil)
+			return
+		}
+	}
+
+	u, err := h.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		response.NotFound(w, "User not found")
+		return
+	}
+
+	calSvc, err := h.client.Service(ctx, u.GoogleRefreshToken)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to create calendar service", "err", err)
+		response.InternalError(w, "Failed to connect to Google Calendar")
+		return
+	}
+
+	calendarID := requestCalendar(r, rules)
+	current, err := calSvc.Events.Get(calendarID, eventID).Context(ctx).Do()
+	if isGone(err) {
+		response.NotFound(w, "Event not found")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to fetch calendar event", "err", err, "eventId", eventID)
+		response.InternalError(w, "Failed to fetch calendar event")
+		return
+	}
+
+	patch := &gcalendar.Event{}
+	if req.Title != nil {
+		patch.Summary = *req.Title
+	}
+	if req.Location != nil {
+		patch.Location = *req.Location
+		patch.ForceSendFields = append(patch.ForceSendFields, "Location")
+	}
+	if req.StartTime != nil || req.EndTime != nil {
+		cur := eventSpan(current, loc)
+		if req.StartTime == nil {
+			start, startDate = cur.start, cur.allDay
+		}
+		// Moving a timed event keeps its length.
+		length := cur.end.Sub(cur.start)
+		if cur.allDay || length <= 0 {
+			length = rules.EventLength()
+		}
+		allDay := cur.allDay || (privateProperty(current, propEventType) == KindDeadline && rules.AllDayDeadlines)
+		when, err := newSpan(start, startDate, end, endDate, allDay, length)
+		if err != nil {
+			response.BadRequest(w, err.Error(), nil)
+			return
+		}
+		patch.Start, patch.End = when.eventTimes(rules.TimeZone)
+	}
+	if req.ReminderMinutes != nil {
+		patch.Reminders = popupReminders(*req.ReminderMinutes)
+	}
+
+	updated, err := calSvc.Events.Patch(calendarID, eventID, patch).Context(ctx).Do()
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to update calendar event", "err", err, "eventId", eventID)
+		response.InternalError(w, "Failed to update calendar event")
+		return
+	}
+
+	slog.Info("calendar event updated", "eventId", eventID, "userId", userID)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"success": true,
+		"event":   toCalendarEvent(updated),
+		"message": "Event updated in your Google Calendar",
 	})
 }
 
@@ -257,7 +454,7 @@ func (h *Handler) DeleteEvent(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create calendar service
-	calSvc, err := google.CreateCalendarService(ctx, u.GoogleRefreshToken)
+	calSvc, err := h.client.Service(ctx, u.GoogleRefreshToken)
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to create calendar service", "err", err)
 		response.InternalError(w, "Failed to connect to Google Calendar")
@@ -265,7 +462,12 @@ func (h *Handler) DeleteEvent(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Delete the event
-	err = calSvc.Events.Delete("primary", eventID).Do()
+	calendarID := requestCalendar(r, h.calendarRules(ctx, userID))
+	err = calSvc.Events.Delete(calendarID, eventID).Context(ctx).Do()
+	if isGone(err) {
+		response.NotFound(w, "Event not found")
+		return
+	}
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to delete calendar event", "err", err, "eventId", eventID)
 		response.InternalError(w, "Failed to delete calendar event")
@@ -292,9 +494,63 @@ type CalendarEvent struct {
 	Link        string `json:"link"`
 	ColorID     string `json:"colorId"`
 	IsAuraMail  bool   `json:"isAuraMail"` // true if added via AuraMail
+	EmailID     string `json:"emailId,omitempty"`
+	EventType   string `json:"eventType,omitempty"`
 }
 
-// GetEvents fetches upcoming calendar events for the user
+func toCalendarEvent(item *gcalendar.Event) CalendarEvent {
+	// Determine start/end times
+	startTime := ""
+	endTime := ""
+	if item.Start != nil {
+		if item.Start.DateTime != "" {
+			startTime = item.Start.DateTime
+		} else {
+			startTime = item.Start.Date
+		}
+	}
+	if item.End != nil {
+		if item.End.DateTime != "" {
+			endTime = item.End.DateTime
+		} else {
+			endTime = item.End.Date
+		}
+	}
+
+	return CalendarEvent{
+		ID:          item.Id,
+		Title:       item.Summary,
+		Description: item.Description,
+		StartTime:   startTime,
+		EndTime:     endTime,
+		Location:    item.Location,
+		Link:        item.HtmlLink,
+		ColorID:     item.ColorId,
+		IsAuraMail:  isAuraMailEvent(item),
+		EmailID:     privateProperty(item, propEmailID),
+		EventType:   privateProperty(item, propEventType),
+	}
+}
+
+// Events are listed a page at a time.
+const (
+	defaultPageSize = 50
+	maxPageSize     = 250
+)
+
+// GetEvents lists the user's calendar events a page at a time, soonest
+// first. By default it lists the next 30 days (or ?days) of the calendar in
+// the user's rules. Query parameters:
+//
+//	timeMin, timeMax  RFC 3339 times or dates in the user's time zone
+//	pageSize          events per page, up to 250
+//	pageToken         nextPageToken from the previous page
+//	calendarId        another of the user's calendars
+//	auramailOnly      true to list only events AuraMail created
+//	eventType         comma-separated AuraMail event types
+//
+// The AuraMail filters are applied to each page, so a filtered page may
+// hold fewer than pageSize events while more pages remain.
 func (h *Handler) GetEvents(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -304,6 +560,65 @@ func (h *Handler) GetEvents(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	q := r.URL.Query()
+	rules := h.calendarRules(ctx, userID)
+	loc := rules.Location()
+
+	// Get time range - default to next 30 days
+	days := 30
+	if d, err := strconv.Atoi(q.Get("days")); err == nil && d > 0 && d <= 90 {
+		days = d
+	}
+	timeMin := time.Now()
+	if v := q.Get("timeMin"); v != "" {
+		t, _, err := parseEventTime(v, loc)
+		if err != nil {
+			response.BadRequest(w, "Invalid timeMin; use an RFC 3339 time or a date", nil)
+			return
+		}
+		timeMin = t
+	}
+	timeMax := timeMin.AddDate(0, 0, days)
+	if v := q.Get("timeMax"); v != "" {
+		t, dateOnly, err := parseEventTime(v, loc)
+		if err != nil {
+			response.BadRequest(w, "Invalid timeMax; use an RFC 3339 time or a date", nil)
+			return
+		}
+		timeMax = t
+		if dateOnly {
+			// A date includes the whole day.
+			timeMax = t.AddDate(0, 0, 1)
+		}
+	}
+	if !timeMax.After(timeMin) {
+		response.BadRequest(w, "timeMax must be after timeMin", nil)
+		return
+	}
+
+	pageSize := defaultPageSize
+	if v := q.Get("pageSize"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageSize {
+			response.BadRequest(w, fmt.Sprintf("pageSize must be between 1 and %d", maxPageSize), nil)
+			return
+		}
+		pageSize = n
+	}
+
+	var types []string
+	if v := q.Get("eventType"); v != "" {
+		for _, t := range strings.Split(v, ",") {
+			t = strings.TrimSpace(t)
+			if !slices.Contains(eventTypes, t) {
+				response.BadRequest(w, fmt.Sprintf("Unknown event type %q", t), map[string]any{"eventTypes": eventTypes})
+				return
+			}
+			types = append(types, t)
+		}
+	}
+	auraMailOnly := q.Get("auramailOnly") == "true" || len(types) > 0
+
 	// Get user
 	u, err := h.userRepo.FindByID(ctx, userID)
 	if err != nil {
@@ -312,36 +627,33 @@ func (h *Handler) GetEvents(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create calendar service
-	calSvc, err := google.CreateCalendarService(ctx, u.GoogleRefreshToken)
+	calSvc, err := h.client.Service(ctx, u.GoogleRefreshToken)
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to create calendar service", "err", err)
 		response.InternalError(w, "Failed to connect to Google Calendar")
 		return
 	}
 
-	// Get time range - default to next 30 days
-	daysStr := r.URL.Query().Get("days")
-	days := 30
-	if daysStr != "" {
-		if d, err := strconv.Atoi(daysStr); err == nil && d > 0 && d <= 90 {
-			days = d
-		}
-	}
-
-	now := time.Now()
-	timeMin := now.Format(time.RFC3339)
-	timeMax := now.AddDate(0, 0, days).Format(time.RFC3339)
-
-	// Fetch events from Google Calendar
-	events, err := calSvc.Events.List("primary").
-		TimeMin(timeMin).
-		TimeMax(timeMax).
+	calendarID := requestCalendar(r, rules)
+	call := calSvc.Events.List(calendarID).
+		TimeMin(timeMin.Format(time.RFC3339)).
+		TimeMax(timeMax.Format(time.RFC3339)).
 		SingleEvents(true).
 		OrderBy("startTime").
-		MaxResults(50).
-		Do()
+		MaxResults(int64(pageSize)).
+		PageToken(q.Get("pageToken"))
+	if len(types) == 1 {
+		// Google can only match all of several properties, so a single
+		// type is filtered on the server and several here.
+		call = call.PrivateExtendedProperty(propEventType + "=" + types[0])
+	}
+	events, err := call.Context(ctx).Do()
+	if isGone(err) {
+		response.NotFound(w, "Calendar not found")
+		return
+	}
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to fetch calendar events", "err", err)
+		slog.ErrorContext(ctx, "failed to fetch calendar events", "err", err, "calendarId", calendarID)
 		response.InternalError(w, "Failed to fetch calendar events")
 		return
 	}
@@ -349,44 +661,68 @@ func (h *Handler) GetEvents(w http.ResponseWriter, r *http.Request) {
 	// Transform to our format
 	calendarEvents := make([]CalendarEvent, 0, len(events.Items))
 	for _, item := range events.Items {
-		// Determine start/end times
-		startTime := ""
-		endTime := ""
-		if item.Start != nil {
-			if item.Start.DateTime != "" {
-				startTime = item.Start.DateTime
-			} else {
-				startTime = item.Start.Date
-			}
+		if auraMailOnly && !isAuraMailEvent(item) {
+			continue
 		}
-		if item.End != nil {
-			if item.End.DateTime != "" {
-				endTime = item.End.DateTime
-			} else {
-				endTime = item.End.Date
-			}
+		if len(types) > 0 && !slices.Contains(types, privateProperty(item, propEventType)) {
+			continue
 		}
+		calendarEvents = append(calendarEvents, toCalendarEvent(item))
+	}
 
-		// Check if this event was added via AuraMail
-		isAuraMail := false
-		if item.Description != "" && len(item.Description) > 20 {
-			isAuraMail = strings.Contains(item.Description, "Added via AuraMail")
-		}
+	slog.Info("fetched calendar events", "count", len(calendarEvents), "userId", userID)
 
-		calendarEvents = append(calendarEvents, CalendarEvent{
-			ID:          item.Id,
-			Title:       item.Summary,
-			Description: item.Description,
-			StartTime:   startTime,
-			EndTime:     endTime,
-			Location:    item.Location,
-			Link:        item.HtmlLink,
-			ColorID:     item.ColorId,
-			IsAuraMail:  isAuraMail,
-		})
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"success":       true,
+		"events":        calendarEvents,
+		"total":         len(calendarEvents),
+		"calendarId":    calendarID,
+		"nextPageToken": events.NextPageToken,
+	})
+}
+
+// EmailEvents returns the calendar events AuraMail created for an email,
+// whether added by the user or scheduled automatically.
+func (h *Handler) EmailEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(auth.UserIDContextKey).(string)
+	if !ok {
+		response.Unauthorized(w, "No UserID found in context")
+		return
 	}
 
-	slog.Info("fetched calendar events", "count", len(calendarEvents), "userId", userID)
+	emailID := r.PathValue("gmailMessageId")
+	if emailID == "" {
+		response.BadRequest(w, "gmailMessageId is required", nil)
+		return
+	}
+
+	u, err := h.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		response.NotFound(w, "User not found")
+		return
+	}
+
+	calSvc, err := h.client.Service(ctx, u.GoogleRefreshToken)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to create calendar service", "err", err)
+		response.InternalError(w, "Failed to connect to Google Calendar")
+		return
+	}
+
+	items, err := emailEvents(ctx, calSvc, h.calendarRules(ctx, userID).Calendar(), emailID, "")
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to fetch calendar events for email", "err", err, "emailId", emailID)
+		response.InternalError(w, "Failed to fetch calendar events")
+		return
+	}
+
+	calendarEvents := make([]CalendarEvent, 0, len(items))
+	for _, item := range items {
+		calendarEvents = append(calendarEvents, toCalendarEvent(item))
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(map[string]any{
@@ -5,29 +5,128 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"maps"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	gcalendar "google.golang.org/api/calendar/v3"
+
+	"github.com/r7rainz/auramail/internal/ai"
 	"github.com/r7rainz/auramail/internal/auth"
+	"github.com/r7rainz/auramail/internal/calendar/calendartest"
 	"github.com/r7rainz/auramail/internal/user"
 )
 
 type fakeUserRepo struct {
 	findByIDFunc func(ctx context.Context, id string) (*user.User, error)
+	// rules defaults to user.DefaultCalendarRules when nil.
+	rules *user.CalendarRules
+	// links back the auto-scheduler, keyed by event key and kind.
+	links         map[string]*user.CalendarLink
+	summaries     map[string]*ai.AIResult
+	notifications []user.Notification
+	// feedHash is the stored calendar feed token hash.
+	feedHash string
 }
 
 func (f *fakeUserRepo) FindByID(ctx context.Context, id string) (*user.User, error) {
 	return f.findByIDFunc(ctx, id)
 }
 
+func (f *fakeUserRepo) GetCalendarRules(ctx context.Context, userID string) (*user.CalendarRules, error) {
+	if f.rules == nil {
+		rules := user.DefaultCalendarRules()
+		return &rules, nil
+	}
+	rules := *f.rules
+	return &rules, nil
+}
+
+func (f *fakeUserRepo) SaveCalendarRules(ctx context.Context, userID string, rules *user.CalendarRules) error {
+	f.rules = rules
+	return nil
+}
+
+func (f *fakeUserRepo) GetCalendarLink(ctx context.Context, userID, key, kind string) (*user.CalendarLink, error) {
+	if link, ok := f.links[key+"/"+kind]; ok {
+		return link, nil
+	}
+	return nil, pgx.ErrNoRows
+}
+
+func (f *fakeUserRepo) SaveCalendarLink(ctx context.Context, userID string, link *user.CalendarLink) error {
+	if f.links == nil {
+		f.links = make(map[string]*user.CalendarLink)
+	}
+	f.links[link.Key+"/"+link.Kind] = link
+	return nil
+}
+
+func (f *fakeUserRepo) ListCalendarLinks(ctx context.Context, userID string, from time.Time) ([]user.CalendarLink, error) {
+	var links []user.CalendarLink
+	for _, link := range f.links {
+		if !link.StartsAt.Before(from) {
+			links = append(links, *link)
+		}
+	}
+	return links, nil
+}
+
+func (f *fakeUserRepo) GetUserSummary(ctx context.Context, userID, gmailID string) (*ai.AIResult, error) {
+	if res, ok := f.summaries[gmailID]; ok {
+		return res, nil
+	}
+	return nil, pgx.ErrNoRows
+}
+
+func (f *fakeUserRepo) AddNotification(ctx context.Context, userID string, n *user.Notification) error {
+	f.notifications = append(f.notifications, *n)
+	return nil
+}
+
+func (f *fakeUserRepo) ListSummaries(ctx context.Context, userID string, filter user.SummaryFilter) ([]*ai.AIResult, error) {
+	var out []*ai.AIResult
+	for _, res := range f.summaries {
+		out = append(out, res)
+	}
+	return out, nil
+}
+
+func (f *fakeUserRepo) SaveCalendarFeedToken(ctx context.Context, userID, tokenHash string) (time.Time, error) {
+	f.feedHash = tokenHash
+	return time.Now(), nil
+}
+
+func (f *fakeUserRepo) GetCalendarFeedToken(ctx context.Context, userID string) (time.Time, error) {
+	if f.feedHash == "" {
+		return time.Time{}, pgx.ErrNoRows
+	}
+	return time.Now(), nil
+}
+
+func (f *fakeUserRepo) DeleteCalendarFeedToken(ctx context.Context, userID string) error {
+	f.feedHash = ""
+	return nil
+}
+
+func (f *fakeUserRepo) FindCalendarFeedUser(ctx context.Context, tokenHash string) (string, error) {
+	if f.feedHash == "" || tokenHash != f.feedHash {
+		return "", pgx.ErrNoRows
+	}
+	return "1", nil
+}
+
 func withUserID(req *http.Request, userID string) *http.Request {
 	ctx := context.WithValue(req.Context(), auth.UserIDContextKey, userID)
 	return req.WithContext(ctx)
 }
 
 func TestAddEvent_Unauthorized(t *testing.T) {
-	h := NewHandler(&fakeUserRepo{})
+	h := NewHandler(&fakeUserRepo{}, GoogleClient{})
 	req := httptest.NewRequest(http.MethodPost, "/events", nil)
 	rr := httptest.NewRecorder()
 
@@ -39,7 +138,7 @@ func TestAddEvent_Unauthorized(t *testing.T) {
 }
 
 func TestAddEvent_MalformedBody(t *testing.T) {
-	h := NewHandler(&fakeUserRepo{})
+	h := NewHandler(&fakeUserRepo{}, GoogleClient{})
 	req := withUserID(httptest.NewRequest(http.MethodPost, "/events", bytes.NewBufferString("{not-json")), "1")
 	rr := httptest.NewRecorder()
 
@@ -51,7 +150,7 @@ func TestAddEvent_MalformedBody(t *testing.T) {
 }
 
 func TestAddEvent_MissingRequiredFields(t *testing.T) {
-	h := NewHandler(&fakeUserRepo{})
+	h := NewHandler(&fakeUserRepo{}, GoogleClient{})
 	body, _ := json.Marshal(AddEventRequest{Title: "", StartTime: ""})
 	req := withUserID(httptest.NewRequest(http.MethodPost, "/events", bytes.NewBuffer(body)), "1")
 	rr := httptest.NewRecorder()
@@ -69,7 +168,7 @@ func TestAddEvent_UserNotFound(t *testing.T) {
 			return nil, errors.New("no rows")
 		},
 	}
-	h := NewHandler(repo)
+	h := NewHandler(repo, GoogleClient{})
 	body, _ := json.Marshal(AddEventRequest{Title: "Interview", StartTime: "2026-02-15T10:00:00Z"})
 	req := withUserID(httptest.NewRequest(http.MethodPost, "/events", bytes.NewBuffer(body)), "1")
 	rr := httptest.NewRecorder()
@@ -102,7 +201,7 @@ func TestGetColorForEventType(t *testing.T) {
 }
 
 func TestDeleteEvent_Unauthorized(t *testing.T) {
-	h := NewHandler(&fakeUserRepo{})
+	h := NewHandler(&fakeUserRepo{}, GoogleClient{})
 	req := httptest.NewRequest(http.MethodDelete, "/events", nil)
 	rr := httptest.NewRecorder()
 
@@ -114,7 +213,7 @@ func TestDeleteEvent_Unauthorized(t *testing.T) {
 }
 
 func TestDeleteEvent_MissingEventID(t *testing.T) {
-	h := NewHandler(&fakeUserRepo{})
+	h := NewHandler(&fakeUserRepo{}, GoogleClient{})
 	req := withUserID(httptest.NewRequest(http.MethodDelete, "/events", nil), "1")
 	rr := httptest.NewRecorder()
 
@@ -131,7 +230,7 @@ func TestDeleteEvent_UserNotFound(t *testing.T) {
 			return nil, errors.New("no rows")
 		},
 	}
-	h := NewHandler(repo)
+	h := NewHandler(repo, GoogleClient{})
 	req := withUserID(httptest.NewRequest(http.MethodDelete, "/events?eventId=abc", nil), "1")
 	rr := httptest.NewRecorder()
 
@@ -142,8 +241,29 @@ func TestDeleteEvent_UserNotFound(t *testing.T) {
 	}
 }
 
+func TestDeleteEvent(t *testing.T) {
+	cal := newFakeCalendar(t)
+	h := newCalendarTestHandler(cal)
+	cal.AddEvent(calendartest.Primary, &gcalendar.Event{Id: "ev1", Summary: "Globex Interview"})
+
+	del := func() int {
+		rr := httptest.NewRecorder()
+		h.DeleteEvent(rr, withUserID(httptest.NewRequest(http.MethodDelete, "/calendar/events?eventId=ev1", nil), "1"))
+		return rr.Code
+	}
+	if code := del(); code != http.StatusOK || cal.Event("ev1") != nil {
+		t.Fatalf("expected the event to be deleted, got %d", code)
+	}
+	if code := del(); code != http.StatusNotFound {
+		t.Errorf("deleting again: expected 404, got %d", code)
+	}
+	if deletes := cal.Deletes(); len(deletes) != 1 || deletes[0] != "ev1" {
+		t.Errorf("unexpected deletes: %v", deletes)
+	}
+}
+
 func TestGetEvents_Unauthorized(t *testing.T) {
-	h := NewHandler(&fakeUserRepo{})
+	h := NewHandler(&fakeUserRepo{}, GoogleClient{})
 	req := httptest.NewRequest(http.MethodGet, "/events", nil)
 	rr := httptest.NewRecorder()
 
@@ -160,7 +280,7 @@ func TestGetEvents_UserNotFound(t *testing.T) {
 			return nil, errors.New("no rows")
 		},
 	}
-	h := NewHandler(repo)
+	h := NewHandler(repo, GoogleClient{})
 	req := withUserID(httptest.NewRequest(http.MethodGet, "/events", nil), "1")
 	rr := httptest.NewRecorder()
 
@@ -170,3 +290,258 @@ func TestGetEvents_UserNotFound(t *testing.T) {
 		t.Fatalf("expected 404, got %d", rr.Code)
 	}
 }
+
+func newFakeCalendar(t *testing.T) *calendartest.Server {
+	cal := calendartest.NewServer()
+	t.Cleanup(cal.Close)
+	return cal
+}
+
+func newCalendarTestHandler(cal *calendartest.Server) *Handler {
+	return NewHandler(&fakeUserRepo{
+		findByIDFunc: func(ctx context.Context, id string) (*user.User, error) {
+			return &user.User{ID: id, GoogleRefreshToken: "token"}, nil
+		},
+	}, cal)
+}
+
+func TestAddEvent_SendsEventPayload(t *testing.T) {
+	cal := newFakeCalendar(t)
+	h := newCalendarTestHandler(cal)
+
+	body, _ := json.Marshal(AddEventRequest{
+		Title:       "Globex Interview",
+		Description: "Technical round",
+		StartTime:   "2026-08-14T10:00:00",
+		EndTime:     "2026-08-14T11:30:00",
+		Location:    "Room 4",
+		EmailID:     "m1",
+		Company:     "Globex",
+		Role:        "SDE Intern",
+		EventType:   KindInterview,
+	})
+	rr := httptest.NewRecorder()
+	h.AddEvent(rr, withUserID(httptest.NewRequest(http.MethodPost, "/calendar/events", bytes.NewBuffer(body)), "1"))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	inserts := cal.Inserts()
+	if len(inserts) != 1 {
+		t.Fatalf("expected one insert, got %d", len(inserts))
+	}
+	ev := inserts[0]
+	if ev.Summary != "Globex Interview" || ev.Location != "Room 4" || ev.ColorId != "7" {
+		t.Errorf("unexpected event: %q at %q, color %q", ev.Summary, ev.Location, ev.ColorId)
+	}
+	if !strings.HasPrefix(ev.Description, "Company: Globex\nRole: SDE Intern\n\nTechnical round") || !strings.HasSuffix(ev.Description, auraMailFooter) {
+		t.Errorf("unexpected description: %q", ev.Description)
+	}
+	if ev.Start.DateTime != "2026-08-14T10:00:00+05:30" || ev.End.DateTime != "2026-08-14T11:30:00+05:30" ||
+		ev.Start.TimeZone != user.DefaultTimeZone || ev.End.TimeZone != user.DefaultTimeZone {
+		t.Errorf("unexpected times: %+v – %+v", ev.Start, ev.End)
+	}
+	if ev.Reminders == nil || ev.Reminders.UseDefault || len(ev.Reminders.Overrides) != 2 ||
+		ev.Reminders.Overrides[0].Method != "popup" || ev.Reminders.Overrides[0].Minutes != 60 || ev.Reminders.Overrides[1].Minutes != 1440 {
+		t.Errorf("unexpected reminders: %+v", ev.Reminders)
+	}
+	want := map[string]string{propEmailID: "m1", propEventType: KindInterview, propCompany: "Globex"}
+	if ev.ExtendedProperties == nil || !maps.Equal(ev.ExtendedProperties.Private, want) {
+		t.Errorf("unexpected extended properties: %+v", ev.ExtendedProperties)
+	}
+}
+
+func TestAddEvent_SameEmailUpdatesEvent(t *testing.T) {
+	cal := newFakeCalendar(t)
+	h := newCalendarTestHandler(cal)
+
+	add := func(start string) AddEventResponse {
+		body, _ := json.Marshal(AddEventRequest{Title: "Globex Interview", StartTime: start, EmailID: "m1", EventType: "interview", Company: "Globex"})
+		rr := httptest.NewRecorder()
+		h.AddEvent(rr, withUserID(httptest.NewRequest(http.MethodPost, "/calendar/events", bytes.NewBuffer(body)), "1"))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var resp AddEventResponse
+		_ = json.NewDecoder(rr.Body).Decode(&resp)
+		return resp
+	}
+
+	first := add("2026-08-14T10:00:00+05:30")
+	second := add("2026-08-14T15:00:00+05:30")
+	if first.Updated || !second.Updated || first.EventID != second.EventID {
+		t.Fatalf("expected the second add to update the first event: %+v, %+v", first, second)
+	}
+	if len(cal.Inserts()) != 1 || len(cal.Patches()) != 1 {
+		t.Errorf("got %d inserts and %d patches", len(cal.Inserts()), len(cal.Patches()))
+	}
+	ev := cal.Event(first.EventID)
+	if privateProperty(ev, propEmailID) != "m1" || privateProperty(ev, propEventType) != "interview" || privateProperty(ev, propCompany) != "Globex" {
+		t.Errorf("event not tagged with its email: %+v", ev.ExtendedProperties)
+	}
+
+	rr := httptest.NewRecorder()
+	req := withUserID(httptest.NewRequest(http.MethodGet, "/emails/m1/calendar", nil), "1")
+	req.SetPathValue("gmailMessageId", "m1")
+	h.EmailEvents(rr, req)
+	var resp struct {
+		Events []CalendarEvent `json:"events"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Events) != 1 || !resp.Events[0].IsAuraMail || resp.Events[0].EmailID != "m1" || resp.Events[0].EventType != "interview" {
+		t.Errorf("unexpected events for email: %+v", resp.Events)
+	}
+}
+
+func TestUpdateEvent(t *testing.T) {
+	cal := newFakeCalendar(t)
+	h := newCalendarTestHandler(cal)
+	cal.AddEvent(calendartest.Primary, &gcalendar.Event{
+		Id:       "ev1",
+		Summary:  "Globex Interview",
+		Location: "Room 4",
+		Start:    &gcalendar.EventDateTime{DateTime: "2026-08-14T10:00:00+05:30"},
+		End:      &gcalendar.EventDateTime{DateTime: "2026-08-14T10:30:00+05:30"},
+	})
+
+	update := func(id, body string) *httptest.ResponseRecorder {
+		req := withUserID(httptest.NewRequest(http.MethodPatch, "/calendar/events/"+id, strings.NewReader(body)), "1")
+		req.SetPathValue("id", id)
+		rr := httptest.NewRecorder()
+		h.UpdateEvent(rr, req)
+		return rr
+	}
+
+	rr := update("ev1", `{"startTime":"2026-08-15T14:00:00+05:30","location":"","reminderMinutes":[15]}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	ev := cal.Event("ev1")
+	if ev.Start.DateTime != "2026-08-15T14:00:00+05:30" || ev.End.DateTime != "2026-08-15T14:30:00+05:30" {
+		t.Errorf("event not moved keeping its length: %s – %s", ev.Start.DateTime, ev.End.DateTime)
+	}
+	if ev.Summary != "Globex Interview" || ev.Location != "" {
+		t.Errorf("expected only the location to be cleared, got %q at %q", ev.Summary, ev.Location)
+	}
+	if ev.Reminders == nil || len(ev.Reminders.Overrides) != 1 || ev.Reminders.Overrides[0].Minutes != 15 {
+		t.Errorf("reminders not replaced: %+v", ev.Reminders)
+	}
+
+	for name, tc := range map[string]struct {
+		id, body string
+		want     int
+	}{
+		"missing event":    {"nope", `{"title":"x"}`, http.StatusNotFound},
+		"empty title":      {"ev1", `{"title":""}`, http.StatusBadRequest},
+		"end before start": {"ev1", `{"endTime":"2026-08-15T13:00:00+05:30"}`, http.StatusBadRequest},
+		"too many":         {"ev1", `{"reminderMinutes":[1,2,3,4,5,6]}`, http.StatusBadRequest},
+	} {
+		if rr := update(tc.id, tc.body); rr.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d", name, tc.want, rr.Code)
+		}
+	}
+}
+
+func TestAddEvent_UsesCalendarPreferences(t *testing.T) {
+	cal := newFakeCalendar(t)
+	h := newCalendarTestHandler(cal)
+	rules := user.DefaultCalendarRules()
+	rules.TimeZone = "Europe/London"
+	rules.EventMinutes = 45
+	rules.ReminderSets = map[string][]int{KindInterview: {20}}
+	h.userRepo.(*fakeUserRepo).rules = &rules
+
+	add := func(req AddEventRequest) *gcalendar.Event {
+		body, _ := json.Marshal(req)
+		rr := httptest.NewRecorder()
+		h.AddEvent(rr, withUserID(httptest.NewRequest(http.MethodPost, "/calendar/events", bytes.NewBuffer(body)), "1"))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var resp AddEventResponse
+		_ = json.NewDecoder(rr.Body).Decode(&resp)
+		return cal.Event(resp.EventID)
+	}
+
+	deadline := add(AddEventRequest{Title: "Apply to Globex", StartTime: "2026-08-14", EventType: KindDeadline})
+	if deadline.Start.Date != "2026-08-14" || deadline.End.Date != "2026-08-15" || deadline.Start.DateTime != "" {
+		t.Errorf("expected an all-day deadline, got %+v – %+v", deadline.Start, deadline.End)
+	}
+
+	interview := add(AddEventRequest{Title: "Globex Interview", StartTime: "2026-08-14T09:00:00", EventType: KindInterview})
+	if interview.Start.DateTime != "2026-08-14T09:00:00+01:00" || interview.End.DateTime != "2026-08-14T09:45:00+01:00" || interview.Start.TimeZone != "Europe/London" {
+		t.Errorf("interview not in the user's time zone and length: %+v – %+v", interview.Start, interview.End)
+	}
+	if len(interview.Reminders.Overrides) != 1 || interview.Reminders.Overrides[0].Minutes != 20 {
+		t.Errorf("interview reminders not applied: %+v", interview.Reminders)
+	}
+
+	talk := add(AddEventRequest{Title: "Pre-placement talk", StartTime: "2026-08-16"})
+	if talk.Start.DateTime != "2026-08-16T10:00:00+01:00" {
+		t.Errorf("date-only event should start at 10:00 local time, got %+v", talk.Start)
+	}
+
+	body, _ := json.Marshal(AddEventRequest{Title: "Backwards", StartTime: "2026-08-14T10:00:00", EndTime: "2026-08-14T09:00:00"})
+	rr := httptest.NewRecorder()
+	h.AddEvent(rr, withUserID(httptest.NewRequest(http.MethodPost, "/calendar/events", bytes.NewBuffer(body)), "1"))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an end before the start, got %d", rr.Code)
+	}
+}
+
+func TestGetEvents_FiltersAndPages(t *testing.T) {
+	cal := newFakeCalendar(t)
+	h := newCalendarTestHandler(cal)
+	at := func(day string) *gcalendar.EventDateTime {
+		return &gcalendar.EventDateTime{DateTime: "2099-08-" + day + "T10:00:00+05:30"}
+	}
+	for id, ev := range map[string]*gcalendar.Event{
+		"deadline":  {Start: at("10"), End: at("10"), ExtendedProperties: auraMailProperties("m1", KindDeadline, "")},
+		"exam":      {Start: at("11"), End: at("11"), ExtendedProperties: auraMailProperties("m1", KindExam, "")},
+		"lecture":   {Start: at("12"), End: at("12")},
+		"interview": {Start: at("13"), End: at("13"), ExtendedProperties: auraMailProperties("m2", KindInterview, "")},
+		"legacy":    {Start: at("14"), End: at("14"), Description: "Globex test\n\n---\n" + auraMailFooter},
+	} {
+		ev.Id = id
+		cal.AddEvent(calendartest.Primary, ev)
+	}
+
+	list := func(query string) (int, []string, string) {
+		rr := httptest.NewRecorder()
+		h.GetEvents(rr, withUserID(httptest.NewRequest(http.MethodGet, "/calendar/events?"+query, nil), "1"))
+		var resp struct {
+			Events        []CalendarEvent `json:"events"`
+			NextPageToken string          `json:"nextPageToken"`
+		}
+		_ = json.NewDecoder(rr.Body).Decode(&resp)
+		var ids []string
+		for _, ev := range resp.Events {
+			ids = append(ids, ev.ID)
+		}
+		return rr.Code, ids, resp.NextPageToken
+	}
+
+	_, ids, next := list("pageSize=2&timeMin=2099-08-01&timeMax=2099-08-31")
+	if strings.Join(ids, ",") != "deadline,exam" || next == "" {
+		t.Fatalf("first page = %v, next %q", ids, next)
+	}
+	_, ids, _ = list("pageSize=2&timeMin=2099-08-01&timeMax=2099-08-31&pageToken=" + next)
+	if strings.Join(ids, ",") != "lecture,interview" {
+		t.Errorf("second page = %v", ids)
+	}
+
+	if _, ids, _ = list("auramailOnly=true"); strings.Join(ids, ",") != "deadline,exam,interview,legacy" {
+		t.Errorf("auramailOnly = %v", ids)
+	}
+	if _, ids, _ = list("eventType=exam,interview"); strings.Join(ids, ",") != "exam,interview" {
+		t.Errorf("eventType = %v", ids)
+	}
+
+	for _, query := range []string{"pageSize=0", "timeMin=tomorrow", "timeMin=2099-08-10&timeMax=2099-08-01", "eventType=party"} {
+		if code, _, _ := list(query); code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, code)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package calendar
+
+import (
+	"context"
+	"strings"
+
+	gcalendar "google.golang.org/api/calendar/v3"
+)
+
+// Private extended properties AuraMail sets on the events it creates. They
+// only exist on the user's own copy of the event and are not shown in the
+// Calendar UI.
+const (
+	propEmailID   = "auramailEmailId"
+	propEventType = "eventType"
+	propCompany   = "company"
+)
+
+// auraMailFooter ends the description of every AuraMail event. Events
+// created before extended properties were used are recognised by it.
+const auraMailFooter = "Added via AuraMail"
+
+// auraMailProperties tags an event with the email and event type it was
+// created for.
+func auraMailProperties(emailID, eventType, company string) *gcalendar.EventExtendedProperties {
+	private := map[string]string{propEmailID: emailID, propEventType: eventType}
+	if company != "" {
+		private[propCompany] = company
+	}
+	return &gcalendar.EventExtendedProperties{Private: private}
+}
+
+// privateProperty returns one of the event's private extended properties.
+func privateProperty(item *gcalendar.Event, key string) string {
+	if item.ExtendedProperties == nil {
+		return ""
+	}
+	return item.ExtendedProperties.Private[key]
+}
+
+// isAuraMailEvent reports whether AuraMail created item.
+func isAuraMailEvent(item *gcalendar.Event) bool {
+	return privateProperty(item, propEmailID) != "" || strings.Contains(item.Description, auraMailFooter)
+}
+
+// emailEvents lists the events on calendarID created for an email,
+// optionally only those of one event type.
+func emailEvents(ctx context.Context, svc *gcalendar.Service, calendarID, emailID, eventType string) ([]*gcalendar.Event, error) {
+	filters := []string{propEmailID + "=" + emailID}
+	if eventType != "" {
+		filters = append(filters, propEventType+"="+eventType)
+	}
+	events, err := svc.Events.List(calendarID).
+		PrivateExtendedProperty(filters...).
+		SingleEvents(true).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return nil, err
+	}
+	return events.Items, nil
+}
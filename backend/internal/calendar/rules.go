@@ -0,0 +1,173 @@
+package calendar
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/auth"
+	"github.com/r7rainz/auramail/internal/response"
+)
+
+// Google Calendar accepts at most five reminder overrides, up to four
+// weeks before the event.
+const (
+	maxReminders      = 5
+	maxReminderMinute = 40320
+)
+
+// Events last between five minutes and a day unless an end is given.
+const (
+	minEventMinutes = 5
+	maxEventMinutes = 24 * 60
+)
+
+// eventTypes are the types reminder sets can be chosen for.
+var eventTypes = []string{KindDeadline, KindExam, KindInterview, KindEvent}
+
+// validateReminders returns why minutes cannot be used as reminder
+// overrides, or "" when they can.
+func validateReminders(minutes []int) string {
+	if len(minutes) > maxReminders {
+		return fmt.Sprintf("At most %d reminders are allowed", maxReminders)
+	}
+	for _, m := range minutes {
+		if m < 0 || m > maxReminderMinute {
+			return fmt.Sprintf("Reminders must be between 0 and %d minutes before the event", maxReminderMinute)
+		}
+	}
+	return ""
+}
+
+// updateRulesRequest changes only the rules that are present.
+type updateRulesRequest struct {
+	AutoSchedule    *bool             `json:"autoSchedule"`
+	Categories      *[]string         `json:"categories"`
+	ReminderMinutes *[]int            `json:"reminderMinutes"`
+	ReminderSets    *map[string][]int `json:"reminderSets"`
+	TimeZone        *string           `json:"timeZone"`
+	EventMinutes    *int              `json:"eventMinutes"`
+	AllDayDeadlines *bool             `json:"allDayDeadlines"`
+	CalendarID      *string           `json:"calendarId"`
+}
+
+// GetRules returns the user's automatic scheduling rules.
+func (h *Handler) GetRules(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(auth.UserIDContextKey).(string)
+	if !ok {
+		response.Unauthorized(w, "No UserID found in context")
+		return
+	}
+
+	rules, err := h.userRepo.GetCalendarRules(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load calendar rules", "err", err)
+		response.InternalError(w, "Failed to load calendar rules")
+		return
+	}
+	response.Success(w, rules)
+}
+
+// UpdateRules changes the user's automatic scheduling rules. With
+// autoSchedule on, every sync puts the deadlines, tests and interviews of
+// emails in the chosen categories on the user's calendar.
+func (h *Handler) UpdateRules(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(auth.UserIDContextKey).(string)
+	if !ok {
+		response.Unauthorized(w, "No UserID found in context")
+		return
+	}
+
+	var req updateRulesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "Invalid request body", nil)
+		return
+	}
+	if req.Categories != nil {
+		for _, c := range *req.Categories {
+			if !ai.AnalysisTemplate().HasCategory(c) {
+				response.BadRequest(w, fmt.Sprintf("Unknown category %q", c), nil)
+				return
+			}
+		}
+	}
+	if req.ReminderMinutes != nil {
+		if msg := validateReminders(*req.ReminderMinutes); msg != "" {
+			response.BadRequest(w, msg, nil)
+			return
+		}
+	}
+	if req.ReminderSets != nil {
+		for eventType, minutes := range *req.ReminderSets {
+			if !slices.Contains(eventTypes, eventType) {
+				response.BadRequest(w, fmt.Sprintf("Unknown event type %q", eventType), map[string]any{"eventTypes": eventTypes})
+				return
+			}
+			if msg := validateReminders(minutes); msg != "" {
+				response.BadRequest(w, msg, nil)
+				return
+			}
+		}
+	}
+	if req.TimeZone != nil {
+		if _, err := time.LoadLocation(*req.TimeZone); err != nil || *req.TimeZone == "" {
+			response.BadRequest(w, fmt.Sprintf("Unknown time zone %q; use an IANA name such as Asia/Kolkata", *req.TimeZone), nil)
+			return
+		}
+	}
+	if req.EventMinutes != nil && (*req.EventMinutes < minEventMinutes || *req.EventMinutes > maxEventMinutes) {
+		response.BadRequest(w, fmt.Sprintf("eventMinutes must be between %d and %d", minEventMinutes, maxEventMinutes), nil)
+		return
+	}
+
+	if req.CalendarID != nil && *req.CalendarID == "" {
+		response.BadRequest(w, "calendarId cannot be empty; use \"primary\" for your main calendar", nil)
+		return
+	}
+
+	rules, err := h.userRepo.GetCalendarRules(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load calendar rules", "err", err)
+		response.InternalError(w, "Failed to load calendar rules")
+		return
+	}
+	if req.AutoSchedule != nil {
+		rules.AutoSchedule = *req.AutoSchedule
+	}
+	if req.Categories != nil {
+		rules.Categories = *req.Categories
+	}
+	if req.ReminderMinutes != nil {
+		rules.ReminderMinutes = *req.ReminderMinutes
+	}
+	if req.ReminderSets != nil {
+		rules.ReminderSets = *req.ReminderSets
+	}
+	if req.TimeZone != nil {
+		rules.TimeZone = *req.TimeZone
+	}
+	if req.EventMinutes != nil {
+		rules.EventMinutes = *req.EventMinutes
+	}
+	if req.AllDayDeadlines != nil {
+		rules.AllDayDeadlines = *req.AllDayDeadlines
+	}
+	if req.CalendarID != nil {
+		rules.CalendarID = *req.CalendarID
+	}
+
+	if err := h.userRepo.SaveCalendarRules(ctx, userID, rules); err != nil {
+		slog.ErrorContext(ctx, "failed to save calendar rules", "err", err)
+		response.InternalError(w, "Failed to save calendar rules")
+		return
+	}
+	response.Success(w, rules)
+}
@@ -0,0 +1,118 @@
+package calendar
+
+import (
+	"errors"
+	"time"
+
+	gcalendar "google.golang.org/api/calendar/v3"
+)
+
+// defaultHour is when timed events known only by date start.
+const defaultHour = 10
+
+var (
+	errEndBeforeStart = errors.New("endTime must be after startTime")
+	errEndNeedsTime   = errors.New("endTime needs a time of day unless the event lasts all day")
+)
+
+// parseEventTime parses an ISO 8601 time with or without a UTC offset, or a
+// bare date. Times without an offset are read in loc; dateOnly reports a
+// bare date, which is returned as midnight in loc.
+func parseEventTime(value string, loc *time.Location) (t time.Time, dateOnly bool, err error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02T15:04:05", value, loc); err == nil {
+		return t, false, nil
+	}
+	t, err = time.ParseInLocation(time.DateOnly, value, loc)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return t, true, nil
+}
+
+// span is when an event runs. All-day events end at midnight after their
+// last day.
+type span struct {
+	start, end time.Time
+	allDay     bool
+}
+
+// newSpan works out when an event runs from its parsed start and end; end
+// is zero when not given. A bare start date is an all-day event when allDay
+// is set and otherwise starts at defaultHour. Timed events without an end
+// last length.
+func newSpan(start time.Time, startDate bool, end time.Time, endDate bool, allDay bool, length time.Duration) (span, error) {
+	var sp span
+	switch {
+	case startDate && allDay:
+		sp = span{start: start, end: start.AddDate(0, 0, 1), allDay: true}
+		if !end.IsZero() {
+			y, m, d := end.In(start.Location()).Date()
+			sp.end = time.Date(y, m, d+1, 0, 0, 0, 0, start.Location())
+		}
+	default:
+		if startDate {
+			y, m, d := start.Date()
+			start = time.Date(y, m, d, defaultHour, 0, 0, 0, start.Location())
+		}
+		sp = span{start: start, end: end}
+		if end.IsZero() {
+			sp.end = start.Add(length)
+		} else if endDate {
+			return span{}, errEndNeedsTime
+		}
+	}
+	if !sp.end.After(sp.start) {
+		return span{}, errEndBeforeStart
+	}
+	return sp, nil
+}
+
+// eventSpan returns when item runs, reading all-day dates in loc.
+func eventSpan(item *gcalendar.Event, loc *time.Location) span {
+	var sp span
+	if item.Start != nil && item.Start.DateTime == "" && item.Start.Date != "" {
+		sp.allDay = true
+	}
+	parse := func(dt *gcalendar.EventDateTime) time.Time {
+		if dt == nil {
+			return time.Time{}
+		}
+		if t, err := time.Parse(time.RFC3339, dt.DateTime); err == nil {
+			return t
+		}
+		t, _ := time.ParseInLocation(time.DateOnly, dt.Date, loc)
+		return t
+	}
+	sp.start, sp.end = parse(item.Start), parse(item.End)
+	return sp
+}
+
+// upcoming reports whether the event has not ended by now.
+func (sp span) upcoming(now time.Time) bool {
+	return sp.end.After(now)
+}
+
+// eventTimes returns the span as Calendar start and end times. The unused
+// field of each is nulled so patching switches between timed and all-day
+// events cleanly.
+func (sp span) eventTimes(timeZone string) (start, end *gcalendar.EventDateTime) {
+	if sp.allDay {
+		return &gcalendar.EventDateTime{Date: sp.start.Format(time.DateOnly), NullFields: []string{"DateTime", "TimeZone"}},
+			&gcalendar.EventDateTime{Date: sp.end.Format(time.DateOnly), NullFields: []string{"DateTime", "TimeZone"}}
+	}
+	return &gcalendar.EventDateTime{DateTime: sp.start.Format(time.RFC3339), TimeZone: timeZone, NullFields: []string{"Date"}},
+		&gcalendar.EventDateTime{DateTime: sp.end.Format(time.RFC3339), TimeZone: timeZone, NullFields: []string{"Date"}}
+}
+
+// popupReminders returns popup reminders the given minutes before an event,
+// or the calendar's default reminders when there are none.
+func popupReminders(minutes []int) *gcalendar.EventReminders {
+	reminders := &gcalendar.EventReminders{UseDefault: len(minutes) == 0, ForceSendFields: []string{"UseDefault", "Overrides"}}
+	for _, m := range minutes {
+		reminders.Overrides = append(reminders.Overrides, &gcalendar.EventReminder{Method: "popup", Minutes: int64(m)})
+	}
+	return reminders
+}
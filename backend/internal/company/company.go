@@ -0,0 +1,164 @@
+// Package company resolves the free-form company names returned by email
+// analysis ("TCS", "Tata Consultancy Services", "TCS Digital") to one
+// canonical company, using name aliases and the sender's email domain.
+package company
+
+import (
+	"net/mail"
+	"strings"
+	"unicode"
+)
+
+// Company is a canonical recruiter. Aliases are the raw names seen in
+// emails; Domains are sender domains that belong to the company itself.
+// Both are learned from every user's mail, so they are never serialized.
+type Company struct {
+	ID      int64    `json:"id"`
+	Name    string   `json:"name"`
+	Aliases []string `json:"-"`
+	Domains []string `json:"-"`
+}
+
+// legalSuffixes are dropped from the end of a name before matching, so
+// "Acme Pvt. Ltd." and "Acme" share a key.
+var legalSuffixes = [][]string{
+	{"private", "limited"},
+	{"pvt", "ltd"},
+	{"pvt", "limited"},
+	{"private", "ltd"},
+	{"limited"},
+	{"ltd"},
+	{"llp"},
+	{"llc"},
+	{"inc"},
+	{"incorporated"},
+	{"plc"},
+	{"corp"},
+	{"corporation"},
+	{"co"},
+	{"gmbh"},
+}
+
+// acronymStopWords are skipped when building an acronym, so "Bank of
+// America" becomes "ba" rather than "boa".
+var acronymStopWords = map[string]bool{"and": true, "of": true, "the": true, "for": true}
+
+// Key returns the matching key for a company name: lower case, punctuation
+// folded to spaces and trailing legal suffixes removed. It returns "" when
+// nothing is left.
+func Key(name string) string {
+	return strings.Join(keyTokens(name), " ")
+}
+
+func keyTokens(name string) []string {
+	name = strings.ReplaceAll(strings.ToLower(name), "&", " and ")
+	tokens := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for {
+		trimmed := false
+		for _, suffix := range legalSuffixes {
+			if len(tokens) > len(suffix) && hasSuffix(tokens, suffix) {
+				tokens = tokens[:len(tokens)-len(suffix)]
+				trimmed = true
+				break
+			}
+		}
+		// "& Co." leaves a dangling "and" once the suffix is gone.
+		if len(tokens) > 1 && tokens[len(tokens)-1] == "and" {
+			tokens = tokens[:len(tokens)-1]
+			trimmed = true
+		}
+		if !trimmed {
+			return tokens
+		}
+	}
+}
+
+func hasSuffix(tokens, suffix []string) bool {
+	offset := len(tokens) - len(suffix)
+	for i, s := range suffix {
+		if tokens[offset+i] != s {
+			return false
+		}
+	}
+	return true
+}
+
+func hasPrefix(tokens, prefix []string) bool {
+	if len(prefix) == 0 || len(prefix) > len(tokens) {
+		return false
+	}
+	for i, p := range prefix {
+		if tokens[i] != p {
+			return false
+		}
+	}
+	return true
+}
+
+// acronym returns the initials of a multi-word name, or "" for single words.
+func acronym(tokens []string) string {
+	var b strings.Builder
+	for _, t := range tokens {
+		if acronymStopWords[t] {
+			continue
+		}
+		r := []rune(t)
+		b.WriteRune(r[0])
+	}
+	if b.Len() < 2 {
+		return ""
+	}
+	return b.String()
+}
+
+// genericDomains never identify a company: free mail providers send on
+// behalf of anyone, and college domains belong to the placement office.
+var genericDomains = map[string]bool{
+	"gmail.com":      true,
+	"googlemail.com": true,
+	"yahoo.com":      true,
+	"yahoo.co.in":    true,
+	"outlook.com":    true,
+	"hotmail.com":    true,
+	"live.com":       true,
+	"icloud.com":     true,
+	"proton.me":      true,
+	"protonmail.com": true,
+	"rediffmail.com": true,
+}
+
+// SenderDomain returns the lower-cased domain of a From header such as
+// "TCS Careers <careers@tcs.com>". It returns "" for unparsable addresses
+// and for domains that cannot identify a company.
+func SenderDomain(from string) string {
+	from = strings.TrimSpace(from)
+	if from == "" {
+		return ""
+	}
+	address := from
+	if addr, err := mail.ParseAddress(from); err == nil {
+		address = addr.Address
+	}
+	at := strings.LastIndex(address, "@")
+	if at < 0 {
+		return ""
+	}
+	domain := strings.Trim(strings.ToLower(address[at+1:]), "> ")
+	if domain == "" || genericDomains[domain] {
+		return ""
+	}
+	for _, label := range strings.Split(domain, ".") {
+		if label == "edu" || label == "ac" {
+			return ""
+		}
+	}
+	return domain
+}
+
+// domainMatches reports whether domain is known or a subdomain of it.
+func domainMatches(domain, known string) bool {
+	known = strings.ToLower(strings.TrimSpace(known))
+	return known != "" && (domain == known || strings.HasSuffix(domain, "."+known))
+}
@@ -0,0 +1,99 @@
+package company
+
+import "testing"
+
+func TestKey(t *testing.T) {
+	tests := map[string]string{
+		"Tata Consultancy Services Ltd.": "tata consultancy services",
+		"Acme Pvt. Ltd":                  "acme",
+		"Goldman Sachs & Co.":            "goldman sachs",
+		"  L&T  Infotech ":               "l and t infotech",
+		"Limited":                        "limited",
+		"":                               "",
+	}
+	for in, want := range tests {
+		if got := Key(in); got != want {
+			t.Errorf("Key(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSenderDomain(t *testing.T) {
+	tests := map[string]string{
+		"TCS Careers <careers@TCS.com>":          "tcs.com",
+		"hr@mail.infosys.com":                    "mail.infosys.com",
+		"Placement Office <cdc@vitbhopal.ac.in>": "",
+		"Recruiter <someone.hiring@gmail.com>":   "",
+		"Training Cell <tpo@college.edu>":        "",
+		"not an address":                         "",
+	}
+	for in, want := range tests {
+		if got := SenderDomain(in); got != want {
+			t.Errorf("SenderDomain(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	known := []Company{
+		{ID: 1, Name: "Tata Consultancy Services", Aliases: []string{"TCS"}, Domains: []string{"tcs.com"}},
+		{ID: 2, Name: "Infosys", Domains: []string{"infosys.com"}},
+		{ID: 3, Name: "Hewlett Packard Enterprise"},
+		{ID: 4, Name: "Amazon"},
+		{ID: 5, Name: "Amazon Web Services", Aliases: []string{"AWS"}},
+		{ID: 6, Name: "Bank of America"},
+	}
+
+	tests := []struct {
+		name, company, sender string
+		want                  int64
+	}{
+		{"alias", "TCS", "", 1},
+		{"canonical with suffix", "Tata Consultancy Services Limited", "", 1},
+		{"program suffix", "TCS Digital", "", 1},
+		{"acronym of canonical", "HPE", "", 3},
+		{"acronym prefix", "HPE Labs", "", 3},
+		{"longest prefix", "Amazon Web Services India", "", 5},
+		{"shorter prefix", "Amazon Pay", "", 4},
+		{"sender domain", "", "TCS iON <noreply@ion.tcs.com>", 1},
+		{"name beats domain", "Infosys", "careers@tcs.com", 2},
+		{"unknown falls back to domain", "Infosys BPM Springboard", "x@infosys.com", 2},
+		{"two letter acronym ignored", "BA", "", 0},
+		{"no match", "Zoho", "Zoho <jobs@zohocorp.com>", 0},
+		{"nothing to go on", "", "cdc@vitbhopal.ac.in", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Resolve(known, tt.company, tt.sender)
+			if tt.want == 0 {
+				if ok {
+					t.Fatalf("Resolve() = %+v, want no match", got)
+				}
+				return
+			}
+			if !ok || got.ID != tt.want {
+				t.Fatalf("Resolve() = %+v, %v, want id %d", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestKnows(t *testing.T) {
+	c := Company{Name: "Tata Consultancy Services", Aliases: []string{"TCS"}}
+	if !c.Knows("tcs") || !c.Knows("Tata Consultancy Services Ltd") {
+		t.Error("expected known names to match")
+	}
+	if c.Knows("TCS Digital") {
+		t.Error("TCS Digital should be a new alias")
+	}
+}
+
+func TestResolveNameIgnoresSender(t *testing.T) {
+	known := []Company{{ID: 1, Name: "Amazon", Domains: []string{"amazon.jobs"}}}
+	if got, ok := ResolveName(known, "Google"); ok {
+		t.Fatalf("ResolveName() = %+v, want no match", got)
+	}
+	if got, ok := ResolveDomain(known, "jobs@amazon.jobs"); !ok || got.ID != 1 {
+		t.Fatalf("ResolveDomain() = %+v, %v, want id 1", got, ok)
+	}
+}
@@ -0,0 +1,117 @@
+package company
+
+import "strings"
+
+// minAcronymLen keeps two-letter initials ("BA", "GE") from linking
+// unrelated companies.
+const minAcronymLen = 3
+
+// Resolve picks the company in known that name (as returned by analysis)
+// or sender (the From header) refers to. Matches are tried from strongest
+// to weakest:
+//
+//  1. the name's key equals the key of a canonical name or alias;
+//  2. one side is the acronym of the other ("TCS" and "Tata Consultancy
+//     Services");
+//  3. a known name is a leading prefix of the name ("TCS Digital" is TCS),
+//     preferring the longest;
+//  4. the sender's domain is one of the company's domains.
+//
+// It returns false when nothing matches.
+func Resolve(known []Company, name, sender string) (*Company, bool) {
+	if c, ok := ResolveName(known, name); ok {
+		return c, true
+	}
+	return ResolveDomain(known, sender)
+}
+
+// ResolveName tries only the name matches (1-3 above), so callers can tell
+// a company the name refers to from one that merely owns the sender domain.
+func ResolveName(known []Company, name string) (*Company, bool) {
+	tokens := keyTokens(name)
+	if len(tokens) == 0 {
+		return nil, false
+	}
+	if i := matchName(known, tokens); i >= 0 {
+		return &known[i], true
+	}
+	return nil, false
+}
+
+// ResolveDomain returns the company whose domains include the sender's.
+func ResolveDomain(known []Company, sender string) (*Company, bool) {
+	domain := SenderDomain(sender)
+	if domain == "" {
+		return nil, false
+	}
+	for i := range known {
+		for _, d := range known[i].Domains {
+			if domainMatches(domain, d) {
+				return &known[i], true
+			}
+		}
+	}
+	return nil, false
+}
+
+func matchName(known []Company, tokens []string) int {
+	key := strings.Join(tokens, " ")
+	for i := range known {
+		for _, n := range known[i].names() {
+			if Key(n) == key {
+				return i
+			}
+		}
+	}
+
+	nameAcronym := acronym(tokens)
+	for i := range known {
+		for _, n := range known[i].names() {
+			candidate := keyTokens(n)
+			if len(candidate) == 0 {
+				continue
+			}
+			if len(nameAcronym) >= minAcronymLen && len(candidate) == 1 && candidate[0] == nameAcronym {
+				return i
+			}
+			if len(tokens) == 1 && len(tokens[0]) >= minAcronymLen && acronym(candidate) == tokens[0] {
+				return i
+			}
+		}
+	}
+
+	best, bestLen := -1, 0
+	for i := range known {
+		for _, n := range known[i].names() {
+			candidate := keyTokens(n)
+			if len(candidate) < len(tokens) && hasPrefix(tokens, candidate) && len(candidate) > bestLen {
+				best, bestLen = i, len(candidate)
+			}
+			// "TCS Digital" against a company only known by its full name.
+			if bestLen == 0 && len(tokens) > 1 && len(tokens[0]) >= minAcronymLen && acronym(candidate) == tokens[0] {
+				best = i
+			}
+		}
+	}
+	return best
+}
+
+// names returns the canonical name followed by the aliases.
+func (c *Company) names() []string {
+	return append([]string{c.Name}, c.Aliases...)
+}
+
+// Knows reports whether name already matches the canonical name or an
+// alias exactly, so callers can decide whether to record it as a new alias.
+func (c *Company) Knows(name string) bool {
+	key := Key(name)
+	if key == "" {
+		return true
+	}
+	for _, n := range c.names() {
+		if Key(n) == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,185 @@
+// Package compensation normalizes the free-form salary text extracted from
+// placement emails ("12 LPA", "₹50k/month stipend", "CTC 8-10 LPA + joining
+// bonus") into a currency and an annualized amount range.
+package compensation
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type Kind string
+
+const (
+	KindCTC     Kind = "ctc"
+	KindStipend Kind = "stipend"
+)
+
+// Compensation is the normalized form of a salary string. MinAnnual and
+// MaxAnnual are in whole currency units per year; they are equal when the
+// email states a single figure.
+type Compensation struct {
+	Currency  string  `json:"currency"`
+	MinAnnual float64 `json:"minAnnual"`
+	MaxAnnual float64 `json:"maxAnnual"`
+	Kind      Kind    `json:"kind"`
+	Raw       string  `json:"raw"`
+}
+
+var (
+	// amountRe matches an optional currency marker, a number (Indian or
+	// Western digit grouping), an optional range upper bound and an optional
+	// unit.
+	amountRe = regexp.MustCompile(`(?i)(₹|\brs\.?|\binr|\$|\busd|€|\beur)?\s*(\d[\d,]*(?:\.\d+)?)\s*(?:(?:-|–|to)\s*(?:₹|\brs\.?|\binr|\$|\busd|€|\beur)?\s*(\d[\d,]*(?:\.\d+)?))?\s*(lpa\b|lakhs?\b|lacs?\b|l\b|crores?\b|cr\b|k\b|thousand\b)?`)
+
+	monthlyRe = regexp.MustCompile(`(?i)per\s+month|/\s*month|/\s*mo\b|\bp\.?m\.?\b|\bmonthly\b|a\s+month`)
+	annualRe  = regexp.MustCompile(`(?i)per\s+annum|/\s*(?:annum|year|yr)\b|\bp\.?a\.?\b|\bannual(?:ly)?\b|\blpa\b|a\s+year`)
+	stipendRe = regexp.MustCompile(`(?i)\bstipend\b`)
+	ctcRe     = regexp.MustCompile(`(?i)\bctc\b|\bpackage\b|\bsalary\b|\blpa\b|\bfixed\b`)
+)
+
+// Parse normalizes salary text. It returns nil when no recognisable amount
+// is present.
+func Parse(raw string) *Compensation {
+	text := strings.TrimSpace(raw)
+	if text == "" {
+		return nil
+	}
+
+	for _, m := range amountRe.FindAllStringSubmatchIndex(text, -1) {
+		symbol := group(text, m, 1)
+		lowText, highText := group(text, m, 2), group(text, m, 3)
+		low, high := parseNumber(lowText), parseNumber(highText)
+		unit := strings.ToLower(group(text, m, 4))
+		// "Freshers 2026 - 12 LPA" is a batch year followed by a single
+		// figure, not a range.
+		if highText != "" && isYear(lowText) {
+			lowText, low = highText, high
+		}
+		if low <= 0 {
+			continue
+		}
+		// Bare numbers only count as pay when they are large enough not to
+		// be a duration or CGPA, are not a year, and sit next to a pay word.
+		clause := surrounding(text, m[0], m[1])
+		if symbol == "" && unit == "" {
+			payWord := stipendRe.MatchString(clause) || ctcRe.MatchString(clause) || monthlyRe.MatchString(clause) || annualRe.MatchString(clause)
+			if low < 1000 || isYear(lowText) || !payWord {
+				continue
+			}
+		}
+		switch {
+		case high == 0:
+			high = low
+		case high < low:
+			// A falling "range" pairs two unrelated numbers.
+			continue
+		}
+
+		multiplier := unitMultiplier(unit)
+		low *= multiplier
+		high *= multiplier
+
+		// Period and kind are judged from the clause around the amount so
+		// "₹50k/month stipend; PPO with 12 LPA" reads the first figure as a
+		// monthly stipend.
+		c := &Compensation{Currency: currency(symbol), Raw: text}
+		switch {
+		case stipendRe.MatchString(clause):
+			c.Kind = KindStipend
+		case ctcRe.MatchString(clause):
+			c.Kind = KindCTC
+		case stipendRe.MatchString(text):
+			c.Kind = KindStipend
+		default:
+			c.Kind = KindCTC
+		}
+
+		monthly := monthlyRe.MatchString(clause) && !strings.HasPrefix(unit, "lpa")
+		if !monthly && !annualRe.MatchString(clause) && c.Kind == KindStipend {
+			monthly = true
+		}
+		if monthly {
+			low *= 12
+			high *= 12
+		}
+		c.MinAnnual, c.MaxAnnual = low, high
+		return c
+	}
+	return nil
+}
+
+// isYear reports whether a matched number reads as a calendar year ("2026
+// batch") rather than an amount.
+func isYear(number string) bool {
+	if len(number) != 4 {
+		return false
+	}
+	year, err := strconv.Atoi(number)
+	return err == nil && year >= 1900 && year <= 2100
+}
+
+func group(text string, m []int, i int) string {
+	if m[2*i] < 0 {
+		return ""
+	}
+	return strings.TrimSpace(text[m[2*i]:m[2*i+1]])
+}
+
+func parseNumber(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	v, err := strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+func unitMultiplier(unit string) float64 {
+	switch {
+	case unit == "":
+		return 1
+	case unit == "k" || unit == "thousand":
+		return 1_000
+	case strings.HasPrefix(unit, "cr"):
+		return 10_000_000
+	default: // lpa, lakh, lac, l
+		return 100_000
+	}
+}
+
+// DefaultCurrency is the currency of amounts without a symbol.
+const DefaultCurrency = "INR"
+
+func currency(symbol string) string {
+	switch strings.ToLower(strings.TrimSuffix(symbol, ".")) {
+	case "$", "usd":
+		return "USD"
+	case "€", "eur":
+		return "EUR"
+	}
+	// Lakh/crore units and unmarked figures are rupees in placement mail.
+	return DefaultCurrency
+}
+
+// surrounding returns the text between the nearest clause separators around
+// [start, end).
+func surrounding(text string, start, end int) string {
+	const separators = "\n;•|"
+	from := 0
+	if i := strings.LastIndexAny(text[:start], separators); i >= 0 {
+		_, size := utf8.DecodeRuneInString(text[i:])
+		from = i + size
+	}
+	to := strings.IndexAny(text[end:], separators)
+	if to < 0 {
+		to = len(text)
+	} else {
+		to += end
+	}
+	return text[from:to]
+}
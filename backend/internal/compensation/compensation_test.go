@@ -0,0 +1,50 @@
+package compensation
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		raw      string
+		currency string
+		min, max float64
+		kind     Kind
+	}{
+		{"12 LPA", "INR", 1_200_000, 1_200_000, KindCTC},
+		{"₹50k/month stipend", "INR", 600_000, 600_000, KindStipend},
+		{"CTC 8-10 LPA + joining bonus", "INR", 800_000, 1_000_000, KindCTC},
+		{"• Stipend: 1,25,000 per month\n• PPO CTC: 24 LPA", "INR", 1_500_000, 1_500_000, KindStipend},
+		{"Stipend of Rs. 30,000", "INR", 360_000, 360_000, KindStipend},
+		{"$120,000 per year", "USD", 120_000, 120_000, KindCTC},
+		{"Package: 1.2 Cr", "INR", 12_000_000, 12_000_000, KindCTC},
+		{"Salary 6,00,000 per annum", "INR", 600_000, 600_000, KindCTC},
+		{"Freshers 2026 - 12 LPA", "INR", 1_200_000, 1_200_000, KindCTC},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			c := Parse(tt.raw)
+			if c == nil {
+				t.Fatal("Parse returned nil")
+			}
+			if c.Currency != tt.currency || c.MinAnnual != tt.min || c.MaxAnnual != tt.max || c.Kind != tt.kind {
+				t.Errorf("Parse() = %+v, want %s %v-%v %s", c, tt.currency, tt.min, tt.max, tt.kind)
+			}
+			if c.Raw != tt.raw {
+				t.Errorf("Raw = %q, want %q", c.Raw, tt.raw)
+			}
+		})
+	}
+}
+
+func TestParse_NoAmount(t *testing.T) {
+	for _, raw := range []string{
+		"",
+		"Competitive, as per industry standards",
+		"6 month internship for the 2026 batch",
+		"Open to the 2026 - 27 batch, salary as per norms",
+		"CGPA 7.5 and above",
+	} {
+		if c := Parse(raw); c != nil {
+			t.Errorf("Parse(%q) = %+v, want nil", raw, c)
+		}
+	}
+}
@@ -25,6 +25,45 @@ type Config struct {
 	SyncInterval       time.Duration
 	SyncMaxResults     int64
 	SyncIncludeThreads bool
+	AdminEmails        []string // Emails allowed to call /admin endpoints
+	// Daily AI budgets applied to users without an ai_budgets override.
+	// Zero disables the corresponding limit.
+	AIDailyTokenBudget   int64
+	AIDailyCostBudgetUSD float64
+	// EmbeddingProvider selects the semantic search embedder: "openai",
+	// "hash" or empty to pick OpenAI when a key is configured.
+	EmbeddingProvider string
+	// AssistantLLM selects the model behind /assistant/ask: "openai",
+	// "fake" (offline, extractive) or empty to pick OpenAI when a key is
+	// configured.
+	AssistantLLM string
+	// RedactionProfile selects the PII patterns applied before email text
+	// is sent to the LLM: empty or "default" for the built-in patterns, or
+	// the path of an institution's JSON profile.
+	RedactionProfile string
+	// PromptTemplateDir holds prompt template files (<name>.tmpl) that
+	// override the embedded ones. Templates saved through /admin/prompts
+	// take precedence over both.
+	PromptTemplateDir string
+	// SMTP server digest emails are sent through. Digests are disabled
+	// when SMTPHost is empty.
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	// SMTPFrom is the From address of digest emails, e.g.
+	// "AuraMail <digest@example.com>".
+	SMTPFrom string
+	// VAPID key pair identifying AuraMail to Web Push services, as
+	// base64url: the uncompressed P-256 public key and the private scalar.
+	// Push notifications are disabled when they are empty.
+	VAPIDPublicKey  string
+	VAPIDPrivateKey string
+	// VAPIDSubject is a mailto: or https: contact for push services.
+	VAPIDSubject string
+	// PushServiceHosts are push service hosts subscriptions may use
+	// besides those of the major browsers, e.g. a self-hosted autopush.
+	PushServiceHosts []string
 }
 
 // Load reads configuration from environment variables and performs basic validation.
@@ -50,6 +89,25 @@ func Load() (*Config, error) {
 		SyncInterval:       getEnvDurationDefault("SYNC_INTERVAL", 30*time.Minute),
 		SyncMaxResults:     getEnvInt64Default("SYNC_MAX_RESULTS", 25),
 		SyncIncludeThreads: getEnvBoolDefault("SYNC_INCLUDE_THREADS", true),
+		AdminEmails:        parseList(os.Getenv("ADMIN_EMAILS")),
+
+		AIDailyTokenBudget:   getEnvInt64Default("AI_DAILY_TOKEN_BUDGET", 0),
+		AIDailyCostBudgetUSD: getEnvFloatDefault("AI_DAILY_COST_BUDGET_USD", 0),
+		EmbeddingProvider:    strings.ToLower(strings.TrimSpace(os.Getenv("EMBEDDING_PROVIDER"))),
+		AssistantLLM:         strings.ToLower(strings.TrimSpace(os.Getenv("ASSISTANT_LLM"))),
+		RedactionProfile:     strings.TrimSpace(os.Getenv("REDACTION_PROFILE")),
+		PromptTemplateDir:    strings.TrimSpace(os.Getenv("PROMPT_TEMPLATE_DIR")),
+
+		SMTPHost:     strings.TrimSpace(os.Getenv("SMTP_HOST")),
+		SMTPPort:     getEnvDefault("SMTP_PORT", "587"),
+		SMTPUsername: os.Getenv("SMTP_USERNAME"),
+		SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+		SMTPFrom:     strings.TrimSpace(os.Getenv("SMTP_FROM")),
+
+		VAPIDPublicKey:   strings.TrimSpace(os.Getenv("VAPID_PUBLIC_KEY")),
+		VAPIDPrivateKey:  strings.TrimSpace(os.Getenv("VAPID_PRIVATE_KEY")),
+		VAPIDSubject:     strings.TrimSpace(os.Getenv("VAPID_SUBJECT")),
+		PushServiceHosts: parseList(os.Getenv("PUSH_SERVICE_HOSTS")),
 	}
 
 	if err := cfg.Validate(); err != nil {
@@ -58,6 +116,11 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
+// DashboardURL is the frontend page emails and notifications link to.
+func (c *Config) DashboardURL() string {
+	return strings.TrimRight(c.FrontendURL, "/") + "/dashboard"
+}
+
 // Validate enforces required settings.
 func (c *Config) Validate() error {
 	if c.DatabaseURL == "" {
@@ -75,6 +138,36 @@ func (c *Config) Validate() error {
 	if c.SyncMaxResults <= 0 {
 		return errors.New("SYNC_MAX_RESULTS must be positive")
 	}
+	if c.AIDailyTokenBudget < 0 || c.AIDailyCostBudgetUSD < 0 {
+		return errors.New("AI daily budgets must not be negative")
+	}
+	switch c.EmbeddingProvider {
+	case "", "hash":
+	case "openai":
+		if c.OpenAIKey == "" {
+			return errors.New("EMBEDDING_PROVIDER=openai requires OPENAI_API_KEY")
+		}
+	default:
+		return errors.New("EMBEDDING_PROVIDER must be openai or hash")
+	}
+	switch c.AssistantLLM {
+	case "", "fake":
+	case "openai":
+		if c.OpenAIKey == "" {
+			return errors.New("ASSISTANT_LLM=openai requires OPENAI_API_KEY")
+		}
+	default:
+		return errors.New("ASSISTANT_LLM must be openai or fake")
+	}
+	if c.SMTPHost != "" && c.SMTPFrom == "" {
+		return errors.New("SMTP_HOST requires SMTP_FROM")
+	}
+	if (c.VAPIDPublicKey == "") != (c.VAPIDPrivateKey == "") {
+		return errors.New("VAPID_PUBLIC_KEY and VAPID_PRIVATE_KEY must be set together")
+	}
+	if c.VAPIDPublicKey != "" && !strings.HasPrefix(c.VAPIDSubject, "mailto:") && !strings.HasPrefix(c.VAPIDSubject, "https://") {
+		return errors.New("VAPID_SUBJECT must be a mailto: or https:// contact when VAPID keys are set")
+	}
 	return nil
 }
 
@@ -135,3 +228,15 @@ func getEnvInt64Default(key string, def int64) int64 {
 	}
 	return parsed
 }
+
+func getEnvFloatDefault(key string, def float64) float64 {
+	val := strings.TrimSpace(os.Getenv(key))
+	if val == "" {
+		return def
+	}
+	parsed, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return def
+	}
+	return parsed
+}
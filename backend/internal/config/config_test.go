@@ -71,6 +71,42 @@ func TestLoad_SyncValidation(t *testing.T) {
 	})
 }
 
+func TestLoad_SMTPRequiresFrom(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	t.Setenv("SMTP_FROM", "")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for SMTP_HOST without SMTP_FROM")
+	}
+
+	t.Setenv("SMTP_FROM", "AuraMail <digest@example.com>")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.SMTPPort != "587" {
+		t.Errorf("expected default SMTP_PORT 587, got %q", cfg.SMTPPort)
+	}
+}
+
+func TestLoad_VAPID(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("VAPID_PUBLIC_KEY", "BPub")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for VAPID_PUBLIC_KEY without VAPID_PRIVATE_KEY")
+	}
+
+	t.Setenv("VAPID_PRIVATE_KEY", "priv")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for VAPID keys without VAPID_SUBJECT")
+	}
+
+	t.Setenv("VAPID_SUBJECT", "mailto:placements@example.edu")
+	if _, err := Load(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
 func TestLoad_Defaults(t *testing.T) {
 	setRequiredEnv(t)
 
@@ -226,3 +262,77 @@ func TestGetEnvInt64Default(t *testing.T) {
 		}
 	})
 }
+
+func TestGetEnvFloatDefault(t *testing.T) {
+	t.Run("unset returns default", func(t *testing.T) {
+		t.Setenv("FLOAT_VAR", "")
+		if got := getEnvFloatDefault("FLOAT_VAR", 1.5); got != 1.5 {
+			t.Errorf("got %v, want 1.5", got)
+		}
+	})
+	t.Run("invalid falls back to default", func(t *testing.T) {
+		t.Setenv("FLOAT_VAR", "lots")
+		if got := getEnvFloatDefault("FLOAT_VAR", 1.5); got != 1.5 {
+			t.Errorf("got %v, want 1.5", got)
+		}
+	})
+	t.Run("valid value parsed", func(t *testing.T) {
+		t.Setenv("FLOAT_VAR", "2.25")
+		if got := getEnvFloatDefault("FLOAT_VAR", 1.5); got != 2.25 {
+			t.Errorf("got %v, want 2.25", got)
+		}
+	})
+}
+
+func TestLoad_NegativeAIBudgetFails(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("AI_DAILY_TOKEN_BUDGET", "-1")
+
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for negative AI_DAILY_TOKEN_BUDGET")
+	}
+}
+
+func TestLoad_EmbeddingProviderValidation(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("OPENAI_API_KEY", "")
+
+	t.Setenv("EMBEDDING_PROVIDER", "Hash")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.EmbeddingProvider != "hash" {
+		t.Errorf("EmbeddingProvider = %q, want hash", cfg.EmbeddingProvider)
+	}
+
+	t.Setenv("EMBEDDING_PROVIDER", "openai")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for openai provider without OPENAI_API_KEY")
+	}
+
+	t.Setenv("EMBEDDING_PROVIDER", "word2vec")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for unknown provider")
+	}
+}
+
+func TestLoad_AssistantLLMValidation(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("OPENAI_API_KEY", "")
+
+	t.Setenv("ASSISTANT_LLM", "fake")
+	if _, err := Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	t.Setenv("ASSISTANT_LLM", "openai")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for openai without OPENAI_API_KEY")
+	}
+
+	t.Setenv("ASSISTANT_LLM", "llama")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for unknown mode")
+	}
+}
@@ -0,0 +1,210 @@
+// Package dedupe recognises placement emails that announce the same
+// opportunity again: "REMINDER:", "Extended:" and "Corrigendum" resends that
+// arrive in new threads. It compares a normalized subject, company and role
+// plus a simhash of the body's word shingles.
+package dedupe
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"unicode"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/company"
+)
+
+// Match thresholds, in differing simhash bits out of 64.
+const (
+	// maxDistanceSameCompany applies when company and role agree.
+	maxDistanceSameCompany = 12
+	// maxDistanceSameSubject applies when only the normalized subjects
+	// agree, e.g. the company was not extracted from one of the emails.
+	maxDistanceSameSubject = 18
+	// shingleSize is the number of words per shingle.
+	shingleSize = 3
+)
+
+// noiseWords are dropped from subjects: resend markers, urgency, and the
+// dates that typically change between an announcement and its reminder.
+var noiseWords = map[string]bool{
+	"re": true, "fw": true, "fwd": true,
+	"reminder": true, "gentle": true, "final": true, "extended": true, "extension": true,
+	"corrigendum": true, "addendum": true, "updated": true, "update": true, "revised": true,
+	"urgent": true, "important": true, "attention": true, "reopened": true,
+	"last": true, "date": true, "deadline": true, "today": true, "tomorrow": true, "till": true, "to": true,
+	"jan": true, "january": true, "feb": true, "february": true, "mar": true, "march": true,
+	"apr": true, "april": true, "may": true, "jun": true, "june": true, "jul": true, "july": true,
+	"aug": true, "august": true, "sep": true, "sept": true, "september": true, "oct": true, "october": true,
+	"nov": true, "november": true, "dec": true, "december": true,
+	"st": true, "nd": true, "rd": true, "th": true,
+}
+
+// Fingerprint is what two summaries are compared on.
+type Fingerprint struct {
+	SubjectKey string
+	CompanyID  *int64
+	CompanyKey string
+	RoleKey    string
+	Simhash    uint64
+}
+
+// Of fingerprints an analyzed email. The simhash covers the body when it is
+// stored and the summary otherwise.
+func Of(res *ai.AIResult) Fingerprint {
+	text := res.Summary
+	if res.Description != nil && strings.TrimSpace(*res.Description) != "" {
+		text = *res.Description
+	}
+	companyName, role := "", ""
+	if res.Company != nil {
+		companyName = *res.Company
+	}
+	if res.Role != nil {
+		role = *res.Role
+	}
+	return Fingerprint{
+		SubjectKey: NormalizeSubject(res.Subject),
+		CompanyID:  res.CompanyID,
+		CompanyKey: company.Key(companyName),
+		RoleKey:    strings.Join(words(role), " "),
+		Simhash:    Simhash(res.Subject + "\n" + text),
+	}
+}
+
+// Stored rebuilds a fingerprint from the columns persisted for a summary:
+// the subject key and simhash from Of, and the raw company and role.
+func Stored(subjectKey, companyName, role string, companyID *int64, simhash uint64) Fingerprint {
+	return Fingerprint{
+		SubjectKey: subjectKey,
+		CompanyID:  companyID,
+		CompanyKey: company.Key(companyName),
+		RoleKey:    strings.Join(words(role), " "),
+		Simhash:    simhash,
+	}
+}
+
+// NormalizeSubject lower-cases subject and drops resend markers, dates and
+// punctuation, so "REMINDER: Acme Hiring – Last date 15th Aug" and
+// "Acme Hiring | Last date 12 Aug" share a key.
+func NormalizeSubject(subject string) string {
+	kept := make([]string, 0)
+	for _, w := range words(subject) {
+		if noiseWords[w] || isNumeric(w) {
+			continue
+		}
+		kept = append(kept, w)
+	}
+	return strings.Join(kept, " ")
+}
+
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// isNumeric reports whether w is digits with an optional ordinal suffix.
+func isNumeric(w string) bool {
+	w = strings.TrimRight(w, "stndrh")
+	if w == "" {
+		return false
+	}
+	for _, r := range w {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Simhash returns the 64-bit simhash of text's word shingles.
+func Simhash(text string) uint64 {
+	tokens := words(text)
+	if len(tokens) == 0 {
+		return 0
+	}
+	var counts [64]int
+	add := func(shingle string) {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(shingle))
+		sum := h.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<bit) != 0 {
+				counts[bit]++
+			} else {
+				counts[bit]--
+			}
+		}
+	}
+	if len(tokens) < shingleSize {
+		add(strings.Join(tokens, " "))
+	}
+	for i := 0; i+shingleSize <= len(tokens); i++ {
+		add(strings.Join(tokens[i:i+shingleSize], " "))
+	}
+
+	var hash uint64
+	for bit, c := range counts {
+		if c > 0 {
+			hash |= 1 << bit
+		}
+	}
+	return hash
+}
+
+// Distance is the number of differing bits between two simhashes.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Same reports whether a and b announce the same opportunity, and how far
+// apart their bodies are.
+func Same(a, b Fingerprint) (bool, int) {
+	distance := Distance(a.Simhash, b.Simhash)
+
+	if sameCompany(a, b) {
+		if a.RoleKey != "" && b.RoleKey != "" && a.RoleKey != b.RoleKey {
+			return false, distance
+		}
+		if (a.SubjectKey != "" && a.SubjectKey == b.SubjectKey) || distance <= maxDistanceSameCompany {
+			return true, distance
+		}
+		return false, distance
+	}
+	if (a.CompanyKey != "" && b.CompanyKey != "") || (a.CompanyID != nil && b.CompanyID != nil) {
+		// Both name a company and they differ.
+		return false, distance
+	}
+	// A bare subject like "placement drive" says too little on its own.
+	if len(strings.Fields(a.SubjectKey)) >= 3 && a.SubjectKey == b.SubjectKey && distance <= maxDistanceSameSubject {
+		return true, distance
+	}
+	return false, distance
+}
+
+func sameCompany(a, b Fingerprint) bool {
+	if a.CompanyID != nil && b.CompanyID != nil {
+		return *a.CompanyID == *b.CompanyID
+	}
+	return a.CompanyKey != "" && a.CompanyKey == b.CompanyKey
+}
+
+// Candidate is a previously stored summary that a new one may duplicate.
+type Candidate struct {
+	GmailID       string
+	OpportunityID string
+	Fingerprint   Fingerprint
+}
+
+// Best returns the opportunity fp belongs to among candidates, preferring
+// the closest body. It returns "" when none match.
+func Best(fp Fingerprint, candidates []Candidate) string {
+	best, bestDistance := "", 65
+	for _, c := range candidates {
+		if ok, distance := Same(fp, c.Fingerprint); ok && distance < bestDistance {
+			best, bestDistance = c.OpportunityID, distance
+		}
+	}
+	return best
+}
@@ -0,0 +1,84 @@
+package dedupe
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/r7rainz/auramail/internal/ai"
+)
+
+func str(s string) *string { return &s }
+
+const acmeBody = `Dear students,
+Acme Technologies is hiring Graduate Engineer Trainees from the 2026 batch.
+Eligibility: B.Tech CSE, IT and ECE with a minimum CGPA of 7.0 and no active backlogs.
+CTC: 8 LPA. Location: Bengaluru. The selection process has an online test, a technical interview and an HR round.
+Register on the portal before the last date. Students who register must attend all rounds.`
+
+func TestNormalizeSubject(t *testing.T) {
+	tests := map[string]string{
+		"REMINDER: Acme Hiring – Last date 15th Aug":  "acme hiring",
+		"Acme Hiring | Last date 12 Aug":              "acme hiring",
+		"Re: Fwd: [Corrigendum] Acme Hiring 2026":     "acme hiring",
+		"Gentle Reminder!! Deadline Extended to 20/8": "",
+	}
+	for in, want := range tests {
+		if got := NormalizeSubject(in); got != want {
+			t.Errorf("NormalizeSubject(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSimhashNearDuplicates(t *testing.T) {
+	original := Simhash(acmeBody)
+	resent := Simhash("REMINDER: the deadline has been extended to 15 August.\n" + acmeBody)
+	other := Simhash(`Globex invites applications for its summer research internship.
+Students from any branch may apply with a statement of purpose and two references.
+Shortlisted students will be interviewed online in the first week of March.`)
+
+	if d := Distance(original, resent); d > maxDistanceSameCompany {
+		t.Errorf("resend distance = %d, want <= %d", d, maxDistanceSameCompany)
+	}
+	if d := Distance(original, other); d <= maxDistanceSameSubject {
+		t.Errorf("unrelated distance = %d, want > %d", d, maxDistanceSameSubject)
+	}
+}
+
+func TestSame(t *testing.T) {
+	original := Of(&ai.AIResult{Subject: "Acme Hiring | Last date 12 Aug", Company: str("Acme Technologies Pvt Ltd"), Role: str("Graduate Engineer Trainee"), Description: str(acmeBody)})
+	reminder := Of(&ai.AIResult{Subject: "REMINDER: Acme Hiring – Last date 15th Aug", Company: str("Acme Technologies"), Summary: "• Deadline extended to 15 Aug"})
+	otherRole := Of(&ai.AIResult{Subject: "Acme Hiring – Data Analyst", Company: str("Acme Technologies"), Role: str("Data Analyst"), Description: str(acmeBody)})
+	otherCompany := Of(&ai.AIResult{Subject: "Acme Hiring | Last date 12 Aug", Company: str("Globex"), Description: str(acmeBody)})
+	noCompany := Of(&ai.AIResult{Subject: "Corrigendum: Acme Graduate Trainee Hiring", Description: str(acmeBody)})
+	noCompanyResend := Of(&ai.AIResult{Subject: "Acme Graduate Trainee Hiring", Description: str(strings.ReplaceAll(acmeBody, "8 LPA", "9 LPA"))})
+
+	tests := []struct {
+		name string
+		a, b Fingerprint
+		want bool
+	}{
+		{"reminder with same subject key", original, reminder, true},
+		{"different role", original, otherRole, false},
+		{"different company", original, otherCompany, false},
+		{"no company, same subject and body", noCompany, noCompanyResend, true},
+	}
+	for _, tt := range tests {
+		if got, _ := Same(tt.a, tt.b); got != tt.want {
+			t.Errorf("%s: Same() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBest(t *testing.T) {
+	fp := Of(&ai.AIResult{Subject: "Reminder: Acme Hiring", Company: str("Acme"), Description: str(acmeBody)})
+	candidates := []Candidate{
+		{GmailID: "g1", OpportunityID: "g1", Fingerprint: Of(&ai.AIResult{Subject: "Globex internship", Company: str("Globex")})},
+		{GmailID: "g2", OpportunityID: "g0", Fingerprint: Of(&ai.AIResult{Subject: "Acme Hiring", Company: str("Acme"), Description: str(acmeBody)})},
+	}
+	if got := Best(fp, candidates); got != "g0" {
+		t.Errorf("Best() = %q, want g0", got)
+	}
+	if got := Best(fp, candidates[:1]); got != "" {
+		t.Errorf("Best() = %q, want no match", got)
+	}
+}
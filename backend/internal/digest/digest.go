@@ -0,0 +1,222 @@
+// Package digest emails users a daily or weekly summary of their placement
+// mail: new opportunities, deadlines coming up, results and shortlists, and
+// the emails they marked important. Many students read email but rarely
+// open the app.
+package digest
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/user"
+)
+
+const (
+	// deadlineDays is how many days ahead deadlines are listed, today
+	// included.
+	deadlineDays = 7
+	// maxItems caps each section; the rest are in the app.
+	maxItems = 10
+	// recentLimit is how many of the newest summaries are searched for
+	// deadlines and important emails.
+	recentLimit = 200
+)
+
+// categoryResult is the category of results and shortlists.
+const categoryResult = "result"
+
+// opportunityCategories are the categories listed as new opportunities.
+// Announcements, reminders and the rounds of drives already under way are
+// left out; their deadlines still show up.
+var opportunityCategories = map[string]bool{
+	"internship":   true,
+	"job offer":    true,
+	"ppt":          true,
+	"workshop":     true,
+	"registration": true,
+}
+
+// Item is one email in a digest.
+type Item struct {
+	GmailID  string
+	Title    string
+	Category string
+	// Deadline is formatted for reading, e.g. "Fri 14 Aug"; empty when the
+	// email has none.
+	Deadline  string
+	Summary   string
+	ApplyLink string
+}
+
+// Digest is one user's digest email before rendering.
+type Digest struct {
+	Name      string
+	Frequency string
+	// Since is the start of the period new emails are listed for.
+	Since     time.Time
+	New       []Item
+	Deadlines []Item
+	Results   []Item
+	// Important are the emails the user marked important that are new or
+	// have a deadline ahead, so an old flag is not mailed forever.
+	Important []Item
+	// AppURL opens AuraMail, where the digest can also be turned off.
+	AppURL string
+}
+
+// Empty reports whether there is nothing to send.
+func (d *Digest) Empty() bool {
+	return len(d.New)+len(d.Deadlines)+len(d.Results)+len(d.Important) == 0
+}
+
+// Subject is the digest email's subject line.
+func (d *Digest) Subject() string {
+	var parts []string
+	if n := len(d.New); n > 0 {
+		parts = append(parts, plural(n, "new opportunity", "new opportunities"))
+	}
+	if n := len(d.Deadlines); n > 0 {
+		parts = append(parts, plural(n, "deadline", "deadlines")+" this week")
+	}
+	if n := len(d.Results); n > 0 {
+		parts = append(parts, plural(n, "result", "results"))
+	}
+	subject := "Your " + d.Frequency + " AuraMail digest"
+	if len(parts) > 0 {
+		subject += ": " + strings.Join(parts, ", ")
+	}
+	return subject
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return "1 " + one
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
+
+// Compose builds the digest for r. fresh are the summaries stored since
+// since, newest first; recent are the user's newest summaries, searched for
+// deadlines in the coming week and important emails. Emails of one
+// opportunity are listed once, by the newest.
+func Compose(r user.DigestRecipient, fresh, recent []*ai.AIResult, since, now time.Time, appURL string) *Digest {
+	loc := location(r.Settings.TimeZone)
+	today := midnight(now.In(loc))
+	d := &Digest{
+		Name:      r.Name,
+		Frequency: r.Settings.Frequency,
+		Since:     since,
+		AppURL:    appURL,
+	}
+
+	isFresh := make(map[string]bool, len(fresh))
+	listed := make(map[string]bool)
+	for _, s := range fresh {
+		isFresh[s.GmailMessageID] = true
+		switch {
+		case s.Category == categoryResult:
+			d.Results = append(d.Results, newItem(s, loc))
+		case opportunityCategories[s.Category]:
+			if s.OpportunityID != "" {
+				if listed[s.OpportunityID] {
+					continue
+				}
+				listed[s.OpportunityID] = true
+			}
+			d.New = append(d.New, newItem(s, loc))
+		}
+	}
+
+	type dated struct {
+		item Item
+		at   time.Time
+	}
+	var deadlines []dated
+	seen := make(map[string]bool)
+	for _, s := range recent {
+		deadline, ok := parseDeadline(s.Deadline, loc)
+		if ok && deadline.Before(today) {
+			// Past deadlines are not worth mailing about, even when
+			// important.
+			continue
+		}
+		if ok && deadline.Before(today.AddDate(0, 0, deadlineDays)) && !seen[s.GmailMessageID] {
+			deadlines = append(deadlines, dated{newItem(s, loc), deadline})
+			seen[s.GmailMessageID] = true
+		}
+		if s.Important && (ok || isFresh[s.GmailMessageID]) {
+			d.Important = append(d.Important, newItem(s, loc))
+		}
+	}
+	slices.SortStableFunc(deadlines, func(a, b dated) int { return a.at.Compare(b.at) })
+	for _, dl := range deadlines {
+		d.Deadlines = append(d.Deadlines, dl.item)
+	}
+
+	d.New = capItems(d.New)
+	d.Deadlines = capItems(d.Deadlines)
+	d.Results = capItems(d.Results)
+	d.Important = capItems(d.Important)
+	return d
+}
+
+func capItems(items []Item) []Item {
+	if len(items) > maxItems {
+		return items[:maxItems]
+	}
+	return items
+}
+
+func newItem(s *ai.AIResult, loc *time.Location) Item {
+	item := Item{
+		GmailID:  s.GmailMessageID,
+		Title:    s.Title(),
+		Category: s.Category,
+		Summary:  firstLine(s.Summary),
+	}
+	if s.ApplyLink != nil {
+		item.ApplyLink = *s.ApplyLink
+	}
+	if deadline, ok := parseDeadline(s.Deadline, loc); ok {
+		item.Deadline = deadline.Format("Mon 2 Jan")
+	}
+	return item
+}
+
+// firstLine returns the first bullet of a summary without its marker.
+func firstLine(summary string) string {
+	for _, line := range strings.Split(summary, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "•*-"))
+		if line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+func parseDeadline(deadline *string, loc *time.Location) (time.Time, bool) {
+	if deadline == nil {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(time.DateOnly, *deadline, loc)
+	return t, err == nil
+}
+
+// location returns the settings' time zone, or the default one when it
+// cannot be loaded.
+func location(name string) *time.Location {
+	if loc, err := time.LoadLocation(name); err == nil {
+		return loc
+	}
+	if loc, err := time.LoadLocation(user.DefaultTimeZone); err == nil {
+		return loc
+	}
+	return time.UTC
+}
+
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
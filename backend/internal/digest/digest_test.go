@@ -0,0 +1,164 @@
+package digest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/user"
+)
+
+func strPtr(s string) *string { return &s }
+
+// monday is 9:00 on Monday 17 Aug 2026 in India.
+var monday = time.Date(2026, 8, 17, 3, 30, 0, 0, time.UTC)
+
+func recipient(frequency string) user.DigestRecipient {
+	s := user.DefaultDigestSettings()
+	s.Frequency = frequency
+	return user.DigestRecipient{UserID: "1", Email: "asha@example.com", Name: "Asha", Settings: s}
+}
+
+func TestCompose(t *testing.T) {
+	fresh := []*ai.AIResult{
+		{GmailMessageID: "m1", Category: "job offer", Company: strPtr("Globex"), Role: strPtr("SDE"), Summary: "• Hiring freshers\n• CTC 12 LPA", Deadline: strPtr("2026-08-20"), OpportunityID: "7"},
+		{GmailMessageID: "m2", Category: categoryResult, Subject: "Shortlist for Initech interviews"},
+		{GmailMessageID: "m6", Category: "announcement", Subject: "Placement policy update", Important: true},
+		{GmailMessageID: "m7", Category: "registration", Company: strPtr("Globex"), Subject: "Globex registration", OpportunityID: "7"},
+	}
+	recent := append([]*ai.AIResult{
+		{GmailMessageID: "m3", Category: "internship", Company: strPtr("Hooli"), Deadline: strPtr("2026-08-17"), Important: true},
+		{GmailMessageID: "m4", Category: "job offer", Company: strPtr("Umbrella"), Deadline: strPtr("2026-08-24")},
+		{GmailMessageID: "m5", Category: "job offer", Company: strPtr("Acme"), Deadline: strPtr("2026-08-10"), Important: true},
+		{GmailMessageID: "m8", Category: "job offer", Company: strPtr("Vandelay"), Important: true},
+	}, fresh...)
+
+	d := Compose(recipient(user.DigestWeekly), fresh, recent, monday.AddDate(0, 0, -7), monday, "http://app/dashboard")
+
+	ids := func(items []Item) string {
+		var out []string
+		for _, it := range items {
+			out = append(out, it.GmailID)
+		}
+		return strings.Join(out, ",")
+	}
+	// Announcements are not opportunities, and the Globex registration is
+	// the same opportunity as its drive.
+	if got := ids(d.New); got != "m1" {
+		t.Errorf("New = %s, want m1", got)
+	}
+	if got := ids(d.Results); got != "m2" {
+		t.Errorf("Results = %s, want m2", got)
+	}
+	// Today's deadline is included, the one a week out and the past one
+	// are not.
+	if got := ids(d.Deadlines); got != "m3,m1" {
+		t.Errorf("Deadlines = %s, want m3,m1", got)
+	}
+	// Important emails are listed while new or before their deadline; the
+	// old, undated Vandelay one is not.
+	if got := ids(d.Important); got != "m3,m6" {
+		t.Errorf("Important = %s, want m3,m6", got)
+	}
+
+	if d.New[0].Title != "Globex – SDE" || d.New[0].Summary != "Hiring freshers" || d.New[0].Deadline != "Thu 20 Aug" {
+		t.Errorf("unexpected item %+v", d.New[0])
+	}
+	if d.Results[0].Title != "Shortlist for Initech interviews" {
+		t.Errorf("result title = %q", d.Results[0].Title)
+	}
+	want := "Your weekly AuraMail digest: 1 new opportunity, 2 deadlines this week, 1 result"
+	if got := d.Subject(); got != want {
+		t.Errorf("Subject = %q, want %q", got, want)
+	}
+}
+
+func TestCompose_Empty(t *testing.T) {
+	d := Compose(recipient(user.DigestDaily), nil, nil, monday.Add(-24*time.Hour), monday, "")
+	if !d.Empty() {
+		t.Errorf("expected an empty digest, got %+v", d)
+	}
+}
+
+func TestDue(t *testing.T) {
+	at := func(t time.Time) *time.Time { return &t }
+	weekly := user.DefaultDigestSettings()
+	weekly.Frequency = user.DigestWeekly
+	daily := user.DefaultDigestSettings()
+	daily.Frequency = user.DigestDaily
+
+	tests := []struct {
+		name      string
+		settings  func() user.DigestSettings
+		now       time.Time
+		want      bool
+		wantSince time.Time
+	}{
+		{"first weekly", func() user.DigestSettings { return weekly }, monday, true, monday.AddDate(0, 0, -7)},
+		{"before send time", func() user.DigestSettings { return weekly }, monday.Add(-2 * time.Hour), false, time.Time{}},
+		{"wrong weekday", func() user.DigestSettings { return weekly }, monday.AddDate(0, 0, 1), false, time.Time{}},
+		{"daily any day", func() user.DigestSettings {
+			s := daily
+			s.LastSentAt = at(monday.AddDate(0, 0, -1))
+			return s
+		}, monday.AddDate(0, 0, 2), true, monday.AddDate(0, 0, -1)},
+		{"already sent today", func() user.DigestSettings {
+			s := daily
+			s.LastSentAt = at(monday.Add(-30 * time.Minute))
+			return s
+		}, monday, false, time.Time{}},
+		{"off", func() user.DigestSettings {
+			s := daily
+			s.Frequency = user.DigestOff
+			return s
+		}, monday, false, time.Time{}},
+		{"still sunday in new york", func() user.DigestSettings {
+			s := weekly
+			s.TimeZone = "America/New_York"
+			return s
+		}, monday, false, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, since := due(tt.settings(), tt.now)
+			if got != tt.want || !since.Equal(tt.wantSince) {
+				t.Errorf("due = %v, %v; want %v, %v", got, since, tt.want, tt.wantSince)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	d := &Digest{
+		Name:      "Asha",
+		Frequency: user.DigestDaily,
+		New:       []Item{{Title: "Globex <Labs> – SDE", Deadline: "Thu 20 Aug", ApplyLink: "https://globex.example/apply"}},
+		AppURL:    "http://app/dashboard",
+	}
+	text, html, err := Render(d)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	for _, want := range []string{"Hi Asha,", "NEW OPPORTUNITIES", "- Globex <Labs> – SDE (deadline Thu 20 Aug)", "Apply: https://globex.example/apply", "http://app/dashboard"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text is missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "DEADLINES") {
+		t.Errorf("text has an empty section:\n%s", text)
+	}
+	for _, want := range []string{"New opportunities", "Globex &lt;Labs&gt; – SDE", `href="https://globex.example/apply"`} {
+		if !strings.Contains(html, want) {
+			t.Errorf("html is missing %q:\n%s", want, html)
+		}
+	}
+}
+
+func TestCompose_OldImportantEmailsDoNotKeepDigestsGoing(t *testing.T) {
+	recent := []*ai.AIResult{{GmailMessageID: "m1", Category: "job offer", Company: strPtr("Acme"), Important: true}}
+	d := Compose(recipient(user.DigestDaily), nil, recent, monday.Add(-24*time.Hour), monday, "")
+	if !d.Empty() {
+		t.Errorf("expected an empty digest, got %+v", d)
+	}
+}
@@ -0,0 +1,174 @@
+package digest
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/r7rainz/auramail/internal/auth"
+	"github.com/r7rainz/auramail/internal/response"
+	"github.com/r7rainz/auramail/internal/user"
+)
+
+type Handler struct {
+	repo   Repository
+	appURL string
+}
+
+func NewHandler(repo Repository, appURL string) *Handler {
+	return &Handler{repo: repo, appURL: appURL}
+}
+
+// updateSettingsRequest changes only the settings that are present.
+type updateSettingsRequest struct {
+	Frequency *string `json:"frequency"`
+	SendAt    *string `json:"sendAt"`
+	Weekday   *string `json:"weekday"`
+	TimeZone  *string `json:"timeZone"`
+}
+
+// GetSettings returns when the user's digest is sent.
+func (h *Handler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(auth.UserIDContextKey).(string)
+	if !ok {
+		response.Unauthorized(w, "No UserID found in context")
+		return
+	}
+
+	settings, err := h.repo.GetDigestSettings(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load digest settings", "err", err, "userID", userID)
+		response.InternalError(w, "Failed to load digest settings")
+		return
+	}
+	response.Success(w, settings)
+}
+
+// UpdateSettings changes how often and at what local time the user's
+// digest is sent. Digests are only sent while notifications are enabled.
+func (h *Handler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(auth.UserIDContextKey).(string)
+	if !ok {
+		response.Unauthorized(w, "No UserID found in context")
+		return
+	}
+
+	var req updateSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "Invalid request body", nil)
+		return
+	}
+	if req.Frequency != nil {
+		switch *req.Frequency {
+		case user.DigestDaily, user.DigestWeekly, user.DigestOff:
+		default:
+			response.BadRequest(w, fmt.Sprintf("Unknown frequency %q; use daily, weekly or off", *req.Frequency), nil)
+			return
+		}
+	}
+	if req.SendAt != nil {
+		if _, _, err := parseSendAt(*req.SendAt); err != nil {
+			response.BadRequest(w, "sendAt must be a time of day such as 08:00", nil)
+			return
+		}
+	}
+	if req.Weekday != nil {
+		if _, ok := weekdays[*req.Weekday]; !ok {
+			response.BadRequest(w, fmt.Sprintf("Unknown weekday %q; use a lowercase day name such as monday", *req.Weekday), nil)
+			return
+		}
+	}
+	if req.TimeZone != nil {
+		if _, err := time.LoadLocation(*req.TimeZone); err != nil || *req.TimeZone == "" {
+			response.BadRequest(w, fmt.Sprintf("Unknown time zone %q; use an IANA name such as Asia/Kolkata", *req.TimeZone), nil)
+			return
+		}
+	}
+
+	settings, err := h.repo.GetDigestSettings(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load digest settings", "err", err, "userID", userID)
+		response.InternalError(w, "Failed to load digest settings")
+		return
+	}
+	if req.Frequency != nil {
+		settings.Frequency = *req.Frequency
+	}
+	if req.SendAt != nil {
+		settings.SendAt = *req.SendAt
+	}
+	if req.Weekday != nil {
+		settings.Weekday = *req.Weekday
+	}
+	if req.TimeZone != nil {
+		settings.TimeZone = *req.TimeZone
+	}
+
+	if err := h.repo.SaveDigestSettings(ctx, userID, settings); err != nil {
+		slog.ErrorContext(ctx, "failed to save digest settings", "err", err, "userID", userID)
+		response.InternalError(w, "Failed to save digest settings")
+		return
+	}
+	response.Success(w, settings)
+}
+
+// Preview renders the digest the user would get now, as HTML, or as plain
+// text with ?format=text.
+func (h *Handler) Preview(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(auth.UserIDContextKey).(string)
+	if !ok {
+		response.Unauthorized(w, "No UserID found in context")
+		return
+	}
+
+	u, err := h.repo.FindByID(ctx, userID)
+	if err != nil {
+		response.NotFound(w, "User not found")
+		return
+	}
+	settings, err := h.repo.GetDigestSettings(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load digest settings", "err", err, "userID", userID)
+		response.InternalError(w, "Failed to load digest settings")
+		return
+	}
+	if settings.Frequency == user.DigestOff {
+		// Preview what turning on a weekly digest would send.
+		settings.Frequency = user.DigestWeekly
+	}
+
+	now := time.Now()
+	since := now.Add(-period(settings.Frequency))
+	if settings.LastSentAt != nil {
+		since = *settings.LastSentAt
+	}
+	rc := user.DigestRecipient{UserID: userID, Email: u.Email, Name: u.Name, Settings: *settings}
+	d, err := build(ctx, h.repo, rc, since, now, h.appURL)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to build digest preview", "err", err, "userID", userID)
+		response.InternalError(w, "Failed to build digest")
+		return
+	}
+	text, html, err := Render(d)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to render digest preview", "err", err, "userID", userID)
+		response.InternalError(w, "Failed to build digest")
+		return
+	}
+
+	if r.URL.Query().Get("format") == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte(text))
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(html))
+}
@@ -0,0 +1,84 @@
+package digest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/auth"
+	"github.com/r7rainz/auramail/internal/user"
+)
+
+func withUserID(req *http.Request, userID string) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), auth.UserIDContextKey, userID))
+}
+
+func TestUpdateSettings(t *testing.T) {
+	repo := &fakeRepo{}
+	h := NewHandler(repo, "http://app/dashboard")
+
+	update := func(body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.UpdateSettings(rr, withUserID(httptest.NewRequest(http.MethodPut, "/digest/settings", strings.NewReader(body)), "1"))
+		return rr
+	}
+
+	for _, body := range []string{
+		`{"frequency":"hourly"}`,
+		`{"sendAt":"8am"}`,
+		`{"weekday":"Funday"}`,
+		`{"timeZone":"Mars/Olympus"}`,
+	} {
+		if rr := update(body); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", body, rr.Code)
+		}
+	}
+	if repo.settings != nil {
+		t.Fatalf("invalid settings were saved: %+v", repo.settings)
+	}
+
+	rr := update(`{"frequency":"daily","sendAt":"18:30"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rr.Code, rr.Body.String())
+	}
+	saved := repo.settings["1"]
+	if saved.Frequency != user.DigestDaily || saved.SendAt != "18:30" || saved.Weekday != "monday" || saved.TimeZone != user.DefaultTimeZone {
+		t.Errorf("saved %+v; other settings should keep their defaults", saved)
+	}
+
+	rr = httptest.NewRecorder()
+	h.GetSettings(rr, withUserID(httptest.NewRequest(http.MethodGet, "/digest/settings", nil), "1"))
+	var resp struct {
+		Data user.DigestSettings `json:"data"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil || resp.Data.SendAt != "18:30" {
+		t.Errorf("GetSettings = %+v (%v)", resp.Data, err)
+	}
+}
+
+func TestPreview(t *testing.T) {
+	repo := &fakeRepo{
+		users: map[string]*user.User{"1": {ID: "1", Email: "asha@example.com", Name: "Asha"}},
+		settings: map[string]*user.DigestSettings{
+			"1": {Frequency: user.DigestOff, SendAt: "08:00", Weekday: "monday", TimeZone: user.DefaultTimeZone},
+		},
+		summaries: map[string][]storedSummary{
+			"1": {{time.Now(), &ai.AIResult{GmailMessageID: "m1", Category: "job offer", Company: strPtr("Globex")}}},
+		},
+	}
+	h := NewHandler(repo, "http://app/dashboard")
+
+	rr := httptest.NewRecorder()
+	h.Preview(rr, withUserID(httptest.NewRequest(http.MethodGet, "/digest/preview?format=text", nil), "1"))
+	if rr.Code != http.StatusOK || !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/plain") {
+		t.Fatalf("Preview: %d %s", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	if body := rr.Body.String(); !strings.Contains(body, "Hi Asha,") || !strings.Contains(body, "weekly") || !strings.Contains(body, "- Globex") {
+		t.Errorf("unexpected preview:\n%s", body)
+	}
+}
@@ -0,0 +1,38 @@
+package digest
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// section is one list of items in the HTML digest.
+type section struct {
+	Heading string
+	Items   []Item
+}
+
+var (
+	textTemplate = texttemplate.Must(texttemplate.ParseFS(templateFS, "templates/digest.txt.tmpl"))
+	htmlTemplate = htmltemplate.Must(htmltemplate.New("digest.html.tmpl").Funcs(htmlFuncs).ParseFS(templateFS, "templates/digest.html.tmpl"))
+)
+
+var htmlFuncs = htmltemplate.FuncMap{
+	"section": func(heading string, items []Item) section { return section{heading, items} },
+}
+
+// Render returns the plain text and HTML bodies of d.
+func Render(d *Digest) (text, html string, err error) {
+	var tb, hb bytes.Buffer
+	if err := textTemplate.Execute(&tb, d); err != nil {
+		return "", "", err
+	}
+	if err := htmlTemplate.Execute(&hb, d); err != nil {
+		return "", "", err
+	}
+	return tb.String(), hb.String(), nil
+}
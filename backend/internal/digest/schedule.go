@@ -0,0 +1,64 @@
+package digest
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/r7rainz/auramail/internal/user"
+)
+
+// weekdays are the day names DigestSettings.Weekday accepts.
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// parseSendAt reads a local time of day written as HH:MM.
+func parseSendAt(value string) (hour, minute int, err error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, 0, fmt.Errorf("send time %q is not HH:MM", value)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// period is how much mail one digest covers.
+func period(frequency string) time.Duration {
+	if frequency == user.DigestWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// due reports whether a digest with settings s should be sent at now, and
+// the start of the period it covers: the last digest, or one period ago
+// for the first.
+func due(s user.DigestSettings, now time.Time) (bool, time.Time) {
+	if s.Frequency != user.DigestDaily && s.Frequency != user.DigestWeekly {
+		return false, time.Time{}
+	}
+	hour, minute, err := parseSendAt(s.SendAt)
+	if err != nil {
+		return false, time.Time{}
+	}
+	local := now.In(location(s.TimeZone))
+	if s.Frequency == user.DigestWeekly && local.Weekday() != weekdays[s.Weekday] {
+		return false, time.Time{}
+	}
+	scheduled := time.Date(local.Year(), local.Month(), local.Day(), hour, minute, 0, 0, local.Location())
+	if local.Before(scheduled) {
+		return false, time.Time{}
+	}
+	if s.LastSentAt != nil {
+		if !s.LastSentAt.Before(scheduled) {
+			return false, time.Time{}
+		}
+		return true, *s.LastSentAt
+	}
+	return true, now.Add(-period(s.Frequency))
+}
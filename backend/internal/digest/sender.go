@@ -0,0 +1,108 @@
+package digest
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"time"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/user"
+)
+
+// freshLimit caps how many new summaries one digest is composed from.
+const freshLimit = 100
+
+// Repository is the subset of the user repository digests depend on.
+type Repository interface {
+	FindByID(ctx context.Context, id string) (*user.User, error)
+	ListSummaries(ctx context.Context, userID string, filter user.SummaryFilter) ([]*ai.AIResult, error)
+	ListDigestRecipients(ctx context.Context) ([]user.DigestRecipient, error)
+	GetDigestSettings(ctx context.Context, userID string) (*user.DigestSettings, error)
+	SaveDigestSettings(ctx context.Context, userID string, s *user.DigestSettings) error
+	MarkDigestSent(ctx context.Context, userID string, at time.Time) error
+}
+
+// Sender emails the digests that are due.
+type Sender struct {
+	repo   Repository
+	mailer Mailer
+	from   string
+	appURL string
+	now    func() time.Time
+}
+
+// NewSender returns a sender mailing from the address from. appURL is
+// linked from every digest.
+func NewSender(repo Repository, mailer Mailer, from, appURL string) *Sender {
+	return &Sender{repo: repo, mailer: mailer, from: from, appURL: appURL, now: time.Now}
+}
+
+// Run sends every digest that is due and returns how many were sent. A
+// digest with nothing in it is not sent but still counts as the user's
+// last one. A failure for one user is logged and the rest are still sent.
+func (s *Sender) Run(ctx context.Context) (int, error) {
+	recipients, err := s.repo.ListDigestRecipients(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	now := s.now()
+	sent := 0
+	for _, r := range recipients {
+		if err := ctx.Err(); err != nil {
+			return sent, err
+		}
+		ok, since := due(r.Settings, now)
+		if !ok {
+			continue
+		}
+		delivered, err := s.send(ctx, r, since, now)
+		if err != nil {
+			slog.ErrorContext(ctx, "digest not sent", "userID", r.UserID, "err", err)
+			continue
+		}
+		if err := s.repo.MarkDigestSent(ctx, r.UserID, now); err != nil {
+			slog.ErrorContext(ctx, "failed to record digest", "userID", r.UserID, "err", err)
+		}
+		if delivered {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// send mails r their digest and reports whether there was anything to send.
+func (s *Sender) send(ctx context.Context, r user.DigestRecipient, since, now time.Time) (bool, error) {
+	d, err := build(ctx, s.repo, r, since, now, s.appURL)
+	if err != nil {
+		return false, err
+	}
+	if d.Empty() {
+		return false, nil
+	}
+	text, html, err := Render(d)
+	if err != nil {
+		return false, fmt.Errorf("failed to render digest: %w", err)
+	}
+	to := (&mail.Address{Name: r.Name, Address: r.Email}).String()
+	err = s.mailer.Send(ctx, Message{From: s.from, To: to, Subject: d.Subject(), Text: text, HTML: html})
+	if err != nil {
+		return false, fmt.Errorf("failed to send digest: %w", err)
+	}
+	return true, nil
+}
+
+// build loads r's summaries and composes their digest.
+func build(ctx context.Context, repo Repository, r user.DigestRecipient, since, now time.Time, appURL string) (*Digest, error) {
+	fresh, err := repo.ListSummaries(ctx, r.UserID, user.SummaryFilter{Since: since, Limit: freshLimit})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load new summaries: %w", err)
+	}
+	recent, err := repo.ListSummaries(ctx, r.UserID, user.SummaryFilter{Limit: recentLimit})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load recent summaries: %w", err)
+	}
+	return Compose(r, fresh, recent, since, now, appURL), nil
+}
@@ -0,0 +1,256 @@
+package digest
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/user"
+)
+
+type storedSummary struct {
+	at  time.Time
+	res *ai.AIResult
+}
+
+type fakeRepo struct {
+	users      map[string]*user.User
+	settings   map[string]*user.DigestSettings
+	recipients []user.DigestRecipient
+	summaries  map[string][]storedSummary
+	sent       map[string]time.Time
+}
+
+func (f *fakeRepo) FindByID(ctx context.Context, id string) (*user.User, error) {
+	if u, ok := f.users[id]; ok {
+		return u, nil
+	}
+	return nil, io.EOF
+}
+
+func (f *fakeRepo) ListSummaries(ctx context.Context, userID string, filter user.SummaryFilter) ([]*ai.AIResult, error) {
+	var out []*ai.AIResult
+	for _, s := range f.summaries[userID] {
+		if s.at.Before(filter.Since) {
+			continue
+		}
+		out = append(out, s.res)
+	}
+	return out, nil
+}
+
+func (f *fakeRepo) ListDigestRecipients(ctx context.Context) ([]user.DigestRecipient, error) {
+	return f.recipients, nil
+}
+
+func (f *fakeRepo) GetDigestSettings(ctx context.Context, userID string) (*user.DigestSettings, error) {
+	if s, ok := f.settings[userID]; ok {
+		c := *s
+		return &c, nil
+	}
+	s := user.DefaultDigestSettings()
+	return &s, nil
+}
+
+func (f *fakeRepo) SaveDigestSettings(ctx context.Context, userID string, s *user.DigestSettings) error {
+	if f.settings == nil {
+		f.settings = make(map[string]*user.DigestSettings)
+	}
+	c := *s
+	f.settings[userID] = &c
+	return nil
+}
+
+func (f *fakeRepo) MarkDigestSent(ctx context.Context, userID string, at time.Time) error {
+	if f.sent == nil {
+		f.sent = make(map[string]time.Time)
+	}
+	f.sent[userID] = at
+	return nil
+}
+
+// smtpSink is a local SMTP server that keeps every message it is sent.
+type smtpSink struct {
+	ln       net.Listener
+	mu       sync.Mutex
+	messages []sunkMessage
+}
+
+type sunkMessage struct {
+	from string
+	to   []string
+	data []byte
+}
+
+func newSMTPSink(t *testing.T) *smtpSink {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &smtpSink{ln: ln}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpSink) serve(conn net.Conn) {
+	defer conn.Close()
+	tc := textproto.NewConn(conn)
+	_ = tc.PrintfLine("220 localhost ESMTP sink")
+	var msg sunkMessage
+	for {
+		line, err := tc.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			_ = tc.PrintfLine("250-localhost\r\n250 8BITMIME")
+		case "MAIL":
+			msg = sunkMessage{from: addrArg(arg)}
+			_ = tc.PrintfLine("250 OK")
+		case "RCPT":
+			msg.to = append(msg.to, addrArg(arg))
+			_ = tc.PrintfLine("250 OK")
+		case "DATA":
+			_ = tc.PrintfLine("354 Go ahead")
+			data, err := io.ReadAll(tc.DotReader())
+			if err != nil {
+				return
+			}
+			msg.data = data
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			_ = tc.PrintfLine("250 Queued")
+		case "QUIT":
+			_ = tc.PrintfLine("221 Bye")
+			return
+		default:
+			_ = tc.PrintfLine("250 OK")
+		}
+	}
+}
+
+// addrArg returns the address in "FROM:<a@b>" or "TO:<a@b>".
+func addrArg(arg string) string {
+	_, addr, _ := strings.Cut(arg, ":")
+	addr, _, _ = strings.Cut(addr, " ")
+	return strings.Trim(addr, "<>")
+}
+
+func (s *smtpSink) Messages() []sunkMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]sunkMessage(nil), s.messages...)
+}
+
+func TestSender_Run(t *testing.T) {
+	sink := newSMTPSink(t)
+
+	weekly := user.DefaultDigestSettings()
+	weekly.Frequency = user.DigestWeekly
+	sentToday := user.DefaultDigestSettings()
+	sentToday.Frequency = user.DigestDaily
+	lastSent := monday.Add(-20 * time.Minute)
+	sentToday.LastSentAt = &lastSent
+	quiet := user.DefaultDigestSettings()
+	quiet.Frequency = user.DigestDaily
+
+	repo := &fakeRepo{
+		recipients: []user.DigestRecipient{
+			{UserID: "1", Email: "asha@example.com", Name: "Asha Rao", Settings: weekly},
+			{UserID: "2", Email: "ravi@example.com", Settings: sentToday},
+			{UserID: "3", Email: "meera@example.com", Settings: quiet},
+		},
+		summaries: map[string][]storedSummary{
+			"1": {
+				{monday.Add(-time.Hour), &ai.AIResult{GmailMessageID: "m1", Category: "job offer", Company: strPtr("Globex"), Role: strPtr("SDE"), Deadline: strPtr("2026-08-19")}},
+				{monday.AddDate(0, 0, -30), &ai.AIResult{GmailMessageID: "m0", Category: "job offer", Company: strPtr("Initech")}},
+			},
+			"2": {
+				{monday.Add(-time.Hour), &ai.AIResult{GmailMessageID: "m2", Category: "job offer"}},
+			},
+		},
+	}
+	mailer := &SMTPMailer{Addr: sink.ln.Addr().String()}
+	s := NewSender(repo, mailer, "AuraMail <digest@auramail.example>", "http://app/dashboard")
+	s.now = func() time.Time { return monday }
+
+	sent, err := s.Run(context.Background())
+	if err != nil || sent != 1 {
+		t.Fatalf("Run = %d, %v; want 1 digest", sent, err)
+	}
+
+	// The empty digest is not sent but counts as sent, so it is not
+	// retried every run; the user already mailed today is left alone.
+	if len(repo.sent) != 2 || !repo.sent["1"].Equal(monday) || !repo.sent["3"].Equal(monday) {
+		t.Errorf("marked sent = %v, want users 1 and 3", repo.sent)
+	}
+
+	messages := sink.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("sink got %d messages, want 1", len(messages))
+	}
+	got := messages[0]
+	if got.from != "digest@auramail.example" || len(got.to) != 1 || got.to[0] != "asha@example.com" {
+		t.Errorf("envelope = %s -> %v", got.from, got.to)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(got.data)))
+	if err != nil {
+		t.Fatalf("read message: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "Your weekly AuraMail digest: 1 new opportunity, 1 deadline this week" {
+		t.Errorf("Subject = %q (%v)", subject, err)
+	}
+	if to := msg.Header.Get("To"); !strings.Contains(to, "asha@example.com") {
+		t.Errorf("To = %q", to)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q (%v)", msg.Header.Get("Content-Type"), err)
+	}
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	bodies := make(map[string]string)
+	for {
+		p, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("next part: %v", err)
+		}
+		body, _ := io.ReadAll(bufio.NewReader(p))
+		contentType, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		bodies[contentType] = string(body)
+	}
+	if !strings.Contains(bodies["text/plain"], "Globex – SDE (deadline Wed 19 Aug)") || strings.Contains(bodies["text/plain"], "Initech") {
+		t.Errorf("unexpected text body:\n%s", bodies["text/plain"])
+	}
+	if !strings.Contains(bodies["text/html"], "Globex – SDE") || !strings.Contains(bodies["text/html"], "http://app/dashboard") {
+		t.Errorf("unexpected html body:\n%s", bodies["text/html"])
+	}
+}
@@ -0,0 +1,241 @@
+// Package eligibility parses the free-form eligibility text extracted from
+// placement emails into typed criteria and matches them against a student's
+// academic profile.
+package eligibility
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Criteria is the structured form of an email's eligibility section. Empty
+// slices and nil pointers mean the email does not restrict that field.
+type Criteria struct {
+	Degrees           []string `json:"degrees,omitempty"`
+	Branches          []string `json:"branches,omitempty"`
+	PassoutYears      []int    `json:"passoutYears,omitempty"`
+	MinCGPA           *float64 `json:"minCgpa,omitempty"`
+	MinTenthPercent   *float64 `json:"minTenthPercent,omitempty"`
+	MinTwelfthPercent *float64 `json:"minTwelfthPercent,omitempty"`
+	MaxActiveBacklogs *int     `json:"maxActiveBacklogs,omitempty"`
+	NoBacklogHistory  bool     `json:"noBacklogHistory,omitempty"`
+	Genders           []string `json:"genders,omitempty"`
+}
+
+// IsEmpty reports whether no restriction was recognised.
+func (c Criteria) IsEmpty() bool {
+	return len(c.Degrees) == 0 && len(c.Branches) == 0 && len(c.PassoutYears) == 0 &&
+		c.MinCGPA == nil && c.MinTenthPercent == nil && c.MinTwelfthPercent == nil &&
+		c.MaxActiveBacklogs == nil && !c.NoBacklogHistory && len(c.Genders) == 0
+}
+
+type alias struct {
+	canonical string
+	pattern   *regexp.Regexp
+}
+
+// Short acronyms are matched case-sensitively so "IT" does not fire on the
+// pronoun "it"; spelled-out names are matched case-insensitively.
+var branchAliases = []alias{
+	{"CSE", regexp.MustCompile(`(?i:\bcomputer\s+science(?:\s+(?:and|&)\s+engineering)?\b)|\bCSE\b|\bCS\b`)},
+	{"IT", regexp.MustCompile(`(?i:\binformation\s+technology\b)|\bIT\b`)},
+	{"ECE", regexp.MustCompile(`(?i:\belectronics\s+(?:and|&)\s+communication(?:\s+engineering)?\b)|\bECE\b`)},
+	{"EEE", regexp.MustCompile(`(?i:\belectrical\s+(?:and|&)\s+electronics(?:\s+engineering)?\b)|\bEEE\b`)},
+	{"EE", regexp.MustCompile(`(?i:\belectrical\s+engineering\b)|\bEE\b`)},
+	{"ME", regexp.MustCompile(`(?i:\bmechanical(?:\s+engineering)?\b)|\bMECH\b|\bME\b`)},
+	{"CE", regexp.MustCompile(`(?i:\bcivil(?:\s+engineering)?\b)|\bCE\b`)},
+	{"CHE", regexp.MustCompile(`(?i:\bchemical(?:\s+engineering)?\b)|\bCHE\b`)},
+	{"AIML", regexp.MustCompile(`(?i:\bartificial\s+intelligence(?:\s+(?:and|&)\s+machine\s+learning)?\b)|\bAI\s*(?:/|&|and)\s*ML\b|\bAIML\b`)},
+	{"DS", regexp.MustCompile(`(?i:\bdata\s+science\b)`)},
+}
+
+var degreeAliases = []alias{
+	{"M.Tech", regexp.MustCompile(`(?i:\bm\.?\s?tech\b)|\bM\.E\.`)},
+	{"B.Tech", regexp.MustCompile(`(?i:\bb\.?\s?tech\b)|\bB\.E\.|\bBE\b`)},
+	{"MCA", regexp.MustCompile(`\bMCA\b`)},
+	{"BCA", regexp.MustCompile(`\bBCA\b`)},
+	{"M.Sc", regexp.MustCompile(`(?i:\bm\.?\s?sc\b)`)},
+	{"B.Sc", regexp.MustCompile(`(?i:\bb\.?\s?sc\b)`)},
+	{"MBA", regexp.MustCompile(`\bMBA\b`)},
+}
+
+var (
+	allBranchesRe = regexp.MustCompile(`(?i)\b(?:all|any)\s+(?:branches|branch|streams|stream|disciplines|departments)\b`)
+	yearCueRe     = regexp.MustCompile(`(?i)batch|pass[\s-]?outs?|passing|graduat|class\s+of`)
+	yearRe        = regexp.MustCompile(`\b20\d{2}\b`)
+	cgpaRe        = regexp.MustCompile(`(?i)\b(?:cgpa|cpi|gpa)\b[^\d\n]{0,25}(\d{1,2}(?:\.\d+)?)|(\d{1,2}(?:\.\d+)?)\s*(?:\+\s*)?(?:cgpa|cpi|gpa)\b`)
+	percentRe     = regexp.MustCompile(`(\d{2}(?:\.\d+)?)\s*%`)
+	tenthRe       = regexp.MustCompile(`(?i)\b10th\b|\bclass\s*(?:x|10)\b|\bssc\b|\bsslc\b`)
+	twelfthRe     = regexp.MustCompile(`(?i)\b12th\b|\bclass\s*(?:xii|12)\b|\bhsc\b|\bxii\b|\bdiploma\b`)
+	throughoutRe  = regexp.MustCompile(`(?i)throughout|all\s+academics|10th\s*(?:,|&|and)\s*12th`)
+
+	noBacklogHistoryRe = regexp.MustCompile(`(?i)no\s+(?:history\s+of\s+backlogs?|backlog\s+history|(?:past|previous|dead)\s+backlogs?)|no\s+backlogs?\s+(?:at\s+any\s+(?:time|stage|point)|ever)|no\s+(?:active|standing)\s+or\s+(?:past|dead|previous)\s+backlogs?`)
+	noActiveBacklogRe  = regexp.MustCompile(`(?i)no\s+(?:(?:active|current|standing|live|pending)\s+)?backlogs?|zero\s+(?:active\s+)?backlogs?`)
+	maxBacklogRe       = regexp.MustCompile(`(?i)(?:max(?:imum)?\.?\s*(?:of\s*)?|up\s*to\s*|not\s+more\s+than\s*|less\s+than\s+or\s+equal\s+to\s*|<=\s*)(\d)\s*(?:active\s+|current\s+|standing\s+)?backlogs?`)
+
+	femaleOnlyRe = regexp.MustCompile(`(?i)\b(?:female|women|girls?)\b[^\n.]{0,25}\bonly\b|\bonly\b[^\n.]{0,25}\b(?:female|women|girls?)\b`)
+	maleOnlyRe   = regexp.MustCompile(`(?i)\b(?:male|men|boys?)\b[^\n.]{0,25}\bonly\b|\bonly\b[^\n.]{0,25}\b(?:male|men|boys?)\b`)
+)
+
+// Parse extracts criteria from eligibility text, typically the bullet list
+// in AIResult.Eligibility. Anything it cannot recognise is left unset.
+func Parse(text string) Criteria {
+	var c Criteria
+	if strings.TrimSpace(text) == "" {
+		return c
+	}
+
+	c.Degrees = matchAliases(degreeAliases, text)
+	if !allBranchesRe.MatchString(text) {
+		c.Branches = matchAliases(branchAliases, text)
+	}
+
+	years := make(map[int]struct{})
+	for _, line := range strings.Split(text, "\n") {
+		if yearCueRe.MatchString(line) {
+			for _, y := range yearRe.FindAllString(line, -1) {
+				n, _ := strconv.Atoi(y)
+				years[n] = struct{}{}
+			}
+		}
+
+		if c.MinCGPA == nil {
+			if m := cgpaRe.FindStringSubmatch(line); m != nil {
+				v := firstNonEmpty(m[1], m[2])
+				if f, err := strconv.ParseFloat(v, 64); err == nil && f > 0 && f <= 10 {
+					c.MinCGPA = &f
+				}
+			}
+		}
+
+		parseSchoolPercentages(line, &c)
+
+		switch {
+		case maxBacklogRe.MatchString(line):
+			n, _ := strconv.Atoi(maxBacklogRe.FindStringSubmatch(line)[1])
+			c.MaxActiveBacklogs = &n
+		case noBacklogHistoryRe.MatchString(line):
+			zero := 0
+			c.MaxActiveBacklogs = &zero
+			c.NoBacklogHistory = true
+		case noActiveBacklogRe.MatchString(line):
+			zero := 0
+			c.MaxActiveBacklogs = &zero
+		}
+
+		switch {
+		case femaleOnlyRe.MatchString(line):
+			c.Genders = []string{"female"}
+		case maleOnlyRe.MatchString(line):
+			c.Genders = []string{"male"}
+		}
+	}
+	for y := range years {
+		c.PassoutYears = append(c.PassoutYears, y)
+	}
+	sort.Ints(c.PassoutYears)
+
+	return c
+}
+
+// parseSchoolPercentages reads minimum 10th/12th percentages from one line.
+// A single percentage on a line that names both (or says "throughout")
+// applies to both; otherwise each keyword takes the nearest percentage.
+func parseSchoolPercentages(line string, c *Criteria) {
+	percents := percentRe.FindAllStringSubmatchIndex(line, -1)
+	if len(percents) == 0 {
+		return
+	}
+	tenth := tenthRe.FindStringIndex(line)
+	twelfth := twelfthRe.FindStringIndex(line)
+
+	if len(percents) == 1 || throughoutRe.MatchString(line) {
+		v, err := strconv.ParseFloat(line[percents[0][2]:percents[0][3]], 64)
+		if err != nil {
+			return
+		}
+		if tenth != nil || throughoutRe.MatchString(line) {
+			c.MinTenthPercent = &v
+		}
+		if twelfth != nil || throughoutRe.MatchString(line) {
+			c.MinTwelfthPercent = &v
+		}
+		return
+	}
+
+	nearest := func(idx []int) *float64 {
+		best, bestDist := -1, len(line)+1
+		for i, p := range percents {
+			dist := p[0] - idx[1]
+			if dist < 0 {
+				dist = idx[0] - p[1]
+			}
+			if dist < 0 {
+				dist = 0
+			}
+			if dist < bestDist {
+				best, bestDist = i, dist
+			}
+		}
+		v, err := strconv.ParseFloat(line[percents[best][2]:percents[best][3]], 64)
+		if err != nil {
+			return nil
+		}
+		return &v
+	}
+	if tenth != nil {
+		c.MinTenthPercent = nearest(tenth)
+	}
+	if twelfth != nil {
+		c.MinTwelfthPercent = nearest(twelfth)
+	}
+}
+
+func matchAliases(aliases []alias, text string) []string {
+	var out []string
+	for _, a := range aliases {
+		if a.pattern.MatchString(text) {
+			out = append(out, a.canonical)
+		}
+	}
+	return out
+}
+
+// NormalizeBranch maps a user-entered branch ("Computer Science", "cse") to
+// the canonical code used in Criteria. Unrecognised input is returned
+// trimmed and upper-cased.
+func NormalizeBranch(branch string) string {
+	return normalize(branchAliases, branch)
+}
+
+// NormalizeDegree maps a user-entered degree ("btech", "B.E.") to the
+// canonical name used in Criteria.
+func NormalizeDegree(degree string) string {
+	return normalize(degreeAliases, degree)
+}
+
+func normalize(aliases []alias, value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return ""
+	}
+	for _, candidate := range []string{value, strings.ToUpper(value)} {
+		for _, a := range aliases {
+			if strings.EqualFold(a.canonical, candidate) || a.pattern.MatchString(candidate) {
+				return a.canonical
+			}
+		}
+	}
+	return strings.ToUpper(value)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,127 @@
+package eligibility
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	text := "• B.Tech / B.E. in CSE, IT and ECE\n" +
+		"• 2026 and 2027 batch only\n" +
+		"• Minimum CGPA of 7.5\n" +
+		"• 60% in 10th and 65% in 12th\n" +
+		"• No active backlogs\n" +
+		"• Open to female candidates only"
+
+	c := Parse(text)
+
+	if !slices.Equal(c.Degrees, []string{"B.Tech"}) {
+		t.Errorf("Degrees = %v", c.Degrees)
+	}
+	if !slices.Equal(c.Branches, []string{"CSE", "IT", "ECE"}) {
+		t.Errorf("Branches = %v", c.Branches)
+	}
+	if !slices.Equal(c.PassoutYears, []int{2026, 2027}) {
+		t.Errorf("PassoutYears = %v", c.PassoutYears)
+	}
+	if c.MinCGPA == nil || *c.MinCGPA != 7.5 {
+		t.Errorf("MinCGPA = %v", c.MinCGPA)
+	}
+	if c.MinTenthPercent == nil || *c.MinTenthPercent != 60 {
+		t.Errorf("MinTenthPercent = %v", c.MinTenthPercent)
+	}
+	if c.MinTwelfthPercent == nil || *c.MinTwelfthPercent != 65 {
+		t.Errorf("MinTwelfthPercent = %v", c.MinTwelfthPercent)
+	}
+	if c.MaxActiveBacklogs == nil || *c.MaxActiveBacklogs != 0 || c.NoBacklogHistory {
+		t.Errorf("backlogs = %v / history %v", c.MaxActiveBacklogs, c.NoBacklogHistory)
+	}
+	if !slices.Equal(c.Genders, []string{"female"}) {
+		t.Errorf("Genders = %v", c.Genders)
+	}
+}
+
+func TestParse_Variants(t *testing.T) {
+	t.Run("all branches and throughout percentage", func(t *testing.T) {
+		c := Parse("All branches eligible\n70% throughout 10th, 12th and graduation\nMaximum 2 active backlogs")
+		if len(c.Branches) != 0 {
+			t.Errorf("Branches = %v, want none", c.Branches)
+		}
+		if c.MinTenthPercent == nil || *c.MinTenthPercent != 70 || c.MinTwelfthPercent == nil || *c.MinTwelfthPercent != 70 {
+			t.Errorf("percentages = %v / %v", c.MinTenthPercent, c.MinTwelfthPercent)
+		}
+		if c.MaxActiveBacklogs == nil || *c.MaxActiveBacklogs != 2 {
+			t.Errorf("MaxActiveBacklogs = %v", c.MaxActiveBacklogs)
+		}
+	})
+
+	t.Run("backlog history and trailing cgpa", func(t *testing.T) {
+		c := Parse("8 CGPA and above\nNo history of backlogs")
+		if c.MinCGPA == nil || *c.MinCGPA != 8 {
+			t.Errorf("MinCGPA = %v", c.MinCGPA)
+		}
+		if !c.NoBacklogHistory || c.MaxActiveBacklogs == nil || *c.MaxActiveBacklogs != 0 {
+			t.Errorf("backlogs = %v / history %v", c.MaxActiveBacklogs, c.NoBacklogHistory)
+		}
+	})
+
+	t.Run("pronoun it is not a branch", func(t *testing.T) {
+		c := Parse("It is open to students of the 2026 batch")
+		if len(c.Branches) != 0 {
+			t.Errorf("Branches = %v, want none", c.Branches)
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		if c := Parse("  "); !c.IsEmpty() {
+			t.Errorf("expected empty criteria, got %+v", c)
+		}
+	})
+}
+
+func TestMatch(t *testing.T) {
+	c := Parse("B.Tech CSE/IT, 2027 batch, CGPA 7.5 and above, no active backlogs")
+	year := 2027
+	cgpa := 8.1
+	backlogs := 0
+	profile := &Profile{Degree: "B.Tech", Branch: "CSE", PassoutYear: &year, CGPA: &cgpa, ActiveBacklogs: &backlogs}
+
+	if got := Match(c, profile); got.Verdict != Yes {
+		t.Errorf("Verdict = %s (%v), want yes", got.Verdict, got.Reasons)
+	}
+
+	low := 7.0
+	lowProfile := *profile
+	lowProfile.CGPA = &low
+	got := Match(c, &lowProfile)
+	if got.Verdict != No || len(got.Reasons) != 1 || got.Reasons[0] != "CGPA 7 below minimum 7.5" {
+		t.Errorf("Match(low cgpa) = %+v", got)
+	}
+
+	partial := &Profile{Branch: "CSE"}
+	if got := Match(c, partial); got.Verdict != Unknown || len(got.Reasons) != 4 {
+		t.Errorf("Match(partial) = %+v, want unknown with four reasons", got)
+	}
+
+	if got := Match(c, nil); got.Verdict != Unknown {
+		t.Errorf("Match(nil profile) = %+v, want unknown", got)
+	}
+	if got := Match(Criteria{}, profile); got.Verdict != Unknown {
+		t.Errorf("Match(empty criteria) = %+v, want unknown", got)
+	}
+}
+
+func TestProfileValidate(t *testing.T) {
+	p := &Profile{Degree: "btech", Branch: "Computer Science", Gender: " Female "}
+	if err := p.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if p.Degree != "B.Tech" || p.Branch != "CSE" || p.Gender != "female" {
+		t.Errorf("normalised profile = %+v", p)
+	}
+
+	cgpa := 11.0
+	if err := (&Profile{CGPA: &cgpa}).Validate(); err == nil {
+		t.Error("expected error for cgpa above 10")
+	}
+}
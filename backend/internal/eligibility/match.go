@@ -0,0 +1,172 @@
+package eligibility
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Profile is a student's academic record as stored in academic_profiles.
+// Nil fields are unknown; a criterion that depends on an unknown field
+// yields an "unknown" verdict rather than a rejection.
+type Profile struct {
+	Degree         string   `json:"degree"`
+	Branch         string   `json:"branch"`
+	PassoutYear    *int     `json:"passoutYear"`
+	CGPA           *float64 `json:"cgpa"`
+	TenthPercent   *float64 `json:"tenthPercent"`
+	TwelfthPercent *float64 `json:"twelfthPercent"`
+	ActiveBacklogs *int     `json:"activeBacklogs"`
+	BacklogHistory *bool    `json:"backlogHistory"`
+	Gender         string   `json:"gender"`
+}
+
+// Validate checks ranges and normalises degree, branch and gender in place.
+func (p *Profile) Validate() error {
+	p.Degree = NormalizeDegree(p.Degree)
+	p.Branch = NormalizeBranch(p.Branch)
+	p.Gender = strings.ToLower(strings.TrimSpace(p.Gender))
+
+	switch {
+	case p.PassoutYear != nil && (*p.PassoutYear < 1990 || *p.PassoutYear > 2100):
+		return fmt.Errorf("passoutYear must be between 1990 and 2100")
+	case p.CGPA != nil && (*p.CGPA < 0 || *p.CGPA > 10):
+		return fmt.Errorf("cgpa must be between 0 and 10")
+	case p.TenthPercent != nil && (*p.TenthPercent < 0 || *p.TenthPercent > 100):
+		return fmt.Errorf("tenthPercent must be between 0 and 100")
+	case p.TwelfthPercent != nil && (*p.TwelfthPercent < 0 || *p.TwelfthPercent > 100):
+		return fmt.Errorf("twelfthPercent must be between 0 and 100")
+	case p.ActiveBacklogs != nil && *p.ActiveBacklogs < 0:
+		return fmt.Errorf("activeBacklogs must not be negative")
+	case p.Gender != "" && p.Gender != "female" && p.Gender != "male" && p.Gender != "other":
+		return fmt.Errorf("gender must be female, male or other")
+	}
+	return nil
+}
+
+type Verdict string
+
+const (
+	Yes     Verdict = "yes"
+	No      Verdict = "no"
+	Unknown Verdict = "unknown"
+)
+
+// Result is the outcome of matching one email's criteria against a profile.
+// Reasons explain every failed or undecidable criterion, or summarise the
+// checks that passed when the verdict is yes.
+type Result struct {
+	Verdict Verdict  `json:"eligible"`
+	Reasons []string `json:"reasons"`
+}
+
+// Match decides whether a student with profile p can apply. Any failed
+// criterion makes the verdict no; otherwise any criterion that cannot be
+// checked makes it unknown.
+func Match(c Criteria, p *Profile) Result {
+	if c.IsEmpty() {
+		return Result{Verdict: Unknown, Reasons: []string{"no eligibility criteria found"}}
+	}
+	if p == nil {
+		return Result{Verdict: Unknown, Reasons: []string{"no academic profile saved"}}
+	}
+
+	var failed, unknown, passed []string
+	check := func(ok, known bool, pass, fail, missing string) {
+		switch {
+		case !known:
+			unknown = append(unknown, missing)
+		case ok:
+			passed = append(passed, pass)
+		default:
+			failed = append(failed, fail)
+		}
+	}
+
+	if len(c.Degrees) > 0 {
+		check(slices.Contains(c.Degrees, p.Degree), p.Degree != "",
+			"degree "+p.Degree+" allowed",
+			fmt.Sprintf("degree %s not in %s", p.Degree, strings.Join(c.Degrees, ", ")),
+			"degree not in profile")
+	}
+	if len(c.Branches) > 0 {
+		check(slices.Contains(c.Branches, p.Branch), p.Branch != "",
+			"branch "+p.Branch+" allowed",
+			fmt.Sprintf("branch %s not in %s", p.Branch, strings.Join(c.Branches, ", ")),
+			"branch not in profile")
+	}
+	if len(c.PassoutYears) > 0 {
+		year := 0
+		if p.PassoutYear != nil {
+			year = *p.PassoutYear
+		}
+		check(slices.Contains(c.PassoutYears, year), p.PassoutYear != nil,
+			fmt.Sprintf("passout year %d allowed", year),
+			fmt.Sprintf("passout year %d not in %s", year, joinInts(c.PassoutYears)),
+			"passout year not in profile")
+	}
+	if c.MinCGPA != nil {
+		check(p.CGPA != nil && *p.CGPA >= *c.MinCGPA, p.CGPA != nil,
+			fmt.Sprintf("CGPA meets minimum %g", *c.MinCGPA),
+			fmt.Sprintf("CGPA %s below minimum %g", fmtFloat(p.CGPA), *c.MinCGPA),
+			"CGPA not in profile")
+	}
+	if c.MinTenthPercent != nil {
+		check(p.TenthPercent != nil && *p.TenthPercent >= *c.MinTenthPercent, p.TenthPercent != nil,
+			fmt.Sprintf("10th percentage meets minimum %g%%", *c.MinTenthPercent),
+			fmt.Sprintf("10th percentage %s below minimum %g%%", fmtFloat(p.TenthPercent), *c.MinTenthPercent),
+			"10th percentage not in profile")
+	}
+	if c.MinTwelfthPercent != nil {
+		check(p.TwelfthPercent != nil && *p.TwelfthPercent >= *c.MinTwelfthPercent, p.TwelfthPercent != nil,
+			fmt.Sprintf("12th percentage meets minimum %g%%", *c.MinTwelfthPercent),
+			fmt.Sprintf("12th percentage %s below minimum %g%%", fmtFloat(p.TwelfthPercent), *c.MinTwelfthPercent),
+			"12th percentage not in profile")
+	}
+	if c.MaxActiveBacklogs != nil {
+		n := 0
+		if p.ActiveBacklogs != nil {
+			n = *p.ActiveBacklogs
+		}
+		check(n <= *c.MaxActiveBacklogs, p.ActiveBacklogs != nil,
+			"active backlogs within limit",
+			fmt.Sprintf("%d active backlogs, at most %d allowed", n, *c.MaxActiveBacklogs),
+			"active backlogs not in profile")
+	}
+	if c.NoBacklogHistory {
+		check(p.BacklogHistory != nil && !*p.BacklogHistory, p.BacklogHistory != nil,
+			"no backlog history",
+			"drive requires no history of backlogs",
+			"backlog history not in profile")
+	}
+	if len(c.Genders) > 0 {
+		check(slices.Contains(c.Genders, p.Gender), p.Gender != "",
+			"gender requirement met",
+			"open to "+strings.Join(c.Genders, ", ")+" candidates only",
+			"gender not in profile")
+	}
+
+	switch {
+	case len(failed) > 0:
+		return Result{Verdict: No, Reasons: failed}
+	case len(unknown) > 0:
+		return Result{Verdict: Unknown, Reasons: unknown}
+	default:
+		return Result{Verdict: Yes, Reasons: passed}
+	}
+}
+
+func fmtFloat(v *float64) string {
+	if v == nil {
+		return "?"
+	}
+	return fmt.Sprintf("%g", *v)
+}
+
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = fmt.Sprint(v)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,118 @@
+// Package embedding turns email summaries and search queries into vectors
+// for semantic search. Providers are pluggable: OpenAI for production and a
+// deterministic feature-hashing provider that works offline and in tests.
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/r7rainz/auramail/internal/ai"
+)
+
+// Provider names accepted by New.
+const (
+	ProviderOpenAI = "openai"
+	ProviderHash   = "hash"
+)
+
+// Provider embeds texts. Vectors from different providers (or models) are
+// not comparable, so stored vectors are tagged with Name.
+type Provider interface {
+	Name() string
+	Embed(ctx context.Context, texts []string) ([][]float32, ai.Usage, error)
+}
+
+// New returns the named provider. An empty name picks OpenAI when a key is
+// configured and the hash provider otherwise.
+func New(name, openAIKey string) (Provider, error) {
+	switch name {
+	case "":
+		if openAIKey != "" {
+			return NewOpenAI(openAIKey), nil
+		}
+		return NewHash(DefaultHashDimensions), nil
+	case ProviderOpenAI:
+		if openAIKey == "" {
+			return nil, ai.ErrOpenAIKeyMissing
+		}
+		return NewOpenAI(openAIKey), nil
+	case ProviderHash:
+		return NewHash(DefaultHashDimensions), nil
+	}
+	return nil, fmt.Errorf("unknown embedding provider %q", name)
+}
+
+// Document is the text embedded for a summary: the fields a student would
+// search on, without links or attachment metadata.
+func Document(res *ai.AIResult) string {
+	var b strings.Builder
+	line := func(label, value string) {
+		if value = strings.TrimSpace(value); value != "" {
+			fmt.Fprintf(&b, "%s: %s\n", label, value)
+		}
+	}
+	ptr := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	line("Subject", res.Subject)
+	line("Company", ptr(res.Company))
+	line("Role", ptr(res.Role))
+	line("Category", res.Category)
+	line("Tags", strings.Join(res.Tags, ", "))
+	line("Location", ai.FieldText(res.Location))
+	line("Compensation", ai.FieldText(res.Salary))
+	line("Eligibility", ai.FieldText(res.Eligibility))
+	line("Summary", res.Summary)
+	return b.String()
+}
+
+// Cosine returns the cosine similarity of a and b, or 0 when their lengths
+// differ or either is zero.
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// Item is a stored vector considered by Rank.
+type Item struct {
+	Key    string
+	Vector []float32
+}
+
+// Scored is an item key with its similarity to the query.
+type Scored struct {
+	Key   string
+	Score float64
+}
+
+// Rank returns the limit items most similar to query, best first. It is the
+// in-process fallback when Postgres has no vector index.
+func Rank(query []float32, items []Item, limit int) []Scored {
+	scored := make([]Scored, 0, len(items))
+	for _, it := range items {
+		scored = append(scored, Scored{Key: it.Key, Score: Cosine(query, it.Vector)})
+	}
+	sort.SliceStable(scored, func(i, j int) bool { return scored[i].Score > scored[j].Score })
+	if limit > 0 && len(scored) > limit {
+		scored = scored[:limit]
+	}
+	return scored
+}
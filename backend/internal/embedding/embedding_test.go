@@ -0,0 +1,90 @@
+package embedding
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/r7rainz/auramail/internal/ai"
+)
+
+func str(s string) *string { return &s }
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name, key, want string
+		wantErr         bool
+	}{
+		{"", "", "hash-256", false},
+		{"", "sk-test", "openai:text-embedding-3-small", false},
+		{ProviderHash, "sk-test", "hash-256", false},
+		{ProviderOpenAI, "", "", true},
+		{"word2vec", "", "", true},
+	}
+	for _, tt := range tests {
+		p, err := New(tt.name, tt.key)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("New(%q) expected error", tt.name)
+			}
+			continue
+		}
+		if err != nil || p.Name() != tt.want {
+			t.Errorf("New(%q, %q) = %v, %v; want %s", tt.name, tt.key, p, err, tt.want)
+		}
+	}
+}
+
+func TestHash_Deterministic(t *testing.T) {
+	h := NewHash(64)
+	a, _, _ := h.Embed(context.Background(), []string{"Remote data science internship"})
+	b, _, _ := h.Embed(context.Background(), []string{"Remote data science internship"})
+	if Cosine(a[0], b[0]) < 0.9999 {
+		t.Fatalf("same text should embed identically")
+	}
+	var norm float64
+	for _, v := range a[0] {
+		norm += float64(v) * float64(v)
+	}
+	if math.Abs(norm-1) > 1e-5 {
+		t.Errorf("vector norm = %v, want 1", norm)
+	}
+}
+
+func TestRank_HashSimilarity(t *testing.T) {
+	h := NewHash(DefaultHashDimensions)
+	docs := map[string]*ai.AIResult{
+		"data": {Subject: "Data Analyst Internship", Company: str("Acme"), Category: "internship", Tags: []string{"remote"},
+			Salary: "• Stipend 40k per month", Summary: "• Remote data analytics internships for 2027 batch"},
+		"sde": {Subject: "SDE hiring drive", Company: str("Globex"), Category: "job offer", Summary: "• On-campus software engineer role, CTC 12 LPA"},
+		"ppt": {Subject: "Pre-placement talk", Company: str("Initech"), Category: "ppt", Summary: "• PPT in the main auditorium"},
+	}
+	keys := []string{"data", "sde", "ppt"}
+	texts := make([]string, len(keys))
+	for i, k := range keys {
+		texts[i] = Document(docs[k])
+	}
+	vectors, _, _ := h.Embed(context.Background(), texts)
+	items := make([]Item, len(keys))
+	for i, k := range keys {
+		items[i] = Item{Key: k, Vector: vectors[i]}
+	}
+
+	query, _, _ := h.Embed(context.Background(), []string{"remote data internships with stipend"})
+	ranked := Rank(query[0], items, 2)
+	if len(ranked) != 2 || ranked[0].Key != "data" {
+		t.Fatalf("Rank() = %+v, want data first", ranked)
+	}
+	if ranked[0].Score <= ranked[1].Score {
+		t.Errorf("scores not descending: %+v", ranked)
+	}
+}
+
+func TestCosine_Mismatched(t *testing.T) {
+	if got := Cosine([]float32{1, 0}, []float32{1, 0, 0}); got != 0 {
+		t.Errorf("Cosine() = %v, want 0 for mismatched lengths", got)
+	}
+	if got := Cosine([]float32{0, 0}, []float32{1, 0}); got != 0 {
+		t.Errorf("Cosine() = %v, want 0 for zero vector", got)
+	}
+}
@@ -0,0 +1,104 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/r7rainz/auramail/internal/ai"
+)
+
+// DefaultHashDimensions is the vector size of the hash provider.
+const DefaultHashDimensions = 256
+
+// Hash embeds text by hashing words and word pairs into a fixed number of
+// signed buckets. It needs no network and is deterministic, so it backs
+// tests and deployments without an OpenAI key; similarity is lexical
+// rather than semantic.
+type Hash struct {
+	dims int
+}
+
+// NewHash returns a hash provider producing vectors of dims dimensions.
+func NewHash(dims int) *Hash {
+	if dims <= 0 {
+		dims = DefaultHashDimensions
+	}
+	return &Hash{dims: dims}
+}
+
+// Name implements Provider.
+func (h *Hash) Name() string {
+	return fmt.Sprintf("%s-%d", ProviderHash, h.dims)
+}
+
+// Embed implements Provider. It never fails and reports no usage.
+func (h *Hash) Embed(_ context.Context, texts []string) ([][]float32, ai.Usage, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = h.embed(text)
+	}
+	return vectors, ai.Usage{}, nil
+}
+
+func (h *Hash) embed(text string) []float32 {
+	vec := make([]float32, h.dims)
+	tokens := hashTokens(text)
+	add := func(feature string, weight float32) {
+		f := fnv.New64a()
+		_, _ = f.Write([]byte(feature))
+		sum := f.Sum64()
+		bucket := int(sum % uint64(h.dims))
+		if sum&(1<<63) != 0 {
+			weight = -weight
+		}
+		vec[bucket] += weight
+	}
+	for i, t := range tokens {
+		add(t, 1)
+		if i > 0 {
+			add(tokens[i-1]+" "+t, 0.5)
+		}
+	}
+
+	var norm float64
+	for _, v := range vec {
+		norm += float64(v) * float64(v)
+	}
+	if norm > 0 {
+		scale := float32(1 / math.Sqrt(norm))
+		for i := range vec {
+			vec[i] *= scale
+		}
+	}
+	return vec
+}
+
+// hashStopWords carry no meaning for search.
+var hashStopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "the": true, "of": true, "for": true, "to": true,
+	"in": true, "on": true, "with": true, "is": true, "are": true, "be": true, "by": true,
+	"at": true, "or": true, "from": true, "as": true, "this": true, "that": true,
+}
+
+// hashTokens lower-cases text, drops stop words and strips a plural "s" so
+// "internships" and "internship" share a feature.
+func hashTokens(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	tokens := make([]string, 0, len(words))
+	for _, w := range words {
+		if hashStopWords[w] {
+			continue
+		}
+		if len(w) > 3 && strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") {
+			w = strings.TrimSuffix(w, "s")
+		}
+		tokens = append(tokens, w)
+	}
+	return tokens
+}
@@ -0,0 +1,70 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sashabaranov/go-openai"
+
+	"github.com/r7rainz/auramail/internal/ai"
+)
+
+// maxOpenAIInputChars keeps each input well under the model's token limit.
+const maxOpenAIInputChars = 16000
+
+// OpenAI embeds text with OpenAI's text-embedding-3-small model.
+type OpenAI struct {
+	client *openai.Client
+	model  openai.EmbeddingModel
+}
+
+// NewOpenAI returns an OpenAI provider using apiKey.
+func NewOpenAI(apiKey string) *OpenAI {
+	return &OpenAI{client: openai.NewClient(apiKey), model: openai.SmallEmbedding3}
+}
+
+// Name implements Provider.
+func (o *OpenAI) Name() string {
+	return ProviderOpenAI + ":" + string(o.model)
+}
+
+// Embed implements Provider.
+func (o *OpenAI) Embed(ctx context.Context, texts []string) ([][]float32, ai.Usage, error) {
+	if len(texts) == 0 {
+		return nil, ai.Usage{}, nil
+	}
+	inputs := make([]string, len(texts))
+	for i, t := range texts {
+		if len(t) > maxOpenAIInputChars {
+			t = t[:maxOpenAIInputChars]
+		}
+		inputs[i] = t
+	}
+
+	resp, err := o.client.CreateEmbeddings(ctx, openai.EmbeddingRequestStrings{
+		Input: inputs,
+		Model: o.model,
+	})
+	if err != nil {
+		return nil, ai.Usage{}, fmt.Errorf("openai error: %w", err)
+	}
+	model := string(o.model)
+	usage := ai.Usage{
+		Operation:    ai.OperationEmbed,
+		Model:        model,
+		PromptTokens: resp.Usage.PromptTokens,
+		CostUSD:      ai.EstimateCost(model, resp.Usage.PromptTokens, 0),
+	}
+	if len(resp.Data) != len(texts) {
+		return nil, usage, ai.ErrInvalidModelReply
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, d := range resp.Data {
+		if d.Index < 0 || d.Index >= len(vectors) {
+			return nil, usage, ai.ErrInvalidModelReply
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, usage, nil
+}
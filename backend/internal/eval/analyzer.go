@@ -0,0 +1,64 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/lang"
+)
+
+// Analyzer produces an AIResult for a corpus case.
+type Analyzer interface {
+	Name() string
+	Analyze(ctx context.Context, c Case) (*ai.AIResult, error)
+}
+
+// RulesAnalyzer runs the offline heuristic analyzer, using the case's
+// received time as "now" so relative dates resolve deterministically.
+type RulesAnalyzer struct{}
+
+func (RulesAnalyzer) Name() string { return ai.RulesAnalysisVersion }
+
+func (RulesAnalyzer) Analyze(_ context.Context, c Case) (*ai.AIResult, error) {
+	return ai.AnalyzeEmailRules(c.Subject, c.Snippet, c.Body, c.ExtractedLinks(), c.ReceivedAt), nil
+}
+
+// OpenAIAnalyzer calls the live model. When Recorder is set every raw reply
+// is written out so the run can be replayed later without network access.
+type OpenAIAnalyzer struct {
+	Recorder *Recorder
+}
+
+func (OpenAIAnalyzer) Name() string { return ai.AnalysisVersion() }
+
+func (a OpenAIAnalyzer) Analyze(ctx context.Context, c Case) (*ai.AIResult, error) {
+	langs := ai.Languages{Email: lang.Detect(c.Subject + "\n" + c.Body)}
+	reply, _, err := ai.RequestAnalysis(ctx, c.Subject, c.Snippet, c.Body, langs)
+	if err != nil {
+		return nil, err
+	}
+	if a.Recorder != nil {
+		if err := a.Recorder.Record(c.ID, reply); err != nil {
+			return nil, fmt.Errorf("record reply: %w", err)
+		}
+	}
+	return ai.ParseAnalysis(reply)
+}
+
+// ReplayAnalyzer feeds recorded model replies through ai.ParseAnalysis. It
+// exercises the parsing and post-processing path with no network access.
+type ReplayAnalyzer struct {
+	Replies map[string]json.RawMessage
+}
+
+func (ReplayAnalyzer) Name() string { return "replay:" + ai.AnalysisVersion() }
+
+func (a ReplayAnalyzer) Analyze(_ context.Context, c Case) (*ai.AIResult, error) {
+	reply, ok := a.Replies[c.ID]
+	if !ok {
+		return nil, fmt.Errorf("no recorded reply for case %s", c.ID)
+	}
+	return ai.ParseAnalysis(string(reply))
+}
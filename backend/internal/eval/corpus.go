@@ -0,0 +1,128 @@
+// Package eval measures the email analyzer against a golden set of
+// anonymized placement emails.
+package eval
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/r7rainz/auramail/internal/utils"
+)
+
+// Case is one anonymized email together with the fields a correct analysis
+// should extract. Expected fields that are nil mean "nothing to extract".
+type Case struct {
+	ID         string    `json:"id"`
+	Subject    string    `json:"subject"`
+	Snippet    string    `json:"snippet"`
+	Body       string    `json:"body"`
+	Links      []Link    `json:"links,omitempty"`
+	ReceivedAt time.Time `json:"receivedAt"`
+	Expected   Expected  `json:"expected"`
+}
+
+type Link struct {
+	URL   string `json:"url"`
+	Label string `json:"label,omitempty"`
+}
+
+type Expected struct {
+	Category  string  `json:"category"`
+	Company   *string `json:"company"`
+	Role      *string `json:"role"`
+	Deadline  *string `json:"deadline"`
+	ApplyLink *string `json:"applyLink"`
+}
+
+// ExtractedLinks converts the case links into the form utils produces from a
+// Gmail payload.
+func (c Case) ExtractedLinks() []utils.ExtractedLink {
+	links := make([]utils.ExtractedLink, 0, len(c.Links))
+	for _, l := range c.Links {
+		links = append(links, utils.ExtractedLink{URL: l.URL, Label: l.Label})
+	}
+	return links
+}
+
+// Response is a recorded raw model reply for one case.
+type Response struct {
+	ID    string          `json:"id"`
+	Reply json.RawMessage `json:"reply"`
+}
+
+// LoadCorpus reads a JSON-lines corpus file.
+func LoadCorpus(path string) ([]Case, error) {
+	var cases []Case
+	err := readJSONLines(path, func(line []byte) error {
+		var c Case
+		if err := json.Unmarshal(line, &c); err != nil {
+			return err
+		}
+		if c.ID == "" {
+			return fmt.Errorf("case without id")
+		}
+		cases = append(cases, c)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load corpus %s: %w", path, err)
+	}
+	return cases, nil
+}
+
+// LoadResponses reads a JSON-lines file of recorded replies keyed by case ID.
+func LoadResponses(path string) (map[string]json.RawMessage, error) {
+	replies := make(map[string]json.RawMessage)
+	err := readJSONLines(path, func(line []byte) error {
+		var r Response
+		if err := json.Unmarshal(line, &r); err != nil {
+			return err
+		}
+		replies[r.ID] = r.Reply
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load responses %s: %w", path, err)
+	}
+	return replies, nil
+}
+
+func readJSONLines(path string, fn func([]byte) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// Recorder writes raw model replies in the format LoadResponses reads.
+type Recorder struct {
+	enc *json.Encoder
+}
+
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{enc: json.NewEncoder(w)}
+}
+
+func (r *Recorder) Record(id, reply string) error {
+	return r.enc.Encode(Response{ID: id, Reply: json.RawMessage(reply)})
+}
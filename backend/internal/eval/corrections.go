@@ -0,0 +1,59 @@
+package eval
+
+import (
+	"net/mail"
+	"time"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/redact"
+)
+
+// CaseFromSummary turns a user-corrected summary into a corpus case whose
+// expectations are the summary's current (corrected) fields. All text goes
+// through s so the case is anonymized like the rest of the corpus; the
+// same session keeps expected values in step with the redacted body.
+func CaseFromSummary(res *ai.AIResult, s *redact.Session) Case {
+	c := Case{
+		ID:      res.GmailMessageID,
+		Subject: s.Redact(res.Subject),
+		Snippet: s.Redact(res.Snippet),
+		Expected: Expected{
+			Category:  res.Category,
+			Company:   redactPtr(s, res.Company),
+			Role:      redactPtr(s, res.Role),
+			Deadline:  res.Deadline,
+			ApplyLink: redactPtr(s, res.ApplyLink),
+		},
+	}
+	if res.Description != nil {
+		c.Body = s.Redact(*res.Description)
+	}
+
+	// LinkLabels line up with the apply link followed by the other links.
+	urls := res.OtherLinks
+	if res.ApplyLink != nil {
+		urls = append([]string{*res.ApplyLink}, urls...)
+	}
+	for i, u := range urls {
+		link := Link{URL: s.Redact(u)}
+		if i < len(res.LinkLabels) {
+			link.Label = res.LinkLabels[i]
+		}
+		c.Links = append(c.Links, link)
+	}
+
+	if t, err := time.Parse(time.RFC3339, res.ReceiverAt); err == nil {
+		c.ReceivedAt = t.UTC()
+	} else if t, err := mail.ParseDate(res.ReceiverAt); err == nil {
+		c.ReceivedAt = t.UTC()
+	}
+	return c
+}
+
+func redactPtr(s *redact.Session, v *string) *string {
+	if v == nil {
+		return nil
+	}
+	redacted := s.Redact(*v)
+	return &redacted
+}
@@ -0,0 +1,101 @@
+package eval
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Diff compares a run against a previous report.
+type Diff struct {
+	Scores      []ScoreDelta `json:"scores"`
+	Regressions []CaseChange `json:"regressions"`
+	Fixes       []CaseChange `json:"fixes"`
+}
+
+type ScoreDelta struct {
+	Field           string  `json:"field"`
+	PrecisionBefore float64 `json:"precisionBefore"`
+	PrecisionAfter  float64 `json:"precisionAfter"`
+	RecallBefore    float64 `json:"recallBefore"`
+	RecallAfter     float64 `json:"recallAfter"`
+}
+
+// CaseChange is a field whose correctness flipped between runs.
+type CaseChange struct {
+	ID     string      `json:"id"`
+	Field  string      `json:"field"`
+	Before FieldResult `json:"before"`
+	After  FieldResult `json:"after"`
+}
+
+// Compare reports score deltas and the per-case fields that became wrong
+// (regressions) or right (fixes). Cases present in only one report are
+// ignored so the corpus can grow without producing noise.
+func Compare(prev, cur *Report) Diff {
+	var d Diff
+
+	prevScores := make(map[string]FieldScore, len(prev.Scores))
+	for _, s := range prev.Scores {
+		prevScores[s.Field] = s
+	}
+	for _, s := range cur.Scores {
+		p := prevScores[s.Field]
+		d.Scores = append(d.Scores, ScoreDelta{
+			Field:           s.Field,
+			PrecisionBefore: p.Precision,
+			PrecisionAfter:  s.Precision,
+			RecallBefore:    p.Recall,
+			RecallAfter:     s.Recall,
+		})
+	}
+
+	prevResults := make(map[string]CaseResult, len(prev.Results))
+	for _, cr := range prev.Results {
+		prevResults[cr.ID] = cr
+	}
+	for _, cr := range cur.Results {
+		before, ok := prevResults[cr.ID]
+		if !ok {
+			continue
+		}
+		for _, f := range Fields {
+			b, a := before.Fields[f], cr.Fields[f]
+			switch {
+			case b.Correct && !a.Correct:
+				d.Regressions = append(d.Regressions, CaseChange{ID: cr.ID, Field: f, Before: b, After: a})
+			case !b.Correct && a.Correct:
+				d.Fixes = append(d.Fixes, CaseChange{ID: cr.ID, Field: f, Before: b, After: a})
+			}
+		}
+	}
+
+	byCase := func(changes []CaseChange) {
+		sort.SliceStable(changes, func(i, j int) bool { return changes[i].ID < changes[j].ID })
+	}
+	byCase(d.Regressions)
+	byCase(d.Fixes)
+	return d
+}
+
+func (d Diff) HasRegressions() bool {
+	return len(d.Regressions) > 0
+}
+
+func (d Diff) WriteText(w io.Writer) error {
+	fmt.Fprintln(w, "changes vs baseline:")
+	for _, s := range d.Scores {
+		fmt.Fprintf(w, "  %-10s precision %.2f -> %.2f (%+.2f)  recall %.2f -> %.2f (%+.2f)\n",
+			s.Field,
+			s.PrecisionBefore, s.PrecisionAfter, s.PrecisionAfter-s.PrecisionBefore,
+			s.RecallBefore, s.RecallAfter, s.RecallAfter-s.RecallBefore)
+	}
+	for _, c := range d.Regressions {
+		fmt.Fprintf(w, "  REGRESSION %s %s: %q -> %q (expected %q)\n", c.ID, c.Field, c.Before.Got, c.After.Got, c.After.Expected)
+	}
+	for _, c := range d.Fixes {
+		fmt.Fprintf(w, "  FIXED      %s %s: %q -> %q\n", c.ID, c.Field, c.Before.Got, c.After.Got)
+	}
+	_, err := fmt.Fprintf(w, "%d regressions, %d fixes\n", len(d.Regressions), len(d.Fixes))
+	return err
+}
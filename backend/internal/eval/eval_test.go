@@ -0,0 +1,180 @@
+package eval
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/redact"
+)
+
+func loadTestdata(t *testing.T) ([]Case, map[string]json.RawMessage) {
+	t.Helper()
+	cases, err := LoadCorpus("testdata/corpus.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	replies, err := LoadResponses("testdata/responses.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cases, replies
+}
+
+func TestCorpusHasRecordedReplies(t *testing.T) {
+	cases, replies := loadTestdata(t)
+	if len(cases) == 0 {
+		t.Fatal("empty corpus")
+	}
+	for _, c := range cases {
+		if _, ok := replies[c.ID]; !ok {
+			t.Errorf("case %s has no recorded reply", c.ID)
+		}
+	}
+}
+
+func TestRun_Replay(t *testing.T) {
+	cases, replies := loadTestdata(t)
+	report := Run(context.Background(), ReplayAnalyzer{Replies: replies}, cases)
+
+	if report.Errors != 0 {
+		t.Fatalf("unexpected errors: %d", report.Errors)
+	}
+	scores := make(map[string]FieldScore)
+	for _, s := range report.Scores {
+		scores[s.Field] = s
+	}
+	// The recorded workshop reply is miscategorised as "registration".
+	if s := scores["category"]; s.TP != len(cases)-1 || s.FP != 1 || s.FN != 1 {
+		t.Errorf("category score = %+v", s)
+	}
+	// "Northwind Systems Pvt Ltd" and a trailing slash on the Globex link
+	// must normalise to the expected values.
+	if s := scores["company"]; s.Precision != 1 || s.Recall != 1 {
+		t.Errorf("company score = %+v", s)
+	}
+	if s := scores["applyLink"]; s.Precision != 1 || s.Recall != 1 {
+		t.Errorf("applyLink score = %+v", s)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(`workshop-resume: category: expected "workshop", got "registration"`)) {
+		t.Errorf("text report does not list the miss:\n%s", buf.String())
+	}
+}
+
+func TestRun_MissingReplyCountsAsMiss(t *testing.T) {
+	company := "Acme"
+	cases := []Case{{ID: "a", Expected: Expected{Category: "internship", Company: &company}}}
+	report := Run(context.Background(), ReplayAnalyzer{}, cases)
+
+	if report.Errors != 1 || report.Results[0].Error == "" {
+		t.Fatalf("expected one recorded error, got %+v", report)
+	}
+	for _, s := range report.Scores {
+		switch s.Field {
+		case "category", "company":
+			if s.FN != 1 || s.Recall != 0 {
+				t.Errorf("%s score = %+v, want one false negative", s.Field, s)
+			}
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	cases, replies := loadTestdata(t)
+	prev := Run(context.Background(), ReplayAnalyzer{Replies: replies}, cases)
+
+	changed := make(map[string]json.RawMessage, len(replies))
+	for id, r := range replies {
+		changed[id] = r
+	}
+	wrong, _ := json.Marshal(ai.AIResult{Category: "announcement", Tags: []string{}, OtherLinks: []string{}})
+	changed["internship-northwind"] = wrong
+	var workshop map[string]any
+	if err := json.Unmarshal(replies["workshop-resume"], &workshop); err != nil {
+		t.Fatal(err)
+	}
+	workshop["category"] = "workshop"
+	fixed, _ := json.Marshal(workshop)
+	changed["workshop-resume"] = fixed
+	cur := Run(context.Background(), ReplayAnalyzer{Replies: changed}, cases)
+
+	d := Compare(prev, cur)
+	if !d.HasRegressions() {
+		t.Fatal("expected regressions")
+	}
+	regressed := make(map[string]bool)
+	for _, c := range d.Regressions {
+		if c.ID != "internship-northwind" {
+			t.Errorf("unexpected regression %+v", c)
+		}
+		regressed[c.Field] = true
+	}
+	for _, f := range Fields {
+		if !regressed[f] {
+			t.Errorf("field %s should have regressed", f)
+		}
+	}
+	foundFix := false
+	for _, c := range d.Fixes {
+		if c.ID == "workshop-resume" && c.Field == "category" {
+			foundFix = true
+		}
+	}
+	if !foundFix {
+		t.Errorf("expected workshop-resume category fix, got %+v", d.Fixes)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		field, a, b string
+	}{
+		{"company", "Globex Corporation", "globex"},
+		{"company", "Northwind Systems Pvt. Ltd.", "Northwind  Systems"},
+		{"applyLink", "https://x.example/apply/", "https://x.example/apply"},
+		{"category", " Job Offer", "job offer"},
+	}
+	for _, tt := range tests {
+		if normalize(tt.field, tt.a) != normalize(tt.field, tt.b) {
+			t.Errorf("normalize(%s): %q != %q", tt.field, normalize(tt.field, tt.a), normalize(tt.field, tt.b))
+		}
+	}
+}
+
+func TestCaseFromSummary_AnonymizesAndKeepsCorrections(t *testing.T) {
+	body := "Register at https://forms.gle/abc. Queries: 9876543210"
+	company, link := "Globex", "https://forms.gle/abc"
+	res := &ai.AIResult{
+		GmailMessageID: "m1",
+		Subject:        "Globex drive",
+		Description:    &body,
+		Category:       "job offer",
+		Company:        &company,
+		ApplyLink:      &link,
+		OtherLinks:     []string{"https://globex.example"},
+		LinkLabels:     []string{"Register", "Website"},
+		ReceiverAt:     "Mon, 10 Aug 2026 09:00:00 +0530",
+	}
+
+	c := CaseFromSummary(res, redact.Default().NewSession())
+	if c.ID != "m1" || c.Body != "Register at https://forms.gle/abc. Queries: [PHONE_1]" {
+		t.Errorf("unexpected case: %+v", c)
+	}
+	if c.Expected.Category != "job offer" || *c.Expected.Company != "Globex" || *c.Expected.ApplyLink != link {
+		t.Errorf("unexpected expectations: %+v", c.Expected)
+	}
+	if len(c.Links) != 2 || c.Links[0].Label != "Register" || c.Links[1].URL != "https://globex.example" {
+		t.Errorf("unexpected links: %+v", c.Links)
+	}
+	if c.ReceivedAt.Format(time.RFC3339) != "2026-08-10T03:30:00Z" {
+		t.Errorf("ReceivedAt = %v", c.ReceivedAt)
+	}
+}
@@ -0,0 +1,210 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/r7rainz/auramail/internal/ai"
+)
+
+// Fields are the scored AIResult fields, in report order.
+var Fields = []string{"category", "company", "role", "deadline", "applyLink"}
+
+// Report is the outcome of running one analyzer over a corpus. It is written
+// as JSON so later runs can be diffed against it.
+type Report struct {
+	Analyzer    string       `json:"analyzer"`
+	GeneratedAt time.Time    `json:"generatedAt"`
+	Cases       int          `json:"cases"`
+	Errors      int          `json:"errors"`
+	Scores      []FieldScore `json:"scores"`
+	Results     []CaseResult `json:"results"`
+}
+
+// FieldScore counts extraction outcomes for one field. A prediction is a
+// true positive when it matches a non-empty expectation; any other non-empty
+// prediction is a false positive, and an expectation that was not matched is
+// a false negative.
+type FieldScore struct {
+	Field     string  `json:"field"`
+	TP        int     `json:"tp"`
+	FP        int     `json:"fp"`
+	FN        int     `json:"fn"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+}
+
+type CaseResult struct {
+	ID     string                 `json:"id"`
+	Error  string                 `json:"error,omitempty"`
+	Fields map[string]FieldResult `json:"fields"`
+}
+
+type FieldResult struct {
+	Expected string `json:"expected"`
+	Got      string `json:"got"`
+	Correct  bool   `json:"correct"`
+}
+
+// Run analyzes every case and scores the results. Analyzer errors are
+// recorded on the case and count as misses rather than aborting the run.
+func Run(ctx context.Context, a Analyzer, cases []Case) *Report {
+	report := &Report{
+		Analyzer:    a.Name(),
+		GeneratedAt: time.Now().UTC(),
+		Cases:       len(cases),
+	}
+	scores := make(map[string]*FieldScore, len(Fields))
+	for _, f := range Fields {
+		scores[f] = &FieldScore{Field: f}
+	}
+
+	for _, c := range cases {
+		res, err := a.Analyze(ctx, c)
+		cr := CaseResult{ID: c.ID, Fields: make(map[string]FieldResult, len(Fields))}
+		if err != nil {
+			cr.Error = err.Error()
+			report.Errors++
+			res = &ai.AIResult{}
+		}
+
+		expected := expectedValues(c.Expected)
+		got := resultValues(res)
+		for _, f := range Fields {
+			fr := FieldResult{Expected: expected[f], Got: got[f]}
+			fr.Correct = normalize(f, fr.Expected) == normalize(f, fr.Got)
+			cr.Fields[f] = fr
+
+			s := scores[f]
+			switch {
+			case fr.Got != "" && fr.Expected != "" && fr.Correct:
+				s.TP++
+			case fr.Got != "":
+				s.FP++
+				if fr.Expected != "" {
+					s.FN++
+				}
+			case fr.Expected != "":
+				s.FN++
+			}
+		}
+		report.Results = append(report.Results, cr)
+	}
+
+	for _, f := range Fields {
+		s := scores[f]
+		s.Precision = ratio(s.TP, s.TP+s.FP)
+		s.Recall = ratio(s.TP, s.TP+s.FN)
+		report.Scores = append(report.Scores, *s)
+	}
+	return report
+}
+
+// ratio treats an empty denominator as a perfect score: no predictions means
+// no wrong predictions, and no expectations means nothing was missed.
+func ratio(n, d int) float64 {
+	if d == 0 {
+		return 1
+	}
+	return float64(n) / float64(d)
+}
+
+func expectedValues(e Expected) map[string]string {
+	return map[string]string{
+		"category":  e.Category,
+		"company":   deref(e.Company),
+		"role":      deref(e.Role),
+		"deadline":  deref(e.Deadline),
+		"applyLink": deref(e.ApplyLink),
+	}
+}
+
+func resultValues(r *ai.AIResult) map[string]string {
+	return map[string]string{
+		"category":  r.Category,
+		"company":   deref(r.Company),
+		"role":      deref(r.Role),
+		"deadline":  deref(r.Deadline),
+		"applyLink": deref(r.ApplyLink),
+	}
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return strings.TrimSpace(*s)
+}
+
+var (
+	spaceRe         = regexp.MustCompile(`\s+`)
+	companySuffixRe = regexp.MustCompile(`(?i)[\s,.]+(pvt\.?|private|ltd\.?|limited|inc\.?|llc|llp|corp\.?|corporation)\b.*$`)
+)
+
+// normalize folds formatting differences that should not count as errors:
+// case and whitespace everywhere, legal suffixes on company names and a
+// trailing slash on links.
+func normalize(field, value string) string {
+	value = strings.ToLower(spaceRe.ReplaceAllString(strings.TrimSpace(value), " "))
+	switch field {
+	case "company":
+		value = companySuffixRe.ReplaceAllString(value, "")
+	case "applyLink":
+		value = strings.TrimRight(value, "/")
+	}
+	return value
+}
+
+// LoadReport reads a report previously written by WriteJSON.
+func LoadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parse report %s: %w", path, err)
+	}
+	return &r, nil
+}
+
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteText prints the per-field scores followed by every incorrect field.
+func (r *Report) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "analyzer: %s  cases: %d  errors: %d\n\n", r.Analyzer, r.Cases, r.Errors)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FIELD\tPRECISION\tRECALL\tTP\tFP\tFN")
+	for _, s := range r.Scores {
+		fmt.Fprintf(tw, "%s\t%.2f\t%.2f\t%d\t%d\t%d\n", s.Field, s.Precision, s.Recall, s.TP, s.FP, s.FN)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, cr := range r.Results {
+		if cr.Error != "" {
+			fmt.Fprintf(w, "\n%s: error: %s", cr.ID, cr.Error)
+			continue
+		}
+		for _, f := range Fields {
+			if fr := cr.Fields[f]; !fr.Correct {
+				fmt.Fprintf(w, "\n%s: %s: expected %q, got %q", cr.ID, f, fr.Expected, fr.Got)
+			}
+		}
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
@@ -0,0 +1,186 @@
+package gmail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/r7rainz/auramail/internal/assistant"
+	"github.com/r7rainz/auramail/internal/auth"
+	"github.com/r7rainz/auramail/internal/config"
+	"github.com/r7rainz/auramail/internal/redact"
+	"github.com/r7rainz/auramail/internal/response"
+	"github.com/r7rainz/auramail/internal/user"
+)
+
+const (
+	maxQuestionLength = 500
+	// assistantPool is how many recent summaries retrieval considers.
+	assistantPool = 300
+	// maxSources caps askRequest.Sources.
+	maxSources = 20
+)
+
+// askRequest is the body of POST /assistant/ask.
+type askRequest struct {
+	Question string `json:"question"`
+	// Sources caps how many emails the answer is grounded on; 0 uses
+	// assistant.DefaultSources.
+	Sources int `json:"sources"`
+}
+
+// newAssistant returns the configured assistant model, or the extractive
+// fake when OpenAI was asked for but no key is set, so Ask keeps answering.
+func newAssistant(cfg *config.Config) assistant.LLM {
+	llm, err := assistant.New(cfg.AssistantLLM)
+	if err != nil {
+		slog.Warn("assistant model unavailable, using fake", "mode", cfg.AssistantLLM, "err", err)
+		return assistant.Fake{}
+	}
+	return llm
+}
+
+// Ask answers a question about the user's placement emails. The emails
+// used are sent first as a "sources" event, the answer streams as data
+// events carrying {"delta": ...}, and a final "complete" event lists the
+// gmail message IDs the answer cites.
+func (h *GmailHandler) Ask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(auth.UserIDContextKey).(string)
+	if !ok {
+		response.Unauthorized(w, "No UserID found in context")
+		return
+	}
+
+	var req askRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "Invalid request body", nil)
+		return
+	}
+	req.Question = strings.TrimSpace(req.Question)
+	if req.Question == "" {
+		response.BadRequest(w, "question is required", nil)
+		return
+	}
+	if len(req.Question) > maxQuestionLength {
+		response.BadRequest(w, fmt.Sprintf("question must be at most %d characters", maxQuestionLength), nil)
+		return
+	}
+	if req.Sources < 0 || req.Sources > maxSources {
+		response.BadRequest(w, fmt.Sprintf("sources must be between 1 and %d, or omitted for the default", maxSources), nil)
+		return
+	}
+
+	summaries, err := h.userRepo.ListSummaries(ctx, userID, user.SummaryFilter{Limit: assistantPool})
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load summaries for assistant", "err", err)
+		response.InternalError(w, "Failed to answer question")
+		return
+	}
+	profile, err := h.userRepo.GetAcademicProfile(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			slog.WarnContext(ctx, "failed to load academic profile", "err", err)
+		}
+		profile = nil
+	}
+
+	now := time.Now()
+	query := assistant.ParseQuestion(req.Question, now)
+	sources := assistant.Retrieve(query, summaries, profile, req.Sources)
+
+	// Over budget the answer falls back to listing the sources rather
+	// than failing. A model only sees the question and sources redacted;
+	// the fake runs here and answers from the originals.
+	llm := h.assistant
+	var masked *redact.Session
+	if _, fake := llm.(assistant.Fake); !fake {
+		budget := newAIBudget(ctx, h.userRepo, userID, SyncOptionsFromConfig(h.cfg))
+		if budget.exceeded(ctx) {
+			llm = assistant.Fake{}
+		} else {
+			masked = h.redactor(ctx, userID).NewSession()
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	flusher := w.(http.Flusher)
+
+	sourcesJSON, _ := json.Marshal(sources)
+	_, _ = fmt.Fprintf(w, "event: sources\ndata: %s\n\n", sourcesJSON)
+	flusher.Flush()
+
+	var answer strings.Builder
+	writeDelta := func(delta string) {
+		if delta == "" {
+			return
+		}
+		answer.WriteString(delta)
+		data, _ := json.Marshal(map[string]string{"delta": delta})
+		_, _ = fmt.Fprintf(w, "data: %s\n\n", data)
+		flusher.Flush()
+	}
+	restorer := masked.NewRestorer()
+	usage, err := llm.Answer(ctx, maskAskRequest(masked, assistant.Request{Question: req.Question, Sources: sources, Now: now}), func(delta string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		writeDelta(restorer.Write(delta))
+		return nil
+	})
+	if err == nil {
+		writeDelta(restorer.Flush())
+	}
+	// The tokens are spent even when the client went away, so record them
+	// with a context that outlives the request.
+	if !usage.IsZero() {
+		if usageErr := h.userRepo.RecordAIUsage(context.WithoutCancel(ctx), userID, req.Question, usage); usageErr != nil {
+			slog.Error("Error recording AI usage", "userID", userID, "err", usageErr)
+		}
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		slog.ErrorContext(ctx, "assistant answer failed", "model", llm.Name(), "err", err)
+		sendSSEError(w, "ASSISTANT_ERROR", "Failed to answer question")
+		return
+	}
+
+	done, _ := json.Marshal(map[string]any{
+		"status":    "done",
+		"model":     llm.Name(),
+		"citations": assistant.Citations(answer.String(), sources),
+	})
+	_, _ = fmt.Fprintf(w, "event: complete\ndata: %s\n\n", done)
+	flusher.Flush()
+}
+
+// maskAskRequest returns a copy of req with the question and the text of
+// its sources redacted. Source IDs are kept so the answer can cite them.
+func maskAskRequest(s *redact.Session, req assistant.Request) assistant.Request {
+	req.Question = s.Redact(req.Question)
+	sources := make([]assistant.Source, len(req.Sources))
+	for i, src := range req.Sources {
+		src.Subject = s.Redact(src.Subject)
+		src.Company = s.Redact(src.Company)
+		src.Role = s.Redact(src.Role)
+		src.Deadline = s.Redact(src.Deadline)
+		src.Timings = s.Redact(src.Timings)
+		src.Location = s.Redact(src.Location)
+		src.Summary = s.Redact(src.Summary)
+		sources[i] = src
+	}
+	req.Sources = sources
+	return req
+}
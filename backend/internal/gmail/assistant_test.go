@@ -0,0 +1,142 @@
+package gmail
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/assistant"
+	"github.com/r7rainz/auramail/internal/auth"
+	"github.com/r7rainz/auramail/internal/user"
+)
+
+func ask(t *testing.T, repo *fakeUserRepo, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/assistant/ask", strings.NewReader(body))
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserIDContextKey, "1"))
+	rr := httptest.NewRecorder()
+	h := newTestHandler(repo)
+	h.assistant = assistant.Fake{}
+	h.Ask(rr, req)
+	return rr
+}
+
+func TestAsk_StreamsCitedAnswer(t *testing.T) {
+	amazon, flipkart := "Amazon", "Flipkart"
+	var filter user.SummaryFilter
+	repo := &fakeUserRepo{
+		listSummariesFunc: func(ctx context.Context, userID string, f user.SummaryFilter) ([]*ai.AIResult, error) {
+			filter = f
+			return []*ai.AIResult{
+				{GmailMessageID: "m-oa", Subject: "Online assessment", Company: &amazon, Category: "exam", Timings: "OA on 14 Aug"},
+				{GmailMessageID: "m-other", Subject: "Hiring drive", Company: &flipkart, Category: "job offer"},
+			}, nil
+		},
+	}
+
+	rr := ask(t, repo, `{"question": "When is the Amazon OA?"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if filter.Limit != assistantPool {
+		t.Errorf("expected the retrieval pool to be loaded, got limit %d", filter.Limit)
+	}
+
+	var (
+		sources   []assistant.Source
+		answer    strings.Builder
+		citations []string
+	)
+	event := ""
+	for _, line := range strings.Split(rr.Body.String(), "\n") {
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data := []byte(strings.TrimPrefix(line, "data: "))
+			switch event {
+			case "sources":
+				_ = json.Unmarshal(data, &sources)
+			case "complete":
+				var done struct {
+					Citations []string `json:"citations"`
+				}
+				_ = json.Unmarshal(data, &done)
+				citations = done.Citations
+			default:
+				var d struct {
+					Delta string `json:"delta"`
+				}
+				_ = json.Unmarshal(data, &d)
+				answer.WriteString(d.Delta)
+			}
+		case line == "":
+			event = ""
+		}
+	}
+
+	if len(sources) != 1 || sources[0].GmailMessageID != "m-oa" {
+		t.Errorf("unexpected sources: %+v", sources)
+	}
+	if !strings.Contains(answer.String(), "OA on 14 Aug [m-oa]") {
+		t.Errorf("unexpected answer: %q", answer.String())
+	}
+	if len(citations) != 1 || citations[0] != "m-oa" {
+		t.Errorf("unexpected citations: %v", citations)
+	}
+}
+
+func TestAsk_RequiresQuestion(t *testing.T) {
+	rr := ask(t, &fakeUserRepo{}, `{"question": "  "}`)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rr.Code)
+	}
+}
+
+// disconnectingLLM cancels the request after its first delta, like a
+// client closing the tab mid-answer, and reports the estimate it spent.
+type disconnectingLLM struct {
+	cancel context.CancelFunc
+}
+
+func (l disconnectingLLM) Name() string { return "disconnecting" }
+
+func (l disconnectingLLM) Answer(ctx context.Context, req assistant.Request, onDelta func(string) error) (ai.Usage, error) {
+	_ = onDelta("The OA")
+	l.cancel()
+	usage := ai.Usage{Operation: ai.OperationAssistant, PromptTokens: 120, CompletionTokens: 2}
+	return usage, onDelta(" is on")
+}
+
+func TestAsk_RecordsUsageWhenClientLeaves(t *testing.T) {
+	repo := &fakeUserRepo{
+		listSummariesFunc: func(ctx context.Context, userID string, f user.SummaryFilter) ([]*ai.AIResult, error) {
+			return nil, nil
+		},
+	}
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), auth.UserIDContextKey, "1"))
+	defer cancel()
+	req := httptest.NewRequest(http.MethodPost, "/assistant/ask", strings.NewReader(`{"question": "When is the OA?"}`)).WithContext(ctx)
+	h := newTestHandler(repo)
+	h.assistant = disconnectingLLM{cancel: cancel}
+	h.Ask(httptest.NewRecorder(), req)
+
+	if len(repo.usage) != 1 || repo.usage[0].PromptTokens != 120 {
+		t.Errorf("recorded usage = %+v, want the tokens spent before the client left", repo.usage)
+	}
+}
+
+func TestAsk_SourcesOutOfRange(t *testing.T) {
+	for _, body := range []string{`{"question": "OA?", "sources": -1}`, `{"question": "OA?", "sources": 21}`} {
+		if rr := ask(t, &fakeUserRepo{}, body); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package gmail
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// aiBudget enforces a user's daily AI budget during a single sync. Once the
+// budget is exhausted it stays exhausted for the rest of the sync so the
+// remaining messages are deferred without re-querying usage for each one.
+type aiBudget struct {
+	repo       UserRepository
+	userID     string
+	tokenLimit int64
+	costLimit  float64
+	exhausted  atomic.Bool
+}
+
+// newAIBudget resolves the user's limits: an ai_budgets override wins over
+// the defaults carried in opts.
+func newAIBudget(ctx context.Context, repo UserRepository, userID string, opts SyncOptions) *aiBudget {
+	b := &aiBudget{
+		repo:       repo,
+		userID:     userID,
+		tokenLimit: opts.DailyTokenBudget,
+		costLimit:  opts.DailyCostBudgetUSD,
+	}
+
+	override, err := repo.GetAIBudget(ctx, userID)
+	switch {
+	case err == nil:
+		if override.DailyTokenLimit != nil {
+			b.tokenLimit = *override.DailyTokenLimit
+		}
+		if override.DailyCostLimitUSD != nil {
+			b.costLimit = *override.DailyCostLimitUSD
+		}
+	case !errors.Is(err, pgx.ErrNoRows):
+		slog.Warn("failed to load ai budget override, using defaults", "userID", userID, "err", err)
+	}
+	return b
+}
+
+// exceeded reports whether today's usage has reached either limit. Usage
+// lookups that fail are logged and treated as within budget so a database
+// hiccup does not stall syncing.
+func (b *aiBudget) exceeded(ctx context.Context) bool {
+	if b.exhausted.Load() {
+		return true
+	}
+	if b.tokenLimit <= 0 && b.costLimit <= 0 {
+		return false
+	}
+
+	totals, err := b.repo.GetDailyAIUsage(ctx, b.userID, time.Now())
+	if err != nil {
+		slog.Warn("failed to load daily ai usage", "userID", b.userID, "err", err)
+		return false
+	}
+
+	if (b.tokenLimit > 0 && totals.TotalTokens() >= b.tokenLimit) ||
+		(b.costLimit > 0 && totals.CostUSD >= b.costLimit) {
+		b.exhausted.Store(true)
+		slog.Info("daily ai budget exhausted",
+			"userID", b.userID,
+			"tokens", totals.TotalTokens(),
+			"tokenLimit", b.tokenLimit,
+			"costUSD", totals.CostUSD,
+			"costLimitUSD", b.costLimit,
+		)
+		return true
+	}
+	return false
+}
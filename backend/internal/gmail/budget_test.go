@@ -0,0 +1,51 @@
+package gmail
+
+import (
+	"context"
+	"testing"
+
+	"github.com/r7rainz/auramail/internal/user"
+)
+
+func TestAIBudget_UnlimitedByDefault(t *testing.T) {
+	repo := &fakeUserRepo{aiUsage: &user.AIUsageTotals{PromptTokens: 1_000_000}}
+	b := newAIBudget(context.Background(), repo, "1", SyncOptions{})
+
+	if b.exceeded(context.Background()) {
+		t.Fatal("expected no limit when budgets are zero")
+	}
+}
+
+func TestAIBudget_DefaultTokenLimit(t *testing.T) {
+	repo := &fakeUserRepo{aiUsage: &user.AIUsageTotals{PromptTokens: 900, CompletionTokens: 100}}
+	b := newAIBudget(context.Background(), repo, "1", SyncOptions{DailyTokenBudget: 1000})
+
+	if !b.exceeded(context.Background()) {
+		t.Fatal("expected budget to be exceeded at the token limit")
+	}
+
+	// Once exhausted the budget stays exhausted for the rest of the sync.
+	repo.aiUsage = &user.AIUsageTotals{}
+	if !b.exceeded(context.Background()) {
+		t.Fatal("expected exhausted budget to latch")
+	}
+}
+
+func TestAIBudget_OverrideWins(t *testing.T) {
+	limit := int64(0)
+	cost := 0.5
+	repo := &fakeUserRepo{
+		aiUsage:  &user.AIUsageTotals{PromptTokens: 5000, CostUSD: 0.25},
+		aiBudget: &user.AIBudget{DailyTokenLimit: &limit, DailyCostLimitUSD: &cost},
+	}
+	b := newAIBudget(context.Background(), repo, "1", SyncOptions{DailyTokenBudget: 1000})
+
+	if b.exceeded(context.Background()) {
+		t.Fatal("override should lift the token limit and leave cost within budget")
+	}
+
+	repo.aiUsage = &user.AIUsageTotals{CostUSD: 0.5}
+	if !b.exceeded(context.Background()) {
+		t.Fatal("expected the cost override to be enforced")
+	}
+}
@@ -0,0 +1,207 @@
+package gmail
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/auth"
+	"github.com/r7rainz/auramail/internal/response"
+)
+
+// Timeline event kinds returned by GetCompany.
+const (
+	eventDrive       = "drive"
+	eventApplication = "application"
+	eventRound       = "round"
+	eventResult      = "result"
+	eventDeadline    = "deadline"
+	eventUpdate      = "update"
+)
+
+// categoryEventKind maps analysis categories onto company timeline kinds.
+var categoryEventKind = map[string]string{
+	"internship":   eventDrive,
+	"job offer":    eventDrive,
+	"ppt":          eventDrive,
+	"workshop":     eventDrive,
+	"registration": eventApplication,
+	"reminder":     eventApplication,
+	"exam":         eventRound,
+	"interview":    eventRound,
+	"result":       eventResult,
+}
+
+// timelineEvent is one entry on a company's timeline. Every email yields
+// an event at its received time; emails with a deadline also yield a
+// deadline event on that date.
+type timelineEvent struct {
+	Kind           string  `json:"kind"`
+	Date           string  `json:"date"`
+	GmailMessageID string  `json:"gmailMessageId"`
+	Subject        string  `json:"subject"`
+	Category       string  `json:"category"`
+	Role           *string `json:"role"`
+	Deadline       *string `json:"deadline"`
+	ApplyLink      *string `json:"applyLink"`
+	Summary        string  `json:"summary"`
+
+	at time.Time
+}
+
+// GetCompanies lists the companies the authenticated user has received
+// email from.
+func (h *GmailHandler) GetCompanies(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(auth.UserIDContextKey).(string)
+	if !ok {
+		response.Unauthorized(w, "No UserID found in context")
+		return
+	}
+
+	companies, err := h.userRepo.ListUserCompanies(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to list companies", "err", err)
+		response.InternalError(w, "Failed to load companies")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"success":   true,
+		"companies": companies,
+	})
+}
+
+// GetCompany returns one company with every drive, application, round,
+// result and deadline from the authenticated user's email on one timeline.
+func (h *GmailHandler) GetCompany(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(auth.UserIDContextKey).(string)
+	if !ok {
+		response.Unauthorized(w, "No UserID found in context")
+		return
+	}
+
+	companyID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || companyID <= 0 {
+		response.BadRequest(w, "company id must be a positive integer", nil)
+		return
+	}
+
+	// Companies are shared between users, so one the user has no email
+	// from is reported as missing rather than revealing that it exists.
+	summaries, err := h.userRepo.CompanySummaries(ctx, userID, companyID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load company summaries", "err", err)
+		response.InternalError(w, "Failed to load company")
+		return
+	}
+	if len(summaries) == 0 {
+		response.NotFound(w, "Company not found")
+		return
+	}
+
+	c, err := h.userRepo.GetCompany(ctx, companyID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			response.NotFound(w, "Company not found")
+			return
+		}
+		slog.ErrorContext(ctx, "failed to load company", "err", err)
+		response.InternalError(w, "Failed to load company")
+		return
+	}
+
+	timeline := buildTimeline(summaries)
+	var nextDeadline *string
+	today := time.Now().Format(time.DateOnly)
+	for _, e := range timeline {
+		if e.Kind == eventDeadline && e.Date >= today {
+			date := e.Date
+			nextDeadline = &date
+			break
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"success":      true,
+		"company":      c,
+		"emailCount":   len(summaries),
+		"nextDeadline": nextDeadline,
+		"timeline":     timeline,
+	})
+}
+
+// buildTimeline orders the events for summaries by date. Events whose date
+// cannot be parsed keep their relative order at the end.
+func buildTimeline(summaries []*ai.AIResult) []timelineEvent {
+	events := make([]timelineEvent, 0, len(summaries))
+	for _, s := range summaries {
+		subject := s.Subject
+		if subject == "" {
+			subject = s.Summary
+		}
+		base := timelineEvent{
+			GmailMessageID: s.GmailMessageID,
+			Subject:        subject,
+			Category:       s.Category,
+			Role:           s.Role,
+			Deadline:       s.Deadline,
+			ApplyLink:      s.ApplyLink,
+			Summary:        s.Summary,
+		}
+
+		received := base
+		received.Kind = eventUpdate
+		if kind, ok := categoryEventKind[s.Category]; ok {
+			received.Kind = kind
+		}
+		received.Date = s.ReceiverAt
+		received.at = parseReceivedAt(s.ReceiverAt)
+		events = append(events, received)
+
+		if s.Deadline == nil {
+			continue
+		}
+		if at, err := time.Parse(time.DateOnly, *s.Deadline); err == nil {
+			deadline := base
+			deadline.Kind = eventDeadline
+			deadline.Date = at.Format(time.DateOnly)
+			deadline.at = at
+			events = append(events, deadline)
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		a, b := events[i].at, events[j].at
+		if a.IsZero() || b.IsZero() {
+			return !a.IsZero() && b.IsZero()
+		}
+		return a.Before(b)
+	})
+	return events
+}
+
+// parseReceivedAt reads AIResult.ReceiverAt, which is RFC 3339 for synced
+// messages but falls back to the raw Date header.
+func parseReceivedAt(value string) time.Time {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t
+	}
+	if t, err := mail.ParseDate(value); err == nil {
+		return t
+	}
+	return time.Time{}
+}
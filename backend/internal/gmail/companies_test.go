@@ -0,0 +1,97 @@
+package gmail
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/auth"
+	"github.com/r7rainz/auramail/internal/company"
+)
+
+func TestBuildTimeline(t *testing.T) {
+	deadline := "2026-08-15"
+	badDeadline := "next Friday"
+	timeline := buildTimeline([]*ai.AIResult{
+		{GmailMessageID: "result", Category: "result", ReceiverAt: "2026-08-20T10:00:00Z"},
+		{GmailMessageID: "drive", Category: "job offer", ReceiverAt: "2026-08-01T10:00:00Z", Deadline: &deadline},
+		{GmailMessageID: "undated", Category: "announcement", ReceiverAt: ""},
+		{GmailMessageID: "oa", Category: "exam", ReceiverAt: "Mon, 10 Aug 2026 09:00:00 +0530", Deadline: &badDeadline},
+	})
+
+	want := []struct{ kind, id string }{
+		{eventDrive, "drive"},
+		{eventRound, "oa"},
+		{eventDeadline, "drive"},
+		{eventResult, "result"},
+		{eventUpdate, "undated"},
+	}
+	if len(timeline) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(timeline), len(want), timeline)
+	}
+	for i, w := range want {
+		if timeline[i].Kind != w.kind || timeline[i].GmailMessageID != w.id {
+			t.Errorf("event %d = %s/%s, want %s/%s", i, timeline[i].Kind, timeline[i].GmailMessageID, w.kind, w.id)
+		}
+	}
+	if timeline[2].Date != deadline {
+		t.Errorf("deadline event date = %q", timeline[2].Date)
+	}
+}
+
+func TestGetCompany(t *testing.T) {
+	tcsID := int64(1)
+	repo := &fakeUserRepo{
+		companies: map[int64]*company.Company{
+			1: {ID: 1, Name: "Tata Consultancy Services", Aliases: []string{"TCS", "TCS Digital"}},
+			3: {ID: 3, Name: "Infosys", Domains: []string{"infosys.com"}},
+		},
+		companySummaries: []*ai.AIResult{
+			{GmailMessageID: "m1", Category: "registration", ReceiverAt: "2026-08-01T10:00:00Z", CompanyID: &tcsID},
+		},
+	}
+	h := newTestHandler(repo)
+
+	tests := []struct {
+		id   string
+		code int
+	}{
+		{"1", http.StatusOK},
+		{"2", http.StatusNotFound},
+		// Companies are shared, but only visible to users with mail from them.
+		{"3", http.StatusNotFound},
+		{"abc", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/companies/"+tt.id, nil)
+		req.SetPathValue("id", tt.id)
+		req = req.WithContext(context.WithValue(req.Context(), auth.UserIDContextKey, "1"))
+		rr := httptest.NewRecorder()
+
+		h.GetCompany(rr, req)
+
+		if rr.Code != tt.code {
+			t.Fatalf("id %s: expected %d, got %d", tt.id, tt.code, rr.Code)
+		}
+		if tt.code != http.StatusOK {
+			continue
+		}
+		if strings.Contains(rr.Body.String(), "aliases") {
+			t.Errorf("response exposes aliases: %s", rr.Body.String())
+		}
+		var body struct {
+			Company  company.Company `json:"company"`
+			Timeline []timelineEvent `json:"timeline"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if body.Company.Name != "Tata Consultancy Services" || len(body.Timeline) != 1 || body.Timeline[0].Kind != eventApplication {
+			t.Errorf("unexpected body: %+v", body)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package gmail
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/auth"
+	"github.com/r7rainz/auramail/internal/eval"
+	"github.com/r7rainz/auramail/internal/redact"
+	"github.com/r7rainz/auramail/internal/response"
+	"github.com/r7rainz/auramail/internal/user"
+)
+
+// CorrectEmail lets the user fix the category, company, role, deadline or
+// apply link of one of their emails. The body holds only the fields to
+// change; null or "" clears every field but the category. Corrections
+// survive re-analysis and are recorded as evaluation cases.
+func (h *GmailHandler) CorrectEmail(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(auth.UserIDContextKey).(string)
+	if !ok {
+		response.Unauthorized(w, "No UserID found in context")
+		return
+	}
+
+	gmailID := r.PathValue("gmailMessageId")
+	if gmailID == "" {
+		response.BadRequest(w, "gmailMessageId is required", nil)
+		return
+	}
+
+	var body map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.BadRequest(w, "invalid request format", nil)
+		return
+	}
+	overrides, problems := parseCorrections(body)
+	if len(problems) > 0 {
+		response.BadRequest(w, "invalid correction", problems)
+		return
+	}
+	if len(overrides) == 0 {
+		response.BadRequest(w, "no correctable fields in request", map[string]any{"fields": ai.CorrectableFields})
+		return
+	}
+
+	summary, err := h.userRepo.GetUserSummary(ctx, userID, gmailID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.NotFound(w, "Email not found")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load summary for correction", "err", err)
+		response.InternalError(w, "Failed to update email")
+		return
+	}
+
+	correction := &user.Correction{
+		Overrides:       overrides,
+		AnalysisVersion: summary.AnalysisVersion,
+		Predicted:       make(map[string]*string, len(overrides)),
+	}
+	for field := range overrides {
+		correction.Predicted[field] = summary.FieldValue(field)
+	}
+
+	corrected := *summary
+	corrected.Overrides = make(map[string]*string, len(summary.Overrides)+len(overrides))
+	for field, value := range summary.Overrides {
+		corrected.Overrides[field] = value
+	}
+	for field, value := range overrides {
+		corrected.Overrides[field] = value
+	}
+	corrected.Confidence = make(map[string]float64, len(summary.Confidence))
+	for field, c := range summary.Confidence {
+		corrected.Confidence[field] = c
+	}
+	corrected.ApplyOverrides()
+
+	if name, ok := overrides[ai.FieldCompany]; ok {
+		corrected.CompanyID = nil
+		if name != nil {
+			// Only link to a company that already exists: the user's
+			// spelling stays in the overrides and must not become a shared
+			// company or alias.
+			c, err := h.userRepo.FindCompany(ctx, *name)
+			if err != nil {
+				slog.WarnContext(ctx, "company lookup failed", "id", gmailID, "company", *name, "err", err)
+			} else if c != nil {
+				corrected.CompanyID = &c.ID
+			}
+		}
+		correction.CompanyID = corrected.CompanyID
+	}
+
+	evalCase, err := json.Marshal(eval.CaseFromSummary(&corrected, redact.LoadOrDefault(h.cfg.RedactionProfile).NewSession()))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to build evaluation case", "err", err)
+		response.InternalError(w, "Failed to update email")
+		return
+	}
+	correction.EvalCase = evalCase
+
+	if err := h.userRepo.CorrectSummary(ctx, userID, gmailID, correction); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			response.NotFound(w, "Email not found")
+			return
+		}
+		slog.ErrorContext(ctx, "failed to save correction", "err", err)
+		response.InternalError(w, "Failed to update email")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"success": true,
+		"email":   corrected,
+	})
+}
+
+// parseCorrections validates a correction request, returning the cleaned
+// overrides and a message per invalid field.
+func parseCorrections(body map[string]json.RawMessage) (map[string]*string, map[string]string) {
+	overrides := make(map[string]*string)
+	problems := make(map[string]string)
+
+	for field, raw := range body {
+		var value *string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			problems[field] = "must be a string or null"
+			continue
+		}
+		if value != nil {
+			trimmed := strings.TrimSpace(*value)
+			value = &trimmed
+			if trimmed == "" {
+				value = nil
+			}
+		}
+
+		if msg := validateCorrection(field, value); msg != "" {
+			problems[field] = msg
+			continue
+		}
+		overrides[field] = value
+	}
+	return overrides, problems
+}
+
+func validateCorrection(field string, value *string) string {
+	switch field {
+	case ai.FieldCategory:
+		if value == nil {
+			return "is required"
+		}
+		*value = strings.ToLower(*value)
+		if t := ai.AnalysisTemplate(); !t.HasCategory(*value) {
+			names := make([]string, 0, len(t.Categories))
+			for _, c := range t.Categories {
+				names = append(names, c.Name)
+			}
+			return "must be one of: " + strings.Join(names, ", ")
+		}
+	case ai.FieldDeadline:
+		if value != nil {
+			if _, err := time.Parse(time.DateOnly, *value); err != nil {
+				return "must be a date in YYYY-MM-DD format"
+			}
+		}
+	case ai.FieldApplyLink:
+		if value != nil {
+			u, err := url.Parse(*value)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return "must be an http(s) URL"
+			}
+		}
+	case ai.FieldCompany, ai.FieldRole:
+		if value != nil && len(*value) > 200 {
+			return fmt.Sprintf("must be at most %d characters", 200)
+		}
+	default:
+		return "cannot be corrected"
+	}
+	return ""
+}
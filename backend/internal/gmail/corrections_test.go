@@ -0,0 +1,126 @@
+package gmail
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/auth"
+	"github.com/r7rainz/auramail/internal/company"
+	"github.com/r7rainz/auramail/internal/eval"
+)
+
+func correctRequest(gmailID, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPatch, "/emails/"+gmailID, strings.NewReader(body))
+	req.SetPathValue("gmailMessageId", gmailID)
+	return req.WithContext(context.WithValue(req.Context(), auth.UserIDContextKey, "1"))
+}
+
+func TestCorrectEmail_RecordsOverridesAndEvalCase(t *testing.T) {
+	companyName, role := "Amazn", "SDE"
+	description := "Call 9876543210 to confirm your OA slot."
+	repo := &fakeUserRepo{
+		getSummaryFunc: func(ctx context.Context, gmailID string) (*ai.AIResult, error) {
+			return &ai.AIResult{
+				GmailMessageID:  gmailID,
+				Subject:         "Amazon OA",
+				Category:        "announcement",
+				Company:         &companyName,
+				Role:            &role,
+				Description:     &description,
+				AnalysisVersion: "detailed-v5",
+				Confidence:      map[string]float64{"category": 0.4, "company": 0.6},
+			}, nil
+		},
+		companies: map[int64]*company.Company{11: {ID: 11, Name: "Amazon"}},
+	}
+	h := newTestHandler(repo)
+
+	rr := httptest.NewRecorder()
+	h.CorrectEmail(rr, correctRequest("m1", `{"category":"Exam","company":"Amazon","role":"","deadline":"2026-08-14"}`))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	c := repo.corrections["m1"]
+	if c == nil {
+		t.Fatal("correction was not saved")
+	}
+	if got := c.Overrides[ai.FieldCategory]; got == nil || *got != "exam" {
+		t.Errorf("category override = %v, want exam", got)
+	}
+	if v, ok := c.Overrides[ai.FieldRole]; !ok || v != nil {
+		t.Errorf("empty role should clear the field, got %v (present %v)", v, ok)
+	}
+	if c.CompanyID == nil || *c.CompanyID != 11 {
+		t.Errorf("company id = %v, want 11", c.CompanyID)
+	}
+	if got := c.Predicted[ai.FieldCompany]; got == nil || *got != "Amazn" {
+		t.Errorf("predicted company = %v, want Amazn", got)
+	}
+	if _, ok := c.Predicted[ai.FieldDeadline]; !ok || c.Predicted[ai.FieldDeadline] != nil {
+		t.Errorf("predicted deadline should be recorded as empty, got %v", c.Predicted[ai.FieldDeadline])
+	}
+	if c.AnalysisVersion != "detailed-v5" {
+		t.Errorf("analysis version = %q", c.AnalysisVersion)
+	}
+
+	var evalCase eval.Case
+	if err := json.Unmarshal(c.EvalCase, &evalCase); err != nil {
+		t.Fatalf("eval case is not a corpus case: %v", err)
+	}
+	if evalCase.Expected.Category != "exam" || strings.Contains(evalCase.Body, "9876543210") {
+		t.Errorf("unexpected eval case: %+v", evalCase)
+	}
+
+	var resp struct {
+		Email ai.AIResult `json:"email"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Email.Category != "exam" || resp.Email.Role != nil || resp.Email.Confidence["company"] != 1 || resp.Email.Confidence["category"] != 1 {
+		t.Errorf("response does not reflect the correction: %+v", resp.Email)
+	}
+}
+
+func TestCorrectEmail_Errors(t *testing.T) {
+	repo := &fakeUserRepo{
+		getSummaryFunc: func(ctx context.Context, gmailID string) (*ai.AIResult, error) {
+			if gmailID != "m1" {
+				return nil, nil
+			}
+			return &ai.AIResult{GmailMessageID: gmailID, Category: "exam"}, nil
+		},
+	}
+	h := newTestHandler(repo)
+
+	for name, tc := range map[string]struct {
+		id, body string
+		want     int
+	}{
+		"bad json":        {"m1", `nope`, http.StatusBadRequest},
+		"no fields":       {"m1", `{}`, http.StatusBadRequest},
+		"unknown field":   {"m1", `{"summary":"x"}`, http.StatusBadRequest},
+		"bad category":    {"m1", `{"category":"spam"}`, http.StatusBadRequest},
+		"null category":   {"m1", `{"category":null}`, http.StatusBadRequest},
+		"bad deadline":    {"m1", `{"deadline":"next friday"}`, http.StatusBadRequest},
+		"bad apply link":  {"m1", `{"applyLink":"javascript:alert(1)"}`, http.StatusBadRequest},
+		"non-string":      {"m1", `{"company":42}`, http.StatusBadRequest},
+		"unknown email":   {"m2", `{"company":"Amazon"}`, http.StatusNotFound},
+		"clear applyLink": {"m1", `{"applyLink":null}`, http.StatusOK},
+	} {
+		rr := httptest.NewRecorder()
+		h.CorrectEmail(rr, correctRequest(tc.id, tc.body))
+		if rr.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d: %s", name, tc.want, rr.Code, rr.Body.String())
+		}
+	}
+	if len(repo.corrections) != 1 {
+		t.Errorf("only the valid request should be saved, got %d", len(repo.corrections))
+	}
+}
@@ -0,0 +1,127 @@
+// Package gmailtest provides an in-memory fake of the Gmail API for tests.
+// It serves the message and draft endpoints AuraMail uses and records every
+// attempt to send mail, which the app must never do.
+package gmailtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+)
+
+// Server is a fake Gmail API backed by httptest.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	messages map[string]*gmail.Message
+	drafts   []*gmail.Draft
+	sends    int
+}
+
+// NewServer starts a fake Gmail API. Close it when done.
+func NewServer() *Server {
+	s := &Server{messages: make(map[string]*gmail.Message)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /gmail/v1/users/{userId}/messages", s.listMessages)
+	mux.HandleFunc("GET /gmail/v1/users/{userId}/messages/{id}", s.getMessage)
+	mux.HandleFunc("POST /gmail/v1/users/{userId}/drafts", s.createDraft)
+	mux.HandleFunc("POST /gmail/v1/users/{userId}/messages/send", s.send)
+	mux.HandleFunc("POST /gmail/v1/users/{userId}/drafts/send", s.send)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Service returns a Gmail client that talks to the fake.
+func (s *Server) Service(ctx context.Context) (*gmail.Service, error) {
+	return gmail.NewService(ctx, option.WithEndpoint(s.URL+"/"), option.WithHTTPClient(s.Client()))
+}
+
+// AddMessage makes m available to messages.get.
+func (s *Server) AddMessage(m *gmail.Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages[m.Id] = m
+}
+
+// Drafts returns the drafts created so far.
+func (s *Server) Drafts() []*gmail.Draft {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*gmail.Draft(nil), s.drafts...)
+}
+
+// Sends returns how many times a send endpoint was called.
+func (s *Server) Sends() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sends
+}
+
+// listMessages returns every message sorted by ID; the search query is
+// ignored.
+func (s *Server) listMessages(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	list := &gmail.ListMessagesResponse{}
+	for id, m := range s.messages {
+		list.Messages = append(list.Messages, &gmail.Message{Id: id, ThreadId: m.ThreadId})
+	}
+	s.mu.Unlock()
+	sort.Slice(list.Messages, func(i, j int) bool { return list.Messages[i].Id < list.Messages[j].Id })
+	list.ResultSizeEstimate = int64(len(list.Messages))
+	writeJSON(w, list)
+}
+
+func (s *Server) getMessage(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	m, ok := s.messages[r.PathValue("id")]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "Requested entity was not found.")
+		return
+	}
+	writeJSON(w, m)
+}
+
+func (s *Server) createDraft(w http.ResponseWriter, r *http.Request) {
+	var d gmail.Draft
+	if err := json.NewDecoder(r.Body).Decode(&d); err != nil || d.Message == nil || d.Message.Raw == "" {
+		writeError(w, http.StatusBadRequest, "Missing draft message")
+		return
+	}
+
+	s.mu.Lock()
+	d.Id = fmt.Sprintf("r-%d", len(s.drafts)+1)
+	d.Message.Id = fmt.Sprintf("draft-msg-%d", len(s.drafts)+1)
+	s.drafts = append(s.drafts, &d)
+	s.mu.Unlock()
+	writeJSON(w, &d)
+}
+
+func (s *Server) send(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	s.sends++
+	s.mu.Unlock()
+	writeError(w, http.StatusForbidden, "gmailtest: sending mail is not allowed")
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]any{"code": status, "message": message},
+	})
+}
@@ -9,16 +9,24 @@ import (
 	"log"
 	"log/slog"
 	"net/http"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	gmailapi "google.golang.org/api/gmail/v1"
 
 	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/assistant"
 	"github.com/r7rainz/auramail/internal/auth"
 	"github.com/r7rainz/auramail/internal/auth/google"
+	"github.com/r7rainz/auramail/internal/calendar"
+	"github.com/r7rainz/auramail/internal/company"
+	"github.com/r7rainz/auramail/internal/compensation"
 	"github.com/r7rainz/auramail/internal/config"
+	"github.com/r7rainz/auramail/internal/eligibility"
+	"github.com/r7rainz/auramail/internal/embedding"
 	"github.com/r7rainz/auramail/internal/response"
 	"github.com/r7rainz/auramail/internal/user"
 	"github.com/r7rainz/auramail/internal/utils"
@@ -31,23 +39,78 @@ import (
 type UserRepository interface {
 	FindByID(ctx context.Context, id string) (*user.User, error)
 	GetSummariesByQuery(ctx context.Context, userID string, searchQuery string) ([]*ai.AIResult, error)
+	ListSummaries(ctx context.Context, userID string, filter user.SummaryFilter) ([]*ai.AIResult, error)
+	CompensationStats(ctx context.Context, userID string) (byCompany, byCategory []user.CompensationStat, err error)
 	GetSummary(ctx context.Context, gmailID string) (*ai.AIResult, error)
 	SaveSummary(ctx context.Context, userID string, gmailID string, res *ai.AIResult) error
 	SetImportant(ctx context.Context, userID string, gmailID string, important bool) error
+	RecordAIUsage(ctx context.Context, userID string, query string, usage ai.Usage) error
+	GetDailyAIUsage(ctx context.Context, userID string, day time.Time) (*user.AIUsageTotals, error)
+	GetAIBudget(ctx context.Context, userID string) (*user.AIBudget, error)
+	GetAcademicProfile(ctx context.Context, userID string) (*eligibility.Profile, error)
+	ResolveCompany(ctx context.Context, name, sender string) (*company.Company, error)
+	FindCompany(ctx context.Context, name string) (*company.Company, error)
+	GetCompany(ctx context.Context, id int64) (*company.Company, error)
+	ListUserCompanies(ctx context.Context, userID string) ([]user.CompanyOverview, error)
+	CompanySummaries(ctx context.Context, userID string, companyID int64) ([]*ai.AIResult, error)
+	ThreadSummaries(ctx context.Context, userID, threadID string) ([]*ai.AIResult, error)
+	GetThreadRollup(ctx context.Context, userID, threadID string) (*user.ThreadRollup, error)
+	SaveThreadRollup(ctx context.Context, userID string, rollup *user.ThreadRollup) error
+	MatchOpportunity(ctx context.Context, userID string, res *ai.AIResult) (string, error)
+	OpportunitySummaries(ctx context.Context, userID string, opportunityIDs []string) (map[string][]*ai.AIResult, error)
+	SaveEmbedding(ctx context.Context, userID, gmailID, provider string, vec []float32) error
+	GetEmbedding(ctx context.Context, userID, gmailID, provider string) ([]float32, error)
+	SemanticSearch(ctx context.Context, userID, provider string, query []float32, excludeGmailID string, limit int) ([]user.ScoredSummary, error)
+	GetUserSummary(ctx context.Context, userID, gmailID string) (*ai.AIResult, error)
+	HasGoogleScope(ctx context.Context, userID, scope string) (bool, error)
+	GetPreferences(ctx context.Context, userID string) (*user.Preferences, error)
+	GetCalendarRules(ctx context.Context, userID string) (*user.CalendarRules, error)
+	GetCalendarLink(ctx context.Context, userID, key, kind string) (*user.CalendarLink, error)
+	SaveCalendarLink(ctx context.Context, userID string, link *user.CalendarLink) error
+	ListCalendarLinks(ctx context.Context, userID string, from time.Time) ([]user.CalendarLink, error)
+	AddNotification(ctx context.Context, userID string, n *user.Notification) error
+	CorrectSummary(ctx context.Context, userID, gmailID string, c *user.Correction) error
+	SavePushSubscription(ctx context.Context, userID string, s *user.PushSubscription) error
+	DeletePushSubscription(ctx context.Context, userID, endpoint string) (bool, error)
+	DeleteExpiredPushSubscription(ctx context.Context, endpoint string) error
+	ListPushSubscriptions(ctx context.Context, userID string) ([]user.PushSubscription, error)
 }
 
 type GmailHandler struct {
-	userRepo UserRepository
-	cfg      *config.Config
+	userRepo  UserRepository
+	cfg       *config.Config
+	embedder  embedding.Provider
+	assistant assistant.LLM
+	calendar  EventScheduler
+	notifier  EmailNotifier
+	// gmailService builds the user's Gmail client; tests point it at a
+	// gmailtest server.
+	gmailService func(ctx context.Context, refreshToken string) (*gmailapi.Service, error)
 }
 
 func NewHandler(cfg *config.Config, repo UserRepository) *GmailHandler {
 	return &GmailHandler{
-		userRepo: repo,
-		cfg:      cfg,
+		userRepo:     repo,
+		cfg:          cfg,
+		embedder:     newEmbedder(cfg),
+		assistant:    newAssistant(cfg),
+		calendar:     calendar.NewAutoScheduler(repo, calendar.GoogleClient{}),
+		notifier:     PushNotifier(cfg, repo),
+		gmailService: google.CreateGmailService,
 	}
 }
 
+// syncOptions are the options for syncs started by the user.
+func (h *GmailHandler) syncOptions() SyncOptions {
+	opts := SyncOptionsFromConfig(h.cfg)
+	opts.Calendar = h.calendar
+	opts.Notifier = h.notifier
+	return opts
+}
+
+// currencyCode matches an ISO 4217 currency code.
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
 // GetEmails returns stored email summaries for the authenticated user
 func (h *GmailHandler) GetEmails(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -60,6 +123,10 @@ func (h *GmailHandler) GetEmails(w http.ResponseWriter, r *http.Request) {
 
 	// Get optional query params
 	searchQuery := r.URL.Query().Get("q")
+	eligibleOnly := r.URL.Query().Get("eligibleOnly") == "true"
+	// collapse folds reminders, extensions and corrigenda of one
+	// opportunity into a single card.
+	collapse := r.URL.Query().Get("collapse") == "true"
 	pageStr := r.URL.Query().Get("page")
 	page := 1
 	if pageStr != "" {
@@ -68,43 +135,111 @@ func (h *GmailHandler) GetEmails(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// minCtc is an annualized amount in currency (INR by default), e.g.
+	// 1000000 for 10 LPA. The CTC sorts rank that currency only.
+	filter := user.SummaryFilter{
+		Query:    searchQuery,
+		Kind:     compensation.Kind(r.URL.Query().Get("compKind")),
+		Sort:     r.URL.Query().Get("sort"),
+		Currency: strings.ToUpper(r.URL.Query().Get("currency")),
+	}
+	if filter.Currency != "" && !currencyCode.MatchString(filter.Currency) {
+		response.BadRequest(w, "currency must be a three-letter code such as INR or USD", nil)
+		return
+	}
+	if v := r.URL.Query().Get("minCtc"); v != "" {
+		minCTC, err := strconv.ParseFloat(v, 64)
+		if err != nil || minCTC < 0 {
+			response.BadRequest(w, "minCtc must be a non-negative number", nil)
+			return
+		}
+		filter.MinCTC = &minCTC
+	}
+	if filter.Kind != "" && filter.Kind != compensation.KindCTC && filter.Kind != compensation.KindStipend {
+		response.BadRequest(w, "compKind must be ctc or stipend", nil)
+		return
+	}
+	if !user.ValidSummarySort(filter.Sort) {
+		response.BadRequest(w, "sort must be newest, ctc_desc or ctc_asc", nil)
+		return
+	}
+
 	// Fetch stored summaries from database
-	summaries, err := h.userRepo.GetSummariesByQuery(ctx, userID, searchQuery)
+	summaries, err := h.userRepo.ListSummaries(ctx, userID, filter)
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to fetch email summaries", "err", err)
 		response.InternalError(w, "Failed to fetch emails")
 		return
 	}
 
+	// Without a saved profile every verdict is "unknown"; that is not an
+	// error for the listing.
+	profile, err := h.userRepo.GetAcademicProfile(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			slog.WarnContext(ctx, "failed to load academic profile", "err", err)
+		}
+		profile = nil
+	}
+
+	// Tests and interviews are checked against each other only; comparing
+	// with the user's Google Calendar is left to /calendar/conflicts.
+	rules, err := h.userRepo.GetCalendarRules(ctx, userID)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to load calendar rules", "err", err)
+		defaults := user.DefaultCalendarRules()
+		rules = &defaults
+	}
+	conflicts := calendar.FindConflicts(calendar.EmailSlots(summaries, rules, time.Now()), nil)
+
+	var cards map[string]*opportunityCard
+	if collapse {
+		summaries, cards, err = h.collapseOpportunities(ctx, userID, summaries)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to collapse duplicate emails", "err", err)
+			response.InternalError(w, "Failed to fetch emails")
+			return
+		}
+	}
+
 	// Transform to frontend expected format
 	type emailResponse struct {
-		ID                string                 `json:"id"`
-		GmailMessageID    string                 `json:"gmailMessageId"`
-		ThreadID          string                 `json:"threadId"`
-		Subject           string                 `json:"subject"`
-		Sender            string                 `json:"sender"`
-		Snippet           string                 `json:"snippet"`
-		ReceivedAt        string                 `json:"receivedAt"`
-		Company           *string                `json:"company"`
-		Role              *string                `json:"role"`
-		Deadline          *string                `json:"deadline"`
-		ApplyLink         *string                `json:"applyLink"`
-		OtherLinks        []string               `json:"otherLinks"`
-		LinkLabels        []string               `json:"linkLabels,omitempty"`
-		Eligibility       any                    `json:"eligibility"`
-		Timings           any                    `json:"timings"`
-		Salary            any                    `json:"salary"`
-		Location          any                    `json:"location"`
-		EventDetails      any                    `json:"eventDetails"`
-		Requirements      any                    `json:"requirements"`
-		Description       *string                `json:"description"`
-		AttachmentSummary *string                `json:"attachmentSummary"`
-		Attachments       []utils.AttachmentMeta `json:"attachments"`
-		Category          string                 `json:"category"`
-		Tags              []string               `json:"tags"`
-		Priority          string                 `json:"priority"`
-		Summary           string                 `json:"summary"`
-		Important         bool                   `json:"important"`
+		ID                  string                     `json:"id"`
+		GmailMessageID      string                     `json:"gmailMessageId"`
+		ThreadID            string                     `json:"threadId"`
+		Subject             string                     `json:"subject"`
+		Sender              string                     `json:"sender"`
+		Snippet             string                     `json:"snippet"`
+		ReceivedAt          string                     `json:"receivedAt"`
+		Company             *string                    `json:"company"`
+		CompanyID           *int64                     `json:"companyId"`
+		Role                *string                    `json:"role"`
+		Deadline            *string                    `json:"deadline"`
+		ApplyLink           *string                    `json:"applyLink"`
+		OtherLinks          []string                   `json:"otherLinks"`
+		LinkLabels          []string                   `json:"linkLabels,omitempty"`
+		Eligibility         any                        `json:"eligibility"`
+		Timings             any                        `json:"timings"`
+		Salary              any                        `json:"salary"`
+		Location            any                        `json:"location"`
+		EventDetails        any                        `json:"eventDetails"`
+		Requirements        any                        `json:"requirements"`
+		Description         *string                    `json:"description"`
+		AttachmentSummary   *string                    `json:"attachmentSummary"`
+		Attachments         []utils.AttachmentMeta     `json:"attachments"`
+		Category            string                     `json:"category"`
+		Tags                []string                   `json:"tags"`
+		Priority            string                     `json:"priority"`
+		Summary             string                     `json:"summary"`
+		Important           bool                       `json:"important"`
+		Eligible            eligibility.Verdict        `json:"eligible"`
+		EligibilityReasons  []string                   `json:"eligibilityReasons"`
+		EligibilityCriteria *eligibility.Criteria      `json:"eligibilityCriteria,omitempty"`
+		Compensation        *compensation.Compensation `json:"compensation"`
+		OpportunityID       string                     `json:"opportunityId,omitempty"`
+		Revisions           []string                   `json:"revisions,omitempty"`
+		Changes             []string                   `json:"changes,omitempty"`
+		Conflicts           []calendar.Conflict        `json:"conflicts,omitempty"`
 	}
 
 	emails := make([]emailResponse, 0, len(summaries))
@@ -114,34 +249,67 @@ func (h *GmailHandler) GetEmails(w http.ResponseWriter, r *http.Request) {
 			subject = s.Summary
 		}
 
+		criteria := eligibility.Parse(ai.FieldText(s.Eligibility))
+		verdict := eligibility.Match(criteria, profile)
+		if eligibleOnly && verdict.Verdict == eligibility.No {
+			continue
+		}
+		var criteriaOut *eligibility.Criteria
+		if !criteria.IsEmpty() {
+			criteriaOut = &criteria
+		}
+		deadline := s.Deadline
+		var clashes []calendar.Conflict
+		for _, c := range conflicts {
+			if c.Involves(s.GmailMessageID) {
+				clashes = append(clashes, c)
+			}
+		}
+		var revisions, changes []string
+		if card := cards[s.GmailMessageID]; card != nil {
+			revisions, changes = card.Revisions, card.Changes
+			if card.Deadline != nil {
+				deadline = card.Deadline
+			}
+		}
+
 		emails = append(emails, emailResponse{
-			ID:                s.GmailMessageID,
-			GmailMessageID:    s.GmailMessageID,
-			ThreadID:          s.ThreadID,
-			Subject:           subject,
-			Sender:            s.Sender,
-			Snippet:           s.Summary,
-			ReceivedAt:        s.ReceiverAt,
-			Company:           s.Company,
-			Role:              s.Role,
-			Deadline:          s.Deadline,
-			ApplyLink:         s.ApplyLink,
-			OtherLinks:        s.OtherLinks,
-			LinkLabels:        s.LinkLabels,
-			Eligibility:       s.Eligibility,
-			Timings:           s.Timings,
-			Salary:            s.Salary,
-			Location:          s.Location,
-			EventDetails:      s.EventDetails,
-			Requirements:      s.Requirements,
-			Description:       s.Description,
-			AttachmentSummary: s.AttachmentSummary,
-			Attachments:       s.Attachments,
-			Category:          s.Category,
-			Tags:              s.Tags,
-			Priority:          s.Priority,
-			Summary:           s.Summary,
-			Important:         s.Important,
+			ID:                  s.GmailMessageID,
+			GmailMessageID:      s.GmailMessageID,
+			ThreadID:            s.ThreadID,
+			Subject:             subject,
+			Sender:              s.Sender,
+			Snippet:             s.Summary,
+			ReceivedAt:          s.ReceiverAt,
+			Company:             s.Company,
+			CompanyID:           s.CompanyID,
+			Role:                s.Role,
+			Deadline:            deadline,
+			ApplyLink:           s.ApplyLink,
+			OtherLinks:          s.OtherLinks,
+			LinkLabels:          s.LinkLabels,
+			Eligibility:         s.Eligibility,
+			Timings:             s.Timings,
+			Salary:              s.Salary,
+			Location:            s.Location,
+			EventDetails:        s.EventDetails,
+			Requirements:        s.Requirements,
+			Description:         s.Description,
+			AttachmentSummary:   s.AttachmentSummary,
+			Attachments:         s.Attachments,
+			Category:            s.Category,
+			Tags:                s.Tags,
+			Priority:            s.Priority,
+			Summary:             s.Summary,
+			Important:           s.Important,
+			Eligible:            verdict.Verdict,
+			EligibilityReasons:  verdict.Reasons,
+			EligibilityCriteria: criteriaOut,
+			Compensation:        s.Compensation,
+			OpportunityID:       s.OpportunityID,
+			Revisions:           revisions,
+			Changes:             changes,
+			Conflicts:           clashes,
 		})
 	}
 
@@ -155,6 +323,32 @@ func (h *GmailHandler) GetEmails(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// GetCompensationStats returns the distribution of parsed compensation
+// across the authenticated user's summaries, by company and by category.
+func (h *GmailHandler) GetCompensationStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(auth.UserIDContextKey).(string)
+	if !ok {
+		response.Unauthorized(w, "No UserID found in context")
+		return
+	}
+
+	byCompany, byCategory, err := h.userRepo.CompensationStats(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load compensation stats", "err", err)
+		response.InternalError(w, "Failed to load compensation stats")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"success":    true,
+		"byCompany":  byCompany,
+		"byCategory": byCategory,
+	})
+}
+
 // setImportantRequest is the body for SetImportant.
 type setImportantRequest struct {
 	Important bool `json:"important"`
@@ -234,7 +428,7 @@ func (h *GmailHandler) SyncPlacementEmails(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	srv, err := google.CreateGmailService(ctx, u.GoogleRefreshToken)
+	srv, err := h.gmailService(ctx, u.GoogleRefreshToken)
 	if err != nil {
 		slog.ErrorContext(ctx, "gmail service init failed", "err", err)
 		response.JSON(w, http.StatusInternalServerError, map[string]any{
@@ -253,10 +447,7 @@ func (h *GmailHandler) SyncPlacementEmails(w http.ResponseWriter, r *http.Reques
 
 	slog.Info("Starting email sync with AI processing", "query", query, "userID", userID)
 
-	processedEmails, syncError := SyncUserPlacementEmails(ctx, srv, h.userRepo, query, u.ID, SyncOptions{
-		MaxResults:            h.cfg.SyncMaxResults,
-		IncludeThreadMessages: h.cfg.SyncIncludeThreads,
-	})
+	processedEmails, syncError := SyncUserPlacementEmails(ctx, srv, h.userRepo, query, u.ID, h.syncOptions())
 
 	// Check for errors after processing
 	if syncError != nil {
@@ -320,7 +511,7 @@ func (h *GmailHandler) StreamPlacementEmails(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	srv, err := google.CreateGmailService(ctx, u.GoogleRefreshToken)
+	srv, err := h.gmailService(ctx, u.GoogleRefreshToken)
 	if err != nil {
 		sendSSEError(w, "auth_error", "Failed to initialize Gmail service")
 		return
@@ -341,10 +532,7 @@ func (h *GmailHandler) StreamPlacementEmails(w http.ResponseWriter, r *http.Requ
 	w.(http.Flusher).Flush()
 
 	// Start live stream for new emails
-	emailStream, errChan := FetchAndSummarize(ctx, srv, h.userRepo, query, u.ID, SyncOptions{
-		MaxResults:            h.cfg.SyncMaxResults,
-		IncludeThreadMessages: h.cfg.SyncIncludeThreads,
-	})
+	emailStream, errChan := FetchAndSummarize(ctx, srv, h.userRepo, query, u.ID, h.syncOptions())
 
 	foundAny := false
 
@@ -441,7 +629,7 @@ func (h *GmailHandler) GetAttachment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	srv, err := google.CreateGmailService(ctx, u.GoogleRefreshToken)
+	srv, err := h.gmailService(ctx, u.GoogleRefreshToken)
 	if err != nil {
 		slog.ErrorContext(ctx, "gmail service init failed", "err", err)
 		response.InternalError(w, "Failed to connect to Gmail")
@@ -8,13 +8,19 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 
 	"github.com/r7rainz/auramail/internal/ai"
 	"github.com/r7rainz/auramail/internal/auth"
+	"github.com/r7rainz/auramail/internal/calendar"
+	"github.com/r7rainz/auramail/internal/company"
 	"github.com/r7rainz/auramail/internal/config"
+	"github.com/r7rainz/auramail/internal/eligibility"
+	"github.com/r7rainz/auramail/internal/embedding"
 	"github.com/r7rainz/auramail/internal/user"
 )
 
@@ -40,8 +46,40 @@ func TestDecodeGmailAttachmentAcceptsPaddedAndRawData(t *testing.T) {
 type fakeUserRepo struct {
 	findByIDFunc            func(ctx context.Context, id string) (*user.User, error)
 	getSummariesByQueryFunc func(ctx context.Context, userID, searchQuery string) ([]*ai.AIResult, error)
+	listSummariesFunc       func(ctx context.Context, userID string, filter user.SummaryFilter) ([]*ai.AIResult, error)
 	getSummaryFunc          func(ctx context.Context, gmailID string) (*ai.AIResult, error)
 	setImportantFunc        func(ctx context.Context, userID, gmailID string, important bool) error
+	aiUsage                 *user.AIUsageTotals
+	aiBudget                *user.AIBudget
+	academicProfile         *eligibility.Profile
+	companies               map[int64]*company.Company
+	userCompanies           []user.CompanyOverview
+	companySummaries        []*ai.AIResult
+	threadSummaries         []*ai.AIResult
+	threadRollup            *user.ThreadRollup
+	savedRollups            []*user.ThreadRollup
+	opportunitySummaries    map[string][]*ai.AIResult
+	// embeddings and embedded back SaveEmbedding and SemanticSearch,
+	// keyed by gmail ID.
+	embeddings map[string][]float32
+	embedded   map[string]*ai.AIResult
+	// scopes are the optional Google scopes the user granted.
+	scopes map[string]bool
+	// preferences defaults to user.DefaultPreferences when nil.
+	preferences *user.Preferences
+	// corrections records CorrectSummary calls, keyed by gmail ID.
+	corrections map[string]*user.Correction
+	// saved records SaveSummary calls, keyed by gmail ID. Sync workers
+	// save concurrently, hence savedMu.
+	savedMu sync.Mutex
+	saved   map[string]*ai.AIResult
+	// pushSubscriptions back the push subscription methods; expired
+	// records DeleteExpiredPushSubscription calls.
+	pushSubscriptions []user.PushSubscription
+	expired           []string
+	// usage records RecordAIUsage calls made with a live context.
+	usageMu sync.Mutex
+	usage   []ai.Usage
 }
 
 func (f *fakeUserRepo) FindByID(ctx context.Context, id string) (*user.User, error) {
@@ -52,6 +90,19 @@ func (f *fakeUserRepo) GetSummariesByQuery(ctx context.Context, userID, searchQu
 	return f.getSummariesByQueryFunc(ctx, userID, searchQuery)
 }
 
+// ListSummaries delegates to getSummariesByQueryFunc unless a test needs to
+// inspect the whole filter.
+func (f *fakeUserRepo) ListSummaries(ctx context.Context, userID string, filter user.SummaryFilter) ([]*ai.AIResult, error) {
+	if f.listSummariesFunc != nil {
+		return f.listSummariesFunc(ctx, userID, filter)
+	}
+	return f.getSummariesByQueryFunc(ctx, userID, filter.Query)
+}
+
+func (f *fakeUserRepo) CompensationStats(ctx context.Context, userID string) ([]user.CompensationStat, []user.CompensationStat, error) {
+	return nil, nil, errors.New("not implemented")
+}
+
 func (f *fakeUserRepo) GetSummary(ctx context.Context, gmailID string) (*ai.AIResult, error) {
 	if f.getSummaryFunc != nil {
 		return f.getSummaryFunc(ctx, gmailID)
@@ -60,7 +111,13 @@ func (f *fakeUserRepo) GetSummary(ctx context.Context, gmailID string) (*ai.AIRe
 }
 
 func (f *fakeUserRepo) SaveSummary(ctx context.Context, userID, gmailID string, res *ai.AIResult) error {
-	return errors.New("not implemented")
+	f.savedMu.Lock()
+	defer f.savedMu.Unlock()
+	if f.saved == nil {
+		f.saved = make(map[string]*ai.AIResult)
+	}
+	f.saved[gmailID] = res
+	return nil
 }
 
 func (f *fakeUserRepo) SetImportant(ctx context.Context, userID, gmailID string, important bool) error {
@@ -70,6 +127,197 @@ func (f *fakeUserRepo) SetImportant(ctx context.Context, userID, gmailID string,
 	return errors.New("not implemented")
 }
 
+func (f *fakeUserRepo) RecordAIUsage(ctx context.Context, userID, query string, usage ai.Usage) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.usageMu.Lock()
+	defer f.usageMu.Unlock()
+	f.usage = append(f.usage, usage)
+	return nil
+}
+
+func (f *fakeUserRepo) GetDailyAIUsage(ctx context.Context, userID string, day time.Time) (*user.AIUsageTotals, error) {
+	if f.aiUsage != nil {
+		return f.aiUsage, nil
+	}
+	return &user.AIUsageTotals{}, nil
+}
+
+func (f *fakeUserRepo) GetAIBudget(ctx context.Context, userID string) (*user.AIBudget, error) {
+	if f.aiBudget != nil {
+		return f.aiBudget, nil
+	}
+	return nil, pgx.ErrNoRows
+}
+
+func (f *fakeUserRepo) GetAcademicProfile(ctx context.Context, userID string) (*eligibility.Profile, error) {
+	if f.academicProfile != nil {
+		return f.academicProfile, nil
+	}
+	return nil, pgx.ErrNoRows
+}
+
+func (f *fakeUserRepo) ResolveCompany(ctx context.Context, name, sender string) (*company.Company, error) {
+	return nil, nil
+}
+
+func (f *fakeUserRepo) FindCompany(ctx context.Context, name string) (*company.Company, error) {
+	known := make([]company.Company, 0, len(f.companies))
+	for _, c := range f.companies {
+		known = append(known, *c)
+	}
+	if c, ok := company.ResolveName(known, name); ok {
+		return c, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeUserRepo) GetCompany(ctx context.Context, id int64) (*company.Company, error) {
+	if c, ok := f.companies[id]; ok {
+		return c, nil
+	}
+	return nil, pgx.ErrNoRows
+}
+
+func (f *fakeUserRepo) ListUserCompanies(ctx context.Context, userID string) ([]user.CompanyOverview, error) {
+	return f.userCompanies, nil
+}
+
+func (f *fakeUserRepo) CompanySummaries(ctx context.Context, userID string, companyID int64) ([]*ai.AIResult, error) {
+	var out []*ai.AIResult
+	for _, s := range f.companySummaries {
+		if s.CompanyID != nil && *s.CompanyID == companyID {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeUserRepo) ThreadSummaries(ctx context.Context, userID, threadID string) ([]*ai.AIResult, error) {
+	return f.threadSummaries, nil
+}
+
+func (f *fakeUserRepo) GetThreadRollup(ctx context.Context, userID, threadID string) (*user.ThreadRollup, error) {
+	if f.threadRollup != nil {
+		return f.threadRollup, nil
+	}
+	return nil, pgx.ErrNoRows
+}
+
+func (f *fakeUserRepo) SaveThreadRollup(ctx context.Context, userID string, rollup *user.ThreadRollup) error {
+	f.savedRollups = append(f.savedRollups, rollup)
+	return nil
+}
+
+func (f *fakeUserRepo) MatchOpportunity(ctx context.Context, userID string, res *ai.AIResult) (string, error) {
+	return "", nil
+}
+
+func (f *fakeUserRepo) OpportunitySummaries(ctx context.Context, userID string, opportunityIDs []string) (map[string][]*ai.AIResult, error) {
+	return f.opportunitySummaries, nil
+}
+
+func (f *fakeUserRepo) SaveEmbedding(ctx context.Context, userID, gmailID, provider string, vec []float32) error {
+	if f.embeddings == nil {
+		f.embeddings = make(map[string][]float32)
+	}
+	f.embeddings[gmailID] = vec
+	return nil
+}
+
+func (f *fakeUserRepo) GetEmbedding(ctx context.Context, userID, gmailID, provider string) ([]float32, error) {
+	if vec, ok := f.embeddings[gmailID]; ok {
+		return vec, nil
+	}
+	return nil, pgx.ErrNoRows
+}
+
+func (f *fakeUserRepo) SemanticSearch(ctx context.Context, userID, provider string, query []float32, excludeGmailID string, limit int) ([]user.ScoredSummary, error) {
+	items := make([]embedding.Item, 0, len(f.embeddings))
+	for id, vec := range f.embeddings {
+		if id != excludeGmailID {
+			items = append(items, embedding.Item{Key: id, Vector: vec})
+		}
+	}
+	var hits []user.ScoredSummary
+	for _, s := range embedding.Rank(query, items, limit) {
+		hits = append(hits, user.ScoredSummary{Summary: f.embedded[s.Key], Score: s.Score})
+	}
+	return hits, nil
+}
+
+// GetUserSummary serves getSummaryFunc, which tests scope to the user.
+func (f *fakeUserRepo) GetUserSummary(ctx context.Context, userID, gmailID string) (*ai.AIResult, error) {
+	if f.getSummaryFunc == nil {
+		return nil, pgx.ErrNoRows
+	}
+	res, err := f.getSummaryFunc(ctx, gmailID)
+	if res == nil && err == nil {
+		return nil, pgx.ErrNoRows
+	}
+	return res, err
+}
+
+func (f *fakeUserRepo) HasGoogleScope(ctx context.Context, userID, scope string) (bool, error) {
+	return f.scopes[scope], nil
+}
+
+func (f *fakeUserRepo) GetPreferences(ctx context.Context, userID string) (*user.Preferences, error) {
+	if f.preferences == nil {
+		p := user.DefaultPreferences()
+		return &p, nil
+	}
+	return f.preferences, nil
+}
+
+func (f *fakeUserRepo) GetCalendarRules(ctx context.Context, userID string) (*user.CalendarRules, error) {
+	rules := user.DefaultCalendarRules()
+	return &rules, nil
+}
+
+func (f *fakeUserRepo) GetCalendarLink(ctx context.Context, userID, key, kind string) (*user.CalendarLink, error) {
+	return nil, pgx.ErrNoRows
+}
+
+func (f *fakeUserRepo) SaveCalendarLink(ctx context.Context, userID string, link *user.CalendarLink) error {
+	return nil
+}
+
+func (f *fakeUserRepo) ListCalendarLinks(ctx context.Context, userID string, from time.Time) ([]user.CalendarLink, error) {
+	return nil, nil
+}
+
+func (f *fakeUserRepo) AddNotification(ctx context.Context, userID string, n *user.Notification) error {
+	return nil
+}
+
+func (f *fakeUserRepo) CorrectSummary(ctx context.Context, userID, gmailID string, c *user.Correction) error {
+	if f.corrections == nil {
+		f.corrections = make(map[string]*user.Correction)
+	}
+	f.corrections[gmailID] = c
+	return nil
+}
+
+func (f *fakeUserRepo) SavePushSubscription(ctx context.Context, userID string, s *user.PushSubscription) error {
+	f.pushSubscriptions = append(f.pushSubscriptions, *s)
+	return nil
+}
+
+func (f *fakeUserRepo) DeletePushSubscription(ctx context.Context, userID, endpoint string) (bool, error) {
+	return false, nil
+}
+
+func (f *fakeUserRepo) DeleteExpiredPushSubscription(ctx context.Context, endpoint string) error {
+	f.expired = append(f.expired, endpoint)
+	return nil
+}
+
+func (f *fakeUserRepo) ListPushSubscriptions(ctx context.Context, userID string) ([]user.PushSubscription, error) {
+	return f.pushSubscriptions, nil
+}
+
 func newTestHandler(repo UserRepository) *GmailHandler {
 	return NewHandler(&config.Config{}, repo)
 }
@@ -140,6 +388,194 @@ func TestGetEmails_Success(t *testing.T) {
 	}
 }
 
+func TestGetEmails_EligibilityVerdicts(t *testing.T) {
+	year := 2027
+	cgpa := 7.2
+	repo := &fakeUserRepo{
+		getSummariesByQueryFunc: func(ctx context.Context, userID, searchQuery string) ([]*ai.AIResult, error) {
+			return []*ai.AIResult{
+				{GmailMessageID: "open", Summary: "sum", Eligibility: "• B.Tech CSE, 2027 batch"},
+				{GmailMessageID: "strict", Summary: "sum", Eligibility: "• Minimum CGPA 8.0"},
+				{GmailMessageID: "none", Summary: "sum"},
+			}, nil
+		},
+		academicProfile: &eligibility.Profile{Degree: "B.Tech", Branch: "CSE", PassoutYear: &year, CGPA: &cgpa},
+	}
+	h := newTestHandler(repo)
+
+	get := func(target string) []map[string]any {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req = req.WithContext(context.WithValue(req.Context(), auth.UserIDContextKey, "1"))
+		rr := httptest.NewRecorder()
+		h.GetEmails(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rr.Code)
+		}
+		var body struct {
+			Emails []map[string]any `json:"emails"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return body.Emails
+	}
+
+	emails := get("/emails")
+	want := map[string]string{"open": "yes", "strict": "no", "none": "unknown"}
+	if len(emails) != len(want) {
+		t.Fatalf("expected %d emails, got %d", len(want), len(emails))
+	}
+	for _, e := range emails {
+		id := e["id"].(string)
+		if e["eligible"] != want[id] {
+			t.Errorf("%s: eligible = %v, want %s (reasons %v)", id, e["eligible"], want[id], e["eligibilityReasons"])
+		}
+	}
+
+	filtered := get("/emails?eligibleOnly=true")
+	if len(filtered) != 2 {
+		t.Fatalf("eligibleOnly: expected 2 emails, got %d", len(filtered))
+	}
+	for _, e := range filtered {
+		if e["id"] == "strict" {
+			t.Error("eligibleOnly should drop ineligible drives")
+		}
+	}
+}
+
+func TestGetEmails_CompensationFilter(t *testing.T) {
+	var got user.SummaryFilter
+	repo := &fakeUserRepo{
+		listSummariesFunc: func(ctx context.Context, userID string, filter user.SummaryFilter) ([]*ai.AIResult, error) {
+			got = filter
+			return nil, nil
+		},
+	}
+	h := newTestHandler(repo)
+
+	req := httptest.NewRequest(http.MethodGet, "/emails?minCtc=1000000&sort=ctc_desc&compKind=ctc&currency=usd", nil)
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserIDContextKey, "1"))
+	rr := httptest.NewRecorder()
+	h.GetEmails(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if got.MinCTC == nil || *got.MinCTC != 1_000_000 || got.Sort != user.SortCTCDesc || got.Kind != "ctc" || got.Currency != "USD" {
+		t.Errorf("unexpected filter: %+v", got)
+	}
+
+	for _, target := range []string{"/emails?minCtc=abc", "/emails?sort=salary", "/emails?compKind=bonus", "/emails?currency=rupees"} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req = req.WithContext(context.WithValue(req.Context(), auth.UserIDContextKey, "1"))
+		rr := httptest.NewRecorder()
+		h.GetEmails(rr, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", target, rr.Code)
+		}
+	}
+}
+
+func TestGetEmails_FlagsScheduleConflicts(t *testing.T) {
+	globex, initech := "Globex", "Initech"
+	repo := &fakeUserRepo{
+		getSummariesByQueryFunc: func(ctx context.Context, userID, searchQuery string) ([]*ai.AIResult, error) {
+			return []*ai.AIResult{
+				{GmailMessageID: "m1", Category: "exam", Company: &globex, Timings: "Online test on 14 Aug 2099 at 2:00 PM"},
+				{GmailMessageID: "m2", Category: "interview", Company: &initech, Timings: "Interview on 14 Aug 2099 at 3:15 PM"},
+				{GmailMessageID: "m3", Category: "internship", Company: &initech},
+			}, nil
+		},
+	}
+	h := newTestHandler(repo)
+
+	req := httptest.NewRequest(http.MethodGet, "/emails", nil)
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserIDContextKey, "1"))
+	rr := httptest.NewRecorder()
+	h.GetEmails(rr, req)
+
+	var body struct {
+		Emails []struct {
+			GmailMessageID string              `json:"gmailMessageId"`
+			Conflicts      []calendar.Conflict `json:"conflicts"`
+		} `json:"emails"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(body.Emails) != 3 {
+		t.Fatalf("expected 3 emails, got %d", len(body.Emails))
+	}
+	for _, e := range body.Emails {
+		want := 1
+		if e.GmailMessageID == "m3" {
+			want = 0
+		}
+		if len(e.Conflicts) != want {
+			t.Errorf("%s: expected %d conflicts, got %+v", e.GmailMessageID, want, e.Conflicts)
+		} else if want == 1 && e.Conflicts[0].Type != calendar.ConflictBackToBack {
+			t.Errorf("%s: expected a back-to-back conflict, got %s", e.GmailMessageID, e.Conflicts[0].Type)
+		}
+	}
+}
+
+func TestGetEmails_CollapseDuplicates(t *testing.T) {
+	first, extended := "2026-08-12", "2026-08-15"
+	original := &ai.AIResult{GmailMessageID: "m1", OpportunityID: "m1", ReceiverAt: "2026-08-01T09:00:00Z", Summary: "Acme hiring", Deadline: &first}
+	reminder := &ai.AIResult{GmailMessageID: "m2", OpportunityID: "m1", ReceiverAt: "2026-08-09T09:00:00Z", Summary: "Reminder: Acme hiring", Deadline: &extended}
+	other := &ai.AIResult{GmailMessageID: "m3", ReceiverAt: "2026-08-05T09:00:00Z", Summary: "Globex PPT"}
+	repo := &fakeUserRepo{
+		getSummariesByQueryFunc: func(ctx context.Context, userID, searchQuery string) ([]*ai.AIResult, error) {
+			return []*ai.AIResult{reminder, other, original}, nil
+		},
+		opportunitySummaries: map[string][]*ai.AIResult{
+			"m1": {reminder, original},
+			"m3": {other},
+		},
+	}
+	h := newTestHandler(repo)
+
+	get := func(target string) []map[string]any {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req = req.WithContext(context.WithValue(req.Context(), auth.UserIDContextKey, "1"))
+		rr := httptest.NewRecorder()
+		h.GetEmails(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", target, rr.Code)
+		}
+		var body struct {
+			Emails []map[string]any `json:"emails"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return body.Emails
+	}
+
+	if emails := get("/emails"); len(emails) != 3 {
+		t.Fatalf("without collapse expected 3 emails, got %d", len(emails))
+	}
+
+	emails := get("/emails?collapse=true")
+	if len(emails) != 2 {
+		t.Fatalf("expected 2 cards, got %d", len(emails))
+	}
+	card := emails[0]
+	if card["gmailMessageId"] != "m2" || card["deadline"] != extended {
+		t.Errorf("unexpected card: %v", card)
+	}
+	if revs, _ := card["revisions"].([]any); len(revs) != 2 || revs[0] != "m1" {
+		t.Errorf("revisions = %v", card["revisions"])
+	}
+	if changes, _ := card["changes"].([]any); len(changes) != 1 || changes[0] != "Deadline extended from 2026-08-12 to 2026-08-15" {
+		t.Errorf("changes = %v", card["changes"])
+	}
+	if _, ok := emails[1]["revisions"]; ok {
+		t.Errorf("single email should not list revisions: %v", emails[1])
+	}
+}
+
 func TestSyncPlacementEmails_Unauthorized(t *testing.T) {
 	h := newTestHandler(&fakeUserRepo{})
 	req := httptest.NewRequest(http.MethodPost, "/sync", nil)
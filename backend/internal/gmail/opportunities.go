@@ -0,0 +1,84 @@
+package gmail
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/revision"
+)
+
+// opportunityCard describes the revisions folded into one GetEmails card
+// when duplicates are collapsed.
+type opportunityCard struct {
+	// Revisions are gmail IDs, oldest first.
+	Revisions []string
+	// Deadline is the most recently announced deadline.
+	Deadline *string
+	Changes  []string
+}
+
+// opportunityOf returns the opportunity a summary belongs to. Summaries
+// stored before duplicate detection are their own opportunity.
+func opportunityOf(s *ai.AIResult) string {
+	if s.OpportunityID != "" {
+		return s.OpportunityID
+	}
+	return s.GmailMessageID
+}
+
+// collapseOpportunities keeps the first summary of each opportunity in
+// summaries (the newest, for the default sort) and describes every stored
+// revision of it, keyed by the kept summary's gmail ID.
+func (h *GmailHandler) collapseOpportunities(ctx context.Context, userID string, summaries []*ai.AIResult) ([]*ai.AIResult, map[string]*opportunityCard, error) {
+	kept := make([]*ai.AIResult, 0, len(summaries))
+	keptFor := make(map[string]string)
+	ids := make([]string, 0, len(summaries))
+	for _, s := range summaries {
+		id := opportunityOf(s)
+		if _, ok := keptFor[id]; ok {
+			continue
+		}
+		keptFor[id] = s.GmailMessageID
+		ids = append(ids, id)
+		kept = append(kept, s)
+	}
+
+	revisions, err := h.userRepo.OpportunitySummaries(ctx, userID, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cards := make(map[string]*opportunityCard, len(ids))
+	for id, revs := range revisions {
+		if len(revs) < 2 {
+			continue
+		}
+		sortByReceived(revs)
+		card := &opportunityCard{Changes: revision.Describe(revision.Changes(revs))}
+		for _, rev := range revs {
+			card.Revisions = append(card.Revisions, rev.GmailMessageID)
+			if rev.Deadline != nil {
+				card.Deadline = rev.Deadline
+			}
+		}
+		cards[keptFor[id]] = card
+	}
+	return kept, cards, nil
+}
+
+// linkOpportunity assigns summary to the opportunity it repeats, or starts
+// a new one. Lookup failures are logged and the summary stands alone.
+func linkOpportunity(ctx context.Context, repo UserRepository, userID string, summary *ai.AIResult) {
+	if summary.OpportunityID != "" {
+		return
+	}
+	id, err := repo.MatchOpportunity(ctx, userID, summary)
+	if err != nil {
+		slog.Warn("duplicate detection failed", "id", summary.GmailMessageID, "err", err)
+	}
+	if id == "" {
+		id = summary.GmailMessageID
+	}
+	summary.OpportunityID = id
+}
@@ -0,0 +1,28 @@
+package gmail
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/r7rainz/auramail/internal/redact"
+)
+
+// userRedactor returns redactor for text sent to a model on userID's
+// behalf, or nil when the user turned redaction off. Preferences that
+// cannot be read fall back to the defaults, which keep redaction on.
+func userRedactor(ctx context.Context, repo UserRepository, redactor *redact.Redactor, userID string) *redact.Redactor {
+	prefs, err := repo.GetPreferences(ctx, userID)
+	if err != nil {
+		slog.Warn("failed to load preferences, redacting", "userID", userID, "err", err)
+		return redactor
+	}
+	if !prefs.RedactPII {
+		return nil
+	}
+	return redactor
+}
+
+// redactor is userRedactor with the configured profile.
+func (h *GmailHandler) redactor(ctx context.Context, userID string) *redact.Redactor {
+	return userRedactor(ctx, h.userRepo, redact.LoadOrDefault(h.cfg.RedactionProfile), userID)
+}
@@ -0,0 +1,131 @@
+package gmail
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/assistant"
+	"github.com/r7rainz/auramail/internal/auth"
+	"github.com/r7rainz/auramail/internal/redact"
+	"github.com/r7rainz/auramail/internal/user"
+)
+
+var personalData = regexp.MustCompile(`\d{5}\s?\d{5}|[A-Za-z0-9.]+@[A-Za-z0-9.]+\.[a-z]{2,}`)
+
+// recordingLLM stands in for a remote model and keeps the prompt it was
+// sent. Its answer repeats the phone placeholder, split across deltas.
+type recordingLLM struct {
+	system, user string
+}
+
+func (l *recordingLLM) Name() string { return "recording" }
+
+func (l *recordingLLM) Answer(ctx context.Context, req assistant.Request, onDelta func(string) error) (ai.Usage, error) {
+	l.system, l.user = assistant.Prompt(req)
+	for _, delta := range []string{"Call [PHO", "NE_1] [m-hr]"} {
+		if err := onDelta(delta); err != nil {
+			return ai.Usage{}, err
+		}
+	}
+	return ai.Usage{}, nil
+}
+
+// recordingEmbedder stands in for a remote embedding provider and keeps the
+// texts it was sent.
+type recordingEmbedder struct {
+	texts []string
+}
+
+func (e *recordingEmbedder) Name() string { return "recording" }
+
+func (e *recordingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, ai.Usage, error) {
+	e.texts = append(e.texts, texts...)
+	vectors := make([][]float32, len(texts))
+	for i := range vectors {
+		vectors[i] = []float32{1, 0}
+	}
+	return vectors, ai.Usage{}, nil
+}
+
+func personalSummary() *ai.AIResult {
+	company := "Acme"
+	return &ai.AIResult{
+		GmailMessageID: "m-hr",
+		Subject:        "Acme interview, call 9876543210",
+		Sender:         "hr.lead@acme.com",
+		Company:        &company,
+		Category:       "interview",
+		Summary:        "Interview with Acme. Reach HR at 98765 43210 or hr.lead@acme.com.",
+	}
+}
+
+func TestAsk_ModelNeverSeesPersonalData(t *testing.T) {
+	repo := &fakeUserRepo{
+		listSummariesFunc: func(ctx context.Context, userID string, f user.SummaryFilter) ([]*ai.AIResult, error) {
+			return []*ai.AIResult{personalSummary()}, nil
+		},
+	}
+	llm := &recordingLLM{}
+	h := newTestHandler(repo)
+	h.assistant = llm
+
+	req := httptest.NewRequest(http.MethodPost, "/assistant/ask", strings.NewReader(`{"question": "What is the Acme interview number? Mail me at asha.rao@gmail.com"}`))
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserIDContextKey, "1"))
+	rr := httptest.NewRecorder()
+	h.Ask(rr, req)
+
+	if llm.user == "" {
+		t.Fatal("the model was not asked")
+	}
+	if leaked := personalData.FindAllString(llm.system+llm.user, -1); len(leaked) > 0 {
+		t.Errorf("prompt contains personal data %q:\n%s", leaked, llm.user)
+	}
+	if !strings.Contains(rr.Body.String(), `{"delta":"9876543210 [m-hr]"}`) {
+		t.Errorf("answer was not restored: %s", rr.Body.String())
+	}
+}
+
+func TestIndexSummaries_RemoteEmbedderNeverSeesPersonalData(t *testing.T) {
+	for _, optOut := range []bool{false, true} {
+		repo := &fakeUserRepo{embedded: make(map[string]*ai.AIResult), preferences: &user.Preferences{RedactPII: !optOut}}
+		embedder := &recordingEmbedder{}
+		opts := SyncOptions{Embedder: embedder, Redactor: redact.Default()}
+		if _, err := IndexSummaries(context.Background(), repo, "1", []*ai.AIResult{personalSummary()}, opts); err != nil {
+			t.Fatalf("IndexSummaries() error = %v", err)
+		}
+
+		leaked := personalData.FindAllString(strings.Join(embedder.texts, "\n"), -1)
+		if !optOut && len(leaked) > 0 {
+			t.Errorf("embedded text contains personal data %q", leaked)
+		}
+		if optOut && len(leaked) == 0 {
+			t.Errorf("redaction was opted out of but the text was still redacted: %q", embedder.texts)
+		}
+	}
+}
+
+func TestMaskReplyRequest(t *testing.T) {
+	s := redact.Default().NewSession()
+	res := personalSummary()
+	req := maskReplyRequest(s, ai.ReplyRequest{
+		Subject: res.Subject,
+		Sender:  res.Sender,
+		Summary: res.Summary,
+		Body:    "Please share your phone number. Contact hr.lead@acme.com.",
+		Fields:  []string{"Phone: 9123456780", "Email: asha.rao@gmail.com"},
+	})
+
+	all := strings.Join(append([]string{req.Subject, req.Sender, req.Summary, req.Body}, req.Fields...), "\n")
+	if leaked := personalData.FindAllString(all, -1); len(leaked) > 0 {
+		t.Errorf("reply request contains personal data %q", leaked)
+	}
+	// The model repeats the detail lines, which restore to the originals.
+	if got := s.Restore(strings.Join(req.Fields, "\n")); got != "Phone: 9123456780\nEmail: asha.rao@gmail.com" {
+		t.Errorf("restored fields = %q", got)
+	}
+}
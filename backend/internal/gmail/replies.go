@@ -0,0 +1,247 @@
+package gmail
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	gmailapi "google.golang.org/api/gmail/v1"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/auth"
+	"github.com/r7rainz/auramail/internal/auth/google"
+	"github.com/r7rainz/auramail/internal/redact"
+	"github.com/r7rainz/auramail/internal/reply"
+	"github.com/r7rainz/auramail/internal/response"
+)
+
+// replyDraftRequest is the optional body of POST /emails/{id}/reply-draft.
+type replyDraftRequest struct {
+	// Save stores the draft in the user's Gmail drafts. It needs the
+	// optional gmail.compose grant.
+	Save bool `json:"save"`
+}
+
+// DraftReply writes a reply to one of the user's emails from its summary
+// and the user's profile. The draft is returned for review and, when asked
+// and permitted, saved to Gmail drafts. Nothing is ever sent.
+func (h *GmailHandler) DraftReply(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(auth.UserIDContextKey).(string)
+	if !ok {
+		response.Unauthorized(w, "No UserID found in context")
+		return
+	}
+
+	gmailID := r.PathValue("gmailMessageId")
+	if gmailID == "" {
+		response.BadRequest(w, "gmailMessageId is required", nil)
+		return
+	}
+
+	var req replyDraftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(w, "Invalid request body", nil)
+		return
+	}
+
+	summary, err := h.userRepo.GetUserSummary(ctx, userID, gmailID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.NotFound(w, "Email not found")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load summary for reply", "err", err)
+		response.InternalError(w, "Failed to draft reply")
+		return
+	}
+
+	u, err := h.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		response.NotFound(w, "User not found")
+		return
+	}
+
+	// Check the grant before spending tokens on a draft that cannot be
+	// saved.
+	if req.Save {
+		granted, err := h.userRepo.HasGoogleScope(ctx, userID, google.ScopeGmailCompose)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to check compose grant", "err", err)
+			response.InternalError(w, "Failed to draft reply")
+			return
+		}
+		if !granted {
+			response.Error(w, http.StatusForbidden, response.ErrCodeForbidden,
+				"Saving drafts needs access to compose Gmail drafts", map[string]string{"scope": "gmail.compose"})
+			return
+		}
+	}
+
+	profile, err := h.userRepo.GetAcademicProfile(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			slog.WarnContext(ctx, "failed to load academic profile", "err", err)
+		}
+		profile = nil
+	}
+
+	draft := reply.Compose(summary, reply.Profile{Name: u.Name, Email: u.Email, Academic: profile})
+	budget := newAIBudget(ctx, h.userRepo, userID, SyncOptionsFromConfig(h.cfg))
+	h.writeReplyBody(ctx, userID, u.Name, summary, draft, budget)
+
+	result := map[string]any{
+		"success": true,
+		"draft":   draft,
+		"saved":   false,
+	}
+	if req.Save {
+		if u.GoogleRefreshToken == "" {
+			response.Unauthorized(w, "Your Gmail authorization has expired. Please log out and log in again.")
+			return
+		}
+		srv, err := h.gmailService(ctx, u.GoogleRefreshToken)
+		if err != nil {
+			slog.ErrorContext(ctx, "gmail service init failed", "err", err)
+			response.InternalError(w, "Failed to connect to Gmail")
+			return
+		}
+		saved, err := saveReplyDraft(srv, summary, draft)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to save gmail draft", "err", err)
+			response.InternalError(w, "Failed to save draft to Gmail")
+			return
+		}
+		result["saved"] = true
+		result["gmailDraftId"] = saved.Id
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+// writeReplyBody replaces the template body with one written by the model
+// when it is reachable and the user has budget left. A model reply that
+// drops any requested detail is discarded in favour of the template.
+func (h *GmailHandler) writeReplyBody(ctx context.Context, userID, studentName string, summary *ai.AIResult, draft *reply.Draft, budget *aiBudget) {
+	if !ai.Available() || budget.exceeded(ctx) {
+		return
+	}
+
+	fields := reply.FieldLines(draft.Fields)
+	masked := h.redactor(ctx, userID).NewSession()
+	body, usage, err := ai.DraftReply(ctx, maskReplyRequest(masked, ai.ReplyRequest{
+		Subject:     summary.Subject,
+		Sender:      summary.Sender,
+		Summary:     summary.Summary,
+		Body:        deref(summary.Description),
+		Fields:      fields,
+		StudentName: studentName,
+		FormLinks:   draft.FormLinks,
+	}))
+	body = masked.Restore(body)
+	if !usage.IsZero() {
+		if usageErr := h.userRepo.RecordAIUsage(ctx, userID, "", usage); usageErr != nil {
+			slog.Error("Error recording AI usage", "id", summary.GmailMessageID, "err", usageErr)
+		}
+	}
+	if err != nil {
+		slog.Warn("reply draft unavailable, using template", "id", summary.GmailMessageID, "err", err)
+		return
+	}
+	for _, line := range fields {
+		if !strings.Contains(body, line) {
+			slog.Warn("model reply dropped a requested detail, using template", "id", summary.GmailMessageID, "detail", line)
+			return
+		}
+	}
+	draft.Body = body
+	draft.Source = reply.SourceAI
+}
+
+// maskReplyRequest redacts the parts of req taken from the email and the
+// student's details. The model repeats the detail placeholders, which the
+// caller restores in its reply.
+func maskReplyRequest(s *redact.Session, req ai.ReplyRequest) ai.ReplyRequest {
+	req.Subject = s.Redact(req.Subject)
+	req.Sender = s.Redact(req.Sender)
+	req.Summary = s.Redact(req.Summary)
+	req.Body = s.Redact(req.Body)
+	req.Fields = redactAll(s, req.Fields)
+	req.FormLinks = redactAll(s, req.FormLinks)
+	return req
+}
+
+// redactAll returns a redacted copy of texts.
+func redactAll(s *redact.Session, texts []string) []string {
+	if texts == nil {
+		return nil
+	}
+	out := make([]string, len(texts))
+	for i, text := range texts {
+		out[i] = s.Redact(text)
+	}
+	return out
+}
+
+// saveReplyDraft stores draft in the user's Gmail drafts, threaded under
+// the original message. It only ever creates a draft.
+func saveReplyDraft(srv *gmailapi.Service, summary *ai.AIResult, draft *reply.Draft) (*gmailapi.Draft, error) {
+	original, err := srv.Users.Messages.Get("me", summary.GmailMessageID).
+		Format("metadata").
+		MetadataHeaders("Message-ID", "References", "Reply-To").
+		Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load original message: %w", err)
+	}
+
+	to := draft.To
+	if replyTo := headerValue(original, "Reply-To"); replyTo != "" {
+		to = reply.ReplyAddress(replyTo)
+	}
+	messageID := headerValue(original, "Message-ID")
+	references := strings.TrimSpace(headerValue(original, "References") + " " + messageID)
+
+	var raw strings.Builder
+	writeHeader := func(name, value string) {
+		if value = headerSafe(value); value != "" {
+			fmt.Fprintf(&raw, "%s: %s\r\n", name, value)
+		}
+	}
+	writeHeader("To", to)
+	writeHeader("Subject", mime.QEncoding.Encode("UTF-8", draft.Subject))
+	writeHeader("In-Reply-To", messageID)
+	writeHeader("References", references)
+	writeHeader("MIME-Version", "1.0")
+	writeHeader("Content-Type", `text/plain; charset="UTF-8"`)
+	raw.WriteString("\r\n")
+	raw.WriteString(strings.ReplaceAll(draft.Body, "\n", "\r\n"))
+
+	return srv.Users.Drafts.Create("me", &gmailapi.Draft{
+		Message: &gmailapi.Message{
+			Raw:      base64.URLEncoding.EncodeToString([]byte(raw.String())),
+			ThreadId: original.ThreadId,
+		},
+	}).Do()
+}
+
+// headerSafe strips line breaks so a value cannot inject extra headers.
+func headerSafe(value string) string {
+	return strings.TrimSpace(strings.NewReplacer("\r", " ", "\n", " ").Replace(value))
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,138 @@
+package gmail
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	gmailapi "google.golang.org/api/gmail/v1"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/auth"
+	"github.com/r7rainz/auramail/internal/auth/google"
+	"github.com/r7rainz/auramail/internal/gmail/gmailtest"
+	"github.com/r7rainz/auramail/internal/user"
+)
+
+func replyRepo(scopes map[string]bool) *fakeUserRepo {
+	return &fakeUserRepo{
+		findByIDFunc: func(ctx context.Context, id string) (*user.User, error) {
+			return &user.User{ID: id, Name: "Asha Rao", Email: "asha@example.com", GoogleRefreshToken: "refresh"}, nil
+		},
+		getSummaryFunc: func(ctx context.Context, gmailID string) (*ai.AIResult, error) {
+			if gmailID != "m1" {
+				return nil, nil
+			}
+			return &ai.AIResult{
+				GmailMessageID: "m1",
+				ThreadID:       "t1",
+				Subject:        "TCS drive registration",
+				Sender:         "Placement Office <placementoffice@vitbhopal.ac.in>",
+				Summary:        "Reply with your name and registration number to register.",
+			}, nil
+		},
+		scopes: scopes,
+	}
+}
+
+func draftReply(t *testing.T, repo *fakeUserRepo, fake *gmailtest.Server, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/emails/m1/reply-draft", strings.NewReader(body))
+	req.SetPathValue("gmailMessageId", "m1")
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserIDContextKey, "1"))
+	rr := httptest.NewRecorder()
+	h := newTestHandler(repo)
+	h.gmailService = func(ctx context.Context, _ string) (*gmailapi.Service, error) {
+		return fake.Service(ctx)
+	}
+	h.DraftReply(rr, req)
+	return rr
+}
+
+func TestDraftReply_ReturnsDraftWithoutSaving(t *testing.T) {
+	fake := gmailtest.NewServer()
+	defer fake.Close()
+
+	rr := draftReply(t, replyRepo(nil), fake, "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var body struct {
+		Saved bool `json:"saved"`
+		Draft struct {
+			To   string `json:"to"`
+			Body string `json:"body"`
+		} `json:"draft"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if body.Saved || len(fake.Drafts()) != 0 {
+		t.Error("draft should not be saved unless asked")
+	}
+	if body.Draft.To != "placementoffice@vitbhopal.ac.in" || !strings.Contains(body.Draft.Body, "Name: Asha Rao") {
+		t.Errorf("unexpected draft: %+v", body.Draft)
+	}
+}
+
+func TestDraftReply_SaveNeedsComposeGrant(t *testing.T) {
+	fake := gmailtest.NewServer()
+	defer fake.Close()
+
+	rr := draftReply(t, replyRepo(nil), fake, `{"save": true}`)
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rr.Code)
+	}
+	if len(fake.Drafts()) != 0 {
+		t.Error("no draft should be created without the grant")
+	}
+}
+
+func TestDraftReply_SavesThreadedDraftAndNeverSends(t *testing.T) {
+	fake := gmailtest.NewServer()
+	defer fake.Close()
+	fake.AddMessage(&gmailapi.Message{
+		Id:       "m1",
+		ThreadId: "t1",
+		Payload: &gmailapi.MessagePart{Headers: []*gmailapi.MessagePartHeader{
+			{Name: "Message-ID", Value: "<abc@vitbhopal.ac.in>"},
+			{Name: "Reply-To", Value: "TPO <tpo@vitbhopal.ac.in>"},
+		}},
+	})
+
+	rr := draftReply(t, replyRepo(map[string]bool{google.ScopeGmailCompose: true}), fake, `{"save": true}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var body struct {
+		Saved        bool   `json:"saved"`
+		GmailDraftID string `json:"gmailDraftId"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !body.Saved || body.GmailDraftID != "r-1" {
+		t.Errorf("unexpected response: %+v", body)
+	}
+
+	drafts := fake.Drafts()
+	if len(drafts) != 1 || drafts[0].Message.ThreadId != "t1" {
+		t.Fatalf("expected one draft in thread t1, got %+v", drafts)
+	}
+	raw, err := base64.URLEncoding.DecodeString(drafts[0].Message.Raw)
+	if err != nil {
+		t.Fatalf("draft raw is not base64url: %v", err)
+	}
+	for _, header := range []string{"To: tpo@vitbhopal.ac.in\r\n", "In-Reply-To: <abc@vitbhopal.ac.in>\r\n", "Subject: Re: TCS drive registration\r\n"} {
+		if !strings.Contains(string(raw), header) {
+			t.Errorf("draft missing header %q:\n%s", header, raw)
+		}
+	}
+	if fake.Sends() != 0 {
+		t.Errorf("expected no send calls, got %d", fake.Sends())
+	}
+}
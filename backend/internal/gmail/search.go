@@ -0,0 +1,257 @@
+package gmail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/auth"
+	"github.com/r7rainz/auramail/internal/config"
+	"github.com/r7rainz/auramail/internal/embedding"
+	"github.com/r7rainz/auramail/internal/redact"
+	"github.com/r7rainz/auramail/internal/response"
+	"github.com/r7rainz/auramail/internal/user"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+)
+
+// searchResult is one hit in the semantic search responses.
+type searchResult struct {
+	GmailMessageID string   `json:"gmailMessageId"`
+	ThreadID       string   `json:"threadId"`
+	Subject        string   `json:"subject"`
+	Sender         string   `json:"sender"`
+	ReceivedAt     string   `json:"receivedAt"`
+	Company        *string  `json:"company"`
+	CompanyID      *int64   `json:"companyId"`
+	Role           *string  `json:"role"`
+	Deadline       *string  `json:"deadline"`
+	Category       string   `json:"category"`
+	Tags           []string `json:"tags"`
+	Summary        string   `json:"summary"`
+	OpportunityID  string   `json:"opportunityId,omitempty"`
+	Score          float64  `json:"score"`
+}
+
+// newEmbedder returns the configured embedding provider. Config validation
+// rejects unusable settings, so an error here only happens with a config
+// built in code; the offline provider keeps search working in that case.
+func newEmbedder(cfg *config.Config) embedding.Provider {
+	p, err := embedding.New(cfg.EmbeddingProvider, cfg.OpenAIKey)
+	if err != nil {
+		slog.Warn("embedding provider unavailable, using hash provider", "provider", cfg.EmbeddingProvider, "err", err)
+		return embedding.NewHash(embedding.DefaultHashDimensions)
+	}
+	return p
+}
+
+// SearchEmails ranks the user's summaries by meaning against a natural
+// language query, e.g. "remote data internships with stipend".
+func (h *GmailHandler) SearchEmails(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(auth.UserIDContextKey).(string)
+	if !ok {
+		response.Unauthorized(w, "No UserID found in context")
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("semantic"))
+	if query == "" {
+		response.BadRequest(w, "semantic query is required", nil)
+		return
+	}
+	limit, ok := parseSearchLimit(r.URL.Query().Get("limit"))
+	if !ok {
+		response.BadRequest(w, fmt.Sprintf("limit must be between 1 and %d", maxSearchLimit), nil)
+		return
+	}
+
+	budget := newAIBudget(ctx, h.userRepo, userID, SyncOptionsFromConfig(h.cfg))
+	vectors, err := embedTexts(ctx, h.userRepo, h.embedder, h.redactor(ctx, userID), userID, query, []string{query}, budget)
+	if errors.Is(err, errEmbeddingBudget) {
+		response.ServiceUnavailable(w, "Daily AI budget reached; semantic search is unavailable until tomorrow")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to embed search query", "err", err)
+		response.InternalError(w, "Failed to search emails")
+		return
+	}
+
+	hits, err := h.userRepo.SemanticSearch(ctx, userID, h.embedder.Name(), vectors[0], "", limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "semantic search failed", "err", err)
+		response.InternalError(w, "Failed to search emails")
+		return
+	}
+
+	writeSearchResults(w, map[string]any{"query": query}, hits)
+}
+
+// SimilarEmails returns the user's summaries closest in meaning to one of
+// their emails, for "find drives similar to this one".
+func (h *GmailHandler) SimilarEmails(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(auth.UserIDContextKey).(string)
+	if !ok {
+		response.Unauthorized(w, "No UserID found in context")
+		return
+	}
+
+	gmailID := r.PathValue("gmailMessageId")
+	if gmailID == "" {
+		response.BadRequest(w, "gmailMessageId is required", nil)
+		return
+	}
+	limit, ok := parseSearchLimit(r.URL.Query().Get("limit"))
+	if !ok {
+		response.BadRequest(w, fmt.Sprintf("limit must be between 1 and %d", maxSearchLimit), nil)
+		return
+	}
+
+	vec, err := h.userRepo.GetEmbedding(ctx, userID, gmailID, h.embedder.Name())
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.NotFound(w, "Email not found or not indexed yet")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load email embedding", "err", err)
+		response.InternalError(w, "Failed to find similar emails")
+		return
+	}
+
+	hits, err := h.userRepo.SemanticSearch(ctx, userID, h.embedder.Name(), vec, gmailID, limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "semantic search failed", "err", err)
+		response.InternalError(w, "Failed to find similar emails")
+		return
+	}
+
+	writeSearchResults(w, map[string]any{"gmailMessageId": gmailID}, hits)
+}
+
+// parseSearchLimit reads the optional limit parameter.
+func parseSearchLimit(v string) (int, bool) {
+	if v == "" {
+		return defaultSearchLimit, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 || n > maxSearchLimit {
+		return 0, false
+	}
+	return n, true
+}
+
+func writeSearchResults(w http.ResponseWriter, body map[string]any, hits []user.ScoredSummary) {
+	results := make([]searchResult, 0, len(hits))
+	for _, hit := range hits {
+		s := hit.Summary
+		subject := s.Subject
+		if subject == "" {
+			subject = s.Summary
+		}
+		results = append(results, searchResult{
+			GmailMessageID: s.GmailMessageID,
+			ThreadID:       s.ThreadID,
+			Subject:        subject,
+			Sender:         s.Sender,
+			ReceivedAt:     s.ReceiverAt,
+			Company:        s.Company,
+			CompanyID:      s.CompanyID,
+			Role:           s.Role,
+			Deadline:       s.Deadline,
+			Category:       s.Category,
+			Tags:           s.Tags,
+			Summary:        s.Summary,
+			OpportunityID:  s.OpportunityID,
+			Score:          hit.Score,
+		})
+	}
+
+	body["success"] = true
+	body["results"] = results
+	body["total"] = len(results)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// errEmbeddingBudget is returned when embedding would exceed the user's
+// daily AI budget.
+var errEmbeddingBudget = errors.New("daily AI budget reached")
+
+// embedTexts embeds texts on the user's behalf, recording any usage the
+// provider reports. The offline provider costs nothing and keeps the text
+// on this server, so it ignores the budget and sees the original text;
+// other providers get each text through redactor, nil when the user turned
+// redaction off.
+func embedTexts(ctx context.Context, repo UserRepository, embedder embedding.Provider, redactor *redact.Redactor, userID, query string, texts []string, budget *aiBudget) ([][]float32, error) {
+	if _, local := embedder.(*embedding.Hash); !local {
+		if budget.exceeded(ctx) {
+			return nil, errEmbeddingBudget
+		}
+		masked := make([]string, len(texts))
+		for i, text := range texts {
+			masked[i] = redactor.NewSession().Redact(text)
+		}
+		texts = masked
+	}
+	vectors, usage, err := embedder.Embed(ctx, texts)
+	if !usage.IsZero() {
+		if usageErr := repo.RecordAIUsage(ctx, userID, query, usage); usageErr != nil {
+			slog.Error("Error recording AI usage", "userID", userID, "err", usageErr)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return vectors, nil
+}
+
+// indexSummaries embeds and stores summaries for semantic search. It
+// returns how many were stored.
+func indexSummaries(ctx context.Context, repo UserRepository, embedder embedding.Provider, redactor *redact.Redactor, userID, query string, summaries []*ai.AIResult, budget *aiBudget) (int, error) {
+	if len(summaries) == 0 {
+		return 0, nil
+	}
+	docs := make([]string, len(summaries))
+	for i, s := range summaries {
+		docs[i] = embedding.Document(s)
+	}
+	vectors, err := embedTexts(ctx, repo, embedder, redactor, userID, query, docs, budget)
+	if err != nil {
+		return 0, err
+	}
+
+	stored := 0
+	for i, s := range summaries {
+		if err := repo.SaveEmbedding(ctx, userID, s.GmailMessageID, embedder.Name(), vectors[i]); err != nil {
+			slog.Error("failed to save embedding", "id", s.GmailMessageID, "err", err)
+			continue
+		}
+		stored++
+	}
+	return stored, nil
+}
+
+// IndexSummaries embeds summaries that were stored without a vector, e.g.
+// before semantic search existed or while the user was over budget.
+func IndexSummaries(ctx context.Context, repo UserRepository, userID string, summaries []*ai.AIResult, opts SyncOptions) (int, error) {
+	if opts.Embedder == nil {
+		return 0, nil
+	}
+	budget := newAIBudget(ctx, repo, userID, opts)
+	return indexSummaries(ctx, repo, opts.Embedder, userRedactor(ctx, repo, opts.Redactor, userID), userID, "", summaries, budget)
+}
@@ -0,0 +1,113 @@
+package gmail
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/auth"
+)
+
+// indexedRepo returns a fake whose summaries are embedded with the handler's
+// default (hash) provider.
+func indexedRepo(t *testing.T, summaries ...*ai.AIResult) *fakeUserRepo {
+	t.Helper()
+	repo := &fakeUserRepo{embedded: make(map[string]*ai.AIResult)}
+	h := newTestHandler(repo)
+	for _, s := range summaries {
+		repo.embedded[s.GmailMessageID] = s
+	}
+	if _, err := indexSummaries(context.Background(), repo, h.embedder, nil, "1", "", summaries, newAIBudget(context.Background(), repo, "1", SyncOptions{})); err != nil {
+		t.Fatalf("indexSummaries() error = %v", err)
+	}
+	return repo
+}
+
+func searchSummaries() []*ai.AIResult {
+	remote, stipend := "Remote", "Stipend 40000 per month"
+	return []*ai.AIResult{
+		{GmailMessageID: "data", Subject: "Data analyst internship", Category: "internship", Summary: "Remote data science internship with stipend", Location: remote, Salary: stipend},
+		{GmailMessageID: "ml", Subject: "Machine learning internship", Category: "internship", Summary: "Data internship on ML pipelines, remote friendly, with stipend"},
+		{GmailMessageID: "hackathon", Subject: "Campus hackathon", Category: "event", Summary: "Register for the 24 hour hackathon in the main auditorium"},
+	}
+}
+
+func decodeSearch(t *testing.T, rr *httptest.ResponseRecorder) []searchResult {
+	t.Helper()
+	var body struct {
+		Results []searchResult `json:"results"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return body.Results
+}
+
+func TestSearchEmails_RanksByMeaning(t *testing.T) {
+	repo := indexedRepo(t, searchSummaries()...)
+
+	req := httptest.NewRequest(http.MethodGet, "/emails/search?semantic=remote+data+internships+with+stipend&limit=2", nil)
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserIDContextKey, "1"))
+	rr := httptest.NewRecorder()
+	newTestHandler(repo).SearchEmails(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	results := decodeSearch(t, rr)
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %+v", results)
+	}
+	if results[0].GmailMessageID != "data" {
+		t.Errorf("expected the remote data internship first, got %q", results[0].GmailMessageID)
+	}
+	for _, r := range results {
+		if r.GmailMessageID == "hackathon" {
+			t.Errorf("hackathon should rank below both internships: %+v", results)
+		}
+	}
+}
+
+func TestSearchEmails_RequiresQuery(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/emails/search?semantic=+", nil)
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserIDContextKey, "1"))
+	rr := httptest.NewRecorder()
+	newTestHandler(&fakeUserRepo{}).SearchEmails(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rr.Code)
+	}
+}
+
+func TestSimilarEmails(t *testing.T) {
+	repo := indexedRepo(t, searchSummaries()...)
+
+	req := httptest.NewRequest(http.MethodGet, "/emails/data/similar", nil)
+	req.SetPathValue("gmailMessageId", "data")
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserIDContextKey, "1"))
+	rr := httptest.NewRecorder()
+	newTestHandler(repo).SimilarEmails(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	results := decodeSearch(t, rr)
+	if len(results) != 2 || results[0].GmailMessageID != "ml" {
+		t.Errorf("expected the other internship first and the source email excluded, got %+v", results)
+	}
+}
+
+func TestSimilarEmails_NotIndexed(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/emails/missing/similar", nil)
+	req.SetPathValue("gmailMessageId", "missing")
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserIDContextKey, "1"))
+	rr := httptest.NewRecorder()
+	newTestHandler(&fakeUserRepo{}).SimilarEmails(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rr.Code)
+	}
+}
@@ -2,17 +2,26 @@ package gmail
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"log/slog"
 	"math"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"google.golang.org/api/gmail/v1"
 
 	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/config"
+	"github.com/r7rainz/auramail/internal/embedding"
+	"github.com/r7rainz/auramail/internal/lang"
+	"github.com/r7rainz/auramail/internal/push"
+	"github.com/r7rainz/auramail/internal/redact"
+	"github.com/r7rainz/auramail/internal/user"
 	"github.com/r7rainz/auramail/internal/utils"
 )
 
@@ -31,6 +40,61 @@ type SyncError struct {
 type SyncOptions struct {
 	MaxResults            int64
 	IncludeThreadMessages bool
+	// Default daily AI budgets for users without an ai_budgets override.
+	// Zero disables the corresponding limit.
+	DailyTokenBudget   int64
+	DailyCostBudgetUSD float64
+	// Embedder indexes new summaries for semantic search; nil skips
+	// indexing.
+	Embedder embedding.Provider
+	// Redactor masks personal data before email text reaches the LLM;
+	// nil sends text unredacted. Users can opt out in their preferences.
+	Redactor *redact.Redactor
+	// Calendar schedules events for newly analyzed emails after the sync;
+	// nil skips it.
+	Calendar EventScheduler
+	// Notifier alerts the user to urgent emails stored for the first time
+	// in the sync; nil skips it.
+	Notifier EmailNotifier
+}
+
+// EventScheduler puts the deadlines, tests and interviews of analyzed
+// emails on the user's calendar, if the user opted in.
+type EventScheduler interface {
+	Schedule(ctx context.Context, userID string, summaries []*ai.AIResult) (int, error)
+}
+
+// EmailNotifier alerts the user to newly stored emails that need their
+// attention.
+type EmailNotifier interface {
+	Notify(ctx context.Context, userID string, summaries []*ai.AIResult) (int, error)
+}
+
+// PushNotifier returns the Web Push notifier for urgent emails, or nil
+// when VAPID keys are not configured.
+func PushNotifier(cfg *config.Config, repo push.Repository) EmailNotifier {
+	keys, err := push.KeysFromConfig(cfg)
+	if err != nil {
+		slog.Warn("push notifications disabled", "err", err)
+		return nil
+	}
+	if keys == nil {
+		return nil
+	}
+	return push.NewNotifier(repo, keys, cfg.DashboardURL(), cfg.PushServiceHosts)
+}
+
+// SyncOptionsFromConfig builds the sync options shared by the HTTP handlers
+// and the background scheduler.
+func SyncOptionsFromConfig(cfg *config.Config) SyncOptions {
+	return SyncOptions{
+		MaxResults:            cfg.SyncMaxResults,
+		IncludeThreadMessages: cfg.SyncIncludeThreads,
+		DailyTokenBudget:      cfg.AIDailyTokenBudget,
+		DailyCostBudgetUSD:    cfg.AIDailyCostBudgetUSD,
+		Embedder:              newEmbedder(cfg),
+		Redactor:              redact.LoadOrDefault(cfg.RedactionProfile),
+	}
 }
 
 func (e *SyncError) Error() string {
@@ -131,6 +195,31 @@ func FetchAndSummarize(ctx context.Context, srv *gmail.Service, repo UserReposit
 		messageIDs := collectMessageIDs(srv, list.Messages, opts.IncludeThreadMessages)
 		slog.Info("Found messages to process", "matched", len(list.Messages), "expanded", len(messageIDs))
 
+		budget := newAIBudget(ctx, repo, userID, opts)
+		var deferred atomic.Int64
+
+		prefs, err := repo.GetPreferences(ctx, userID)
+		if err != nil {
+			// Fall back to the defaults, which keep redaction on, when the
+			// preferences cannot be read.
+			slog.Warn("failed to load preferences", "userID", userID, "err", err)
+			defaults := user.DefaultPreferences()
+			prefs = &defaults
+		}
+		redactor := opts.Redactor
+		if !prefs.RedactPII {
+			redactor = nil
+		}
+
+		// Threads that gained a newly analyzed message get their rollup
+		// recomputed once all workers are done.
+		var threadsMu sync.Mutex
+		updatedThreads := make(map[string]struct{})
+		var analyzed []*ai.AIResult
+		// stored are the analyzed emails seen for the first time, which
+		// the user has not been told about yet.
+		var stored []*ai.AIResult
+
 		var wg sync.WaitGroup
 		jobs := make(chan string, len(messageIDs))
 
@@ -143,9 +232,10 @@ func FetchAndSummarize(ctx context.Context, srv *gmail.Service, repo UserReposit
 				for id := range jobs {
 					slog.Info("Processing message", "id", id)
 
-					//checking db first
+					//checking db first; rules fallback results are only kept
+					//until the LLM is reachable again
 					cached, err := repo.GetSummary(ctx, id)
-					if err == nil && cached != nil {
+					if err == nil && cached != nil && (cached.AnalysisVersion != ai.RulesAnalysisVersion || !ai.Available()) {
 						slog.Info("Using cached summary", "id", id)
 						if msg, fetchErr := srv.Users.Messages.Get("me", id).Format("full").Do(); fetchErr == nil {
 							changed := mergeExtractedLinks(cached, msg.Payload)
@@ -154,6 +244,11 @@ func FetchAndSummarize(ctx context.Context, srv *gmail.Service, repo UserReposit
 								cached.Description = &body
 								changed = true
 							}
+							// Summaries from before language detection.
+							if cached.Language == "" {
+								cached.Language = lang.Detect(cached.Subject + "\n" + body)
+								changed = true
+							}
 							if changed {
 								if saveErr := repo.SaveSummary(ctx, userID, id, cached); saveErr != nil {
 									slog.Error("Error updating cached email", "id", id, "err", saveErr)
@@ -168,6 +263,13 @@ func FetchAndSummarize(ctx context.Context, srv *gmail.Service, repo UserReposit
 						}
 					}
 
+					// Over budget: leave the message unsaved so a later sync
+					// (e.g. tomorrow's) picks it up again.
+					if budget.exceeded(ctx) {
+						deferred.Add(1)
+						continue
+					}
+
 					msg, err := srv.Users.Messages.Get("me", id).Format("full").Do()
 					if err != nil {
 						slog.Error("Failed to get message", "id", id, "err", err)
@@ -184,14 +286,43 @@ func FetchAndSummarize(ctx context.Context, srv *gmail.Service, repo UserReposit
 
 					var summary *ai.AIResult
 
+					// Detect on the original text; the model is told so it
+					// can still extract fields in English.
+					language := lang.Detect(subject + "\n" + body)
+					langs := ai.Languages{Email: language, Summary: prefs.SummaryLanguage}
+
+					// The model only ever sees the redacted text; the rules
+					// fallback and the stored description use the original.
+					aiSubject, aiSnippet, aiBody := subject, msg.Snippet, body
+					var masked *redact.Session
+					if redactor != nil {
+						masked = redactor.NewSession()
+						aiSubject, aiSnippet, aiBody = masked.Redact(subject), masked.Redact(msg.Snippet), masked.Redact(body)
+					}
+
 					maxRetries := 3
 					for i := 0; i < maxRetries; i++ {
+						var usage ai.Usage
 						aiSemaphore <- struct{}{}
-						summary, err = ai.AnalyzeEmail(ctx, userID, subject, msg.Snippet, body)
+						summary, usage, err = ai.AnalyzeEmail(ctx, userID, aiSubject, aiSnippet, aiBody, langs)
 						<-aiSemaphore
 
+						if !usage.IsZero() {
+							if usageErr := repo.RecordAIUsage(ctx, userID, query, usage); usageErr != nil {
+								slog.Error("Error recording AI usage", "id", id, "err", usageErr)
+							}
+						}
+
 						if err == nil {
 							slog.Info("AI analysis successful", "id", id, "category", summary.Category)
+							if masked != nil {
+								summary = masked.RestoreResult(summary)
+							}
+							break
+						}
+						// No key configured: retrying cannot help, go
+						// straight to the rules fallback.
+						if errors.Is(err, ai.ErrOpenAIKeyMissing) {
 							break
 						}
 
@@ -209,11 +340,12 @@ func FetchAndSummarize(ctx context.Context, srv *gmail.Service, repo UserReposit
 					}
 
 					if err != nil || summary == nil {
-						slog.Error("Skipping message - AI failed", "id", id, "err", err)
-						continue
+						slog.Warn("AI analysis unavailable, using rules fallback", "id", id, "err", err)
+						summary = ai.AnalyzeEmailRules(subject, msg.Snippet, body, utils.ExtractLinkDetails(msg.Payload), time.Now())
 					}
 
 					summary.GmailMessageID = id
+					summary.Language = language
 					summary.ThreadID = msg.ThreadId
 					summary.Subject = subject
 					summary.Sender = headerValue(msg, "From")
@@ -224,12 +356,32 @@ func FetchAndSummarize(ctx context.Context, srv *gmail.Service, repo UserReposit
 					}
 					summary.Attachments = attachments
 					mergeExtractedLinks(summary, msg.Payload)
+					if cached != nil && len(cached.Overrides) > 0 {
+						carryOverrides(summary, cached.Overrides)
+					}
+					linkCompany(ctx, repo, summary)
+					linkOpportunity(ctx, repo, userID, summary)
 
 					err = repo.SaveSummary(ctx, userID, id, summary)
 					if err != nil {
 						slog.Error("Error saving summary to DB", "err", err)
 					} else {
 						slog.Info("Saved summary to DB", "id", id)
+						// A missed vector is picked up by the indexing job.
+						if opts.Embedder != nil {
+							if _, embedErr := indexSummaries(ctx, repo, opts.Embedder, redactor, userID, query, []*ai.AIResult{summary}, budget); embedErr != nil {
+								slog.Warn("failed to index summary", "id", id, "err", embedErr)
+							}
+						}
+						threadsMu.Lock()
+						if summary.ThreadID != "" {
+							updatedThreads[summary.ThreadID] = struct{}{}
+						}
+						analyzed = append(analyzed, summary)
+						if cached == nil {
+							stored = append(stored, summary)
+						}
+						threadsMu.Unlock()
 					}
 
 					// Only send if we have a valid result
@@ -250,11 +402,64 @@ func FetchAndSummarize(ctx context.Context, srv *gmail.Service, repo UserReposit
 
 		// 5. Wait for completion
 		wg.Wait()
-		slog.Info("FetchAndSummarize completed")
+		threadIDs := make([]string, 0, len(updatedThreads))
+		for threadID := range updatedThreads {
+			threadIDs = append(threadIDs, threadID)
+		}
+		sort.Strings(threadIDs)
+		refreshThreadRollups(ctx, repo, redactor, userID, query, threadIDs, budget)
+		if opts.Calendar != nil && len(analyzed) > 0 {
+			if n, err := opts.Calendar.Schedule(ctx, userID, analyzed); err != nil {
+				slog.Warn("calendar auto-scheduling failed", "userID", userID, "scheduled", n, "err", err)
+			} else if n > 0 {
+				slog.Info("calendar events scheduled", "userID", userID, "scheduled", n)
+			}
+		}
+		if opts.Notifier != nil && len(stored) > 0 {
+			if n, err := opts.Notifier.Notify(ctx, userID, stored); err != nil {
+				slog.Warn("push notification failed", "userID", userID, "sent", n, "err", err)
+			} else if n > 0 {
+				slog.Info("push notifications sent", "userID", userID, "sent", n)
+			}
+		}
+		if n := deferred.Load(); n > 0 {
+			errChan <- &SyncError{Code: "AI_BUDGET_EXCEEDED", Message: fmt.Sprintf("Daily AI budget reached; %d emails deferred to a later sync", n)}
+		}
+		slog.Info("FetchAndSummarize completed", "deferred", deferred.Load())
 	}()
 	return out, errChan
 }
 
+// carryOverrides re-applies the user's corrections to a fresh analysis so
+// the streamed result matches what SaveSummary keeps. The confidence map is
+// copied because analyzer results may be shared through its cache.
+func carryOverrides(summary *ai.AIResult, overrides map[string]*string) {
+	confidence := make(map[string]float64, len(summary.Confidence)+len(overrides))
+	for field, c := range summary.Confidence {
+		confidence[field] = c
+	}
+	summary.Confidence = confidence
+	summary.Overrides = overrides
+	summary.ApplyOverrides()
+}
+
+// linkCompany resolves summary's company to a canonical company. Failures
+// only cost the link, so they are logged rather than failing the message.
+func linkCompany(ctx context.Context, repo UserRepository, summary *ai.AIResult) {
+	name := ""
+	if summary.Company != nil {
+		name = *summary.Company
+	}
+	c, err := repo.ResolveCompany(ctx, name, summary.Sender)
+	if err != nil {
+		slog.Warn("company resolution failed", "id", summary.GmailMessageID, "company", name, "err", err)
+		return
+	}
+	if c != nil {
+		summary.CompanyID = &c.ID
+	}
+}
+
 func mergeExtractedLinks(summary *ai.AIResult, payload *gmail.MessagePart) bool {
 	details := utils.ExtractLinkDetails(payload)
 	if len(details) > 0 {
@@ -0,0 +1,88 @@
+package gmail
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	gmailapi "google.golang.org/api/gmail/v1"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/config"
+	"github.com/r7rainz/auramail/internal/gmail/gmailtest"
+	"github.com/r7rainz/auramail/internal/push"
+	"github.com/r7rainz/auramail/internal/push/pushtest"
+)
+
+func plainMessage(id, subject, body string) *gmailapi.Message {
+	return &gmailapi.Message{
+		Id:      id,
+		Snippet: body,
+		Payload: &gmailapi.MessagePart{
+			MimeType: "text/plain",
+			Headers:  []*gmailapi.MessagePartHeader{{Name: "Subject", Value: subject}},
+			Body:     &gmailapi.MessagePartBody{Data: base64.URLEncoding.EncodeToString([]byte(body))},
+		},
+	}
+}
+
+func TestSyncUserPlacementEmails_PushesUrgentEmails(t *testing.T) {
+	if ai.Available() {
+		t.Skip("analyzes with the rules fallback; unset OPENAI_API_KEY")
+	}
+	fake := gmailtest.NewServer()
+	t.Cleanup(fake.Close)
+	fake.AddMessage(plainMessage("m1", "URGENT: Globex online test registration", "Register immediately for the Globex online test."))
+	fake.AddMessage(plainMessage("m2", "Initech campus newsletter", "Read about last month's placement talks."))
+	srv, err := fake.Service(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service := pushtest.NewServer()
+	t.Cleanup(service.Close)
+	public, private, err := push.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		FrontendURL:      "http://app",
+		VAPIDPublicKey:   public,
+		VAPIDPrivateKey:  private,
+		VAPIDSubject:     "mailto:placements@example.edu",
+		PushServiceHosts: []string{"127.0.0.1"},
+	}
+	repo := &fakeUserRepo{}
+	repo.pushSubscriptions = append(repo.pushSubscriptions, service.Subscribe())
+	opts := SyncOptions{MaxResults: 10, Notifier: PushNotifier(cfg, repo)}
+
+	processed, err := SyncUserPlacementEmails(context.Background(), srv, repo, "placement", "1", opts)
+	if err != nil || len(processed) != 2 {
+		t.Fatalf("sync processed %d emails, err %v", len(processed), err)
+	}
+	messages := service.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("push service got %d messages, want 1", len(messages))
+	}
+	var p push.Payload
+	if err := json.Unmarshal(messages[0].Payload, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.GmailID != "m1" || p.URL != "http://app/dashboard" {
+		t.Errorf("payload = %+v, want the urgent Globex email", p)
+	}
+
+	// Emails already stored are not announced again.
+	repo.getSummaryFunc = func(ctx context.Context, gmailID string) (*ai.AIResult, error) {
+		repo.savedMu.Lock()
+		defer repo.savedMu.Unlock()
+		return repo.saved[gmailID], nil
+	}
+	if _, err := SyncUserPlacementEmails(context.Background(), srv, repo, "placement", "1", opts); err != nil {
+		t.Fatalf("second sync: %v", err)
+	}
+	if n := len(service.Messages()); n != 1 {
+		t.Errorf("push service got %d messages after a repeat sync, want 1", n)
+	}
+}
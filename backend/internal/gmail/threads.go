@@ -0,0 +1,193 @@
+package gmail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sort"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/auth"
+	"github.com/r7rainz/auramail/internal/redact"
+	"github.com/r7rainz/auramail/internal/response"
+	"github.com/r7rainz/auramail/internal/revision"
+	"github.com/r7rainz/auramail/internal/user"
+	"github.com/r7rainz/auramail/internal/utils"
+)
+
+// threadMessage is one message in the GetThread response.
+type threadMessage struct {
+	GmailMessageID string                 `json:"gmailMessageId"`
+	Subject        string                 `json:"subject"`
+	Sender         string                 `json:"sender"`
+	ReceivedAt     string                 `json:"receivedAt"`
+	Category       string                 `json:"category"`
+	Summary        string                 `json:"summary"`
+	Deadline       *string                `json:"deadline"`
+	Attachments    []utils.AttachmentMeta `json:"attachments"`
+}
+
+// GetThread returns the messages of one thread, oldest first, with a
+// rollup of what changed across them. A missing or stale rollup is
+// recomputed before responding.
+func (h *GmailHandler) GetThread(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(auth.UserIDContextKey).(string)
+	if !ok {
+		response.Unauthorized(w, "No UserID found in context")
+		return
+	}
+
+	threadID := r.PathValue("threadId")
+	if threadID == "" {
+		response.BadRequest(w, "threadId is required", nil)
+		return
+	}
+
+	messages, err := h.userRepo.ThreadSummaries(ctx, userID, threadID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load thread", "err", err)
+		response.InternalError(w, "Failed to load thread")
+		return
+	}
+	if len(messages) == 0 {
+		response.NotFound(w, "Thread not found")
+		return
+	}
+	sortByReceived(messages)
+
+	rollup, err := h.userRepo.GetThreadRollup(ctx, userID, threadID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		slog.WarnContext(ctx, "failed to load thread rollup", "threadID", threadID, "err", err)
+	}
+	if err != nil || rollupStale(rollup, messages) {
+		budget := newAIBudget(ctx, h.userRepo, userID, SyncOptionsFromConfig(h.cfg))
+		rollup = computeThreadRollup(ctx, h.userRepo, h.redactor(ctx, userID), userID, "", threadID, messages, budget)
+	}
+
+	out := make([]threadMessage, 0, len(messages))
+	for _, m := range messages {
+		out = append(out, threadMessage{
+			GmailMessageID: m.GmailMessageID,
+			Subject:        m.Subject,
+			Sender:         m.Sender,
+			ReceivedAt:     m.ReceiverAt,
+			Category:       m.Category,
+			Summary:        m.Summary,
+			Deadline:       m.Deadline,
+			Attachments:    m.Attachments,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"success":  true,
+		"threadId": threadID,
+		"messages": out,
+		"rollup":   rollup,
+	})
+}
+
+// sortByReceived orders summaries by received time; those without a
+// parsable time go last.
+func sortByReceived(messages []*ai.AIResult) {
+	sort.SliceStable(messages, func(i, j int) bool {
+		a, b := parseReceivedAt(messages[i].ReceiverAt), parseReceivedAt(messages[j].ReceiverAt)
+		if a.IsZero() || b.IsZero() {
+			return !a.IsZero() && b.IsZero()
+		}
+		return a.Before(b)
+	})
+}
+
+// rollupStale reports whether rollup was computed from a different set of
+// messages than the sorted messages.
+func rollupStale(rollup *user.ThreadRollup, messages []*ai.AIResult) bool {
+	if rollup == nil || len(messages) == 0 {
+		return true
+	}
+	return rollup.MessageCount != len(messages) || rollup.LatestGmailID != messages[len(messages)-1].GmailMessageID
+}
+
+// computeThreadRollup builds and stores the rollup for the sorted messages
+// of threadID. The model phrases the rollup when it is reachable and the
+// user has budget left; otherwise the detected changes are described
+// directly. The model sees the messages through redactor, nil when the
+// user turned redaction off. Storage failures are logged and the rollup is
+// still returned.
+func computeThreadRollup(ctx context.Context, repo UserRepository, redactor *redact.Redactor, userID, query, threadID string, messages []*ai.AIResult, budget *aiBudget) *user.ThreadRollup {
+	changes := revision.Changes(messages)
+	rollup := &user.ThreadRollup{
+		ThreadID:     threadID,
+		MessageCount: len(messages),
+		Summary:      revision.Describe(changes),
+		Changes:      changes,
+		Source:       user.RollupSourceRules,
+	}
+	if len(messages) > 0 {
+		rollup.LatestGmailID = messages[len(messages)-1].GmailMessageID
+	}
+
+	if len(messages) > 1 && ai.Available() && !budget.exceeded(ctx) {
+		masked := redactor.NewSession()
+		input := make([]ai.ThreadMessage, 0, len(messages))
+		for _, m := range messages {
+			input = append(input, ai.ThreadMessage{
+				ReceivedAt: m.ReceiverAt,
+				Sender:     masked.Redact(m.Sender),
+				Subject:    masked.Redact(m.Subject),
+				Summary:    masked.Redact(m.Summary),
+			})
+		}
+		detected := make([]string, 0, len(changes))
+		for _, c := range changes {
+			detected = append(detected, masked.Redact(c.String()))
+		}
+
+		lines, usage, err := ai.SummarizeThread(ctx, input, detected)
+		if !usage.IsZero() {
+			if usageErr := repo.RecordAIUsage(ctx, userID, query, usage); usageErr != nil {
+				slog.Error("Error recording AI usage", "threadID", threadID, "err", usageErr)
+			}
+		}
+		if err != nil {
+			slog.Warn("thread rollup unavailable, describing detected changes", "threadID", threadID, "err", err)
+		} else {
+			for i := range lines {
+				lines[i] = masked.Restore(lines[i])
+			}
+			rollup.Summary = lines
+			rollup.Source = user.RollupSourceAI
+		}
+	}
+
+	if err := repo.SaveThreadRollup(ctx, userID, rollup); err != nil {
+		slog.Error("failed to save thread rollup", "threadID", threadID, "err", err)
+	}
+	return rollup
+}
+
+// refreshThreadRollups recomputes the rollups of threads that received a
+// newly analyzed message during a sync.
+func refreshThreadRollups(ctx context.Context, repo UserRepository, redactor *redact.Redactor, userID, query string, threadIDs []string, budget *aiBudget) {
+	for _, threadID := range threadIDs {
+		if ctx.Err() != nil {
+			return
+		}
+		messages, err := repo.ThreadSummaries(ctx, userID, threadID)
+		if err != nil {
+			slog.Error("failed to load thread for rollup", "threadID", threadID, "err", err)
+			continue
+		}
+		if len(messages) < 2 {
+			continue
+		}
+		sortByReceived(messages)
+		computeThreadRollup(ctx, repo, redactor, userID, query, threadID, messages, budget)
+	}
+}
@@ -0,0 +1,80 @@
+package gmail
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/auth"
+	"github.com/r7rainz/auramail/internal/user"
+)
+
+func getThread(t *testing.T, repo *fakeUserRepo, threadID string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/threads/"+threadID, nil)
+	req.SetPathValue("threadId", threadID)
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserIDContextKey, "1"))
+	rr := httptest.NewRecorder()
+	newTestHandler(repo).GetThread(rr, req)
+	return rr
+}
+
+func TestGetThread_RecomputesStaleRollup(t *testing.T) {
+	before, after := "2026-08-12", "2026-08-15"
+	repo := &fakeUserRepo{
+		threadSummaries: []*ai.AIResult{
+			{GmailMessageID: "m2", ThreadID: "t1", ReceiverAt: "2026-08-10T09:00:00Z", Category: "reminder", Deadline: &after},
+			{GmailMessageID: "m1", ThreadID: "t1", ReceiverAt: "2026-08-01T09:00:00Z", Category: "job offer", Deadline: &before},
+		},
+		// Computed before m2 arrived.
+		threadRollup: &user.ThreadRollup{ThreadID: "t1", MessageCount: 1, LatestGmailID: "m1"},
+	}
+
+	rr := getThread(t, repo, "t1")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+
+	var body struct {
+		Messages []threadMessage   `json:"messages"`
+		Rollup   user.ThreadRollup `json:"rollup"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(body.Messages) != 2 || body.Messages[0].GmailMessageID != "m1" {
+		t.Fatalf("messages not ordered oldest first: %+v", body.Messages)
+	}
+	if body.Rollup.LatestGmailID != "m2" || len(body.Rollup.Summary) != 2 ||
+		body.Rollup.Summary[1] != "Deadline extended from 2026-08-12 to 2026-08-15" {
+		t.Errorf("unexpected rollup: %+v", body.Rollup)
+	}
+	if len(repo.savedRollups) != 1 {
+		t.Errorf("expected the recomputed rollup to be saved, got %d saves", len(repo.savedRollups))
+	}
+}
+
+func TestGetThread_FreshRollupServedAsIs(t *testing.T) {
+	repo := &fakeUserRepo{
+		threadSummaries: []*ai.AIResult{{GmailMessageID: "m1", ThreadID: "t1"}},
+		threadRollup:    &user.ThreadRollup{ThreadID: "t1", MessageCount: 1, LatestGmailID: "m1", Source: user.RollupSourceAI},
+	}
+
+	rr := getThread(t, repo, "t1")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if len(repo.savedRollups) != 0 {
+		t.Errorf("fresh rollup should not be recomputed")
+	}
+}
+
+func TestGetThread_NotFound(t *testing.T) {
+	rr := getThread(t, &fakeUserRepo{}, "missing")
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rr.Code)
+	}
+}
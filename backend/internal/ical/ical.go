@@ -0,0 +1,164 @@
+// Package ical writes iCalendar (RFC 5545) feeds that calendar apps such
+// as Apple Calendar, Outlook and Thunderbird can subscribe to.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	dateLayout     = "20060102"
+	dateTimeLayout = "20060102T150405Z"
+	// maxLineOctets is where content lines are folded.
+	maxLineOctets = 75
+)
+
+// Calendar is a feed of events.
+type Calendar struct {
+	// ProdID identifies the product that created the feed.
+	ProdID string
+	Name   string
+	// TimeZone is a hint for clients showing the feed; event times are
+	// written in UTC.
+	TimeZone string
+	// RefreshInterval is how often clients should poll the feed.
+	RefreshInterval time.Duration
+	Events          []Event
+}
+
+// Event is one VEVENT.
+type Event struct {
+	// UID must stay the same across feed refreshes so clients update the
+	// event instead of adding another one.
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	URL         string
+	Start, End  time.Time
+	// AllDay events use the dates of Start and End; End is exclusive.
+	AllDay     bool
+	Categories []string
+	// Alarms are display reminders this long before the event.
+	Alarms []time.Duration
+	// Stamp is when the event information was last changed.
+	Stamp time.Time
+}
+
+// Encode writes c to w.
+func (c *Calendar) Encode(w io.Writer) error {
+	enc := &encoder{w: bufio.NewWriter(w)}
+	enc.line("BEGIN:VCALENDAR")
+	enc.line("VERSION:2.0")
+	enc.prop("PRODID", c.ProdID)
+	enc.line("CALSCALE:GREGORIAN")
+	enc.line("METHOD:PUBLISH")
+	if c.Name != "" {
+		enc.prop("X-WR-CALNAME", c.Name)
+	}
+	if c.TimeZone != "" {
+		enc.prop("X-WR-TIMEZONE", c.TimeZone)
+	}
+	if c.RefreshInterval > 0 {
+		enc.line("REFRESH-INTERVAL;VALUE=DURATION:" + duration(c.RefreshInterval))
+		enc.line("X-PUBLISHED-TTL:" + duration(c.RefreshInterval))
+	}
+	for i := range c.Events {
+		enc.event(&c.Events[i])
+	}
+	enc.line("END:VCALENDAR")
+	if enc.err != nil {
+		return enc.err
+	}
+	return enc.w.Flush()
+}
+
+type encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (e *encoder) event(ev *Event) {
+	e.line("BEGIN:VEVENT")
+	e.prop("UID", ev.UID)
+	e.line("DTSTAMP:" + ev.Stamp.UTC().Format(dateTimeLayout))
+	if ev.AllDay {
+		e.line("DTSTART;VALUE=DATE:" + ev.Start.Format(dateLayout))
+		e.line("DTEND;VALUE=DATE:" + ev.End.Format(dateLayout))
+	} else {
+		e.line("DTSTART:" + ev.Start.UTC().Format(dateTimeLayout))
+		e.line("DTEND:" + ev.End.UTC().Format(dateTimeLayout))
+	}
+	e.prop("SUMMARY", ev.Summary)
+	if ev.Description != "" {
+		e.prop("DESCRIPTION", ev.Description)
+	}
+	if ev.Location != "" {
+		e.prop("LOCATION", ev.Location)
+	}
+	if ev.URL != "" {
+		// URL is a URI value, which is not escaped like text.
+		e.line("URL:" + ev.URL)
+	}
+	if len(ev.Categories) > 0 {
+		escaped := make([]string, len(ev.Categories))
+		for i, c := range ev.Categories {
+			escaped[i] = escape(c)
+		}
+		e.line("CATEGORIES:" + strings.Join(escaped, ","))
+	}
+	for _, before := range ev.Alarms {
+		e.line("BEGIN:VALARM")
+		e.line("ACTION:DISPLAY")
+		e.prop("DESCRIPTION", ev.Summary)
+		e.line("TRIGGER:-" + duration(before))
+		e.line("END:VALARM")
+	}
+	e.line("END:VEVENT")
+}
+
+// prop writes a property with a text value.
+func (e *encoder) prop(name, value string) {
+	e.line(name + ":" + escape(value))
+}
+
+// line writes a content line, folded so no line is longer than 75 octets.
+// Continuation lines start with a space, which counts towards the limit.
+func (e *encoder) line(s string) {
+	if e.err != nil {
+		return
+	}
+	for len(s) > maxLineOctets {
+		// Fold between characters, never inside one.
+		cut := maxLineOctets
+		for cut > 1 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		if _, e.err = e.w.WriteString(s[:cut] + "\r\n"); e.err != nil {
+			return
+		}
+		s = " " + s[cut:]
+	}
+	_, e.err = e.w.WriteString(s + "\r\n")
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// escape escapes a TEXT value.
+func escape(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// duration formats d as an RFC 5545 duration, e.g. PT90M or P1D.
+func duration(d time.Duration) string {
+	minutes := int64(d / time.Minute)
+	if minutes > 0 && minutes%(24*60) == 0 {
+		return fmt.Sprintf("P%dD", minutes/(24*60))
+	}
+	return fmt.Sprintf("PT%dM", minutes)
+}
@@ -0,0 +1,91 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncode(t *testing.T) {
+	ist := time.FixedZone("IST", 5*3600+1800)
+	cal := &Calendar{
+		ProdID:          "-//AuraMail//Placements//EN",
+		Name:            "AuraMail",
+		RefreshInterval: time.Hour,
+		Events: []Event{
+			{
+				UID:         "m1-deadline@auramail",
+				Summary:     "Deadline: Globex, Inc; SDE",
+				Description: "Apply soon\nLink below",
+				Start:       time.Date(2026, 8, 14, 0, 0, 0, 0, ist),
+				End:         time.Date(2026, 8, 15, 0, 0, 0, 0, ist),
+				AllDay:      true,
+				Categories:  []string{"AuraMail", "Deadline"},
+				Alarms:      []time.Duration{time.Hour, 24 * time.Hour},
+				Stamp:       time.Date(2026, 8, 10, 9, 0, 0, 0, time.UTC),
+			},
+			{
+				UID:   "m2-exam@auramail",
+				Start: time.Date(2026, 8, 14, 14, 30, 0, 0, ist),
+				End:   time.Date(2026, 8, 14, 15, 30, 0, 0, ist),
+				URL:   "https://mail.google.com/mail/u/0/#all/m2",
+				Stamp: time.Date(2026, 8, 10, 9, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	var b strings.Builder
+	if err := cal.Encode(&b); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	out := b.String()
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\nVERSION:2.0\r\n",
+		"REFRESH-INTERVAL;VALUE=DURATION:PT60M\r\n",
+		"DTSTART;VALUE=DATE:20260814\r\nDTEND;VALUE=DATE:20260815\r\n",
+		`SUMMARY:Deadline: Globex\, Inc\; SDE` + "\r\n",
+		`DESCRIPTION:Apply soon\nLink below` + "\r\n",
+		"CATEGORIES:AuraMail,Deadline\r\n",
+		"TRIGGER:-PT60M\r\n",
+		"TRIGGER:-P1D\r\n",
+		"DTSTART:20260814T090000Z\r\nDTEND:20260814T100000Z\r\n",
+		"URL:https://mail.google.com/mail/u/0/#all/m2\r\n",
+		"END:VEVENT\r\nEND:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "BEGIN:VALARM") != 2 {
+		t.Errorf("expected two alarms")
+	}
+}
+
+func TestLineFolding(t *testing.T) {
+	var b strings.Builder
+	cal := &Calendar{Events: []Event{{UID: "x", Summary: strings.Repeat("é", 100)}}}
+	if err := cal.Encode(&b); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	var summary strings.Builder
+	inSummary := false
+	for _, line := range strings.Split(b.String(), "\r\n") {
+		if len(line) > maxLineOctets {
+			t.Errorf("line longer than %d octets: %q", maxLineOctets, line)
+		}
+		switch {
+		case strings.HasPrefix(line, "SUMMARY:"):
+			inSummary = true
+			summary.WriteString(strings.TrimPrefix(line, "SUMMARY:"))
+		case inSummary && strings.HasPrefix(line, " "):
+			summary.WriteString(line[1:])
+		default:
+			inSummary = false
+		}
+	}
+	if summary.String() != strings.Repeat("é", 100) {
+		t.Errorf("folded summary does not unfold to the original: %q", summary.String())
+	}
+}
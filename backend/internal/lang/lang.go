@@ -0,0 +1,89 @@
+// Package lang detects the language an email is written in. Detection is by
+// script, which is enough to tell English apart from the Indian languages
+// placement notices arrive in; Romanized Hindi is reported as English.
+package lang
+
+import (
+	"unicode"
+)
+
+// English is the language fields are always extracted in.
+const English = "en"
+
+// minShare is the fraction of letters a non-Latin script needs before the
+// text counts as written in it. Mixed-script notices keep English terms
+// such as company names and URLs, so a simple majority is too strict.
+const minShare = 0.25
+
+// Language is a supported language, identified by its ISO 639-1 code.
+type Language struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+
+	script *unicode.RangeTable
+}
+
+// Supported lists the languages that can be detected or requested for
+// summaries. Languages sharing a script are detected as the first one
+// listed, so Marathi text is reported as Hindi.
+var Supported = []Language{
+	{Code: English, Name: "English", script: unicode.Latin},
+	{Code: "hi", Name: "Hindi", script: unicode.Devanagari},
+	{Code: "mr", Name: "Marathi", script: unicode.Devanagari},
+	{Code: "bn", Name: "Bengali", script: unicode.Bengali},
+	{Code: "ta", Name: "Tamil", script: unicode.Tamil},
+	{Code: "te", Name: "Telugu", script: unicode.Telugu},
+	{Code: "kn", Name: "Kannada", script: unicode.Kannada},
+	{Code: "ml", Name: "Malayalam", script: unicode.Malayalam},
+	{Code: "gu", Name: "Gujarati", script: unicode.Gujarati},
+	{Code: "pa", Name: "Punjabi", script: unicode.Gurmukhi},
+}
+
+// Lookup returns the supported language with code.
+func Lookup(code string) (Language, bool) {
+	for _, l := range Supported {
+		if l.Code == code {
+			return l, true
+		}
+	}
+	return Language{}, false
+}
+
+// Name returns the English name of the language with code, or code itself
+// when it is not supported.
+func Name(code string) string {
+	if l, ok := Lookup(code); ok {
+		return l.Name
+	}
+	return code
+}
+
+// Detect returns the code of the language text is written in. Text whose
+// letters are mostly Latin, or that has no letters, is English.
+func Detect(text string) string {
+	counts := make(map[*unicode.RangeTable]int)
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) && !unicode.Is(unicode.Mn, r) && !unicode.Is(unicode.Mc, r) {
+			continue
+		}
+		letters++
+		for _, l := range Supported[1:] {
+			if unicode.Is(l.script, r) {
+				counts[l.script]++
+				break
+			}
+		}
+	}
+
+	best, bestCount := English, 0
+	for _, l := range Supported[1:] {
+		if n := counts[l.script]; n > bestCount {
+			best, bestCount = l.Code, n
+		}
+	}
+	if letters == 0 || float64(bestCount)/float64(letters) < minShare {
+		return English
+	}
+	return best
+}
@@ -0,0 +1,34 @@
+package lang
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	for name, tc := range map[string]struct {
+		text string
+		want string
+	}{
+		"english":   {"Amazon is hiring SDE interns. Apply by 14 Aug.", English},
+		"empty":     {"", English},
+		"hindi":     {"भारतीय रेल में प्रशिक्षु पदों के लिए आवेदन आमंत्रित हैं। अंतिम तिथि 14 अगस्त।", "hi"},
+		"mixed":     {"BHEL Recruitment 2026: इंजीनियर ट्रेनी पदों के लिए आवेदन करें https://careers.bhel.in", "hi"},
+		"tamil":     {"வேலைவாய்ப்பு அறிவிப்பு: விண்ணப்பிக்க கடைசி நாள்", "ta"},
+		"romanized": {"Aap sabhi ko suchit kiya jata hai ki placement drive kal hai", English},
+		"stray":     {"Please read the attached circular (परिपत्र) before applying to the drive next week.", English},
+	} {
+		if got := Detect(tc.text); got != tc.want {
+			t.Errorf("%s: Detect() = %q, want %q", name, got, tc.want)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	if l, ok := Lookup("hi"); !ok || l.Name != "Hindi" {
+		t.Errorf("Lookup(hi) = %+v, %v", l, ok)
+	}
+	if _, ok := Lookup("xx"); ok {
+		t.Error("unsupported code should not be found")
+	}
+	if Name("xx") != "xx" || Name("ta") != "Tamil" {
+		t.Error("Name() is wrong")
+	}
+}
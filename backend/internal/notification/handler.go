@@ -0,0 +1,114 @@
+// Package notification serves the user's in-app notifications.
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/r7rainz/auramail/internal/auth"
+	"github.com/r7rainz/auramail/internal/response"
+	"github.com/r7rainz/auramail/internal/user"
+)
+
+// Kinds of notifications.
+const (
+	// KindCalendarUpdated is sent when an email moved a calendar event.
+	KindCalendarUpdated = "calendar_event_updated"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 200
+)
+
+// Repository is the subset of the user repository the notification
+// endpoints depend on.
+type Repository interface {
+	ListNotifications(ctx context.Context, userID string, unreadOnly bool, limit int) ([]user.Notification, error)
+	MarkNotificationsRead(ctx context.Context, userID string, ids []int64) (int64, error)
+}
+
+type Handler struct {
+	repo Repository
+}
+
+func NewHandler(repo Repository) *Handler {
+	return &Handler{repo: repo}
+}
+
+// List returns the user's latest notifications, newest first. ?unread=true
+// leaves out those already read; ?limit caps how many are returned.
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(auth.UserIDContextKey).(string)
+	if !ok {
+		response.Unauthorized(w, "No UserID found in context")
+		return
+	}
+
+	limit := defaultLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxLimit {
+			response.BadRequest(w, "limit must be between 1 and 200", nil)
+			return
+		}
+		limit = n
+	}
+	unreadOnly := r.URL.Query().Get("unread") == "true"
+
+	notifications, err := h.repo.ListNotifications(ctx, userID, unreadOnly, limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to list notifications", "err", err, "userID", userID)
+		response.InternalError(w, "Failed to load notifications")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"success":       true,
+		"notifications": notifications,
+		"total":         len(notifications),
+	})
+}
+
+type markReadRequest struct {
+	// IDs are the notifications to mark as read; empty marks all of them.
+	IDs []int64 `json:"ids"`
+}
+
+// MarkRead marks notifications as read.
+func (h *Handler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(auth.UserIDContextKey).(string)
+	if !ok {
+		response.Unauthorized(w, "No UserID found in context")
+		return
+	}
+
+	var req markReadRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			response.BadRequest(w, "Invalid request body", nil)
+			return
+		}
+	}
+
+	marked, err := h.repo.MarkNotificationsRead(ctx, userID, req.IDs)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to mark notifications read", "err", err, "userID", userID)
+		response.InternalError(w, "Failed to update notifications")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"success": true,
+		"marked":  marked,
+	})
+}
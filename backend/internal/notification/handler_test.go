@@ -0,0 +1,80 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/r7rainz/auramail/internal/auth"
+	"github.com/r7rainz/auramail/internal/user"
+)
+
+type fakeRepo struct {
+	notifications []user.Notification
+	read          []int64
+}
+
+func (f *fakeRepo) ListNotifications(ctx context.Context, userID string, unreadOnly bool, limit int) ([]user.Notification, error) {
+	var out []user.Notification
+	for _, n := range f.notifications {
+		if unreadOnly && slices.Contains(f.read, n.ID) {
+			continue
+		}
+		out = append(out, n)
+	}
+	return out[:min(limit, len(out))], nil
+}
+
+func (f *fakeRepo) MarkNotificationsRead(ctx context.Context, userID string, ids []int64) (int64, error) {
+	var marked int64
+	for _, n := range f.notifications {
+		if (len(ids) == 0 || slices.Contains(ids, n.ID)) && !slices.Contains(f.read, n.ID) {
+			f.read = append(f.read, n.ID)
+			marked++
+		}
+	}
+	return marked, nil
+}
+
+func withUserID(req *http.Request, userID string) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), auth.UserIDContextKey, userID))
+}
+
+func TestListAndMarkRead(t *testing.T) {
+	repo := &fakeRepo{notifications: []user.Notification{
+		{ID: 2, Kind: KindCalendarUpdated, Title: "Deadline moved"},
+		{ID: 1, Kind: KindCalendarUpdated, Title: "Test moved"},
+	}}
+	h := NewHandler(repo)
+
+	rr := httptest.NewRecorder()
+	h.MarkRead(rr, withUserID(httptest.NewRequest(http.MethodPost, "/notifications/read", strings.NewReader(`{"ids":[1]}`)), "1"))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"marked":1`) {
+		t.Fatalf("MarkRead: %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	h.List(rr, withUserID(httptest.NewRequest(http.MethodGet, "/notifications?unread=true", nil), "1"))
+	var resp struct {
+		Notifications []user.Notification `json:"notifications"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Notifications) != 1 || resp.Notifications[0].ID != 2 {
+		t.Errorf("expected only the unread notification, got %+v", resp.Notifications)
+	}
+}
+
+func TestList_InvalidLimit(t *testing.T) {
+	h := NewHandler(&fakeRepo{})
+	rr := httptest.NewRecorder()
+	h.List(rr, withUserID(httptest.NewRequest(http.MethodGet, "/notifications?limit=0", nil), "1"))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rr.Code)
+	}
+}
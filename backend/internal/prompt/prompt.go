@@ -0,0 +1,201 @@
+// Package prompt holds the versioned templates behind the LLM prompts. A
+// template file is a JSON header declaring its name, version, target schema
+// and vocabularies, a line containing only "---", and a text/template body
+// rendered with that header. Templates ship embedded in the binary and can
+// be overridden from a directory on disk or from the database.
+package prompt
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// AnalyzeEmail is the template behind email analysis.
+const AnalyzeEmail = "analyze_email"
+
+// SchemaVersion is the AIResult schema the code understands. Templates
+// targeting another schema are rejected.
+const SchemaVersion = 1
+
+// Template sources.
+const (
+	SourceEmbedded = "embedded"
+	SourceDisk     = "disk"
+	SourceDB       = "db"
+)
+
+//go:embed templates/*.tmpl
+var embedded embed.FS
+
+const separator = "\n---\n"
+
+// Category is one value the model may put in the category field.
+type Category struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Template is a parsed prompt template.
+type Template struct {
+	Name string `json:"name"`
+	// Version identifies the prompt. It becomes the AnalysisVersion of
+	// every result produced with it, so bump it whenever the text changes.
+	Version       string     `json:"version"`
+	SchemaVersion int        `json:"schemaVersion"`
+	Categories    []Category `json:"categories"`
+	Tags          []string   `json:"tags"`
+	// Source is where the template was loaded from.
+	Source string `json:"source"`
+	// Text is the full template file, header included.
+	Text string `json:"-"`
+
+	body *template.Template
+}
+
+var funcs = template.FuncMap{
+	"quoteList": func(items []string) string {
+		quoted := make([]string, len(items))
+		for i, item := range items {
+			quoted[i] = strconv.Quote(item)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	},
+}
+
+// Parse reads a template file.
+func Parse(text, source string) (*Template, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	header, body, ok := strings.Cut(text, separator)
+	if !ok {
+		return nil, errors.New("prompt template needs a JSON header followed by a --- line")
+	}
+
+	t := &Template{}
+	if err := json.Unmarshal([]byte(header), t); err != nil {
+		return nil, fmt.Errorf("invalid prompt template header: %w", err)
+	}
+	t.Source, t.Text = source, text
+	switch {
+	case t.Name == "":
+		return nil, errors.New("prompt template name is required")
+	case t.Version == "":
+		return nil, errors.New("prompt template version is required")
+	case t.SchemaVersion != SchemaVersion:
+		return nil, fmt.Errorf("prompt template targets schema %d, want %d", t.SchemaVersion, SchemaVersion)
+	case len(t.Categories) == 0:
+		return nil, errors.New("prompt template declares no categories")
+	}
+
+	tmpl, err := template.New(t.Name).Funcs(funcs).Option("missingkey=error").Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prompt template body: %w", err)
+	}
+	t.body = tmpl
+	// Render once so a template that parses but cannot execute is caught
+	// when it is loaded rather than on the first email.
+	if _, err := t.Render(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Render returns the prompt text.
+func (t *Template) Render() (string, error) {
+	var b bytes.Buffer
+	if err := t.body.Execute(&b, t); err != nil {
+		return "", fmt.Errorf("failed to render prompt template %s: %w", t.Name, err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// HasCategory reports whether name is in the template's vocabulary.
+func (t *Template) HasCategory(name string) bool {
+	for _, c := range t.Categories {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// HasTag reports whether tag is in the template's vocabulary.
+func (t *Template) HasTag(tag string) bool {
+	for _, v := range t.Tags {
+		if v == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Embedded returns the template compiled into the binary.
+func Embedded(name string) (*Template, error) {
+	data, err := embedded.ReadFile("templates/" + name + ".tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("no embedded prompt template %q: %w", name, err)
+	}
+	return Parse(string(data), SourceEmbedded)
+}
+
+// MustEmbedded is Embedded for templates known to ship with the binary.
+func MustEmbedded(name string) *Template {
+	t, err := Embedded(name)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Store reads templates saved in the database.
+type Store interface {
+	// ActivePromptTemplate returns the active template text for name, or
+	// "" when none is stored.
+	ActivePromptTemplate(ctx context.Context, name string) (string, error)
+}
+
+// Load returns the template for name from the first source that has one:
+// the store, then dir/<name>.tmpl, then the embedded copy. store may be nil
+// and dir empty.
+func Load(ctx context.Context, name, dir string, store Store) (*Template, error) {
+	if store != nil {
+		text, err := store.ActivePromptTemplate(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if text != "" {
+			return parseNamed(text, SourceDB, name)
+		}
+	}
+	if dir != "" {
+		data, err := os.ReadFile(filepath.Join(dir, name+".tmpl"))
+		if err == nil {
+			return parseNamed(string(data), SourceDisk, name)
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read prompt template: %w", err)
+		}
+	}
+	return Embedded(name)
+}
+
+// parseNamed parses an override and checks it is the template asked for.
+func parseNamed(text, source, name string) (*Template, error) {
+	t, err := Parse(text, source)
+	if err != nil {
+		return nil, err
+	}
+	if t.Name != name {
+		return nil, fmt.Errorf("prompt template is named %q, want %q", t.Name, name)
+	}
+	return t, nil
+}
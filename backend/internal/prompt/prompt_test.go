@@ -0,0 +1,95 @@
+package prompt
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const candidate = `{
+  "name": "analyze_email",
+  "version": "detailed-v5",
+  "schemaVersion": 1,
+  "categories": [{"name": "internship", "description": "Internships"}],
+  "tags": ["urgent", "remote"]
+}
+---
+Categories:{{range .Categories}} {{.Name}}{{end}}
+Tags: {{quoteList .Tags}}
+`
+
+type fakeStore map[string]string
+
+func (f fakeStore) ActivePromptTemplate(ctx context.Context, name string) (string, error) {
+	return f[name], nil
+}
+
+func TestEmbeddedAnalyzeEmail(t *testing.T) {
+	tmpl := MustEmbedded(AnalyzeEmail)
+	if tmpl.Version == "" || tmpl.Source != SourceEmbedded {
+		t.Fatalf("unexpected template: %+v", tmpl)
+	}
+	text, err := tmpl.Render()
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	for _, want := range []string{`- "job offer" - Full-time job offers`, `["urgent", "high-package",`} {
+		if !strings.Contains(text, want) {
+			t.Errorf("rendered prompt missing %q", want)
+		}
+	}
+	if !tmpl.HasCategory("exam") || tmpl.HasCategory("spam") || !tmpl.HasTag("wfh") {
+		t.Error("vocabulary lookups are wrong")
+	}
+}
+
+func TestParse(t *testing.T) {
+	tmpl, err := Parse(candidate, SourceDB)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	text, _ := tmpl.Render()
+	if text != "Categories: internship\nTags: [\"urgent\", \"remote\"]" {
+		t.Errorf("Render() = %q", text)
+	}
+
+	for name, text := range map[string]string{
+		"no header":    "just a prompt",
+		"bad schema":   strings.Replace(candidate, `"schemaVersion": 1`, `"schemaVersion": 2`, 1),
+		"no version":   strings.Replace(candidate, `"detailed-v5"`, `""`, 1),
+		"bad body":     candidate + "{{.Nope}}",
+		"unclosed tag": candidate + "{{range .Tags}}",
+	} {
+		if _, err := Parse(text, SourceDB); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	got, err := Load(ctx, AnalyzeEmail, dir, fakeStore{})
+	if err != nil || got.Source != SourceEmbedded {
+		t.Fatalf("expected embedded template, got %+v, %v", got, err)
+	}
+
+	onDisk := strings.Replace(candidate, "detailed-v5", "disk-v1", 1)
+	if err := os.WriteFile(filepath.Join(dir, AnalyzeEmail+".tmpl"), []byte(onDisk), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = Load(ctx, AnalyzeEmail, dir, fakeStore{}); err != nil || got.Version != "disk-v1" || got.Source != SourceDisk {
+		t.Fatalf("expected disk template, got %+v, %v", got, err)
+	}
+
+	if got, err = Load(ctx, AnalyzeEmail, dir, fakeStore{AnalyzeEmail: candidate}); err != nil || got.Version != "detailed-v5" || got.Source != SourceDB {
+		t.Fatalf("expected db template, got %+v, %v", got, err)
+	}
+
+	if _, err = Load(ctx, "other", dir, fakeStore{"other": candidate}); err == nil {
+		t.Error("expected a template stored under the wrong name to be rejected")
+	}
+}
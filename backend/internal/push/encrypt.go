@@ -0,0 +1,110 @@
+package push
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/r7rainz/auramail/internal/user"
+)
+
+// recordSize is the aes128gcm record size. Payloads are sent as a single
+// record, so they must fit in it.
+const recordSize = 4096
+
+// maxPayload is the largest payload that fits in one record: the record
+// holds the payload, a delimiter byte and the 16-byte GCM tag.
+const maxPayload = recordSize - 17
+
+// encryptPayload encrypts plaintext for sub with the aes128gcm content
+// coding of RFC 8291, using a fresh key pair and salt.
+func encryptPayload(sub user.PushSubscription, plaintext []byte) ([]byte, error) {
+	uaPublic, err := decodeBase64(sub.P256dh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	authSecret, err := decodeBase64(sub.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth secret: %w", err)
+	}
+	asKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return encrypt(plaintext, uaPublic, authSecret, asKey, salt)
+}
+
+// encrypt is encryptPayload with the application server key and salt
+// given, so it can be checked against the RFC's example.
+func encrypt(plaintext, uaPublic, authSecret []byte, asKey *ecdh.PrivateKey, salt []byte) ([]byte, error) {
+	if len(plaintext) > maxPayload {
+		return nil, fmt.Errorf("payload is %d bytes, at most %d fit", len(plaintext), maxPayload)
+	}
+	if len(authSecret) != 16 {
+		return nil, errors.New("auth secret must be 16 bytes")
+	}
+	uaKey, err := ecdh.P256().NewPublicKey(uaPublic)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	ecdhSecret, err := asKey.ECDH(uaKey)
+	if err != nil {
+		return nil, err
+	}
+	asPublic := asKey.PublicKey().Bytes()
+
+	// RFC 8291 section 3.4: mix the auth secret and both public keys into
+	// the input keying material, then derive the content encryption key
+	// and nonce from it as RFC 8188 describes.
+	prkKey, err := hkdf.Extract(sha256.New, ecdhSecret, authSecret)
+	if err != nil {
+		return nil, err
+	}
+	keyInfo := "WebPush: info\x00" + string(uaPublic) + string(asPublic)
+	ikm, err := hkdf.Expand(sha256.New, prkKey, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// Header: salt, record size, key ID length and the key ID, which is
+	// the application server's public key.
+	out := make([]byte, 0, 16+4+1+len(asPublic)+len(plaintext)+17)
+	out = append(out, salt...)
+	out = binary.BigEndian.AppendUint32(out, recordSize)
+	out = append(out, byte(len(asPublic)))
+	out = append(out, asPublic...)
+	// 0x02 marks the last (and only) record.
+	record := append(append([]byte(nil), plaintext...), 0x02)
+	return gcm.Seal(out, nonce, record, nil), nil
+}
@@ -0,0 +1,37 @@
+package push
+
+import (
+	"crypto/ecdh"
+	"testing"
+)
+
+// TestEncrypt_RFC8291Example checks encrypt against the worked example in
+// RFC 8291 Appendix A.
+func TestEncrypt_RFC8291Example(t *testing.T) {
+	mustDecode := func(s string) []byte {
+		b, err := decodeBase64(s)
+		if err != nil {
+			t.Fatalf("decode %q: %v", s, err)
+		}
+		return b
+	}
+	asKey, err := ecdh.P256().NewPrivateKey(mustDecode("yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := encrypt(
+		[]byte("When I grow up, I want to be a watermelon"),
+		mustDecode("BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4"),
+		mustDecode("BTBZMqHH6r4Tts7J_aSIgg"),
+		asKey,
+		mustDecode("DGv6ra1nlYgDCS1FRnbzlw"),
+	)
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	want := "DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN"
+	if encodeBase64(got) != want {
+		t.Errorf("encrypt =\n%s\nwant\n%s", encodeBase64(got), want)
+	}
+}
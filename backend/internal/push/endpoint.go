@@ -0,0 +1,37 @@
+package push
+
+import (
+	"net/url"
+	"strings"
+)
+
+// serviceHosts are the push services of the major browsers: Chrome and
+// other Chromium browsers (FCM), Firefox (autopush), Safari and Edge (WNS).
+// Subscription endpoints must be on one of them, or a subdomain, so the
+// server never posts to an address a user picked.
+var serviceHosts = []string{
+	"fcm.googleapis.com",
+	"push.services.mozilla.com",
+	"push.apple.com",
+	"notify.windows.com",
+}
+
+// allowedEndpoint reports whether endpoint is on a known push service or
+// on one of extraHosts, which the operator configured.
+func allowedEndpoint(endpoint string, extraHosts []string) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return false
+	}
+	for _, allowed := range append(serviceHosts[:len(serviceHosts):len(serviceHosts)], extraHosts...) {
+		allowed = strings.ToLower(allowed)
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package push
+
+import (
+	"crypto/ecdh"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"github.com/r7rainz/auramail/internal/auth"
+	"github.com/r7rainz/auramail/internal/response"
+	"github.com/r7rainz/auramail/internal/user"
+)
+
+type Handler struct {
+	repo Repository
+	// keys is nil when push notifications are not configured.
+	keys *VAPIDKeys
+	// extraHosts are push service hosts allowed besides serviceHosts.
+	extraHosts []string
+}
+
+func NewHandler(repo Repository, keys *VAPIDKeys, extraHosts []string) *Handler {
+	return &Handler{repo: repo, keys: keys, extraHosts: extraHosts}
+}
+
+// subscribeRequest is the browser's PushSubscription.toJSON().
+type subscribeRequest struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+type unsubscribeRequest struct {
+	Endpoint string `json:"endpoint"`
+}
+
+// GetKey returns the VAPID public key browsers pass to
+// pushManager.subscribe as the applicationServerKey.
+func (h *Handler) GetKey(w http.ResponseWriter, r *http.Request) {
+	if h.keys == nil {
+		response.ServiceUnavailable(w, "Push notifications are not configured")
+		return
+	}
+	response.Success(w, map[string]string{"publicKey": h.keys.PublicKey()})
+}
+
+// Subscribe registers the user's browser for notifications about new
+// high-priority emails.
+func (h *Handler) Subscribe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(auth.UserIDContextKey).(string)
+	if !ok {
+		response.Unauthorized(w, "No UserID found in context")
+		return
+	}
+	if h.keys == nil {
+		response.ServiceUnavailable(w, "Push notifications are not configured")
+		return
+	}
+
+	var req subscribeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "Invalid request body", nil)
+		return
+	}
+	if u, err := url.Parse(req.Endpoint); err != nil || u.Scheme != "https" || u.Host == "" {
+		response.BadRequest(w, "endpoint must be an https URL", nil)
+		return
+	}
+	if !allowedEndpoint(req.Endpoint, h.extraHosts) {
+		response.BadRequest(w, "endpoint is not on a known push service", nil)
+		return
+	}
+	if msg := validateKeys(req.Keys.P256dh, req.Keys.Auth); msg != "" {
+		response.BadRequest(w, msg, nil)
+		return
+	}
+
+	sub := &user.PushSubscription{Endpoint: req.Endpoint, P256dh: req.Keys.P256dh, Auth: req.Keys.Auth}
+	if err := h.repo.SavePushSubscription(ctx, userID, sub); err != nil {
+		slog.ErrorContext(ctx, "failed to save push subscription", "err", err, "userID", userID)
+		response.InternalError(w, "Failed to save subscription")
+		return
+	}
+	response.Created(w, map[string]string{"endpoint": sub.Endpoint})
+}
+
+// validateKeys returns why a browser's encryption keys cannot be used, or
+// "" when they can.
+func validateKeys(p256dh, authSecret string) string {
+	key, err := decodeBase64(p256dh)
+	if err == nil {
+		_, err = ecdh.P256().NewPublicKey(key)
+	}
+	if err != nil {
+		return "keys.p256dh must be a base64url P-256 public key"
+	}
+	if secret, err := decodeBase64(authSecret); err != nil || len(secret) != 16 {
+		return "keys.auth must be a base64url 16-byte secret"
+	}
+	return ""
+}
+
+// Unsubscribe stops notifications to one of the user's browsers.
+func (h *Handler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(auth.UserIDContextKey).(string)
+	if !ok {
+		response.Unauthorized(w, "No UserID found in context")
+		return
+	}
+
+	var req unsubscribeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Endpoint == "" {
+		response.BadRequest(w, "endpoint is required", nil)
+		return
+	}
+
+	deleted, err := h.repo.DeletePushSubscription(ctx, userID, req.Endpoint)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to delete push subscription", "err", err, "userID", userID)
+		response.InternalError(w, "Failed to delete subscription")
+		return
+	}
+	if !deleted {
+		response.NotFound(w, "Subscription not found")
+		return
+	}
+	response.NoContent(w)
+}
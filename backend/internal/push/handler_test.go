@@ -0,0 +1,89 @@
+package push
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/r7rainz/auramail/internal/auth"
+	"github.com/r7rainz/auramail/internal/push/pushtest"
+)
+
+func withUserID(req *http.Request, userID string) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), auth.UserIDContextKey, userID))
+}
+
+func TestGetKey_NotConfigured(t *testing.T) {
+	rr := httptest.NewRecorder()
+	NewHandler(&fakeRepo{}, nil, nil).GetKey(rr, httptest.NewRequest(http.MethodGet, "/push/key", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", rr.Code)
+	}
+}
+
+func TestSubscribeAndUnsubscribe(t *testing.T) {
+	repo := &fakeRepo{}
+	h := NewHandler(repo, newTestKeys(t), []string{"127.0.0.1"})
+	service := pushtest.NewServer()
+	t.Cleanup(service.Close)
+	browser := service.Subscribe()
+	endpoint := strings.Replace(browser.Endpoint, "http://", "https://", 1)
+
+	subscribe := func(body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.Subscribe(rr, withUserID(httptest.NewRequest(http.MethodPost, "/push/subscriptions", strings.NewReader(body)), "1"))
+		return rr
+	}
+	for _, body := range []string{
+		`{"endpoint":"` + browser.Endpoint + `","keys":{"p256dh":"` + browser.P256dh + `","auth":"` + browser.Auth + `"}}`,
+		`{"endpoint":"https://169.254.169.254/latest/meta-data","keys":{"p256dh":"` + browser.P256dh + `","auth":"` + browser.Auth + `"}}`,
+		`{"endpoint":"` + endpoint + `","keys":{"p256dh":"BAAA","auth":"` + browser.Auth + `"}}`,
+		`{"endpoint":"` + endpoint + `","keys":{"p256dh":"` + browser.P256dh + `","auth":"c2hvcnQ"}}`,
+	} {
+		if rr := subscribe(body); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", body, rr.Code)
+		}
+	}
+
+	rr := subscribe(`{"endpoint":"` + endpoint + `","expirationTime":null,"keys":{"p256dh":"` + browser.P256dh + `","auth":"` + browser.Auth + `"}}`)
+	if rr.Code != http.StatusCreated || len(repo.subs["1"]) != 1 {
+		t.Fatalf("Subscribe: %d %s", rr.Code, rr.Body.String())
+	}
+
+	unsubscribe := func(userID string) int {
+		rr := httptest.NewRecorder()
+		h.Unsubscribe(rr, withUserID(httptest.NewRequest(http.MethodDelete, "/push/subscriptions", strings.NewReader(`{"endpoint":"`+endpoint+`"}`)), userID))
+		return rr.Code
+	}
+	if code := unsubscribe("2"); code != http.StatusNotFound {
+		t.Errorf("another user's unsubscribe: status = %d, want 404", code)
+	}
+	if code := unsubscribe("1"); code != http.StatusNoContent || len(repo.subs["1"]) != 0 {
+		t.Errorf("Unsubscribe: status = %d, subscriptions %v", code, repo.subs["1"])
+	}
+}
+
+func TestAllowedEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     bool
+	}{
+		{"https://fcm.googleapis.com/fcm/send/abc", true},
+		{"https://updates.push.services.mozilla.com/wpush/v2/abc", true},
+		{"https://web.push.apple.com/abc", true},
+		{"https://wns2-par02p.notify.windows.com/w/?token=abc", true},
+		{"https://push.internal:8443/abc", true},
+		{"https://fcm.googleapis.com.evil.example/abc", false},
+		{"https://evilpush.apple.com.example/abc", false},
+		{"https://localhost/abc", false},
+		{"https://10.0.0.5/abc", false},
+		{"https://[::1]/abc", false},
+	}
+	for _, tt := range tests {
+		if got := allowedEndpoint(tt.endpoint, []string{"push.internal"}); got != tt.want {
+			t.Errorf("allowedEndpoint(%q) = %v, want %v", tt.endpoint, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+// Package push sends Web Push notifications (RFC 8030) to the browsers a
+// user registered, so new high-priority placement emails reach them
+// without opening the app. Payloads are encrypted for each browser
+// (RFC 8291) and requests are signed with AuraMail's VAPID key (RFC 8292).
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/user"
+)
+
+const (
+	// messageTTL is how long a push service keeps a message for a browser
+	// that is offline. A day-old alert is still worth showing.
+	messageTTL = 24 * time.Hour
+	// maxBody caps the notification text, in runes.
+	maxBody = 140
+)
+
+// tagUrgent is the analysis tag of emails asking for immediate action.
+const tagUrgent = "urgent"
+
+// errGone means the push service no longer accepts messages for a
+// subscription, e.g. because the user revoked permission.
+var errGone = errors.New("push subscription expired")
+
+// errUnknownService means a subscription's endpoint is not on an allowed
+// push service.
+var errUnknownService = errors.New("push endpoint is not on a known push service")
+
+// Urgent reports whether s is worth interrupting the user for: high
+// priority or tagged urgent.
+func Urgent(s *ai.AIResult) bool {
+	if strings.EqualFold(s.Priority, "high") {
+		return true
+	}
+	for _, tag := range s.Tags {
+		if strings.EqualFold(tag, tagUrgent) {
+			return true
+		}
+	}
+	return false
+}
+
+// Repository is the subset of the user repository push notifications
+// depend on.
+type Repository interface {
+	SavePushSubscription(ctx context.Context, userID string, s *user.PushSubscription) error
+	DeletePushSubscription(ctx context.Context, userID, endpoint string) (bool, error)
+	ListPushSubscriptions(ctx context.Context, userID string) ([]user.PushSubscription, error)
+	DeleteExpiredPushSubscription(ctx context.Context, endpoint string) error
+}
+
+// Payload is the JSON the service worker receives and shows.
+type Payload struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	// URL is opened when the notification is clicked.
+	URL string `json:"url"`
+	// Tag lets a newer notification replace an older one for the same
+	// email.
+	Tag     string `json:"tag"`
+	GmailID string `json:"gmailId,omitempty"`
+}
+
+// newPayload describes the urgent emails from one sync in one
+// notification.
+func newPayload(urgent []*ai.AIResult, appURL string) Payload {
+	if len(urgent) == 1 {
+		s := urgent[0]
+		label := "High priority"
+		if !strings.EqualFold(s.Priority, "high") {
+			label = "Urgent"
+		}
+		return Payload{
+			Title:   label + ": " + s.Title(),
+			Body:    truncate(s.Snippet),
+			URL:     appURL,
+			Tag:     s.GmailMessageID,
+			GmailID: s.GmailMessageID,
+		}
+	}
+	titles := make([]string, 0, len(urgent))
+	for _, s := range urgent {
+		titles = append(titles, s.Title())
+	}
+	return Payload{
+		Title: fmt.Sprintf("%d urgent placement emails", len(urgent)),
+		Body:  truncate(strings.Join(titles, "; ")),
+		URL:   appURL,
+		Tag:   "auramail-urgent",
+	}
+}
+
+func truncate(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > maxBody {
+		return string(r[:maxBody-1]) + "…"
+	}
+	return s
+}
+
+// Notifier pushes new urgent emails to the user's browsers.
+type Notifier struct {
+	repo       Repository
+	keys       *VAPIDKeys
+	appURL     string
+	extraHosts []string
+	client     *http.Client
+	now        func() time.Time
+}
+
+// NewNotifier returns a notifier signing with keys. Notifications open
+// appURL. Endpoints must be on a known push service or one of extraHosts.
+func NewNotifier(repo Repository, keys *VAPIDKeys, appURL string, extraHosts []string) *Notifier {
+	return &Notifier{
+		repo:       repo,
+		keys:       keys,
+		appURL:     appURL,
+		extraHosts: extraHosts,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		now:        time.Now,
+	}
+}
+
+// Notify sends one notification about the urgent emails among summaries
+// to each of the user's browsers and returns how many were delivered.
+// Subscriptions the push service reports as gone are deleted.
+func (n *Notifier) Notify(ctx context.Context, userID string, summaries []*ai.AIResult) (int, error) {
+	var urgent []*ai.AIResult
+	for _, s := range summaries {
+		if Urgent(s) {
+			urgent = append(urgent, s)
+		}
+	}
+	if len(urgent) == 0 {
+		return 0, nil
+	}
+
+	subs, err := n.repo.ListPushSubscriptions(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if len(subs) == 0 {
+		return 0, nil
+	}
+	body, err := json.Marshal(newPayload(urgent, n.appURL))
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var errs []error
+	for _, sub := range subs {
+		err := n.send(ctx, sub, body)
+		switch {
+		case errors.Is(err, errGone):
+			slog.InfoContext(ctx, "push subscription expired, removing", "userID", userID)
+			if err := n.repo.DeleteExpiredPushSubscription(ctx, sub.Endpoint); err != nil {
+				errs = append(errs, err)
+			}
+		case err != nil:
+			errs = append(errs, err)
+		default:
+			sent++
+		}
+	}
+	return sent, errors.Join(errs...)
+}
+
+// send delivers one encrypted message to sub's push service. Endpoints
+// are checked again here since subscriptions saved before the allowlist
+// existed were not. Response bodies are not read; the status is enough.
+func (n *Notifier) send(ctx context.Context, sub user.PushSubscription, payload []byte) error {
+	if !allowedEndpoint(sub.Endpoint, n.extraHosts) {
+		return errUnknownService
+	}
+	body, err := encryptPayload(sub, payload)
+	if err != nil {
+		return err
+	}
+	authorization, err := n.keys.authorization(sub.Endpoint, n.now())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(int(messageTTL.Seconds())))
+	req.Header.Set("Urgency", "high")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return errGone
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return fmt.Errorf("push service returned %d", resp.StatusCode)
+	}
+	return nil
+}
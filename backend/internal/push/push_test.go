@@ -0,0 +1,137 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/push/pushtest"
+	"github.com/r7rainz/auramail/internal/user"
+)
+
+func strPtr(s string) *string { return &s }
+
+type fakeRepo struct {
+	subs    map[string][]user.PushSubscription
+	expired []string
+}
+
+func (f *fakeRepo) SavePushSubscription(ctx context.Context, userID string, s *user.PushSubscription) error {
+	if f.subs == nil {
+		f.subs = make(map[string][]user.PushSubscription)
+	}
+	f.subs[userID] = append(f.subs[userID], *s)
+	return nil
+}
+
+func (f *fakeRepo) DeletePushSubscription(ctx context.Context, userID, endpoint string) (bool, error) {
+	n := len(f.subs[userID])
+	f.subs[userID] = slices.DeleteFunc(f.subs[userID], func(s user.PushSubscription) bool { return s.Endpoint == endpoint })
+	return len(f.subs[userID]) < n, nil
+}
+
+func (f *fakeRepo) DeleteExpiredPushSubscription(ctx context.Context, endpoint string) error {
+	f.expired = append(f.expired, endpoint)
+	return nil
+}
+
+func (f *fakeRepo) ListPushSubscriptions(ctx context.Context, userID string) ([]user.PushSubscription, error) {
+	return f.subs[userID], nil
+}
+
+func newTestKeys(t *testing.T) *VAPIDKeys {
+	t.Helper()
+	public, private, err := GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := ParseKeys(public, private, "mailto:placements@example.edu")
+	if err != nil {
+		t.Fatalf("ParseKeys: %v", err)
+	}
+	return keys
+}
+
+func TestParseKeys_RejectsMismatchedPair(t *testing.T) {
+	public, _, _ := GenerateKeys()
+	_, private, _ := GenerateKeys()
+	if _, err := ParseKeys(public, private, "mailto:a@example.edu"); err == nil {
+		t.Error("expected an error for a public key from another pair")
+	}
+}
+
+func TestNotify(t *testing.T) {
+	service := pushtest.NewServer()
+	t.Cleanup(service.Close)
+	phone, laptop := service.Subscribe(), service.Subscribe()
+	service.Expire(laptop.Endpoint)
+
+	repo := &fakeRepo{subs: map[string][]user.PushSubscription{"1": {phone, laptop}}}
+	n := NewNotifier(repo, newTestKeys(t), "http://app/dashboard", []string{"127.0.0.1"})
+
+	summaries := []*ai.AIResult{
+		{GmailMessageID: "m1", Priority: "low", Company: strPtr("Initech")},
+		{GmailMessageID: "m2", Priority: "high", Company: strPtr("Globex"), Role: strPtr("SDE"), Snippet: "Online test   tomorrow at 9 AM"},
+	}
+	sent, err := n.Notify(context.Background(), "1", summaries)
+	if err != nil || sent != 1 {
+		t.Fatalf("Notify = %d, %v; want 1 push", sent, err)
+	}
+	if !slices.Equal(repo.expired, []string{laptop.Endpoint}) {
+		t.Errorf("expired = %v, want the laptop's subscription", repo.expired)
+	}
+
+	messages := service.Messages()
+	if len(messages) != 1 || messages[0].Endpoint != phone.Endpoint {
+		t.Fatalf("push service got %+v, want one push to the phone", messages)
+	}
+	if got := messages[0].Header.Get("Urgency"); got != "high" {
+		t.Errorf("Urgency = %q", got)
+	}
+	if messages[0].Subject != "mailto:placements@example.edu" {
+		t.Errorf("VAPID subject = %q", messages[0].Subject)
+	}
+	var p Payload
+	if err := json.Unmarshal(messages[0].Payload, &p); err != nil {
+		t.Fatalf("payload %q: %v", messages[0].Payload, err)
+	}
+	want := Payload{Title: "High priority: Globex – SDE", Body: "Online test tomorrow at 9 AM", URL: "http://app/dashboard", Tag: "m2", GmailID: "m2"}
+	if p != want {
+		t.Errorf("payload = %+v, want %+v", p, want)
+	}
+
+	// Several urgent emails from one sync make one notification.
+	summaries = append(summaries, &ai.AIResult{GmailMessageID: "m3", Tags: []string{"urgent"}, Subject: "Submit documents today"})
+	if sent, err := n.Notify(context.Background(), "1", summaries); err != nil || sent != 1 {
+		t.Fatalf("Notify = %d, %v", sent, err)
+	}
+	messages = service.Messages()
+	if err := json.Unmarshal(messages[len(messages)-1].Payload, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Title != "2 urgent placement emails" || p.Body != "Globex – SDE; Submit documents today" {
+		t.Errorf("payload = %+v", p)
+	}
+
+	if sent, err := n.Notify(context.Background(), "1", summaries[:1]); err != nil || sent != 0 {
+		t.Errorf("Notify without urgent emails = %d, %v; want nothing sent", sent, err)
+	}
+}
+
+func TestNotify_RefusesUnknownPushServices(t *testing.T) {
+	service := pushtest.NewServer()
+	t.Cleanup(service.Close)
+	repo := &fakeRepo{subs: map[string][]user.PushSubscription{"1": {service.Subscribe()}}}
+	n := NewNotifier(repo, newTestKeys(t), "http://app/dashboard", nil)
+
+	sent, err := n.Notify(context.Background(), "1", []*ai.AIResult{{GmailMessageID: "m1", Priority: "high"}})
+	if sent != 0 || !errors.Is(err, errUnknownService) {
+		t.Errorf("Notify = %d, %v; want errUnknownService", sent, err)
+	}
+	if len(service.Messages()) != 0 {
+		t.Error("a push was sent to a host that is not allowed")
+	}
+}
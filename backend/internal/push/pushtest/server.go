@@ -0,0 +1,246 @@
+// Package pushtest provides a local stand-in for a Web Push service. It
+// hands out subscriptions the way a browser would, checks the VAPID
+// signature of every push, decrypts the payload and keeps it for tests.
+package pushtest
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/r7rainz/auramail/internal/user"
+)
+
+// Message is a push the server accepted.
+type Message struct {
+	Endpoint string
+	// Payload is the decrypted message.
+	Payload []byte
+	Header  http.Header
+	// Subject is the sub claim of the VAPID token.
+	Subject string
+}
+
+type browser struct {
+	key  *ecdh.PrivateKey
+	auth []byte
+	gone bool
+}
+
+// Server is a fake push service backed by httptest.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	browsers map[string]*browser
+	messages []Message
+}
+
+// NewServer starts a fake push service. Close it when done.
+func NewServer() *Server {
+	s := &Server{browsers: make(map[string]*browser)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /push/{id}", s.push)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Subscribe creates a browser subscription, with its own keys, on the
+// fake.
+func (s *Server) Subscribe() user.PushSubscription {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	auth := make([]byte, 16)
+	_, _ = rand.Read(auth)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := fmt.Sprintf("b%d", len(s.browsers)+1)
+	s.browsers[id] = &browser{key: key, auth: auth}
+	return user.PushSubscription{
+		Endpoint: s.URL + "/push/" + id,
+		P256dh:   base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()),
+		Auth:     base64.RawURLEncoding.EncodeToString(auth),
+	}
+}
+
+// Expire makes pushes to endpoint fail with 410 Gone, as when the user
+// revoked notification permission.
+func (s *Server) Expire(endpoint string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if b, ok := s.browsers[strings.TrimPrefix(endpoint, s.URL+"/push/")]; ok {
+		b.gone = true
+	}
+}
+
+// Messages returns the pushes accepted so far.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+func (s *Server) push(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	b, ok := s.browsers[r.PathValue("id")]
+	s.mu.Unlock()
+	switch {
+	case !ok:
+		http.Error(w, "no such subscription", http.StatusNotFound)
+		return
+	case b.gone:
+		http.Error(w, "subscription expired", http.StatusGone)
+		return
+	}
+
+	subject, err := s.checkVAPID(r.Header.Get("Authorization"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if r.Header.Get("Content-Encoding") != "aes128gcm" || r.Header.Get("TTL") == "" {
+		http.Error(w, "Content-Encoding aes128gcm and TTL are required", http.StatusBadRequest)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	payload, err := decrypt(body, b)
+	if err != nil {
+		http.Error(w, "cannot decrypt payload: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.messages = append(s.messages, Message{Endpoint: s.URL + r.URL.Path, Payload: payload, Header: r.Header.Clone(), Subject: subject})
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusCreated)
+}
+
+// checkVAPID verifies a "vapid t=<jwt>, k=<key>" header and returns the
+// token's subject.
+func (s *Server) checkVAPID(header string) (string, error) {
+	params, ok := strings.CutPrefix(header, "vapid ")
+	if !ok {
+		return "", errors.New("missing vapid authorization")
+	}
+	var token, key string
+	for _, p := range strings.Split(params, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(p), "=")
+		switch name {
+		case "t":
+			token = value
+		case "k":
+			key = value
+		}
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(key)
+	if err != nil {
+		return "", errors.New("invalid vapid key")
+	}
+	if _, err := ecdh.P256().NewPublicKey(raw); err != nil {
+		return "", errors.New("invalid vapid key")
+	}
+	pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(raw[1:33]), Y: new(big.Int).SetBytes(raw[33:])}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) { return pub, nil },
+		jwt.WithValidMethods([]string{jwt.SigningMethodES256.Alg()}),
+		jwt.WithAudience(s.URL),
+		jwt.WithExpirationRequired())
+	if err != nil {
+		return "", fmt.Errorf("invalid vapid token: %w", err)
+	}
+	subject, _ := claims.GetSubject()
+	if subject == "" {
+		return "", errors.New("vapid token has no subject")
+	}
+	return subject, nil
+}
+
+// decrypt reverses the aes128gcm content coding of RFC 8291 for b.
+func decrypt(body []byte, b *browser) ([]byte, error) {
+	if len(body) < 21 {
+		return nil, errors.New("body too short")
+	}
+	salt := body[:16]
+	recordSize := binary.BigEndian.Uint32(body[16:20])
+	idLen := int(body[20])
+	if len(body) < 21+idLen {
+		return nil, errors.New("body too short")
+	}
+	asPublic, ciphertext := body[21:21+idLen], body[21+idLen:]
+	if uint32(len(ciphertext)) > recordSize {
+		return nil, errors.New("more than one record")
+	}
+
+	asKey, err := ecdh.P256().NewPublicKey(asPublic)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := b.key.ECDH(asKey)
+	if err != nil {
+		return nil, err
+	}
+	prkKey, err := hkdf.Extract(sha256.New, secret, b.auth)
+	if err != nil {
+		return nil, err
+	}
+	ikm, err := hkdf.Expand(sha256.New, prkKey, "WebPush: info\x00"+string(b.key.PublicKey().Bytes())+string(asPublic), 32)
+	if err != nil {
+		return nil, err
+	}
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	record, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Strip the padding and the last-record delimiter.
+	record = []byte(strings.TrimRight(string(record), "\x00"))
+	if len(record) == 0 || record[len(record)-1] != 0x02 {
+		return nil, errors.New("missing last record delimiter")
+	}
+	return record[:len(record)-1], nil
+}
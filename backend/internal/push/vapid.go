@@ -0,0 +1,108 @@
+package push
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/r7rainz/auramail/internal/config"
+)
+
+// vapidTTL is how long a VAPID token is valid; push services reject
+// tokens valid for more than 24 hours.
+const vapidTTL = 12 * time.Hour
+
+// VAPIDKeys identify AuraMail to push services (RFC 8292).
+type VAPIDKeys struct {
+	public  []byte
+	private *ecdsa.PrivateKey
+	subject string
+}
+
+// KeysFromConfig returns the VAPID keys in cfg, or nil when push
+// notifications are not configured.
+func KeysFromConfig(cfg *config.Config) (*VAPIDKeys, error) {
+	if cfg.VAPIDPublicKey == "" && cfg.VAPIDPrivateKey == "" {
+		return nil, nil
+	}
+	return ParseKeys(cfg.VAPIDPublicKey, cfg.VAPIDPrivateKey, cfg.VAPIDSubject)
+}
+
+// ParseKeys reads a base64url encoded key pair: the uncompressed P-256
+// public key and the 32-byte private scalar.
+func ParseKeys(public, private, subject string) (*VAPIDKeys, error) {
+	d, err := decodeBase64(private)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	key, err := ecdh.P256().NewPrivateKey(d)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	pub := key.PublicKey().Bytes()
+	if want, err := decodeBase64(public); err != nil || string(want) != string(pub) {
+		return nil, errors.New("VAPID public key does not match the private key")
+	}
+
+	// The uncompressed point is 0x04 || X || Y.
+	signer := &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(pub[1:33]),
+			Y:     new(big.Int).SetBytes(pub[33:]),
+		},
+		D: new(big.Int).SetBytes(d),
+	}
+	return &VAPIDKeys{public: pub, private: signer, subject: subject}, nil
+}
+
+// GenerateKeys returns a new base64url encoded VAPID key pair.
+func GenerateKeys() (public, private string, err error) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return encodeBase64(key.PublicKey().Bytes()), encodeBase64(key.Bytes()), nil
+}
+
+// PublicKey is the applicationServerKey browsers subscribe with.
+func (k *VAPIDKeys) PublicKey() string {
+	return encodeBase64(k.public)
+}
+
+// authorization returns the Authorization header for a push to endpoint.
+func (k *VAPIDKeys) authorization(endpoint string, now time.Time) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": now.Add(vapidTTL).Unix(),
+		"sub": k.subject,
+	}).SignedString(k.private)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("vapid t=%s, k=%s", token, k.PublicKey()), nil
+}
+
+func encodeBase64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeBase64 accepts base64url with or without padding, as browsers and
+// key generators differ.
+func decodeBase64(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
@@ -0,0 +1,373 @@
+// Package redact replaces personal data in email text with stable
+// placeholders before it is sent to the LLM, and restores the originals in
+// the extracted fields afterwards. Patterns come from an institution
+// profile layered on top of built-in defaults that always apply.
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/r7rainz/auramail/internal/ai"
+)
+
+// DefaultProfile is the name of the built-in profile.
+const DefaultProfile = "default"
+
+// Pattern matches one kind of personal data. Matches are replaced with
+// "[LABEL_n]".
+type Pattern struct {
+	Label string `json:"label"`
+	Regex string `json:"regex"`
+}
+
+// Profile is an institution's redaction configuration. Its patterns run
+// before the defaults, so a more specific pattern wins over a generic one.
+type Profile struct {
+	Name     string    `json:"name"`
+	Patterns []Pattern `json:"patterns"`
+	// KeepEmails and KeepEmailDomains list addresses that are not personal,
+	// such as the placement office, and are sent as is.
+	KeepEmails       []string `json:"keepEmails"`
+	KeepEmailDomains []string `json:"keepEmailDomains"`
+}
+
+const emailLabel = "EMAIL"
+
+// defaultPatterns always apply. Phone numbers are matched before Aadhaar
+// numbers so that a +91 prefix is not read as the start of a 12 digit ID.
+var defaultPatterns = []Pattern{
+	{Label: emailLabel, Regex: `[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`},
+	{Label: "PHONE", Regex: `(?:\+91[\s-]?|\b)[6-9]\d{4}[\s-]?\d{5}\b`},
+	{Label: "AADHAAR", Regex: `\b[2-9]\d{3}[\s-]?\d{4}[\s-]?\d{4}\b`},
+	{Label: "PAN", Regex: `\b[A-Z]{5}\d{4}[A-Z]\b`},
+	{Label: "REGNO", Regex: `\b\d{2}[A-Z]{3}\d{5}\b`},
+}
+
+var labelPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+type compiledPattern struct {
+	label string
+	re    *regexp.Regexp
+}
+
+// Redactor applies a profile. It is safe for concurrent use.
+type Redactor struct {
+	name        string
+	patterns    []compiledPattern
+	keepEmails  map[string]bool
+	keepDomains []string
+}
+
+// New compiles p on top of the default patterns.
+func New(p Profile) (*Redactor, error) {
+	r := &Redactor{
+		name:       p.Name,
+		keepEmails: make(map[string]bool),
+	}
+	if r.name == "" {
+		r.name = DefaultProfile
+	}
+	for _, pat := range append(append([]Pattern(nil), p.Patterns...), defaultPatterns...) {
+		if !labelPattern.MatchString(pat.Label) {
+			return nil, fmt.Errorf("invalid redaction label %q", pat.Label)
+		}
+		re, err := regexp.Compile(pat.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %s: %w", pat.Label, err)
+		}
+		r.patterns = append(r.patterns, compiledPattern{label: pat.Label, re: re})
+	}
+	for _, e := range p.KeepEmails {
+		r.keepEmails[strings.ToLower(strings.TrimSpace(e))] = true
+	}
+	for _, d := range p.KeepEmailDomains {
+		r.keepDomains = append(r.keepDomains, strings.ToLower(strings.TrimPrefix(strings.TrimSpace(d), "@")))
+	}
+	return r, nil
+}
+
+// Default returns the redactor for the built-in profile.
+func Default() *Redactor {
+	r, err := New(Profile{Name: DefaultProfile})
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+var (
+	loadedMu sync.Mutex
+	loaded   = make(map[string]*Redactor)
+)
+
+// Load returns the redactor for a profile name: empty or "default" for the
+// built-in profile, or the path of a JSON profile file. Results are cached
+// per name.
+func Load(name string) (*Redactor, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || name == DefaultProfile {
+		name = DefaultProfile
+	}
+
+	loadedMu.Lock()
+	defer loadedMu.Unlock()
+	if r, ok := loaded[name]; ok {
+		return r, nil
+	}
+
+	var r *Redactor
+	if name == DefaultProfile {
+		r = Default()
+	} else {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read redaction profile: %w", err)
+		}
+		var p Profile
+		if err := json.Unmarshal(data, &p); err != nil {
+			return nil, fmt.Errorf("failed to parse redaction profile: %w", err)
+		}
+		if r, err = New(p); err != nil {
+			return nil, err
+		}
+	}
+	loaded[name] = r
+	return r, nil
+}
+
+// LoadOrDefault is Load for callers that must keep redacting: a profile
+// that fails to load is logged and the built-in profile is used instead.
+func LoadOrDefault(name string) *Redactor {
+	r, err := Load(name)
+	if err != nil {
+		slog.Error("redaction profile unavailable, using default patterns", "profile", name, "err", err)
+		return Default()
+	}
+	return r
+}
+
+// Name returns the profile name.
+func (r *Redactor) Name() string {
+	return r.name
+}
+
+// Session redacts the texts of one email. The same value gets the same
+// placeholder across all texts in a session, so the model can still tell
+// that the subject and body mention the same number.
+type Session struct {
+	r        *Redactor
+	byValue  map[string]string
+	byToken  map[string]string
+	counters map[string]int
+}
+
+// NewSession starts redacting a new email. A nil Redactor, as used for
+// users who turned redaction off, returns a nil Session, which leaves text
+// as is.
+func (r *Redactor) NewSession() *Session {
+	if r == nil {
+		return nil
+	}
+	return &Session{
+		r:        r,
+		byValue:  make(map[string]string),
+		byToken:  make(map[string]string),
+		counters: make(map[string]int),
+	}
+}
+
+// Redact replaces personal data in text with placeholders.
+func (s *Session) Redact(text string) string {
+	if s == nil {
+		return text
+	}
+	for _, p := range s.r.patterns {
+		text = p.re.ReplaceAllStringFunc(text, func(match string) string {
+			if p.label == emailLabel && s.r.keepEmail(match) {
+				return match
+			}
+			return s.token(p.label, match)
+		})
+	}
+	return text
+}
+
+// Count returns how many distinct values were redacted.
+func (s *Session) Count() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.byToken)
+}
+
+func (s *Session) token(label, value string) string {
+	if t, ok := s.byValue[value]; ok {
+		return t
+	}
+	s.counters[label]++
+	t := "[" + label + "_" + strconv.Itoa(s.counters[label]) + "]"
+	s.byValue[value] = t
+	s.byToken[t] = value
+	return t
+}
+
+func (r *Redactor) keepEmail(addr string) bool {
+	addr = strings.ToLower(addr)
+	if r.keepEmails[addr] {
+		return true
+	}
+	at := strings.LastIndex(addr, "@")
+	for _, d := range r.keepDomains {
+		if addr[at+1:] == d {
+			return true
+		}
+	}
+	return false
+}
+
+// Restore puts the original values back into text.
+func (s *Session) Restore(text string) string {
+	if s == nil || len(s.byToken) == 0 || !strings.Contains(text, "[") {
+		return text
+	}
+	pairs := make([]string, 0, 2*len(s.byToken))
+	for t, value := range s.byToken {
+		pairs = append(pairs, t, value)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// maxTokenLength bounds how long a placeholder can be, so a stray "[" in a
+// stream does not hold back the rest of it.
+const maxTokenLength = 32
+
+// Restorer restores placeholders in text that arrives in pieces, such as a
+// streamed model answer, where a placeholder can be split across pieces.
+type Restorer struct {
+	s       *Session
+	pending string
+}
+
+// NewRestorer starts restoring a stream.
+func (s *Session) NewRestorer() *Restorer {
+	return &Restorer{s: s}
+}
+
+// Write returns the restored text of piece that is ready to be shown. A
+// trailing, possibly incomplete placeholder is held back until the next
+// piece or Flush.
+func (r *Restorer) Write(piece string) string {
+	text := r.pending + piece
+	r.pending = ""
+	if open := strings.LastIndex(text, "["); open >= 0 && !strings.Contains(text[open:], "]") && len(text)-open < maxTokenLength {
+		text, r.pending = text[:open], text[open:]
+	}
+	return r.s.Restore(text)
+}
+
+// Flush returns the text still held back.
+func (r *Restorer) Flush() string {
+	text := r.pending
+	r.pending = ""
+	return text
+}
+
+// hasToken reports whether text still contains a placeholder.
+func (s *Session) hasToken(text string) bool {
+	for t := range s.byToken {
+		if strings.Contains(text, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// RestoreResult returns a copy of res with the original values put back
+// into the fields that are only shown to the user who owns the email. res
+// itself is left as is, since the analyzer caches it. Company, role,
+// category and tags feed shared tables and aggregates, so they are never
+// restored; a company or role the model could only name by placeholder is
+// dropped, as are tags that carry one.
+func (s *Session) RestoreResult(res *ai.AIResult) *ai.AIResult {
+	if s == nil || res == nil || len(s.byToken) == 0 {
+		return res
+	}
+
+	out := *res
+	out.Summary = s.Restore(res.Summary)
+	out.Eligibility = s.restoreAny(res.Eligibility)
+	out.Timings = s.restoreAny(res.Timings)
+	out.Salary = s.restoreAny(res.Salary)
+	out.Location = s.restoreAny(res.Location)
+	out.EventDetails = s.restoreAny(res.EventDetails)
+	out.Requirements = s.restoreAny(res.Requirements)
+	out.Deadline = s.restorePtr(res.Deadline)
+	out.ApplyLink = s.restorePtr(res.ApplyLink)
+	out.AttachmentSummary = s.restorePtr(res.AttachmentSummary)
+	if res.OtherLinks != nil {
+		out.OtherLinks = make([]string, len(res.OtherLinks))
+		for i, link := range res.OtherLinks {
+			out.OtherLinks[i] = s.Restore(link)
+		}
+	}
+
+	if res.Company != nil && s.hasToken(*res.Company) {
+		out.Company = nil
+	}
+	if res.Role != nil && s.hasToken(*res.Role) {
+		out.Role = nil
+	}
+	if res.Tags != nil {
+		out.Tags = make([]string, 0, len(res.Tags))
+		for _, tag := range res.Tags {
+			if !s.hasToken(tag) {
+				out.Tags = append(out.Tags, tag)
+			}
+		}
+	}
+	return &out
+}
+
+func (s *Session) restorePtr(v *string) *string {
+	if v == nil {
+		return nil
+	}
+	restored := s.Restore(*v)
+	return &restored
+}
+
+// restoreAny copies the JSON-shaped values the analyzer returns for
+// loosely typed fields, restoring every string in them.
+func (s *Session) restoreAny(v any) any {
+	switch val := v.(type) {
+	case string:
+		return s.Restore(val)
+	case []any:
+		out := make([]any, len(val))
+		for i := range val {
+			out[i] = s.restoreAny(val[i])
+		}
+		return out
+	case []string:
+		out := make([]string, len(val))
+		for i := range val {
+			out[i] = s.Restore(val[i])
+		}
+		return out
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k := range val {
+			out[k] = s.restoreAny(val[k])
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -0,0 +1,119 @@
+package redact
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/r7rainz/auramail/internal/ai"
+)
+
+func TestRedact_DefaultPatterns(t *testing.T) {
+	s := Default().NewSession()
+	got := s.Redact("Shortlist: Asha (21BCE10234, asha.rao@gmail.com, +91 98765 43210), Aadhaar 2345 6789 0123, PAN ABCDE1234F. Call 9876543210 or +91 98765 43210.")
+	want := "Shortlist: Asha ([REGNO_1], [EMAIL_1], [PHONE_1]), Aadhaar [AADHAAR_1], PAN [PAN_1]. Call [PHONE_2] or [PHONE_1]."
+	if got != want {
+		t.Errorf("Redact() =\n%s\nwant\n%s", got, want)
+	}
+	if s.Count() != 6 {
+		t.Errorf("Count() = %d, want 6", s.Count())
+	}
+	if restored := s.Restore(got); !strings.Contains(restored, "asha.rao@gmail.com, +91 98765 43210") {
+		t.Errorf("Restore() = %s", restored)
+	}
+}
+
+func TestRedact_LeavesDatesAndAmountsAlone(t *testing.T) {
+	text := "Deadline 2026-08-14, CTC 1200000 INR, OA on 14AUG2026 at 10:00."
+	if got := Default().NewSession().Redact(text); got != text {
+		t.Errorf("Redact() = %s", got)
+	}
+}
+
+func TestLoad_ProfileFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "college.json")
+	profile := `{
+		"name": "college",
+		"patterns": [{"label": "ROLLNO", "regex": "\\bCS\\d{6}\\b"}],
+		"keepEmails": ["placementoffice@college.ac.in"],
+		"keepEmailDomains": ["recruiter.example.com"]
+	}`
+	if err := os.WriteFile(path, []byte(profile), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if r.Name() != "college" {
+		t.Errorf("Name() = %q", r.Name())
+	}
+	got := r.NewSession().Redact("Mail placementoffice@college.ac.in or hr@recruiter.example.com, not me@college.ac.in. Roll CS123456, reg 21BCE10234.")
+	want := "Mail placementoffice@college.ac.in or hr@recruiter.example.com, not [EMAIL_1]. Roll [ROLLNO_1], reg [REGNO_1]."
+	if got != want {
+		t.Errorf("Redact() =\n%s\nwant\n%s", got, want)
+	}
+
+	if _, err := New(Profile{Patterns: []Pattern{{Label: "bad label", Regex: "x"}}}); err == nil {
+		t.Error("expected invalid label to be rejected")
+	}
+}
+
+func TestRestoreResult_OnlyUserFields(t *testing.T) {
+	s := Default().NewSession()
+	s.Redact("Contact 9876543210 or hr.personal@gmail.com")
+	company, role := "[EMAIL_1]", "SDE"
+	link := "https://wa.me/[PHONE_1]"
+	res := &ai.AIResult{
+		Summary:   "Call [PHONE_1] to confirm.",
+		Timings:   map[string]any{"contact": []any{"[PHONE_1]", "[EMAIL_1]"}},
+		ApplyLink: &link,
+		Company:   &company,
+		Role:      &role,
+		Tags:      []string{"drive", "[PHONE_1]"},
+	}
+	original := res.Summary
+	res = s.RestoreResult(res)
+
+	if res.Summary != "Call 9876543210 to confirm." || *res.ApplyLink != "https://wa.me/9876543210" {
+		t.Errorf("unexpected restore: %q %q", res.Summary, *res.ApplyLink)
+	}
+	contact := res.Timings.(map[string]any)["contact"].([]any)
+	if contact[0] != "9876543210" || contact[1] != "hr.personal@gmail.com" {
+		t.Errorf("nested values not restored: %v", contact)
+	}
+	if res.Company != nil || res.Role == nil || len(res.Tags) != 1 {
+		t.Errorf("shared fields should drop placeholders: company=%v role=%v tags=%v", res.Company, res.Role, res.Tags)
+	}
+	if original != "Call [PHONE_1] to confirm." {
+		t.Errorf("input was modified: %q", original)
+	}
+}
+
+func TestRestorer_PlaceholderSplitAcrossPieces(t *testing.T) {
+	s := Default().NewSession()
+	s.Redact("Call 9876543210 or mail hr@acme.com")
+
+	r := s.NewRestorer()
+	var out strings.Builder
+	for _, piece := range []string{"Call [PH", "ONE_1] or mail ", "[EMAIL_1", "] [abc123]", " [note"} {
+		out.WriteString(r.Write(piece))
+	}
+	out.WriteString(r.Flush())
+	if want := "Call 9876543210 or mail hr@acme.com [abc123] [note"; out.String() != want {
+		t.Errorf("restored stream = %q, want %q", out.String(), want)
+	}
+}
+
+func TestNilSession_LeavesTextAlone(t *testing.T) {
+	var r *Redactor
+	s := r.NewSession()
+	if got := s.Redact("Call 9876543210"); got != "Call 9876543210" {
+		t.Errorf("Redact() = %q", got)
+	}
+	if got := s.NewRestorer().Write("[PHONE_1]"); got != "[PHONE_1]" {
+		t.Errorf("Restorer.Write() = %q", got)
+	}
+}
@@ -0,0 +1,309 @@
+// Package reply drafts responses to placement emails that ask students to
+// reply with their details or register through a form. Drafts are only
+// ever returned or saved as Gmail drafts; nothing here sends mail.
+package reply
+
+import (
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/eligibility"
+)
+
+// Field names a draft can fill in.
+const (
+	FieldName        = "Name"
+	FieldRegNo       = "Registration number"
+	FieldEmail       = "Email"
+	FieldPhone       = "Phone"
+	FieldDegree      = "Degree"
+	FieldBranch      = "Branch"
+	FieldPassoutYear = "Passout year"
+	FieldCGPA        = "CGPA"
+	FieldTenth       = "10th percentage"
+	FieldTwelfth     = "12th percentage"
+	FieldBacklogs    = "Active backlogs"
+	FieldGender      = "Gender"
+	FieldResume      = "Resume"
+)
+
+// fieldKeywords detect which details an email asks for. Order is the order
+// fields appear in the draft.
+var fieldKeywords = []struct {
+	field    string
+	keywords []string
+}{
+	{FieldName, []string{"name"}},
+	{FieldRegNo, []string{"registration number", "registration no", "reg no", "reg. no", "reg number", "roll number", "roll no", "enrollment number", "enrolment number"}},
+	{FieldEmail, []string{"email id", "email address", "mail id"}},
+	{FieldPhone, []string{"phone", "mobile", "contact number", "whatsapp"}},
+	{FieldDegree, []string{"degree", "course", "program"}},
+	{FieldBranch, []string{"branch", "specialization", "specialisation", "department"}},
+	{FieldPassoutYear, []string{"passout", "pass out", "passing year", "year of passing", "graduation year", "batch"}},
+	{FieldCGPA, []string{"cgpa", "gpa"}},
+	{FieldTenth, []string{"10th", "tenth", "ssc", "class x "}},
+	{FieldTwelfth, []string{"12th", "twelfth", "hsc", "class xii", "diploma"}},
+	{FieldBacklogs, []string{"backlog", "arrear"}},
+	{FieldGender, []string{"gender"}},
+	{FieldResume, []string{"resume", "cv"}},
+}
+
+// requestTriggers mark text that asks the student to send details back.
+var requestTriggers = []string{
+	"reply with", "reply to this", "revert with", "send your", "share your", "provide your",
+	"following details", "below details", "details below", "fill", "mention your", "submit your",
+}
+
+// formHosts are link hosts for registration forms.
+var formHosts = []string{
+	"forms.gle", "docs.google.com/forms", "forms.office.com", "forms.microsoft.com",
+	"typeform.com", "jotform.com", "airtable.com/shr", "zfrmz.com",
+}
+
+// Profile is what the draft can fill in about the student.
+type Profile struct {
+	Name     string
+	Email    string
+	Academic *eligibility.Profile
+}
+
+// Field is a detail the email asked for and the value the draft uses.
+type Field struct {
+	Name  string `json:"name"`
+	Value string `json:"value,omitempty"`
+	// Missing fields appear in the body as a [placeholder] for the student
+	// to fill in before sending.
+	Missing bool `json:"missing"`
+}
+
+// Draft is a reply ready for the student to review.
+type Draft struct {
+	To        string   `json:"to"`
+	Subject   string   `json:"subject"`
+	Body      string   `json:"body"`
+	Fields    []Field  `json:"fields"`
+	FormLinks []string `json:"formLinks"`
+	// Source is "ai" when the model wrote the body and "template"
+	// otherwise.
+	Source string `json:"source"`
+}
+
+// Draft sources.
+const (
+	SourceAI       = "ai"
+	SourceTemplate = "template"
+)
+
+// Requested returns the fields an email asks the student to send, in draft
+// order. It is empty when the email does not ask for a reply with details.
+func Requested(text string) []string {
+	lower := " " + strings.ToLower(text) + " "
+	asks := false
+	for _, t := range requestTriggers {
+		if strings.Contains(lower, t) {
+			asks = true
+			break
+		}
+	}
+	if !asks {
+		return nil
+	}
+
+	var fields []string
+	for _, fk := range fieldKeywords {
+		for _, kw := range fk.keywords {
+			if containsWord(lower, kw) {
+				fields = append(fields, fk.field)
+				break
+			}
+		}
+	}
+	return fields
+}
+
+// containsWord reports whether kw occurs in text with non-letters on both
+// sides, so "cv" does not match "cvs" and "name" does not match "rename".
+func containsWord(text, kw string) bool {
+	for i := 0; ; {
+		j := strings.Index(text[i:], kw)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(kw)
+		if !isLetter(text, start-1) && !isLetter(text, end) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+func isLetter(text string, i int) bool {
+	if i < 0 || i >= len(text) {
+		return false
+	}
+	c := text[i]
+	return c >= 'a' && c <= 'z'
+}
+
+// FormLinks returns the registration form links in a summary.
+func FormLinks(res *ai.AIResult) []string {
+	links := make([]string, 0)
+	seen := make(map[string]bool)
+	candidates := append([]string(nil), res.OtherLinks...)
+	if res.ApplyLink != nil {
+		candidates = append([]string{*res.ApplyLink}, candidates...)
+	}
+	for _, link := range candidates {
+		lower := strings.ToLower(link)
+		for _, host := range formHosts {
+			if strings.Contains(lower, host) && !seen[link] {
+				seen[link] = true
+				links = append(links, link)
+			}
+		}
+	}
+	return links
+}
+
+// Compose builds a template reply to res for the student in p. Requested
+// details are filled from the profile; those it lacks become placeholders.
+func Compose(res *ai.AIResult, p Profile) *Draft {
+	text := strings.Join([]string{res.Subject, res.Summary, deref(res.Description), ai.FieldText(res.Requirements)}, "\n")
+	d := &Draft{
+		To:        ReplyAddress(res.Sender),
+		Subject:   ReplySubject(res.Subject),
+		FormLinks: FormLinks(res),
+		Fields:    make([]Field, 0),
+		Source:    SourceTemplate,
+	}
+	for _, name := range Requested(text) {
+		value := fieldValue(name, p)
+		d.Fields = append(d.Fields, Field{Name: name, Value: value, Missing: value == ""})
+	}
+	d.Body = templateBody(res, p, d)
+	return d
+}
+
+// FieldLines renders fields as "Name: value" lines with placeholders for
+// missing values.
+func FieldLines(fields []Field) []string {
+	lines := make([]string, 0, len(fields))
+	for _, f := range fields {
+		value := f.Value
+		if f.Missing {
+			value = "[" + f.Name + "]"
+		}
+		lines = append(lines, f.Name+": "+value)
+	}
+	return lines
+}
+
+func templateBody(res *ai.AIResult, p Profile, d *Draft) string {
+	var b strings.Builder
+	b.WriteString("Dear Sir/Madam,\n\n")
+
+	about := res.Subject
+	if res.Company != nil && *res.Company != "" {
+		about = *res.Company
+		if res.Role != nil && *res.Role != "" {
+			about += " (" + *res.Role + ")"
+		}
+	}
+	if len(d.Fields) > 0 {
+		fmt.Fprintf(&b, "With reference to your email regarding %s, please find my details below:\n\n", about)
+		for _, line := range FieldLines(d.Fields) {
+			b.WriteString(line + "\n")
+		}
+		b.WriteString("\n")
+	} else {
+		fmt.Fprintf(&b, "Thank you for sharing the opportunity regarding %s. I am interested and would like to register.\n\n", about)
+	}
+	if len(d.FormLinks) > 0 {
+		b.WriteString("I will also complete the registration form shared in the email.\n\n")
+	}
+
+	b.WriteString("Regards,\n")
+	name := p.Name
+	if name == "" {
+		name = "[" + FieldName + "]"
+	}
+	b.WriteString(name + "\n")
+	if p.Email != "" {
+		b.WriteString(p.Email + "\n")
+	}
+	return b.String()
+}
+
+func fieldValue(name string, p Profile) string {
+	a := p.Academic
+	if a == nil {
+		a = &eligibility.Profile{}
+	}
+	switch name {
+	case FieldName:
+		return p.Name
+	case FieldEmail:
+		return p.Email
+	case FieldDegree:
+		return strings.ToUpper(a.Degree)
+	case FieldBranch:
+		return strings.ToUpper(a.Branch)
+	case FieldPassoutYear:
+		if a.PassoutYear != nil {
+			return strconv.Itoa(*a.PassoutYear)
+		}
+	case FieldCGPA:
+		return formatFloat(a.CGPA)
+	case FieldTenth:
+		if v := formatFloat(a.TenthPercent); v != "" {
+			return v + "%"
+		}
+	case FieldTwelfth:
+		if v := formatFloat(a.TwelfthPercent); v != "" {
+			return v + "%"
+		}
+	case FieldBacklogs:
+		if a.ActiveBacklogs != nil {
+			return strconv.Itoa(*a.ActiveBacklogs)
+		}
+	case FieldGender:
+		return a.Gender
+	}
+	return ""
+}
+
+func formatFloat(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
+
+// ReplyAddress returns the bare address of a From header, or the header
+// itself when it does not parse.
+func ReplyAddress(from string) string {
+	addr, err := mail.ParseAddress(from)
+	if err != nil {
+		return strings.TrimSpace(from)
+	}
+	return addr.Address
+}
+
+// ReplySubject prefixes subject with "Re: " unless it already has it.
+func ReplySubject(subject string) string {
+	subject = strings.TrimSpace(subject)
+	if strings.HasPrefix(strings.ToLower(subject), "re:") {
+		return subject
+	}
+	return "Re: " + subject
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
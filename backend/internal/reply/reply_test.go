@@ -0,0 +1,70 @@
+package reply
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/eligibility"
+)
+
+func TestRequested(t *testing.T) {
+	text := "Interested students should reply with the following details: Name, Reg No, CGPA, Branch and updated CV."
+	want := []string{FieldName, FieldRegNo, FieldBranch, FieldCGPA, FieldResume}
+	if got := Requested(text); !reflect.DeepEqual(got, want) {
+		t.Errorf("Requested() = %v, want %v", got, want)
+	}
+
+	if got := Requested("Amazon is hiring. Company name: Amazon. CGPA cutoff 7."); got != nil {
+		t.Errorf("expected nothing requested without a reply request, got %v", got)
+	}
+}
+
+func TestCompose_FillsProfileAndMarksMissing(t *testing.T) {
+	company, role := "Infosys", "Systems Engineer"
+	link := "https://forms.gle/abc123"
+	res := &ai.AIResult{
+		Subject:    "Infosys drive – reply with details",
+		Sender:     "Placement Office <placementoffice@vitbhopal.ac.in>",
+		Company:    &company,
+		Role:       &role,
+		Summary:    "Reply with your name, registration number and CGPA by Friday.",
+		OtherLinks: []string{"https://infosys.com", link},
+	}
+	cgpa := 8.42
+	p := Profile{Name: "Asha Rao", Email: "asha@example.com", Academic: &eligibility.Profile{CGPA: &cgpa}}
+
+	d := Compose(res, p)
+	if d.To != "placementoffice@vitbhopal.ac.in" || d.Subject != "Re: Infosys drive – reply with details" {
+		t.Errorf("unexpected addressing: to=%q subject=%q", d.To, d.Subject)
+	}
+	want := []Field{
+		{Name: FieldName, Value: "Asha Rao"},
+		{Name: FieldRegNo, Missing: true},
+		{Name: FieldCGPA, Value: "8.42"},
+	}
+	if !reflect.DeepEqual(d.Fields, want) {
+		t.Errorf("Fields = %+v, want %+v", d.Fields, want)
+	}
+	if !reflect.DeepEqual(d.FormLinks, []string{link}) {
+		t.Errorf("FormLinks = %v", d.FormLinks)
+	}
+	for _, line := range []string{"Infosys (Systems Engineer)", "Registration number: [Registration number]", "CGPA: 8.42", "registration form", "Asha Rao\nasha@example.com"} {
+		if !strings.Contains(d.Body, line) {
+			t.Errorf("body missing %q:\n%s", line, d.Body)
+		}
+	}
+	if d.Source != SourceTemplate {
+		t.Errorf("Source = %q", d.Source)
+	}
+}
+
+func TestReplySubject(t *testing.T) {
+	if got := ReplySubject("RE: Shortlist"); got != "RE: Shortlist" {
+		t.Errorf("ReplySubject() = %q", got)
+	}
+	if got := ReplySubject(" Shortlist "); got != "Re: Shortlist" {
+		t.Errorf("ReplySubject() = %q", got)
+	}
+}
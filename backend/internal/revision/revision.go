@@ -0,0 +1,157 @@
+// Package revision works out what changed between successive analyses of
+// the same opportunity: a deadline moved, a venue changed, a shortlist was
+// attached.
+package revision
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/r7rainz/auramail/internal/ai"
+)
+
+// Change is one field that differs from the value last seen. From is empty
+// when the field is first stated; attachments only ever report To.
+type Change struct {
+	Field          string `json:"field"`
+	From           string `json:"from,omitempty"`
+	To             string `json:"to"`
+	GmailMessageID string `json:"gmailMessageId"`
+	At             string `json:"at,omitempty"`
+}
+
+// String renders the change for logs and model prompts, e.g.
+// "deadline: 2026-08-12 -> 2026-08-15".
+func (c Change) String() string {
+	if c.From == "" {
+		return fmt.Sprintf("%s: %s", c.Field, c.To)
+	}
+	return fmt.Sprintf("%s: %s -> %s", c.Field, c.From, c.To)
+}
+
+// field extracts one comparable value from a result.
+type field struct {
+	name  string
+	value func(*ai.AIResult) string
+}
+
+func ptr(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// fields are compared in this order, which is also the order changes are
+// reported in for a single message.
+var fields = []field{
+	{"category", func(r *ai.AIResult) string { return r.Category }},
+	{"company", func(r *ai.AIResult) string { return ptr(r.Company) }},
+	{"role", func(r *ai.AIResult) string { return ptr(r.Role) }},
+	{"deadline", func(r *ai.AIResult) string { return ptr(r.Deadline) }},
+	{"location", func(r *ai.AIResult) string { return ai.FieldText(r.Location) }},
+	{"timings", func(r *ai.AIResult) string { return ai.FieldText(r.Timings) }},
+	{"salary", func(r *ai.AIResult) string { return ai.FieldText(r.Salary) }},
+	{"eligibility", func(r *ai.AIResult) string { return ai.FieldText(r.Eligibility) }},
+	{"applyLink", func(r *ai.AIResult) string { return ptr(r.ApplyLink) }},
+}
+
+// Changes walks results in order and reports every field that differs from
+// the last value stated for it. A message that leaves a field out (a short
+// "reminder" reply, say) is not treated as clearing it.
+func Changes(results []*ai.AIResult) []Change {
+	changes := make([]Change, 0)
+	last := make(map[string]string, len(fields))
+	seenAttachments := make(map[string]bool)
+
+	for i, r := range results {
+		for _, f := range fields {
+			value := strings.TrimSpace(f.value(r))
+			if value == "" {
+				continue
+			}
+			prev, seen := last[f.name]
+			last[f.name] = value
+			if i == 0 || normalize(prev) == normalize(value) {
+				continue
+			}
+			change := Change{Field: f.name, To: value, GmailMessageID: r.GmailMessageID, At: r.ReceiverAt}
+			if seen {
+				change.From = prev
+			}
+			changes = append(changes, change)
+		}
+
+		for _, a := range r.Attachments {
+			name := strings.TrimSpace(a.Filename)
+			if name == "" || seenAttachments[strings.ToLower(name)] {
+				continue
+			}
+			seenAttachments[strings.ToLower(name)] = true
+			if i > 0 {
+				changes = append(changes, Change{Field: "attachments", To: name, GmailMessageID: r.GmailMessageID, At: r.ReceiverAt})
+			}
+		}
+	}
+	return changes
+}
+
+// normalize ignores case, whitespace and bullet markers, which the model
+// varies between otherwise identical answers.
+func normalize(s string) string {
+	s = strings.ToLower(strings.ReplaceAll(s, "•", " "))
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// fieldLabels are the human names used by Describe.
+var fieldLabels = map[string]string{
+	"category":    "Category",
+	"company":     "Company",
+	"role":        "Role",
+	"deadline":    "Deadline",
+	"location":    "Venue",
+	"timings":     "Timings",
+	"salary":      "Compensation",
+	"eligibility": "Eligibility",
+	"applyLink":   "Apply link",
+}
+
+// Describe renders changes as plain sentences, one per change. It is the
+// rollup shown when the model is unavailable.
+func Describe(changes []Change) []string {
+	lines := make([]string, 0, len(changes))
+	for _, c := range changes {
+		if c.Field == "attachments" {
+			lines = append(lines, fmt.Sprintf("%s attached", c.To))
+			continue
+		}
+		label := fieldLabels[c.Field]
+		if label == "" {
+			label = c.Field
+		}
+		to := oneLine(c.To)
+		if c.From == "" {
+			lines = append(lines, fmt.Sprintf("%s announced: %s", label, to))
+			continue
+		}
+		// Deadlines are YYYY-MM-DD, so they order as strings.
+		verb := "changed"
+		if c.Field == "deadline" && c.From < c.To {
+			verb = "extended"
+		}
+		lines = append(lines, fmt.Sprintf("%s %s from %s to %s", label, verb, oneLine(c.From), to))
+	}
+	return lines
+}
+
+// oneLine flattens a bullet list into "a; b".
+func oneLine(s string) string {
+	parts := make([]string, 0)
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "•-*"))
+		if line != "" {
+			parts = append(parts, line)
+		}
+	}
+	return strings.Join(parts, "; ")
+}
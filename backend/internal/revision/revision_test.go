@@ -0,0 +1,84 @@
+package revision
+
+import (
+	"testing"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/utils"
+)
+
+func str(s string) *string { return &s }
+
+func TestChanges(t *testing.T) {
+	results := []*ai.AIResult{
+		{
+			GmailMessageID: "m1",
+			Category:       "job offer",
+			Company:        str("Acme"),
+			Deadline:       str("2026-08-12"),
+			Location:       "• Main Auditorium",
+			Attachments:    []utils.AttachmentMeta{{Filename: "JD.pdf"}},
+		},
+		{
+			// A bare reminder repeats nothing: no change.
+			GmailMessageID: "m2",
+			Category:       "job offer",
+		},
+		{
+			GmailMessageID: "m3",
+			Category:       "job offer",
+			Company:        str("acme"),
+			Deadline:       str("2026-08-15"),
+			Location:       "• Seminar Hall 2",
+			Attachments:    []utils.AttachmentMeta{{Filename: "jd.pdf"}, {Filename: "Shortlist.xlsx"}},
+		},
+	}
+
+	got := Changes(results)
+	want := []Change{
+		{Field: "deadline", From: "2026-08-12", To: "2026-08-15", GmailMessageID: "m3"},
+		{Field: "location", From: "• Main Auditorium", To: "• Seminar Hall 2", GmailMessageID: "m3"},
+		{Field: "attachments", To: "Shortlist.xlsx", GmailMessageID: "m3"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Changes() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("change %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestChanges_FirstStatement(t *testing.T) {
+	got := Changes([]*ai.AIResult{
+		{GmailMessageID: "m1", Category: "ppt"},
+		{GmailMessageID: "m2", Category: "ppt", Timings: "• 10:00 AM"},
+	})
+	if len(got) != 1 || got[0].From != "" || got[0].String() != "timings: • 10:00 AM" {
+		t.Fatalf("Changes() = %+v", got)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	got := Describe([]Change{
+		{Field: "deadline", From: "2026-08-12", To: "2026-08-15"},
+		{Field: "location", From: "• Main Auditorium", To: "• Seminar Hall 2\n• Block B"},
+		{Field: "attachments", To: "Shortlist.xlsx"},
+		{Field: "timings", To: "• 10:00 AM"},
+	})
+	want := []string{
+		"Deadline extended from 2026-08-12 to 2026-08-15",
+		"Venue changed from Main Auditorium to Seminar Hall 2; Block B",
+		"Shortlist.xlsx attached",
+		"Timings announced: 10:00 AM",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Describe() = %q", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package user
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/r7rainz/auramail/internal/eligibility"
+)
+
+// GetAcademicProfile returns the user's saved academic profile, or
+// pgx.ErrNoRows when none has been saved.
+func (r *PostgresRepository) GetAcademicProfile(ctx context.Context, userID string) (*eligibility.Profile, error) {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	q := `
+		SELECT degree, branch, passout_year, cgpa, tenth_percent, twelfth_percent,
+		       active_backlogs, backlog_history, gender
+		FROM academic_profiles
+		WHERE user_id = $1`
+
+	var p eligibility.Profile
+	err = r.db.QueryRow(ctx, q, id).Scan(
+		&p.Degree,
+		&p.Branch,
+		&p.PassoutYear,
+		&p.CGPA,
+		&p.TenthPercent,
+		&p.TwelfthPercent,
+		&p.ActiveBacklogs,
+		&p.BacklogHistory,
+		&p.Gender,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// SaveAcademicProfile creates or replaces the user's academic profile.
+func (r *PostgresRepository) SaveAcademicProfile(ctx context.Context, userID string, p *eligibility.Profile) error {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	q := `
+		INSERT INTO academic_profiles (user_id, degree, branch, passout_year, cgpa, tenth_percent,
+			twelfth_percent, active_backlogs, backlog_history, gender)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (user_id) DO UPDATE SET
+			degree = EXCLUDED.degree,
+			branch = EXCLUDED.branch,
+			passout_year = EXCLUDED.passout_year,
+			cgpa = EXCLUDED.cgpa,
+			tenth_percent = EXCLUDED.tenth_percent,
+			twelfth_percent = EXCLUDED.twelfth_percent,
+			active_backlogs = EXCLUDED.active_backlogs,
+			backlog_history = EXCLUDED.backlog_history,
+			gender = EXCLUDED.gender,
+			updated_at = CURRENT_TIMESTAMP`
+
+	_, err = r.db.Exec(ctx, q,
+		id,
+		p.Degree,
+		p.Branch,
+		p.PassoutYear,
+		p.CGPA,
+		p.TenthPercent,
+		p.TwelfthPercent,
+		p.ActiveBacklogs,
+		p.BacklogHistory,
+		p.Gender,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save academic profile: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+
+	"github.com/r7rainz/auramail/internal/eligibility"
+)
+
+func TestGetAcademicProfile(t *testing.T) {
+	t.Run("found", func(t *testing.T) {
+		repo, mock := newMockRepo(t)
+		year := 2027
+		cgpa := 8.2
+		rows := pgxmock.NewRows([]string{"degree", "branch", "passout_year", "cgpa", "tenth_percent", "twelfth_percent", "active_backlogs", "backlog_history", "gender"}).
+			AddRow("B.Tech", "CSE", &year, &cgpa, nil, nil, nil, nil, "")
+		mock.ExpectQuery("FROM academic_profiles").
+			WithArgs(int64(4)).
+			WillReturnRows(rows)
+
+		p, err := repo.GetAcademicProfile(context.Background(), "4")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if p.Branch != "CSE" || p.PassoutYear == nil || *p.PassoutYear != 2027 || p.TenthPercent != nil {
+			t.Errorf("unexpected profile: %+v", p)
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		repo, mock := newMockRepo(t)
+		mock.ExpectQuery("FROM academic_profiles").
+			WithArgs(int64(4)).
+			WillReturnError(pgx.ErrNoRows)
+
+		if _, err := repo.GetAcademicProfile(context.Background(), "4"); !errors.Is(err, pgx.ErrNoRows) {
+			t.Fatalf("expected pgx.ErrNoRows, got %v", err)
+		}
+	})
+}
+
+func TestSaveAcademicProfile(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	year := 2027
+	p := &eligibility.Profile{Degree: "B.Tech", Branch: "IT", PassoutYear: &year, Gender: "female"}
+	mock.ExpectExec("INSERT INTO academic_profiles").
+		WithArgs(int64(4), "B.Tech", "IT", p.PassoutYear, p.CGPA, p.TenthPercent, p.TwelfthPercent, p.ActiveBacklogs, p.BacklogHistory, "female").
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	if err := repo.SaveAcademicProfile(context.Background(), "4", p); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
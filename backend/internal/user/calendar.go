@@ -0,0 +1,243 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	// Time zone data for hosts without a zoneinfo database.
+	_ "time/tzdata"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// DefaultTimeZone is where placement events happen for most users (VIT
+// students).
+const DefaultTimeZone = "Asia/Kolkata"
+
+// PrimaryCalendar is the calendar ID Google uses for the user's main
+// calendar.
+const PrimaryCalendar = "primary"
+
+// CalendarRules decide which emails are put on the user's Google Calendar
+// automatically after a sync, and how AuraMail creates events.
+type CalendarRules struct {
+	AutoSchedule bool `json:"autoSchedule"`
+	// Categories are the email categories whose deadlines, tests and
+	// interviews get events.
+	Categories []string `json:"categories"`
+	// ReminderMinutes are popup reminders, in minutes before the event.
+	ReminderMinutes []int `json:"reminderMinutes"`
+	// ReminderSets replace ReminderMinutes for some event types.
+	ReminderSets map[string][]int `json:"reminderSets"`
+	// TimeZone is the IANA zone times without an offset are read in.
+	TimeZone string `json:"timeZone"`
+	// EventMinutes is how long events without an end time last.
+	EventMinutes int `json:"eventMinutes"`
+	// AllDayDeadlines puts deadlines known only by date on the calendar as
+	// all-day events rather than at a fixed time of day.
+	AllDayDeadlines bool `json:"allDayDeadlines"`
+	// CalendarID is the Google calendar events are added to and listed
+	// from.
+	CalendarID string `json:"calendarId"`
+}
+
+// DefaultCalendarRules are used for users who never saved any. Auto
+// scheduling is opt-in.
+func DefaultCalendarRules() CalendarRules {
+	return CalendarRules{
+		Categories:      []string{"internship", "job offer", "exam", "interview", "registration"},
+		ReminderMinutes: []int{60, 1440},
+		ReminderSets:    map[string][]int{},
+		TimeZone:        DefaultTimeZone,
+		EventMinutes:    60,
+		AllDayDeadlines: true,
+		CalendarID:      PrimaryCalendar,
+	}
+}
+
+// RemindersFor returns the reminders for events of eventType.
+func (r *CalendarRules) RemindersFor(eventType string) []int {
+	if set, ok := r.ReminderSets[eventType]; ok {
+		return set
+	}
+	return r.ReminderMinutes
+}
+
+// EventLength is how long events without an end time last.
+func (r *CalendarRules) EventLength() time.Duration {
+	if r.EventMinutes <= 0 {
+		return time.Hour
+	}
+	return time.Duration(r.EventMinutes) * time.Minute
+}
+
+// Calendar returns the ID of the calendar events go on.
+func (r *CalendarRules) Calendar() string {
+	if r.CalendarID == "" {
+		return PrimaryCalendar
+	}
+	return r.CalendarID
+}
+
+// Location returns the rules' time zone, falling back to DefaultTimeZone
+// when it cannot be loaded.
+func (r *CalendarRules) Location() *time.Location {
+	if r.TimeZone != "" {
+		if loc, err := time.LoadLocation(r.TimeZone); err == nil {
+			return loc
+		}
+	}
+	loc, err := time.LoadLocation(DefaultTimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// CalendarLink ties an automatically created calendar event to the email it
+// came from.
+type CalendarLink struct {
+	// Key is the opportunity the email belongs to, or its gmail ID when it
+	// is not linked to one.
+	Key  string `json:"key"`
+	Kind string `json:"kind"`
+	// GmailID is the email that last created or updated the event.
+	GmailID  string    `json:"gmailId"`
+	EventID  string    `json:"eventId"`
+	Title    string    `json:"title"`
+	StartsAt time.Time `json:"startsAt"`
+}
+
+// GetCalendarRules returns the user's calendar rules, or the defaults when
+// none have been saved.
+func (r *PostgresRepository) GetCalendarRules(ctx context.Context, userID string) (*CalendarRules, error) {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := DefaultCalendarRules()
+	var sets []byte
+	err = r.db.QueryRow(ctx, `
+		SELECT auto_schedule, categories, reminder_minutes, reminder_sets, time_zone, event_minutes, all_day_deadlines, calendar_id
+		FROM calendar_rules WHERE user_id = $1`, id).
+		Scan(&rules.AutoSchedule, &rules.Categories, &rules.ReminderMinutes, &sets, &rules.TimeZone, &rules.EventMinutes, &rules.AllDayDeadlines, &rules.CalendarID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &rules, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load calendar rules: %w", err)
+	}
+	if err := json.Unmarshal(sets, &rules.ReminderSets); err != nil {
+		return nil, fmt.Errorf("failed to decode reminder sets: %w", err)
+	}
+	return &rules, nil
+}
+
+// SaveCalendarRules creates or replaces the user's calendar rules.
+func (r *PostgresRepository) SaveCalendarRules(ctx context.Context, userID string, rules *CalendarRules) error {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	sets, err := json.Marshal(rules.ReminderSets)
+	if err != nil {
+		return fmt.Errorf("failed to marshal reminder sets: %w", err)
+	}
+
+	_, err = r.db.Exec(ctx, `
+		INSERT INTO calendar_rules (user_id, auto_schedule, categories, reminder_minutes, reminder_sets,
+			time_zone, event_minutes, all_day_deadlines, calendar_id, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, now())
+		ON CONFLICT (user_id) DO UPDATE SET
+			auto_schedule = EXCLUDED.auto_schedule,
+			categories = EXCLUDED.categories,
+			reminder_minutes = EXCLUDED.reminder_minutes,
+			reminder_sets = EXCLUDED.reminder_sets,
+			time_zone = EXCLUDED.time_zone,
+			event_minutes = EXCLUDED.event_minutes,
+			all_day_deadlines = EXCLUDED.all_day_deadlines,
+			calendar_id = EXCLUDED.calendar_id,
+			updated_at = EXCLUDED.updated_at`,
+		id, rules.AutoSchedule, rules.Categories, rules.ReminderMinutes, sets,
+		rules.TimeZone, rules.EventMinutes, rules.AllDayDeadlines, rules.Calendar())
+	if err != nil {
+		return fmt.Errorf("failed to save calendar rules: %w", err)
+	}
+	return nil
+}
+
+// GetCalendarLink returns the event created for key and kind, or
+// pgx.ErrNoRows when there is none.
+func (r *PostgresRepository) GetCalendarLink(ctx context.Context, userID, key, kind string) (*CalendarLink, error) {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	link := &CalendarLink{Key: key, Kind: kind}
+	err = r.db.QueryRow(ctx, `
+		SELECT gmail_id, event_id, title, starts_at
+		FROM calendar_links WHERE user_id = $1 AND event_key = $2 AND kind = $3`, id, key, kind).
+		Scan(&link.GmailID, &link.EventID, &link.Title, &link.StartsAt)
+	if err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// SaveCalendarLink records the event created or updated for link.Key and
+// link.Kind.
+func (r *PostgresRepository) SaveCalendarLink(ctx context.Context, userID string, link *CalendarLink) error {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(ctx, `
+		INSERT INTO calendar_links (user_id, event_key, kind, gmail_id, event_id, title, starts_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, now())
+		ON CONFLICT (user_id, event_key, kind) DO UPDATE SET
+			gmail_id = EXCLUDED.gmail_id,
+			event_id = EXCLUDED.event_id,
+			title = EXCLUDED.title,
+			starts_at = EXCLUDED.starts_at,
+			updated_at = EXCLUDED.updated_at`,
+		id, link.Key, link.Kind, link.GmailID, link.EventID, link.Title, link.StartsAt)
+	if err != nil {
+		return fmt.Errorf("failed to save calendar link: %w", err)
+	}
+	return nil
+}
+
+// ListCalendarLinks returns the user's automatically created events that
+// start at or after from, soonest first.
+func (r *PostgresRepository) ListCalendarLinks(ctx context.Context, userID string, from time.Time) ([]CalendarLink, error) {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT event_key, kind, gmail_id, event_id, title, starts_at
+		FROM calendar_links
+		WHERE user_id = $1 AND starts_at >= $2
+		ORDER BY starts_at`, id, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list calendar links: %w", err)
+	}
+	defer rows.Close()
+
+	var links []CalendarLink
+	for rows.Next() {
+		var link CalendarLink
+		if err := rows.Scan(&link.Key, &link.Kind, &link.GmailID, &link.EventID, &link.Title, &link.StartsAt); err != nil {
+			return nil, fmt.Errorf("failed to scan calendar link: %w", err)
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
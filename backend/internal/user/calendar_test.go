@@ -0,0 +1,102 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+)
+
+func TestCalendarRules(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	ctx := context.Background()
+
+	mock.ExpectQuery("FROM calendar_rules").
+		WithArgs(int64(3)).
+		WillReturnError(pgx.ErrNoRows)
+	rules, err := repo.GetCalendarRules(ctx, "3")
+	if err != nil {
+		t.Fatalf("GetCalendarRules: %v", err)
+	}
+	if rules.AutoSchedule || len(rules.Categories) == 0 {
+		t.Errorf("unexpected defaults: %+v", rules)
+	}
+
+	rules.AutoSchedule = true
+	rules.Categories = []string{"exam"}
+	rules.ReminderSets = map[string][]int{"interview": {30}}
+	mock.ExpectExec("INSERT INTO calendar_rules").
+		WithArgs(int64(3), true, []string{"exam"}, []int{60, 1440}, []byte(`{"interview":[30]}`), DefaultTimeZone, 60, true, PrimaryCalendar).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	if err := repo.SaveCalendarRules(ctx, "3", rules); err != nil {
+		t.Fatalf("SaveCalendarRules: %v", err)
+	}
+
+	mock.ExpectQuery("FROM calendar_rules").
+		WithArgs(int64(3)).
+		WillReturnRows(pgxmock.NewRows([]string{"auto_schedule", "categories", "reminder_minutes", "reminder_sets", "time_zone", "event_minutes", "all_day_deadlines", "calendar_id"}).
+			AddRow(true, []string{"exam"}, []int{60}, []byte(`{"interview":[30]}`), "Europe/Berlin", 45, false, "placements@group.calendar.google.com"))
+	rules, err = repo.GetCalendarRules(ctx, "3")
+	if err != nil {
+		t.Fatalf("GetCalendarRules: %v", err)
+	}
+	if rules.Location().String() != "Europe/Berlin" || rules.EventMinutes != 45 || rules.AllDayDeadlines || rules.Calendar() != "placements@group.calendar.google.com" {
+		t.Errorf("unexpected rules: %+v", rules)
+	}
+	if got := rules.RemindersFor("interview"); len(got) != 1 || got[0] != 30 {
+		t.Errorf("RemindersFor(interview) = %v", got)
+	}
+	if got := rules.RemindersFor("deadline"); len(got) != 1 || got[0] != 60 {
+		t.Errorf("RemindersFor(deadline) = %v", got)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestCalendarLinks(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	ctx := context.Background()
+	startsAt := time.Date(2026, 8, 14, 10, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("FROM calendar_links").
+		WithArgs(int64(3), "opp-1", "deadline").
+		WillReturnError(pgx.ErrNoRows)
+	if _, err := repo.GetCalendarLink(ctx, "3", "opp-1", "deadline"); !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("expected ErrNoRows, got %v", err)
+	}
+
+	link := &CalendarLink{Key: "opp-1", Kind: "deadline", GmailID: "m2", EventID: "ev1", Title: "Deadline: Globex", StartsAt: startsAt}
+	mock.ExpectExec("INSERT INTO calendar_links").
+		WithArgs(int64(3), "opp-1", "deadline", "m2", "ev1", "Deadline: Globex", startsAt).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	if err := repo.SaveCalendarLink(ctx, "3", link); err != nil {
+		t.Fatalf("SaveCalendarLink: %v", err)
+	}
+
+	mock.ExpectQuery("FROM calendar_links").
+		WithArgs(int64(3), "opp-1", "deadline").
+		WillReturnRows(pgxmock.NewRows([]string{"gmail_id", "event_id", "title", "starts_at"}).AddRow("m2", "ev1", "Deadline: Globex", startsAt))
+	got, err := repo.GetCalendarLink(ctx, "3", "opp-1", "deadline")
+	if err != nil || got.EventID != "ev1" || got.GmailID != "m2" {
+		t.Fatalf("GetCalendarLink = %+v, %v", got, err)
+	}
+
+	now := startsAt.Add(-48 * time.Hour)
+	mock.ExpectQuery("FROM calendar_links").
+		WithArgs(int64(3), now).
+		WillReturnRows(pgxmock.NewRows([]string{"event_key", "kind", "gmail_id", "event_id", "title", "starts_at"}).
+			AddRow("opp-1", "deadline", "m2", "ev1", "Deadline: Globex", startsAt))
+	links, err := repo.ListCalendarLinks(ctx, "3", now)
+	if err != nil || len(links) != 1 || links[0].Key != "opp-1" || !links[0].StartsAt.Equal(startsAt) {
+		t.Fatalf("ListCalendarLinks = %+v, %v", links, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,287 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/company"
+)
+
+// companyCacheTTL bounds how long a company created by another instance
+// can go unseen by the resolver. Creating it again is harmless: the insert
+// returns the existing row on a name_key conflict.
+const companyCacheTTL = 5 * time.Minute
+
+type companyCache struct {
+	mu       sync.Mutex
+	known    []company.Company
+	loadedAt time.Time
+}
+
+// withCompanies calls fn with the cached companies, loading them when the
+// cache is empty or older than companyCacheTTL. fn runs under the cache
+// lock and may append to the slice or update its entries.
+func (r *PostgresRepository) withCompanies(ctx context.Context, fn func(known *[]company.Company) error) error {
+	cache := &r.companies
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if cache.loadedAt.IsZero() || time.Since(cache.loadedAt) > companyCacheTTL {
+		known, err := r.ListCompanies(ctx)
+		if err != nil {
+			return err
+		}
+		cache.known, cache.loadedAt = known, time.Now()
+	}
+	return fn(&cache.known)
+}
+
+// ListCompanies returns every known company.
+func (r *PostgresRepository) ListCompanies(ctx context.Context) ([]company.Company, error) {
+	rows, err := r.db.Query(ctx, `SELECT id, name, aliases, domains FROM companies ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list companies: %w", err)
+	}
+	defer rows.Close()
+
+	companies := make([]company.Company, 0)
+	for rows.Next() {
+		var c company.Company
+		if err := rows.Scan(&c.ID, &c.Name, &c.Aliases, &c.Domains); err != nil {
+			return nil, err
+		}
+		companies = append(companies, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return companies, nil
+}
+
+// GetCompany returns one company, or pgx.ErrNoRows when it does not exist.
+func (r *PostgresRepository) GetCompany(ctx context.Context, id int64) (*company.Company, error) {
+	var c company.Company
+	err := r.db.QueryRow(ctx, `SELECT id, name, aliases, domains FROM companies WHERE id = $1`, id).
+		Scan(&c.ID, &c.Name, &c.Aliases, &c.Domains)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ResolveCompany links an analyzed company name and sender to a canonical
+// company. A name that matches only loosely ("TCS Digital") is recorded as
+// a new alias. A name that matches nothing falls back to the sender domain
+// without becoming an alias, since companies are shared by every user and
+// one email must not teach the resolver that an unrelated name means the
+// sender's company; with no domain match either, it creates a new company. It
+// returns nil without error when there is neither a name nor a
+// recognisable sender domain.
+func (r *PostgresRepository) ResolveCompany(ctx context.Context, name, sender string) (*company.Company, error) {
+	var out *company.Company
+	err := r.withCompanies(ctx, func(known *[]company.Company) error {
+		c, err := r.resolveCompany(ctx, known, name, sender)
+		if c != nil {
+			copied := *c
+			out = &copied
+		}
+		return err
+	})
+	return out, err
+}
+
+// FindCompany returns the existing company name refers to, or nil when
+// there is none. Unlike ResolveCompany it never creates a company or
+// records an alias, so free-form user input cannot change shared rows.
+func (r *PostgresRepository) FindCompany(ctx context.Context, name string) (*company.Company, error) {
+	var out *company.Company
+	err := r.withCompanies(ctx, func(known *[]company.Company) error {
+		if c, ok := company.ResolveName(*known, name); ok {
+			copied := *c
+			out = &copied
+		}
+		return nil
+	})
+	return out, err
+}
+
+// resolveCompany resolves against known, keeping it in step with the
+// aliases it records and the companies it creates.
+func (r *PostgresRepository) resolveCompany(ctx context.Context, known *[]company.Company, name, sender string) (*company.Company, error) {
+	name = strings.TrimSpace(name)
+	if c, ok := company.ResolveName(*known, name); ok {
+		if !c.Knows(name) {
+			_, err := r.db.Exec(ctx, `
+				UPDATE companies SET aliases = array_append(aliases, $1)
+				WHERE id = $2 AND NOT ($1 = ANY(aliases))`, name, c.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to add alias %q to company %d: %w", name, c.ID, err)
+			}
+			c.Aliases = append(c.Aliases, name)
+		}
+		return c, nil
+	}
+	if c, ok := company.ResolveDomain(*known, sender); ok {
+		return c, nil
+	}
+
+	key := company.Key(name)
+	if key == "" {
+		return nil, nil
+	}
+	// Two workers can race to create the same company; the no-op update
+	// makes RETURNING yield the existing row.
+	var c company.Company
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO companies (name, name_key)
+		VALUES ($1, $2)
+		ON CONFLICT (name_key) DO UPDATE SET name_key = EXCLUDED.name_key
+		RETURNING id, name, aliases, domains`, name, key).
+		Scan(&c.ID, &c.Name, &c.Aliases, &c.Domains)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create company %q: %w", name, err)
+	}
+	*known = append(*known, c)
+	return &c, nil
+}
+
+// CompanyOverview is one entry of a user's company list.
+type CompanyOverview struct {
+	company.Company
+	EmailCount  int64     `json:"emailCount"`
+	LastEmailAt time.Time `json:"lastEmailAt"`
+}
+
+// ListUserCompanies returns the companies the user has summaries for, most
+// recently heard from first.
+func (r *PostgresRepository) ListUserCompanies(ctx context.Context, userID string) ([]CompanyOverview, error) {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT c.id, c.name, COUNT(*), MAX(s.created_at)
+		FROM email_summaries s
+		JOIN companies c ON c.id = s.company_id
+		WHERE s.user_id = $1
+		GROUP BY c.id
+		ORDER BY MAX(s.created_at) DESC`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list companies for user: %w", err)
+	}
+	defer rows.Close()
+
+	overviews := make([]CompanyOverview, 0)
+	for rows.Next() {
+		var o CompanyOverview
+		if err := rows.Scan(&o.ID, &o.Name, &o.EmailCount, &o.LastEmailAt); err != nil {
+			return nil, err
+		}
+		overviews = append(overviews, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return overviews, nil
+}
+
+// CompanySummaries returns the user's summaries linked to companyID, oldest
+// first.
+func (r *PostgresRepository) CompanySummaries(ctx context.Context, userID string, companyID int64) ([]*ai.AIResult, error) {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT data
+		FROM email_summaries
+		WHERE user_id = $1 AND company_id = $2
+		ORDER BY created_at ASC`, id, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load company summaries: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]*ai.AIResult, 0)
+	for rows.Next() {
+		var jsonData []byte
+		if err := rows.Scan(&jsonData); err != nil {
+			continue
+		}
+		var res ai.AIResult
+		if err := json.Unmarshal(jsonData, &res); err != nil {
+			continue
+		}
+		results = append(results, &res)
+	}
+	return results, nil
+}
+
+// BackfillCompanyLinks resolves a company for summaries stored before
+// summaries were linked to companies. It returns the number of rows linked.
+func (r *PostgresRepository) BackfillCompanyLinks(ctx context.Context) (int, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT gmail_id, data
+		FROM email_summaries
+		WHERE company_id IS NULL AND (COALESCE(company, '') <> '' OR COALESCE(data->>'sender', '') <> '')`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list summaries for company backfill: %w", err)
+	}
+
+	type pending struct {
+		gmailID string
+		res     ai.AIResult
+	}
+	var todo []pending
+	for rows.Next() {
+		var (
+			p        pending
+			jsonData []byte
+		)
+		if err := rows.Scan(&p.gmailID, &jsonData); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if err := json.Unmarshal(jsonData, &p.res); err != nil {
+			continue
+		}
+		todo = append(todo, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(todo) == 0 {
+		return 0, nil
+	}
+
+	linked := 0
+	for _, p := range todo {
+		name := ""
+		if p.res.Company != nil {
+			name = *p.res.Company
+		}
+		c, err := r.ResolveCompany(ctx, name, p.res.Sender)
+		if err != nil {
+			return linked, err
+		}
+		if c == nil {
+			continue
+		}
+		_, err = r.db.Exec(ctx, `
+			UPDATE email_summaries
+			SET company_id = $1, data = jsonb_set(data, '{companyId}', to_jsonb($1::bigint), true)
+			WHERE gmail_id = $2`, c.ID, p.gmailID)
+		if err != nil {
+			return linked, fmt.Errorf("failed to link company for %s: %w", p.gmailID, err)
+		}
+		linked++
+	}
+	return linked, nil
+}
@@ -0,0 +1,165 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/pashagolub/pgxmock/v4"
+
+	"github.com/r7rainz/auramail/internal/ai"
+)
+
+var companyCols = []string{"id", "name", "aliases", "domains"}
+
+func TestResolveCompany(t *testing.T) {
+	t.Run("known alias", func(t *testing.T) {
+		repo, mock := newMockRepo(t)
+		mock.ExpectQuery("FROM companies").
+			WillReturnRows(pgxmock.NewRows(companyCols).
+				AddRow(int64(1), "Tata Consultancy Services", []string{"TCS"}, []string{"tcs.com"}))
+
+		c, err := repo.ResolveCompany(context.Background(), "TCS", "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if c == nil || c.ID != 1 {
+			t.Fatalf("ResolveCompany() = %+v", c)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("loose match records alias", func(t *testing.T) {
+		repo, mock := newMockRepo(t)
+		mock.ExpectQuery("FROM companies").
+			WillReturnRows(pgxmock.NewRows(companyCols).
+				AddRow(int64(1), "Tata Consultancy Services", []string{"TCS"}, []string{"tcs.com"}))
+		mock.ExpectExec("UPDATE companies SET aliases = array_append").
+			WithArgs("TCS Digital", int64(1)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+		c, err := repo.ResolveCompany(context.Background(), "TCS Digital", "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if c.ID != 1 || len(c.Aliases) != 2 {
+			t.Fatalf("ResolveCompany() = %+v", c)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("domain match records no alias", func(t *testing.T) {
+		repo, mock := newMockRepo(t)
+		mock.ExpectQuery("FROM companies").
+			WillReturnRows(pgxmock.NewRows(companyCols).
+				AddRow(int64(11), "Amazon", []string{}, []string{"amazon.com", "amazon.jobs"}))
+
+		c, err := repo.ResolveCompany(context.Background(), "Google", "Hiring <jobs@amazon.jobs>")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if c.ID != 11 || len(c.Aliases) != 0 {
+			t.Fatalf("ResolveCompany() = %+v", c)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("unknown creates company", func(t *testing.T) {
+		repo, mock := newMockRepo(t)
+		mock.ExpectQuery("FROM companies").
+			WillReturnRows(pgxmock.NewRows(companyCols))
+		mock.ExpectQuery("INSERT INTO companies").
+			WithArgs("Zoho Corp", "zoho").
+			WillReturnRows(pgxmock.NewRows(companyCols).AddRow(int64(9), "Zoho Corp", []string{}, []string{}))
+
+		c, err := repo.ResolveCompany(context.Background(), "Zoho Corp", "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if c.ID != 9 {
+			t.Fatalf("ResolveCompany() = %+v", c)
+		}
+	})
+
+	t.Run("nothing to resolve", func(t *testing.T) {
+		repo, mock := newMockRepo(t)
+		mock.ExpectQuery("FROM companies").
+			WillReturnRows(pgxmock.NewRows(companyCols))
+
+		c, err := repo.ResolveCompany(context.Background(), "", "cdc@vitbhopal.ac.in")
+		if err != nil || c != nil {
+			t.Fatalf("ResolveCompany() = %+v, %v; want nil, nil", c, err)
+		}
+	})
+}
+
+func TestResolveCompany_LoadsCompaniesOnce(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	mock.ExpectQuery("FROM companies").
+		WillReturnRows(pgxmock.NewRows(companyCols).
+			AddRow(int64(1), "Tata Consultancy Services", []string{"TCS"}, []string{"tcs.com"}))
+
+	for _, sender := range []string{"careers@tcs.com", "TCS iON <noreply@ion.tcs.com>"} {
+		c, err := repo.ResolveCompany(context.Background(), "", sender)
+		if err != nil || c == nil || c.ID != 1 {
+			t.Fatalf("ResolveCompany(%q) = %+v, %v", sender, c, err)
+		}
+	}
+	if _, err := repo.FindCompany(context.Background(), "TCS"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestFindCompany(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	mock.ExpectQuery("FROM companies").
+		WillReturnRows(pgxmock.NewRows(companyCols).
+			AddRow(int64(1), "Tata Consultancy Services", []string{"TCS"}, []string{"tcs.com"}))
+
+	// An unknown spelling is neither inserted nor recorded as an alias.
+	c, err := repo.FindCompany(context.Background(), "Amazn")
+	if err != nil || c != nil {
+		t.Fatalf("FindCompany() = %+v, %v; want nil, nil", c, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestBackfillCompanyLinks(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	tcs := "TCS"
+	data, _ := json.Marshal(ai.AIResult{Category: "job offer", Company: &tcs})
+	senderOnly, _ := json.Marshal(ai.AIResult{Category: "announcement", Sender: "cdc@vitbhopal.ac.in"})
+
+	mock.ExpectQuery("WHERE company_id IS NULL").
+		WillReturnRows(pgxmock.NewRows([]string{"gmail_id", "data"}).
+			AddRow("g1", data).
+			AddRow("g2", senderOnly))
+	mock.ExpectQuery("FROM companies").
+		WillReturnRows(pgxmock.NewRows(companyCols).
+			AddRow(int64(1), "Tata Consultancy Services", []string{"TCS"}, []string{"tcs.com"}))
+	mock.ExpectExec("UPDATE email_summaries").
+		WithArgs(int64(1), "g1").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	n, err := repo.BackfillCompanyLinks(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("linked = %d, want 1", n)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Correction is a user's fix to the extracted fields of one summary.
+type Correction struct {
+	// Overrides are the corrected values keyed by JSON field name; nil
+	// clears a field.
+	Overrides map[string]*string
+	// CompanyID is the company resolved for a corrected company name.
+	CompanyID *int64
+	// AnalysisVersion and Predicted record what the analyzer produced for
+	// the corrected fields.
+	AnalysisVersion string
+	Predicted       map[string]*string
+	// EvalCase is the anonymized email as an eval.Case with the corrected
+	// values as expectations.
+	EvalCase json.RawMessage
+}
+
+// correctedData returns the SQL expression merged into a summary's data
+// blob so it reflects overrides: the corrected values, the overrides
+// themselves, full confidence for corrected fields and, when the company
+// was corrected, its resolved ID.
+func correctedData(overrides, data, companyID string) string {
+	return fmt.Sprintf(`%[1]s
+		|| CASE WHEN %[1]s = '{}'::jsonb THEN '{}'::jsonb ELSE jsonb_build_object(
+			'overrides', %[1]s,
+			'confidence', COALESCE(%[2]s->'confidence', '{}'::jsonb)
+				|| (SELECT jsonb_object_agg(field, 1) FROM jsonb_object_keys(%[1]s) AS field)) END
+		|| CASE WHEN %[1]s ? 'company' THEN jsonb_build_object('companyId', %[3]s) ELSE '{}'::jsonb END`,
+		overrides, data, companyID)
+}
+
+// CorrectSummary applies a user's corrections to their summary and records
+// the email as an evaluation case. It returns pgx.ErrNoRows when the user
+// has no such email.
+func (r *PostgresRepository) CorrectSummary(ctx context.Context, userID, gmailID string, c *Correction) error {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	overrides, err := json.Marshal(c.Overrides)
+	if err != nil {
+		return fmt.Errorf("failed to marshal overrides: %w", err)
+	}
+	predicted, err := json.Marshal(c.Predicted)
+	if err != nil {
+		return fmt.Errorf("failed to marshal predictions: %w", err)
+	}
+
+	// Right-hand sides see the row as it was, so overrides || $3 is the
+	// merged set everywhere.
+	query := `
+		UPDATE email_summaries SET
+			overrides = overrides || $3::jsonb,
+			category = COALESCE($3::jsonb->>'category', category),
+			company = CASE WHEN $3::jsonb ? 'company' THEN $3::jsonb->>'company' ELSE company END,
+			company_id = CASE WHEN $3::jsonb ? 'company' THEN $4::bigint ELSE company_id END,
+			role = CASE WHEN $3::jsonb ? 'role' THEN $3::jsonb->>'role' ELSE role END,
+			deadline = CASE WHEN $3::jsonb ? 'deadline' THEN $3::jsonb->>'deadline' ELSE deadline END,
+			apply_link = CASE WHEN $3::jsonb ? 'applyLink' THEN $3::jsonb->>'applyLink' ELSE apply_link END,
+			data = data || ` + correctedData("(overrides || $3::jsonb)", "data",
+		"CASE WHEN $3::jsonb ? 'company' THEN $4::bigint ELSE company_id END") + `
+		WHERE user_id = $1 AND gmail_id = $2`
+
+	tag, err := r.db.Exec(ctx, query, id, gmailID, overrides, c.CompanyID)
+	if err != nil {
+		return fmt.Errorf("failed to correct summary: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	// The first prediction for a field is the one worth keeping.
+	_, err = r.db.Exec(ctx, `
+		INSERT INTO summary_corrections (user_id, gmail_id, analysis_version, predicted, eval_case, updated_at)
+		VALUES ($1, $2, $3, $4, $5, now())
+		ON CONFLICT (user_id, gmail_id) DO UPDATE SET
+			predicted = EXCLUDED.predicted || summary_corrections.predicted,
+			eval_case = EXCLUDED.eval_case,
+			updated_at = EXCLUDED.updated_at`,
+		id, gmailID, c.AnalysisVersion, predicted, []byte(c.EvalCase))
+	if err != nil {
+		return fmt.Errorf("failed to record correction: %w", err)
+	}
+	return nil
+}
+
+// ListCorrectionCases returns every recorded evaluation case, oldest first.
+func (r *PostgresRepository) ListCorrectionCases(ctx context.Context) ([]json.RawMessage, error) {
+	rows, err := r.db.Query(ctx, `SELECT eval_case FROM summary_corrections ORDER BY created_at, gmail_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list corrections: %w", err)
+	}
+	defer rows.Close()
+
+	cases := make([]json.RawMessage, 0)
+	for rows.Next() {
+		var c []byte
+		if err := rows.Scan(&c); err != nil {
+			return nil, fmt.Errorf("failed to scan correction: %w", err)
+		}
+		cases = append(cases, c)
+	}
+	return cases, rows.Err()
+}
@@ -0,0 +1,53 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+)
+
+func TestCorrectSummary(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	ctx := context.Background()
+	company := "Globex"
+	companyID := int64(7)
+	c := &Correction{
+		Overrides:       map[string]*string{"company": &company, "role": nil},
+		CompanyID:       &companyID,
+		AnalysisVersion: "detailed-v5",
+		Predicted:       map[string]*string{"company": nil, "role": nil},
+		EvalCase:        json.RawMessage(`{"id":"m1"}`),
+	}
+
+	mock.ExpectExec("UPDATE email_summaries SET").
+		WithArgs(int64(3), "m1", []byte(`{"company":"Globex","role":null}`), &companyID).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec("INSERT INTO summary_corrections").
+		WithArgs(int64(3), "m1", "detailed-v5", []byte(`{"company":null,"role":null}`), []byte(`{"id":"m1"}`)).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	if err := repo.CorrectSummary(ctx, "3", "m1", c); err != nil {
+		t.Fatalf("CorrectSummary: %v", err)
+	}
+
+	mock.ExpectExec("UPDATE email_summaries SET").
+		WithArgs(int64(3), "other", pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	if err := repo.CorrectSummary(ctx, "3", "other", c); !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("expected ErrNoRows for another user's email, got %v", err)
+	}
+
+	mock.ExpectQuery("SELECT eval_case FROM summary_corrections").
+		WillReturnRows(pgxmock.NewRows([]string{"eval_case"}).AddRow([]byte(`{"id":"m1"}`)))
+	cases, err := repo.ListCorrectionCases(ctx)
+	if err != nil || len(cases) != 1 || string(cases[0]) != `{"id":"m1"}` {
+		t.Fatalf("ListCorrectionCases = %s, %v", cases, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Digest frequencies.
+const (
+	DigestOff    = "off"
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// DigestSettings decide when the user is emailed a digest of their
+// placement mail.
+type DigestSettings struct {
+	// Frequency is DigestDaily, DigestWeekly or DigestOff.
+	Frequency string `json:"frequency"`
+	// SendAt is the local time of day, as HH:MM.
+	SendAt string `json:"sendAt"`
+	// Weekday is the day weekly digests are sent, e.g. "monday".
+	Weekday  string `json:"weekday"`
+	TimeZone string `json:"timeZone"`
+	// LastSentAt is when the last digest went out; nil before the first.
+	LastSentAt *time.Time `json:"lastSentAt"`
+}
+
+// DefaultDigestSettings are used for users who never saved any. Digests are
+// opt-in, so the frequency is off until the user picks one.
+func DefaultDigestSettings() DigestSettings {
+	return DigestSettings{
+		Frequency: DigestOff,
+		SendAt:    "08:00",
+		Weekday:   "monday",
+		TimeZone:  DefaultTimeZone,
+	}
+}
+
+// DigestRecipient is a user due a digest at some point: one with
+// notifications enabled and digests turned on.
+type DigestRecipient struct {
+	UserID   string
+	Email    string
+	Name     string
+	Settings DigestSettings
+}
+
+// GetDigestSettings returns the user's digest settings, or the defaults
+// when none have been saved.
+func (r *PostgresRepository) GetDigestSettings(ctx context.Context, userID string) (*DigestSettings, error) {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s := DefaultDigestSettings()
+	err = r.db.QueryRow(ctx, `
+		SELECT frequency, send_at, weekday, time_zone, last_sent_at
+		FROM digest_settings WHERE user_id = $1`, id).
+		Scan(&s.Frequency, &s.SendAt, &s.Weekday, &s.TimeZone, &s.LastSentAt)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to load digest settings: %w", err)
+	}
+	return &s, nil
+}
+
+// SaveDigestSettings creates or replaces the user's digest settings. The
+// time the last digest was sent is kept.
+func (r *PostgresRepository) SaveDigestSettings(ctx context.Context, userID string, s *DigestSettings) error {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(ctx, `
+		INSERT INTO digest_settings (user_id, frequency, send_at, weekday, time_zone, updated_at)
+		VALUES ($1, $2, $3, $4, $5, now())
+		ON CONFLICT (user_id) DO UPDATE SET
+			frequency = EXCLUDED.frequency,
+			send_at = EXCLUDED.send_at,
+			weekday = EXCLUDED.weekday,
+			time_zone = EXCLUDED.time_zone,
+			updated_at = EXCLUDED.updated_at`,
+		id, s.Frequency, s.SendAt, s.Weekday, s.TimeZone)
+	if err != nil {
+		return fmt.Errorf("failed to save digest settings: %w", err)
+	}
+	return nil
+}
+
+// MarkDigestSent records that the user's digest was sent at. Only users
+// who saved settings receive digests, so their row already exists.
+func (r *PostgresRepository) MarkDigestSent(ctx context.Context, userID string, at time.Time) error {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(ctx, `
+		UPDATE digest_settings SET last_sent_at = $2 WHERE user_id = $1`, id, at)
+	if err != nil {
+		return fmt.Errorf("failed to record digest: %w", err)
+	}
+	return nil
+}
+
+// ListDigestRecipients returns the users with notifications enabled who
+// turned their digest on, with their settings.
+func (r *PostgresRepository) ListDigestRecipients(ctx context.Context) ([]DigestRecipient, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT u.id, u.email, COALESCE(u.name, ''),
+			d.frequency, d.send_at, d.weekday, d.time_zone, d.last_sent_at
+		FROM users u
+		JOIN digest_settings d ON d.user_id = u.id
+		WHERE u.notifications_enabled AND u.email <> '' AND d.frequency <> $1
+		ORDER BY u.id`, DigestOff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list digest recipients: %w", err)
+	}
+	defer rows.Close()
+
+	var recipients []DigestRecipient
+	for rows.Next() {
+		var id int64
+		var rc DigestRecipient
+		s := &rc.Settings
+		if err := rows.Scan(&id, &rc.Email, &rc.Name, &s.Frequency, &s.SendAt, &s.Weekday, &s.TimeZone, &s.LastSentAt); err != nil {
+			return nil, fmt.Errorf("failed to scan digest recipient: %w", err)
+		}
+		rc.UserID = strconv.FormatInt(id, 10)
+		recipients = append(recipients, rc)
+	}
+	return recipients, rows.Err()
+}
@@ -0,0 +1,58 @@
+package user
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+)
+
+func TestDigestSettings(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	ctx := context.Background()
+
+	mock.ExpectQuery("FROM digest_settings").
+		WithArgs(int64(3)).
+		WillReturnError(pgx.ErrNoRows)
+	s, err := repo.GetDigestSettings(ctx, "3")
+	if err != nil || *s != DefaultDigestSettings() {
+		t.Fatalf("GetDigestSettings = %+v, %v; want the defaults", s, err)
+	}
+
+	s.Frequency = DigestDaily
+	s.SendAt = "19:30"
+	mock.ExpectExec("INSERT INTO digest_settings").
+		WithArgs(int64(3), DigestDaily, "19:30", "monday", DefaultTimeZone).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	if err := repo.SaveDigestSettings(ctx, "3", s); err != nil {
+		t.Fatalf("SaveDigestSettings: %v", err)
+	}
+
+	sent := time.Date(2026, 8, 10, 14, 0, 0, 0, time.UTC)
+	mock.ExpectExec("UPDATE digest_settings SET last_sent_at").
+		WithArgs(int64(3), sent).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	if err := repo.MarkDigestSent(ctx, "3", sent); err != nil {
+		t.Fatalf("MarkDigestSent: %v", err)
+	}
+
+	mock.ExpectQuery("FROM users u\\s+JOIN digest_settings").
+		WithArgs(DigestOff).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "email", "name", "frequency", "send_at", "weekday", "time_zone", "last_sent_at"}).
+			AddRow(int64(3), "a@example.com", "Asha", DigestDaily, "19:30", "monday", DefaultTimeZone, &sent).
+			AddRow(int64(4), "b@example.com", "", DigestWeekly, "08:00", "friday", "Europe/London", (*time.Time)(nil)))
+	recipients, err := repo.ListDigestRecipients(ctx)
+	if err != nil {
+		t.Fatalf("ListDigestRecipients: %v", err)
+	}
+	if len(recipients) != 2 || recipients[0].UserID != "3" || !recipients[0].Settings.LastSentAt.Equal(sent) ||
+		recipients[1].Settings.Weekday != "friday" || recipients[1].Settings.LastSentAt != nil {
+		t.Errorf("unexpected recipients: %+v", recipients)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,214 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/embedding"
+)
+
+// ScoredSummary is a semantic search hit.
+type ScoredSummary struct {
+	Summary *ai.AIResult
+	Score   float64
+}
+
+// PendingEmbedding is a summary that has no vector for a provider yet.
+type PendingEmbedding struct {
+	UserID  string
+	Summary *ai.AIResult
+}
+
+// vectorSupport reports whether summary_embeddings has the pgvector column.
+// The answer is looked up once; a failed lookup means in-process search.
+func (r *PostgresRepository) vectorSupport(ctx context.Context) bool {
+	r.vectorOnce.Do(func() {
+		err := r.db.QueryRow(ctx, `
+			SELECT EXISTS (
+				SELECT 1 FROM information_schema.columns
+				WHERE table_name = 'summary_embeddings' AND column_name = 'vec'
+			)`).Scan(&r.hasVector)
+		if err != nil {
+			slog.Warn("pgvector detection failed, using in-process search", "err", err)
+			r.hasVector = false
+		}
+	})
+	return r.hasVector
+}
+
+// SaveEmbedding stores the vector for one summary and provider.
+func (r *PostgresRepository) SaveEmbedding(ctx context.Context, userID, gmailID, provider string, vec []float32) error {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO summary_embeddings (gmail_id, provider, user_id, embedding, updated_at)
+		VALUES ($1, $2, $3, $4, now())
+		ON CONFLICT (gmail_id, provider) DO UPDATE SET
+			embedding = EXCLUDED.embedding,
+			updated_at = EXCLUDED.updated_at`
+	if r.vectorSupport(ctx) {
+		query = `
+		INSERT INTO summary_embeddings (gmail_id, provider, user_id, embedding, vec, updated_at)
+		VALUES ($1, $2, $3, $4, $4::real[]::vector, now())
+		ON CONFLICT (gmail_id, provider) DO UPDATE SET
+			embedding = EXCLUDED.embedding,
+			vec = EXCLUDED.vec,
+			updated_at = EXCLUDED.updated_at`
+	}
+
+	if _, err := r.db.Exec(ctx, query, gmailID, provider, id, vec); err != nil {
+		return fmt.Errorf("failed to save embedding for %s: %w", gmailID, err)
+	}
+	return nil
+}
+
+// GetEmbedding returns the stored vector for one of the user's summaries,
+// or pgx.ErrNoRows when it has not been embedded with provider.
+func (r *PostgresRepository) GetEmbedding(ctx context.Context, userID, gmailID, provider string) ([]float32, error) {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var vec []float32
+	err = r.db.QueryRow(ctx, `
+		SELECT embedding
+		FROM summary_embeddings
+		WHERE user_id = $1 AND gmail_id = $2 AND provider = $3`, id, gmailID, provider).Scan(&vec)
+	if err != nil {
+		return nil, err
+	}
+	return vec, nil
+}
+
+// SemanticSearch returns the user's summaries closest to query among those
+// embedded with provider, best first. excludeGmailID, when set, is left
+// out of the results (the email a "similar" search starts from).
+func (r *PostgresRepository) SemanticSearch(ctx context.Context, userID, provider string, query []float32, excludeGmailID string, limit int) ([]ScoredSummary, error) {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	if r.vectorSupport(ctx) {
+		return r.semanticSearchVector(ctx, id, provider, query, excludeGmailID, limit)
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT s.gmail_id, s.data, e.embedding
+		FROM summary_embeddings e
+		JOIN email_summaries s ON s.gmail_id = e.gmail_id
+		WHERE e.user_id = $1 AND e.provider = $2 AND e.gmail_id <> $3`, id, provider, excludeGmailID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load embeddings: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]embedding.Item, 0)
+	summaries := make(map[string]*ai.AIResult)
+	for rows.Next() {
+		var (
+			gmailID  string
+			jsonData []byte
+			vec      []float32
+		)
+		if err := rows.Scan(&gmailID, &jsonData, &vec); err != nil {
+			return nil, err
+		}
+		var res ai.AIResult
+		if err := json.Unmarshal(jsonData, &res); err != nil {
+			continue
+		}
+		items = append(items, embedding.Item{Key: gmailID, Vector: vec})
+		summaries[gmailID] = &res
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	ranked := embedding.Rank(query, items, limit)
+	results := make([]ScoredSummary, 0, len(ranked))
+	for _, s := range ranked {
+		results = append(results, ScoredSummary{Summary: summaries[s.Key], Score: s.Score})
+	}
+	return results, nil
+}
+
+func (r *PostgresRepository) semanticSearchVector(ctx context.Context, userID int64, provider string, query []float32, excludeGmailID string, limit int) ([]ScoredSummary, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT s.data, 1 - (e.vec <=> $3::real[]::vector)
+		FROM summary_embeddings e
+		JOIN email_summaries s ON s.gmail_id = e.gmail_id
+		WHERE e.user_id = $1 AND e.provider = $2 AND e.gmail_id <> $4 AND e.vec IS NOT NULL
+		ORDER BY e.vec <=> $3::real[]::vector
+		LIMIT $5`, userID, provider, query, excludeGmailID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search embeddings: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]ScoredSummary, 0, limit)
+	for rows.Next() {
+		var (
+			jsonData []byte
+			score    float64
+		)
+		if err := rows.Scan(&jsonData, &score); err != nil {
+			return nil, err
+		}
+		var res ai.AIResult
+		if err := json.Unmarshal(jsonData, &res); err != nil {
+			continue
+		}
+		results = append(results, ScoredSummary{Summary: &res, Score: score})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// SummariesMissingEmbedding returns up to limit summaries, across users,
+// that have no vector for provider, newest first.
+func (r *PostgresRepository) SummariesMissingEmbedding(ctx context.Context, provider string, limit int) ([]PendingEmbedding, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT s.user_id, s.data
+		FROM email_summaries s
+		WHERE NOT EXISTS (
+			SELECT 1 FROM summary_embeddings e WHERE e.gmail_id = s.gmail_id AND e.provider = $1
+		)
+		ORDER BY s.created_at DESC
+		LIMIT $2`, provider, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list summaries missing embeddings: %w", err)
+	}
+	defer rows.Close()
+
+	pending := make([]PendingEmbedding, 0)
+	for rows.Next() {
+		var (
+			userID   int64
+			jsonData []byte
+		)
+		if err := rows.Scan(&userID, &jsonData); err != nil {
+			return nil, err
+		}
+		var res ai.AIResult
+		if err := json.Unmarshal(jsonData, &res); err != nil {
+			continue
+		}
+		pending = append(pending, PendingEmbedding{UserID: fmt.Sprint(userID), Summary: &res})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return pending, nil
+}
@@ -0,0 +1,89 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/pashagolub/pgxmock/v4"
+
+	"github.com/r7rainz/auramail/internal/ai"
+)
+
+func expectVectorSupport(mock pgxmock.PgxPoolIface, has bool) {
+	mock.ExpectQuery("information_schema.columns").
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(has))
+}
+
+func TestSaveEmbedding_WritesVectorColumnOnlyWithPgvector(t *testing.T) {
+	for _, has := range []bool{false, true} {
+		repo, mock := newMockRepo(t)
+		vec := []float32{0.6, 0.8}
+
+		expectVectorSupport(mock, has)
+		query := "INSERT INTO summary_embeddings \\(gmail_id, provider, user_id, embedding, updated_at\\)"
+		if has {
+			query = "INSERT INTO summary_embeddings \\(gmail_id, provider, user_id, embedding, vec, updated_at\\)"
+		}
+		for i := 0; i < 2; i++ {
+			mock.ExpectExec(query).
+				WithArgs("m1", "hash-256", int64(3), vec).
+				WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		}
+
+		// The second save reuses the detected capability.
+		for i := 0; i < 2; i++ {
+			if err := repo.SaveEmbedding(context.Background(), "3", "m1", "hash-256", vec); err != nil {
+				t.Fatalf("SaveEmbedding (pgvector=%v): %v", has, err)
+			}
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("pgvector=%v: unmet expectations: %v", has, err)
+		}
+	}
+}
+
+func TestSemanticSearch_RanksInProcessWithoutPgvector(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	near, _ := json.Marshal(&ai.AIResult{GmailMessageID: "near", Summary: "remote data internship"})
+	far, _ := json.Marshal(&ai.AIResult{GmailMessageID: "far", Summary: "hackathon"})
+
+	expectVectorSupport(mock, false)
+	mock.ExpectQuery("FROM summary_embeddings e").
+		WithArgs(int64(3), "hash-256", "m1").
+		WillReturnRows(pgxmock.NewRows([]string{"gmail_id", "data", "embedding"}).
+			AddRow("far", far, []float32{0, 1}).
+			AddRow("near", near, []float32{1, 0.1}))
+
+	hits, err := repo.SemanticSearch(context.Background(), "3", "hash-256", []float32{1, 0}, "m1", 5)
+	if err != nil {
+		t.Fatalf("SemanticSearch: %v", err)
+	}
+	if len(hits) != 2 || hits[0].Summary.GmailMessageID != "near" || hits[0].Score <= hits[1].Score {
+		t.Errorf("unexpected ranking: %+v", hits)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestSemanticSearch_UsesPgvectorWhenAvailable(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	data, _ := json.Marshal(&ai.AIResult{GmailMessageID: "near"})
+
+	expectVectorSupport(mock, true)
+	mock.ExpectQuery("ORDER BY e.vec <=>").
+		WithArgs(int64(3), "hash-256", []float32{1, 0}, "", 20).
+		WillReturnRows(pgxmock.NewRows([]string{"data", "score"}).AddRow(data, 0.93))
+
+	hits, err := repo.SemanticSearch(context.Background(), "3", "hash-256", []float32{1, 0}, "", 0)
+	if err != nil {
+		t.Fatalf("SemanticSearch: %v", err)
+	}
+	if len(hits) != 1 || hits[0].Score != 0.93 {
+		t.Errorf("unexpected hits: %+v", hits)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
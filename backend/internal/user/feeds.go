@@ -0,0 +1,68 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// SaveCalendarFeedToken sets the hash of the user's calendar feed token,
+// replacing and so revoking any earlier one. It returns when the token was
+// created.
+func (r *PostgresRepository) SaveCalendarFeedToken(ctx context.Context, userID, tokenHash string) (time.Time, error) {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var created time.Time
+	err = r.db.QueryRow(ctx, `
+		INSERT INTO calendar_feed_tokens (user_id, token_hash, created_at)
+		VALUES ($1, $2, now())
+		ON CONFLICT (user_id) DO UPDATE SET
+			token_hash = EXCLUDED.token_hash,
+			created_at = EXCLUDED.created_at
+		RETURNING created_at`, id, tokenHash).Scan(&created)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to save calendar feed token: %w", err)
+	}
+	return created, nil
+}
+
+// GetCalendarFeedToken returns when the user's calendar feed token was
+// created, or pgx.ErrNoRows when they have none.
+func (r *PostgresRepository) GetCalendarFeedToken(ctx context.Context, userID string) (time.Time, error) {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var created time.Time
+	err = r.db.QueryRow(ctx, `SELECT created_at FROM calendar_feed_tokens WHERE user_id = $1`, id).Scan(&created)
+	return created, err
+}
+
+// DeleteCalendarFeedToken revokes the user's calendar feed token.
+func (r *PostgresRepository) DeleteCalendarFeedToken(ctx context.Context, userID string) error {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.db.Exec(ctx, `DELETE FROM calendar_feed_tokens WHERE user_id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete calendar feed token: %w", err)
+	}
+	return nil
+}
+
+// FindCalendarFeedUser returns the ID of the user whose feed token hashes
+// to tokenHash, or pgx.ErrNoRows when there is none.
+func (r *PostgresRepository) FindCalendarFeedUser(ctx context.Context, tokenHash string) (string, error) {
+	var id int64
+	err := r.db.QueryRow(ctx, `SELECT user_id FROM calendar_feed_tokens WHERE token_hash = $1`, tokenHash).Scan(&id)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(id, 10), nil
+}
@@ -0,0 +1,49 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+)
+
+func TestCalendarFeedTokens(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	ctx := context.Background()
+	created := time.Date(2026, 8, 10, 9, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("INSERT INTO calendar_feed_tokens").
+		WithArgs(int64(3), "hash-1").
+		WillReturnRows(pgxmock.NewRows([]string{"created_at"}).AddRow(created))
+	if got, err := repo.SaveCalendarFeedToken(ctx, "3", "hash-1"); err != nil || !got.Equal(created) {
+		t.Fatalf("SaveCalendarFeedToken = %v, %v", got, err)
+	}
+
+	mock.ExpectQuery("FROM calendar_feed_tokens WHERE token_hash").
+		WithArgs("hash-1").
+		WillReturnRows(pgxmock.NewRows([]string{"user_id"}).AddRow(int64(3)))
+	if id, err := repo.FindCalendarFeedUser(ctx, "hash-1"); err != nil || id != "3" {
+		t.Fatalf("FindCalendarFeedUser = %q, %v", id, err)
+	}
+
+	mock.ExpectExec("DELETE FROM calendar_feed_tokens").
+		WithArgs(int64(3)).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	if err := repo.DeleteCalendarFeedToken(ctx, "3"); err != nil {
+		t.Fatalf("DeleteCalendarFeedToken: %v", err)
+	}
+
+	mock.ExpectQuery("FROM calendar_feed_tokens WHERE user_id").
+		WithArgs(int64(3)).
+		WillReturnError(pgx.ErrNoRows)
+	if _, err := repo.GetCalendarFeedToken(ctx, "3"); !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("expected ErrNoRows after revoking, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
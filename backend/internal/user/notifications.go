@@ -0,0 +1,94 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Notification is an in-app message for the user.
+type Notification struct {
+	ID    int64  `json:"id"`
+	Kind  string `json:"kind"`
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	// GmailID is the email the notification is about, if any.
+	GmailID string `json:"gmailId,omitempty"`
+	// Details is kind-specific, e.g. what changed on a calendar event.
+	Details   json.RawMessage `json:"details,omitempty"`
+	CreatedAt time.Time       `json:"createdAt"`
+	ReadAt    *time.Time      `json:"readAt,omitempty"`
+}
+
+// AddNotification stores a new unread notification for the user.
+func (r *PostgresRepository) AddNotification(ctx context.Context, userID string, n *Notification) error {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	details := n.Details
+	if len(details) == 0 {
+		details = json.RawMessage(`{}`)
+	}
+	err = r.db.QueryRow(ctx, `
+		INSERT INTO notifications (user_id, kind, title, body, gmail_id, details)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`,
+		id, n.Kind, n.Title, n.Body, n.GmailID, []byte(details)).
+		Scan(&n.ID, &n.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add notification: %w", err)
+	}
+	return nil
+}
+
+// ListNotifications returns the user's latest notifications, newest first.
+func (r *PostgresRepository) ListNotifications(ctx context.Context, userID string, unreadOnly bool, limit int) ([]Notification, error) {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT id, kind, title, body, gmail_id, details, created_at, read_at
+		FROM notifications
+		WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3`, id, unreadOnly, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := make([]Notification, 0)
+	for rows.Next() {
+		var n Notification
+		var details []byte
+		if err := rows.Scan(&n.ID, &n.Kind, &n.Title, &n.Body, &n.GmailID, &details, &n.CreatedAt, &n.ReadAt); err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		n.Details = details
+		notifications = append(notifications, n)
+	}
+	return notifications, rows.Err()
+}
+
+// MarkNotificationsRead marks the given notifications, or all of them when
+// ids is empty, as read. It returns how many were unread.
+func (r *PostgresRepository) MarkNotificationsRead(ctx context.Context, userID string, ids []int64) (int64, error) {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	tag, err := r.db.Exec(ctx, `
+		UPDATE notifications SET read_at = now()
+		WHERE user_id = $1 AND read_at IS NULL AND (cardinality($2::bigint[]) = 0 OR id = ANY($2))`,
+		id, ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
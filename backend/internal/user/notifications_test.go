@@ -0,0 +1,46 @@
+package user
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+)
+
+func TestNotifications(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	ctx := context.Background()
+	created := time.Date(2026, 8, 10, 9, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("INSERT INTO notifications").
+		WithArgs(int64(3), "calendar_event_updated", "Event moved", "", "m1", []byte(`{}`)).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}).AddRow(int64(7), created))
+	n := &Notification{Kind: "calendar_event_updated", Title: "Event moved", GmailID: "m1"}
+	if err := repo.AddNotification(ctx, "3", n); err != nil {
+		t.Fatalf("AddNotification: %v", err)
+	}
+	if n.ID != 7 || !n.CreatedAt.Equal(created) {
+		t.Errorf("notification not filled in: %+v", n)
+	}
+
+	mock.ExpectQuery("FROM notifications").
+		WithArgs(int64(3), true, 20).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "kind", "title", "body", "gmail_id", "details", "created_at", "read_at"}).
+			AddRow(int64(7), "calendar_event_updated", "Event moved", "", "m1", []byte(`{"eventId":"ev1"}`), created, nil))
+	list, err := repo.ListNotifications(ctx, "3", true, 20)
+	if err != nil || len(list) != 1 || string(list[0].Details) != `{"eventId":"ev1"}` || list[0].ReadAt != nil {
+		t.Fatalf("ListNotifications = %+v, %v", list, err)
+	}
+
+	mock.ExpectExec("UPDATE notifications SET read_at").
+		WithArgs(int64(3), []int64{7}).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	if marked, err := repo.MarkNotificationsRead(ctx, "3", []int64{7}); err != nil || marked != 1 {
+		t.Fatalf("MarkNotificationsRead = %d, %v", marked, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/dedupe"
+)
+
+// opportunityWindow bounds how far back a resend is looked for. Drives
+// from the same company a season apart are separate opportunities.
+const opportunityWindow = 90 * 24 * time.Hour
+
+// maxOpportunityCandidates caps the summaries compared per new email.
+const maxOpportunityCandidates = 500
+
+// MatchOpportunity returns the opportunity an analyzed email repeats, or ""
+// when it announces something new. Only the user's summaries from the last
+// opportunityWindow are considered.
+func (r *PostgresRepository) MatchOpportunity(ctx context.Context, userID string, res *ai.AIResult) (string, error) {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return "", err
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT gmail_id, COALESCE(opportunity_id, gmail_id), COALESCE(subject_key, ''),
+		       COALESCE(company, ''), COALESCE(role, ''), company_id, simhash
+		FROM email_summaries
+		WHERE user_id = $1 AND gmail_id <> $2 AND simhash IS NOT NULL AND created_at > $3
+		ORDER BY created_at DESC
+		LIMIT $4`,
+		id, res.GmailMessageID, time.Now().Add(-opportunityWindow), maxOpportunityCandidates,
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to load opportunity candidates: %w", err)
+	}
+	defer rows.Close()
+
+	candidates := make([]dedupe.Candidate, 0)
+	for rows.Next() {
+		var (
+			c                         dedupe.Candidate
+			subjectKey, company, role string
+			companyID                 *int64
+			simhash                   int64
+		)
+		if err := rows.Scan(&c.GmailID, &c.OpportunityID, &subjectKey, &company, &role, &companyID, &simhash); err != nil {
+			return "", err
+		}
+		c.Fingerprint = dedupe.Stored(subjectKey, company, role, companyID, uint64(simhash))
+		candidates = append(candidates, c)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	return dedupe.Best(dedupe.Of(res), candidates), nil
+}
+
+// OpportunitySummaries returns every summary belonging to the given
+// opportunities, keyed by opportunity ID. A summary without an
+// opportunity_id is its own opportunity.
+func (r *PostgresRepository) OpportunitySummaries(ctx context.Context, userID string, opportunityIDs []string) (map[string][]*ai.AIResult, error) {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT COALESCE(opportunity_id, gmail_id), data
+		FROM email_summaries
+		WHERE user_id = $1 AND COALESCE(opportunity_id, gmail_id) = ANY($2)`, id, opportunityIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load opportunity revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := make(map[string][]*ai.AIResult)
+	for rows.Next() {
+		var (
+			opportunityID string
+			jsonData      []byte
+		)
+		if err := rows.Scan(&opportunityID, &jsonData); err != nil {
+			continue
+		}
+		var res ai.AIResult
+		if err := json.Unmarshal(jsonData, &res); err != nil {
+			continue
+		}
+		revisions[opportunityID] = append(revisions[opportunityID], &res)
+	}
+	return revisions, nil
+}
+
+// BackfillOpportunities fingerprints summaries stored before duplicate
+// detection existed and links them to opportunities, oldest first. It
+// returns the number of rows updated.
+func (r *PostgresRepository) BackfillOpportunities(ctx context.Context) (int, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT user_id, gmail_id, data, opportunity_id, simhash IS NULL, created_at
+		FROM email_summaries
+		WHERE user_id IN (SELECT DISTINCT user_id FROM email_summaries WHERE simhash IS NULL)
+		ORDER BY user_id, created_at ASC`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list summaries for opportunity backfill: %w", err)
+	}
+
+	type row struct {
+		userID        int64
+		gmailID       string
+		res           ai.AIResult
+		opportunityID *string
+		pending       bool
+		createdAt     time.Time
+	}
+	var all []row
+	for rows.Next() {
+		var (
+			rw       row
+			jsonData []byte
+		)
+		if err := rows.Scan(&rw.userID, &rw.gmailID, &jsonData, &rw.opportunityID, &rw.pending, &rw.createdAt); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if err := json.Unmarshal(jsonData, &rw.res); err != nil {
+			continue
+		}
+		all = append(all, rw)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	type seen struct {
+		candidate dedupe.Candidate
+		createdAt time.Time
+	}
+	var (
+		updated   int
+		userID    int64 = -1
+		processed []seen
+	)
+	for _, rw := range all {
+		if rw.userID != userID {
+			userID, processed = rw.userID, nil
+		}
+		fp := dedupe.Of(&rw.res)
+
+		opportunityID := rw.gmailID
+		if rw.opportunityID != nil {
+			opportunityID = *rw.opportunityID
+		} else if rw.pending {
+			candidates := make([]dedupe.Candidate, 0, len(processed))
+			for _, p := range processed {
+				if rw.createdAt.Sub(p.createdAt) <= opportunityWindow {
+					candidates = append(candidates, p.candidate)
+				}
+			}
+			if match := dedupe.Best(fp, candidates); match != "" {
+				opportunityID = match
+			}
+		}
+		processed = append(processed, seen{
+			candidate: dedupe.Candidate{GmailID: rw.gmailID, OpportunityID: opportunityID, Fingerprint: fp},
+			createdAt: rw.createdAt,
+		})
+		if !rw.pending {
+			continue
+		}
+
+		_, err := r.db.Exec(ctx, `
+			UPDATE email_summaries
+			SET opportunity_id = $1, subject_key = $2, simhash = $3,
+				data = jsonb_set(data, '{opportunityId}', to_jsonb($1::text), true)
+			WHERE gmail_id = $4`,
+			opportunityID, fp.SubjectKey, int64(fp.Simhash), rw.gmailID,
+		)
+		if err != nil {
+			return updated, fmt.Errorf("failed to backfill opportunity for %s: %w", rw.gmailID, err)
+		}
+		updated++
+	}
+	return updated, nil
+}
@@ -0,0 +1,63 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/dedupe"
+)
+
+func ptr(s string) *string { return &s }
+
+func TestMatchOpportunity(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	original := &ai.AIResult{GmailMessageID: "g1", Subject: "Acme Hiring | Last date 12 Aug", Company: ptr("Acme"), Summary: "• Acme hiring GETs"}
+	fp := dedupe.Of(original)
+
+	cols := []string{"gmail_id", "opportunity", "subject_key", "company", "role", "company_id", "simhash"}
+	mock.ExpectQuery("FROM email_summaries").
+		WithArgs(int64(2), "g2", pgxmock.AnyArg(), maxOpportunityCandidates).
+		WillReturnRows(pgxmock.NewRows(cols).
+			AddRow("g0", "g0", "globex internship", "Globex", "", nil, int64(0)).
+			AddRow("g1", "g1", fp.SubjectKey, "Acme", "", nil, int64(fp.Simhash)))
+
+	reminder := &ai.AIResult{GmailMessageID: "g2", Subject: "REMINDER: Acme Hiring - Last date 15th Aug", Company: ptr("Acme Pvt Ltd"), Summary: "• Deadline extended"}
+	got, err := repo.MatchOpportunity(context.Background(), "2", reminder)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "g1" {
+		t.Errorf("MatchOpportunity() = %q, want g1", got)
+	}
+}
+
+func TestBackfillOpportunities(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	first, _ := json.Marshal(ai.AIResult{GmailMessageID: "g1", Subject: "Acme Hiring", Company: ptr("Acme")})
+	resend, _ := json.Marshal(ai.AIResult{GmailMessageID: "g2", Subject: "Corrigendum: Acme Hiring", Company: ptr("Acme")})
+	day := time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("WHERE user_id IN").
+		WillReturnRows(pgxmock.NewRows([]string{"user_id", "gmail_id", "data", "opportunity_id", "pending", "created_at"}).
+			AddRow(int64(1), "g1", first, ptr("g1"), false, day).
+			AddRow(int64(1), "g2", resend, nil, true, day.Add(48*time.Hour)))
+	mock.ExpectExec("UPDATE email_summaries").
+		WithArgs("g1", "acme hiring", pgxmock.AnyArg(), "g2").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	n, err := repo.BackfillOpportunities(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("updated = %d, want 1", n)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -7,12 +7,14 @@ import (
 	"fmt"
 	"log"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 
 	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/dedupe"
 )
 
 // dbConn is the subset of *pgxpool.Pool used by PostgresRepository. Narrowing
@@ -26,6 +28,15 @@ type dbConn interface {
 
 type PostgresRepository struct {
 	db dbConn
+
+	// vectorOnce guards hasVector, which records whether the pgvector
+	// column on summary_embeddings exists.
+	vectorOnce sync.Once
+	hasVector  bool
+
+	// companies caches the companies table for resolution, which runs
+	// for every analyzed message.
+	companies companyCache
 }
 
 func scanUser(row pgx.Row) (*User, error) {
@@ -192,46 +203,92 @@ func (r *PostgresRepository) GetSummary(ctx context.Context, gmailID string) (*a
 	return &res, nil
 }
 
+// GetUserSummary is GetSummary restricted to the user's own summaries; it
+// returns pgx.ErrNoRows for another user's email.
+func (r *PostgresRepository) GetUserSummary(ctx context.Context, userID, gmailID string) (*ai.AIResult, error) {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var jsonData []byte
+	err = r.db.QueryRow(ctx, `SELECT data FROM email_summaries WHERE user_id = $1 AND gmail_id = $2`, id, gmailID).Scan(&jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	var res ai.AIResult
+	if err := json.Unmarshal(jsonData, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// SaveSummary upserts an analysis. Re-analyzing an email keeps the user's
+// important flag and their corrections.
 func (r *PostgresRepository) SaveSummary(ctx context.Context, userID string, gmailID string, res *ai.AIResult) error {
 	id, err := parseUserID(userID)
 	if err != nil {
 		return err
 	}
 
+	comp := normalizeCompensation(res)
+	fp := dedupe.Of(res)
+	var opportunityID *string
+	if res.OpportunityID != "" {
+		opportunityID = &res.OpportunityID
+	}
+
 	jsonData, err := json.Marshal(res)
 	if err != nil {
 		return fmt.Errorf("failed to marshal AI result: %w", err)
 	}
 
 	query := `
-		INSERT INTO email_summaries (user_id, gmail_id, thread_id, category, company, role, summary, deadline, apply_link, data)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO email_summaries (user_id, gmail_id, thread_id, category, company, role, summary, deadline, apply_link, data,
+			comp_currency, comp_min_annual, comp_max_annual, comp_kind, comp_raw, company_id,
+			opportunity_id, subject_key, simhash)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
 		ON CONFLICT (gmail_id) DO UPDATE SET
 			thread_id = EXCLUDED.thread_id,
-			category = EXCLUDED.category,
-			company = EXCLUDED.company,
-			role = EXCLUDED.role,
+			category = COALESCE(email_summaries.overrides->>'category', EXCLUDED.category),
+			company = CASE WHEN email_summaries.overrides ? 'company' THEN email_summaries.overrides->>'company' ELSE EXCLUDED.company END,
+			role = CASE WHEN email_summaries.overrides ? 'role' THEN email_summaries.overrides->>'role' ELSE EXCLUDED.role END,
 			summary = EXCLUDED.summary,
-			deadline = EXCLUDED.deadline,
-			apply_link = EXCLUDED.apply_link,
-			data = jsonb_set(EXCLUDED.data, '{important}', to_jsonb(email_summaries.important), true)`
-
-	// eligibility, _ := json.Marshal(res.Eligibility)
-	// timings, _ := json.Marshal(res.Timings)
-	// salary, _ := json.Marshal(res.Salary)
-	// location, _ := json.Marshal(res.Location)
+			deadline = CASE WHEN email_summaries.overrides ? 'deadline' THEN email_summaries.overrides->>'deadline' ELSE EXCLUDED.deadline END,
+			apply_link = CASE WHEN email_summaries.overrides ? 'applyLink' THEN email_summaries.overrides->>'applyLink' ELSE EXCLUDED.apply_link END,
+			data = jsonb_set(EXCLUDED.data, '{important}', to_jsonb(email_summaries.important), true)
+				|| ` + correctedData("email_summaries.overrides", "EXCLUDED.data", "email_summaries.company_id") + `,
+			comp_currency = EXCLUDED.comp_currency,
+			comp_min_annual = EXCLUDED.comp_min_annual,
+			comp_max_annual = EXCLUDED.comp_max_annual,
+			comp_kind = EXCLUDED.comp_kind,
+			comp_raw = EXCLUDED.comp_raw,
+			company_id = CASE WHEN email_summaries.overrides ? 'company' THEN email_summaries.company_id ELSE EXCLUDED.company_id END,
+			opportunity_id = COALESCE(EXCLUDED.opportunity_id, email_summaries.opportunity_id),
+			subject_key = EXCLUDED.subject_key,
+			simhash = EXCLUDED.simhash`
 
 	_, err = r.db.Exec(ctx, query,
-		id,            // $1
-		gmailID,       // $2
-		res.ThreadID,  // $3
-		res.Category,  // $4
-		res.Company,   // $5
-		res.Role,      // $6
-		res.Summary,   // $7
-		res.Deadline,  // $8
-		res.ApplyLink, // $9
-		jsonData,      // $10 (The JSONB payload)
+		id,                // $1
+		gmailID,           // $2
+		res.ThreadID,      // $3
+		res.Category,      // $4
+		res.Company,       // $5
+		res.Role,          // $6
+		res.Summary,       // $7
+		res.Deadline,      // $8
+		res.ApplyLink,     // $9
+		jsonData,          // $10 (The JSONB payload)
+		comp.currency,     // $11
+		comp.minAnnual,    // $12
+		comp.maxAnnual,    // $13
+		comp.kind,         // $14
+		comp.raw,          // $15
+		res.CompanyID,     // $16
+		opportunityID,     // $17
+		fp.SubjectKey,     // $18
+		int64(fp.Simhash), // $19 (stored signed; the bits are what matter)
 	)
 	if err != nil {
 		return fmt.Errorf("failed to save summary to db: %w", err)
@@ -239,46 +296,10 @@ func (r *PostgresRepository) SaveSummary(ctx context.Context, userID string, gma
 	return nil
 }
 
+// GetSummariesByQuery returns the newest 50 summaries whose summary, company
+// or role matches searchQuery.
 func (r *PostgresRepository) GetSummariesByQuery(ctx context.Context, userID string, searchQuery string) ([]*ai.AIResult, error) {
-	id, err := parseUserID(userID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Query the JSONB column, utilizing the correct column names (user_id, created_at)
-	query := `
-		SELECT data 
-		FROM email_summaries 
-		WHERE user_id = $1 
-		AND (summary ILIKE $2 OR company ILIKE $2 OR role ILIKE $2) 
-		ORDER BY created_at DESC 
-		LIMIT 50`
-
-	rows, err := r.db.Query(ctx, query, id, "%"+searchQuery+"%")
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var results []*ai.AIResult
-	for rows.Next() {
-		var jsonData []byte
-
-		// Scan the single JSONB column
-		if err := rows.Scan(&jsonData); err != nil {
-			continue
-		}
-
-		// Unmarshal into the struct
-		var res ai.AIResult
-		if err := json.Unmarshal(jsonData, &res); err != nil {
-			continue
-		}
-
-		results = append(results, &res)
-	}
-
-	return results, nil
+	return r.ListSummaries(ctx, userID, SummaryFilter{Query: searchQuery})
 }
 
 // SetImportant flips the important flag for a user's email, keeping both
@@ -0,0 +1,63 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/r7rainz/auramail/internal/lang"
+)
+
+// Preferences are per-user processing settings.
+type Preferences struct {
+	// RedactPII replaces personal data in emails with placeholders before
+	// they are sent to the LLM.
+	RedactPII bool `json:"redactPii"`
+	// SummaryLanguage is the ISO 639-1 code of the language summary bullets
+	// are written in. Extracted fields are always in English.
+	SummaryLanguage string `json:"summaryLanguage"`
+}
+
+// DefaultPreferences are used for users who never changed a setting.
+func DefaultPreferences() Preferences {
+	return Preferences{RedactPII: true, SummaryLanguage: lang.English}
+}
+
+// GetPreferences returns the user's preferences, or the defaults when none
+// have been saved.
+func (r *PostgresRepository) GetPreferences(ctx context.Context, userID string) (*Preferences, error) {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	p := DefaultPreferences()
+	err = r.db.QueryRow(ctx, `SELECT redact_pii, summary_language FROM user_preferences WHERE user_id = $1`, id).
+		Scan(&p.RedactPII, &p.SummaryLanguage)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to load preferences: %w", err)
+	}
+	return &p, nil
+}
+
+// SavePreferences creates or replaces the user's preferences.
+func (r *PostgresRepository) SavePreferences(ctx context.Context, userID string, p *Preferences) error {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(ctx, `
+		INSERT INTO user_preferences (user_id, redact_pii, summary_language, updated_at)
+		VALUES ($1, $2, $3, now())
+		ON CONFLICT (user_id) DO UPDATE SET
+			redact_pii = EXCLUDED.redact_pii,
+			summary_language = EXCLUDED.summary_language,
+			updated_at = EXCLUDED.updated_at`, id, p.RedactPII, p.SummaryLanguage)
+	if err != nil {
+		return fmt.Errorf("failed to save preferences: %w", err)
+	}
+	return nil
+}
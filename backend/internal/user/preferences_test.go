@@ -0,0 +1,42 @@
+package user
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+)
+
+func TestGetPreferences_DefaultsWithoutRow(t *testing.T) {
+	repo, mock := newMockRepo(t)
+
+	mock.ExpectQuery("FROM user_preferences").
+		WithArgs(int64(3)).
+		WillReturnError(pgx.ErrNoRows)
+	p, err := repo.GetPreferences(context.Background(), "3")
+	if err != nil {
+		t.Fatalf("GetPreferences: %v", err)
+	}
+	if !p.RedactPII || p.SummaryLanguage != "en" {
+		t.Errorf("unexpected defaults: %+v", p)
+	}
+
+	mock.ExpectExec("INSERT INTO user_preferences").
+		WithArgs(int64(3), false, "hi").
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	if err := repo.SavePreferences(context.Background(), "3", &Preferences{RedactPII: false, SummaryLanguage: "hi"}); err != nil {
+		t.Fatalf("SavePreferences: %v", err)
+	}
+
+	mock.ExpectQuery("FROM user_preferences").
+		WithArgs(int64(3)).
+		WillReturnRows(pgxmock.NewRows([]string{"redact_pii", "summary_language"}).AddRow(false, "hi"))
+	if p, err = repo.GetPreferences(context.Background(), "3"); err != nil || p.RedactPII || p.SummaryLanguage != "hi" {
+		t.Fatalf("GetPreferences = %+v, %v", p, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
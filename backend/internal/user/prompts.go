@@ -0,0 +1,55 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrPromptVersionExists is returned when a template version is saved again
+// with different text.
+var ErrPromptVersionExists = errors.New("prompt template version already exists with different text")
+
+// ActivePromptTemplate returns the active stored template for name, or ""
+// when none is stored.
+func (r *PostgresRepository) ActivePromptTemplate(ctx context.Context, name string) (string, error) {
+	var text string
+	err := r.db.QueryRow(ctx, `
+		SELECT template FROM prompt_templates
+		WHERE name = $1 AND active
+		ORDER BY created_at DESC
+		LIMIT 1`, name).Scan(&text)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to load prompt template: %w", err)
+	}
+	return text, nil
+}
+
+// SavePromptTemplate stores a template version and makes it the active one
+// for its name. Versions are immutable once saved, so saving different
+// text under an existing version fails.
+func (r *PostgresRepository) SavePromptTemplate(ctx context.Context, name, version, text string) error {
+	tag, err := r.db.Exec(ctx, `
+		INSERT INTO prompt_templates (name, version, template, active, created_at)
+		VALUES ($1, $2, $3, TRUE, now())
+		ON CONFLICT (name, version) DO UPDATE SET active = TRUE, created_at = now()
+		WHERE prompt_templates.template = EXCLUDED.template`, name, version, text)
+	if err != nil {
+		return fmt.Errorf("failed to save prompt template: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrPromptVersionExists
+	}
+
+	if _, err := r.db.Exec(ctx, `
+		UPDATE prompt_templates SET active = FALSE
+		WHERE name = $1 AND version <> $2 AND active`, name, version); err != nil {
+		return fmt.Errorf("failed to deactivate previous prompt templates: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+)
+
+func TestPromptTemplates(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	ctx := context.Background()
+
+	mock.ExpectQuery("FROM prompt_templates").
+		WithArgs("analyze_email").
+		WillReturnError(pgx.ErrNoRows)
+	if text, err := repo.ActivePromptTemplate(ctx, "analyze_email"); err != nil || text != "" {
+		t.Fatalf("ActivePromptTemplate = %q, %v", text, err)
+	}
+
+	mock.ExpectExec("INSERT INTO prompt_templates").
+		WithArgs("analyze_email", "v5", "text").
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectExec("UPDATE prompt_templates SET active = FALSE").
+		WithArgs("analyze_email", "v5").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	if err := repo.SavePromptTemplate(ctx, "analyze_email", "v5", "text"); err != nil {
+		t.Fatalf("SavePromptTemplate: %v", err)
+	}
+
+	mock.ExpectExec("INSERT INTO prompt_templates").
+		WithArgs("analyze_email", "v5", "changed").
+		WillReturnResult(pgxmock.NewResult("INSERT", 0))
+	if err := repo.SavePromptTemplate(ctx, "analyze_email", "v5", "changed"); !errors.Is(err, ErrPromptVersionExists) {
+		t.Fatalf("expected ErrPromptVersionExists, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
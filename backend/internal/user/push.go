@@ -0,0 +1,92 @@
+package user
+
+import (
+	"context"
+	"fmt"
+)
+
+// PushSubscription is a browser registered for Web Push.
+type PushSubscription struct {
+	// Endpoint is the push service URL the browser handed out.
+	Endpoint string
+	// P256dh and Auth are the browser's base64url encoded public key and
+	// authentication secret, used to encrypt payloads.
+	P256dh string
+	Auth   string
+}
+
+// SavePushSubscription registers a browser for the user. Registering an
+// endpoint again replaces its keys and moves it to this user.
+func (r *PostgresRepository) SavePushSubscription(ctx context.Context, userID string, s *PushSubscription) error {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(ctx, `
+		INSERT INTO push_subscriptions (user_id, endpoint, p256dh, auth)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (endpoint) DO UPDATE SET
+			user_id = EXCLUDED.user_id,
+			p256dh = EXCLUDED.p256dh,
+			auth = EXCLUDED.auth`,
+		id, s.Endpoint, s.P256dh, s.Auth)
+	if err != nil {
+		return fmt.Errorf("failed to save push subscription: %w", err)
+	}
+	return nil
+}
+
+// DeletePushSubscription unregisters one of the user's browsers. It
+// reports whether the endpoint was registered to the user.
+func (r *PostgresRepository) DeletePushSubscription(ctx context.Context, userID, endpoint string) (bool, error) {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return false, err
+	}
+
+	tag, err := r.db.Exec(ctx, `DELETE FROM push_subscriptions WHERE user_id = $1 AND endpoint = $2`, id, endpoint)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete push subscription: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// DeleteExpiredPushSubscription removes an endpoint the push service
+// reported as gone, whoever it belongs to.
+func (r *PostgresRepository) DeleteExpiredPushSubscription(ctx context.Context, endpoint string) error {
+	if _, err := r.db.Exec(ctx, `DELETE FROM push_subscriptions WHERE endpoint = $1`, endpoint); err != nil {
+		return fmt.Errorf("failed to delete push subscription: %w", err)
+	}
+	return nil
+}
+
+// ListPushSubscriptions returns the browsers to notify for the user. It is
+// empty while the user has notifications turned off.
+func (r *PostgresRepository) ListPushSubscriptions(ctx context.Context, userID string) ([]PushSubscription, error) {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT s.endpoint, s.p256dh, s.auth
+		FROM push_subscriptions s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.user_id = $1 AND u.notifications_enabled
+		ORDER BY s.id`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list push subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var subs []PushSubscription
+	for rows.Next() {
+		var s PushSubscription
+		if err := rows.Scan(&s.Endpoint, &s.P256dh, &s.Auth); err != nil {
+			return nil, fmt.Errorf("failed to scan push subscription: %w", err)
+		}
+		subs = append(subs, s)
+	}
+	return subs, rows.Err()
+}
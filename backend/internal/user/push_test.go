@@ -0,0 +1,47 @@
+package user
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pashagolub/pgxmock/v4"
+)
+
+func TestPushSubscriptions(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	ctx := context.Background()
+	sub := &PushSubscription{Endpoint: "https://push.example/abc", P256dh: "BPk", Auth: "c2Vj"}
+
+	mock.ExpectExec("INSERT INTO push_subscriptions").
+		WithArgs(int64(3), sub.Endpoint, sub.P256dh, sub.Auth).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	if err := repo.SavePushSubscription(ctx, "3", sub); err != nil {
+		t.Fatalf("SavePushSubscription: %v", err)
+	}
+
+	mock.ExpectQuery("FROM push_subscriptions s\\s+JOIN users u").
+		WithArgs(int64(3)).
+		WillReturnRows(pgxmock.NewRows([]string{"endpoint", "p256dh", "auth"}).AddRow(sub.Endpoint, sub.P256dh, sub.Auth))
+	subs, err := repo.ListPushSubscriptions(ctx, "3")
+	if err != nil || len(subs) != 1 || subs[0] != *sub {
+		t.Fatalf("ListPushSubscriptions = %+v, %v", subs, err)
+	}
+
+	mock.ExpectExec("DELETE FROM push_subscriptions WHERE user_id").
+		WithArgs(int64(4), sub.Endpoint).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	if deleted, err := repo.DeletePushSubscription(ctx, "4", sub.Endpoint); err != nil || deleted {
+		t.Errorf("DeletePushSubscription for another user = %v, %v; want false", deleted, err)
+	}
+
+	mock.ExpectExec("DELETE FROM push_subscriptions WHERE endpoint").
+		WithArgs(sub.Endpoint).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	if err := repo.DeleteExpiredPushSubscription(ctx, sub.Endpoint); err != nil {
+		t.Errorf("DeleteExpiredPushSubscription: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -2,6 +2,8 @@ package user
 
 import (
 	"context"
+
+	"github.com/r7rainz/auramail/internal/eligibility"
 )
 
 type Repository interface {
@@ -31,4 +33,18 @@ type Repository interface {
 	ListUsersWithGoogleRefreshToken(ctx context.Context) ([]*User, error)
 
 	UpdateNotificationsEnabled(ctx context.Context, userID string, enabled bool) error
+
+	GetAcademicProfile(ctx context.Context, userID string) (*eligibility.Profile, error)
+
+	SaveAcademicProfile(ctx context.Context, userID string, profile *eligibility.Profile) error
+
+	GrantGoogleScope(ctx context.Context, userID, scope string) error
+
+	RevokeGoogleScope(ctx context.Context, userID, scope string) error
+
+	HasGoogleScope(ctx context.Context, userID, scope string) (bool, error)
+
+	GetPreferences(ctx context.Context, userID string) (*Preferences, error)
+
+	SavePreferences(ctx context.Context, userID string, p *Preferences) error
 }
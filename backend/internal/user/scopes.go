@@ -0,0 +1,54 @@
+package user
+
+import (
+	"context"
+	"fmt"
+)
+
+// GrantGoogleScope records that the user granted an optional Google scope.
+func (r *PostgresRepository) GrantGoogleScope(ctx context.Context, userID, scope string) error {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(ctx, `
+		INSERT INTO google_scope_grants (user_id, scope, granted_at)
+		VALUES ($1, $2, now())
+		ON CONFLICT (user_id, scope) DO UPDATE SET granted_at = EXCLUDED.granted_at`, id, scope)
+	if err != nil {
+		return fmt.Errorf("failed to record scope grant: %w", err)
+	}
+	return nil
+}
+
+// RevokeGoogleScope forgets an optional scope grant. The refresh token
+// keeps the scope until the user revokes access in their Google account,
+// but the app stops using it.
+func (r *PostgresRepository) RevokeGoogleScope(ctx context.Context, userID, scope string) error {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.db.Exec(ctx, `DELETE FROM google_scope_grants WHERE user_id = $1 AND scope = $2`, id, scope); err != nil {
+		return fmt.Errorf("failed to revoke scope grant: %w", err)
+	}
+	return nil
+}
+
+// HasGoogleScope reports whether the user granted an optional scope.
+func (r *PostgresRepository) HasGoogleScope(ctx context.Context, userID, scope string) (bool, error) {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return false, err
+	}
+
+	var granted bool
+	err = r.db.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM google_scope_grants WHERE user_id = $1 AND scope = $2)`, id, scope).Scan(&granted)
+	if err != nil {
+		return false, fmt.Errorf("failed to check scope grant: %w", err)
+	}
+	return granted, nil
+}
@@ -0,0 +1,39 @@
+package user
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pashagolub/pgxmock/v4"
+)
+
+func TestGoogleScopeGrants(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	const scope = "https://www.googleapis.com/auth/gmail.compose"
+
+	mock.ExpectExec("INSERT INTO google_scope_grants").
+		WithArgs(int64(3), scope).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	if err := repo.GrantGoogleScope(context.Background(), "3", scope); err != nil {
+		t.Fatalf("GrantGoogleScope: %v", err)
+	}
+
+	mock.ExpectQuery("FROM google_scope_grants").
+		WithArgs(int64(3), scope).
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+	granted, err := repo.HasGoogleScope(context.Background(), "3", scope)
+	if err != nil || !granted {
+		t.Fatalf("HasGoogleScope = %v, %v", granted, err)
+	}
+
+	mock.ExpectExec("DELETE FROM google_scope_grants").
+		WithArgs(int64(3), scope).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	if err := repo.RevokeGoogleScope(context.Background(), "3", scope); err != nil {
+		t.Fatalf("RevokeGoogleScope: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,280 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/compensation"
+)
+
+// Summary sort orders accepted by ListSummaries.
+const (
+	SortNewest  = "newest"
+	SortCTCDesc = "ctc_desc"
+	SortCTCAsc  = "ctc_asc"
+)
+
+// SummaryFilter narrows ListSummaries. The zero value lists the newest 50
+// summaries for the user.
+type SummaryFilter struct {
+	// Query is matched case-insensitively against summary, company and role.
+	Query string
+	// MinCTC keeps summaries in Currency whose annualized upper bound is
+	// at least this amount. Summaries without a parsed amount are excluded
+	// when it is set.
+	MinCTC *float64
+	// Currency is the ISO code MinCTC and the CTC sorts compare amounts in;
+	// summaries in other currencies are excluded by MinCTC and sorted
+	// last. Empty means compensation.DefaultCurrency.
+	Currency string
+	// Kind restricts to compensation.KindCTC or compensation.KindStipend.
+	Kind compensation.Kind
+	Sort string
+	// Since keeps summaries stored at or after this time when set.
+	Since time.Time
+	// Limit defaults to 50.
+	Limit int
+}
+
+// summaryOrderBy maps sorts to ORDER BY clauses; $currency stands for the
+// filter's currency parameter.
+var summaryOrderBy = map[string]string{
+	"":          "created_at DESC",
+	SortNewest:  "created_at DESC",
+	SortCTCDesc: "CASE WHEN comp_currency = $currency THEN comp_max_annual END DESC NULLS LAST, created_at DESC",
+	SortCTCAsc:  "CASE WHEN comp_currency = $currency THEN comp_min_annual END ASC NULLS LAST, created_at DESC",
+}
+
+// ValidSummarySort reports whether sort is accepted by ListSummaries.
+func ValidSummarySort(sort string) bool {
+	_, ok := summaryOrderBy[sort]
+	return ok
+}
+
+// ListSummaries returns the user's summaries matching filter.
+func (r *PostgresRepository) ListSummaries(ctx context.Context, userID string, filter SummaryFilter) ([]*ai.AIResult, error) {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	orderBy, ok := summaryOrderBy[filter.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unsupported sort %q", filter.Sort)
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+
+	conds := []string{"user_id = $1", "(summary ILIKE $2 OR company ILIKE $2 OR role ILIKE $2)"}
+	args := []any{id, "%" + filter.Query + "%"}
+	currency := filter.Currency
+	if currency == "" {
+		currency = compensation.DefaultCurrency
+	}
+	if filter.MinCTC != nil {
+		args = append(args, currency, *filter.MinCTC)
+		conds = append(conds, fmt.Sprintf("comp_currency = $%d AND comp_max_annual >= $%d", len(args)-1, len(args)))
+	}
+	if filter.Kind != "" {
+		args = append(args, string(filter.Kind))
+		conds = append(conds, fmt.Sprintf("comp_kind = $%d", len(args)))
+	}
+	if !filter.Since.IsZero() {
+		args = append(args, filter.Since)
+		conds = append(conds, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if strings.Contains(orderBy, "$currency") {
+		args = append(args, currency)
+		orderBy = strings.ReplaceAll(orderBy, "$currency", fmt.Sprintf("$%d", len(args)))
+	}
+	args = append(args, limit)
+
+	query := fmt.Sprintf(`
+		SELECT data
+		FROM email_summaries
+		WHERE %s
+		ORDER BY %s
+		LIMIT $%d`, strings.Join(conds, " AND "), orderBy, len(args))
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*ai.AIResult
+	for rows.Next() {
+		var jsonData []byte
+		if err := rows.Scan(&jsonData); err != nil {
+			continue
+		}
+		var res ai.AIResult
+		if err := json.Unmarshal(jsonData, &res); err != nil {
+			continue
+		}
+		results = append(results, &res)
+	}
+
+	return results, nil
+}
+
+// compensationColumns are the email_summaries comp_* values for one result.
+type compensationColumns struct {
+	currency  *string
+	minAnnual *float64
+	maxAnnual *float64
+	kind      *string
+	raw       *string
+}
+
+// normalizeCompensation parses res.Salary, stores the result on
+// res.Compensation and returns the matching column values.
+func normalizeCompensation(res *ai.AIResult) compensationColumns {
+	var cols compensationColumns
+	raw := strings.TrimSpace(ai.FieldText(res.Salary))
+	res.Compensation = compensation.Parse(raw)
+	if raw == "" {
+		return cols
+	}
+	cols.raw = &raw
+	if c := res.Compensation; c != nil {
+		kind := string(c.Kind)
+		cols.currency = &c.Currency
+		cols.minAnnual = &c.MinAnnual
+		cols.maxAnnual = &c.MaxAnnual
+		cols.kind = &kind
+	}
+	return cols
+}
+
+// BackfillCompensation fills the comp_* columns for summaries saved before
+// compensation was normalized. It returns the number of rows updated.
+func (r *PostgresRepository) BackfillCompensation(ctx context.Context) (int, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT gmail_id, data
+		FROM email_summaries
+		WHERE comp_raw IS NULL AND jsonb_typeof(data->'salary') IN ('string', 'array')`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list summaries for compensation backfill: %w", err)
+	}
+
+	type pending struct {
+		gmailID string
+		res     ai.AIResult
+	}
+	var todo []pending
+	for rows.Next() {
+		var (
+			p        pending
+			jsonData []byte
+		)
+		if err := rows.Scan(&p.gmailID, &jsonData); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if err := json.Unmarshal(jsonData, &p.res); err != nil {
+			continue
+		}
+		todo = append(todo, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for _, p := range todo {
+		cols := normalizeCompensation(&p.res)
+		if cols.raw == nil {
+			continue
+		}
+		compJSON, err := json.Marshal(p.res.Compensation)
+		if err != nil {
+			return updated, err
+		}
+		_, err = r.db.Exec(ctx, `
+			UPDATE email_summaries
+			SET comp_currency = $1, comp_min_annual = $2, comp_max_annual = $3, comp_kind = $4, comp_raw = $5,
+				data = jsonb_set(data, '{compensation}', $6::jsonb, true)
+			WHERE gmail_id = $7`,
+			cols.currency, cols.minAnnual, cols.maxAnnual, cols.kind, cols.raw, compJSON, p.gmailID,
+		)
+		if err != nil {
+			return updated, fmt.Errorf("failed to backfill compensation for %s: %w", p.gmailID, err)
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// CompensationStat summarizes parsed compensation for one group (a company
+// or a category) in one currency and kind. Amounts are annualized; Median
+// and Average use the midpoint of each summary's range.
+type CompensationStat struct {
+	Key      string            `json:"key"`
+	Currency string            `json:"currency"`
+	Kind     compensation.Kind `json:"kind"`
+	Count    int64             `json:"count"`
+	Min      float64           `json:"min"`
+	Max      float64           `json:"max"`
+	Median   float64           `json:"median"`
+	Average  float64           `json:"average"`
+}
+
+// CompensationStats groups the user's parsed compensation by company and by
+// category.
+func (r *PostgresRepository) CompensationStats(ctx context.Context, userID string) (byCompany, byCategory []CompensationStat, err error) {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if byCompany, err = r.compensationStats(ctx, id, "COALESCE(NULLIF(company, ''), 'Unknown')"); err != nil {
+		return nil, nil, err
+	}
+	if byCategory, err = r.compensationStats(ctx, id, "category"); err != nil {
+		return nil, nil, err
+	}
+	return byCompany, byCategory, nil
+}
+
+// compensationStats aggregates by groupExpr, which must be a trusted SQL
+// expression over email_summaries columns.
+func (r *PostgresRepository) compensationStats(ctx context.Context, userID int64, groupExpr string) ([]CompensationStat, error) {
+	query := fmt.Sprintf(`
+		SELECT %[1]s AS key, comp_currency, comp_kind, COUNT(*),
+		       MIN(comp_min_annual), MAX(comp_max_annual),
+		       percentile_cont(0.5) WITHIN GROUP (ORDER BY (comp_min_annual + comp_max_annual) / 2),
+		       AVG((comp_min_annual + comp_max_annual) / 2)
+		FROM email_summaries
+		WHERE user_id = $1 AND comp_max_annual IS NOT NULL
+		GROUP BY %[1]s, comp_currency, comp_kind
+		ORDER BY MAX(comp_max_annual) DESC`, groupExpr)
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load compensation stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := make([]CompensationStat, 0)
+	for rows.Next() {
+		var (
+			s    CompensationStat
+			kind string
+		)
+		if err := rows.Scan(&s.Key, &s.Currency, &kind, &s.Count, &s.Min, &s.Max, &s.Median, &s.Average); err != nil {
+			return nil, err
+		}
+		s.Kind = compensation.Kind(kind)
+		stats = append(stats, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
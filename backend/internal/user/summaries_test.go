@@ -0,0 +1,155 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/compensation"
+)
+
+func TestListSummaries(t *testing.T) {
+	t.Run("default filter", func(t *testing.T) {
+		repo, mock := newMockRepo(t)
+		data, _ := json.Marshal(ai.AIResult{Category: "internship", Summary: "s"})
+		mock.ExpectQuery(`WHERE user_id = \$1 AND \(summary ILIKE \$2 OR company ILIKE \$2 OR role ILIKE \$2\)\s+ORDER BY created_at DESC\s+LIMIT \$3`).
+			WithArgs(int64(2), "%acme%", 50).
+			WillReturnRows(pgxmock.NewRows([]string{"data"}).AddRow(data))
+
+		got, err := repo.GetSummariesByQuery(context.Background(), "2", "acme")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(got) != 1 || got[0].Category != "internship" {
+			t.Fatalf("unexpected results: %+v", got)
+		}
+	})
+
+	t.Run("compensation filter and sort", func(t *testing.T) {
+		repo, mock := newMockRepo(t)
+		min := 1_000_000.0
+		mock.ExpectQuery(`comp_currency = \$3 AND comp_max_annual >= \$4 AND comp_kind = \$5\s+ORDER BY CASE WHEN comp_currency = \$6 THEN comp_max_annual END DESC NULLS LAST, created_at DESC\s+LIMIT \$7`).
+			WithArgs(int64(2), "%%", "USD", min, "ctc", "USD", 20).
+			WillReturnRows(pgxmock.NewRows([]string{"data"}))
+
+		_, err := repo.ListSummaries(context.Background(), "2", SummaryFilter{
+			MinCTC:   &min,
+			Currency: "USD",
+			Kind:     compensation.KindCTC,
+			Sort:     SortCTCDesc,
+			Limit:    20,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("compensation sort defaults to rupees", func(t *testing.T) {
+		repo, mock := newMockRepo(t)
+		mock.ExpectQuery(`ORDER BY CASE WHEN comp_currency = \$3 THEN comp_min_annual END ASC NULLS LAST, created_at DESC\s+LIMIT \$4`).
+			WithArgs(int64(2), "%%", "INR", 50).
+			WillReturnRows(pgxmock.NewRows([]string{"data"}))
+
+		if _, err := repo.ListSummaries(context.Background(), "2", SummaryFilter{Sort: SortCTCAsc}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("since", func(t *testing.T) {
+		repo, mock := newMockRepo(t)
+		since := time.Date(2026, 8, 10, 2, 30, 0, 0, time.UTC)
+		mock.ExpectQuery(`AND created_at >= \$3\s+ORDER BY created_at DESC\s+LIMIT \$4`).
+			WithArgs(int64(2), "%%", since, 50).
+			WillReturnRows(pgxmock.NewRows([]string{"data"}))
+
+		if _, err := repo.ListSummaries(context.Background(), "2", SummaryFilter{Since: since}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("unknown sort", func(t *testing.T) {
+		repo, _ := newMockRepo(t)
+		if _, err := repo.ListSummaries(context.Background(), "2", SummaryFilter{Sort: "salary; DROP TABLE"}); err == nil {
+			t.Fatal("expected error for unsupported sort")
+		}
+	})
+}
+
+func TestSaveSummary_NormalizesCompensation(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	res := &ai.AIResult{Category: "job offer", Summary: "s", Salary: "• CTC 8-10 LPA + joining bonus"}
+
+	raw := "• CTC 8-10 LPA + joining bonus"
+	currency, kind := "INR", "ctc"
+	min, max := 800_000.0, 1_000_000.0
+	mock.ExpectExec("INSERT INTO email_summaries").
+		WithArgs(int64(5), "g1", "", "job offer", res.Company, res.Role, "s", res.Deadline, res.ApplyLink, pgxmock.AnyArg(),
+			&currency, &min, &max, &kind, &raw, res.CompanyID, pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	if err := repo.SaveSummary(context.Background(), "5", "g1", res); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Compensation == nil || res.Compensation.MaxAnnual != max {
+		t.Errorf("Compensation = %+v", res.Compensation)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestBackfillCompensation(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	withSalary, _ := json.Marshal(ai.AIResult{Category: "internship", Summary: "s", Salary: "₹50k/month stipend"})
+	mock.ExpectQuery("WHERE comp_raw IS NULL").
+		WillReturnRows(pgxmock.NewRows([]string{"gmail_id", "data"}).AddRow("g1", withSalary))
+	mock.ExpectExec("UPDATE email_summaries").
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), "g1").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	n, err := repo.BackfillCompensation(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("updated = %d, want 1", n)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestCompensationStats(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	cols := []string{"key", "comp_currency", "comp_kind", "count", "min", "max", "median", "avg"}
+	mock.ExpectQuery("GROUP BY COALESCE").
+		WithArgs(int64(2)).
+		WillReturnRows(pgxmock.NewRows(cols).AddRow("Acme", "INR", "ctc", int64(2), 800_000.0, 1_200_000.0, 1_000_000.0, 1_000_000.0))
+	mock.ExpectQuery("GROUP BY category").
+		WithArgs(int64(2)).
+		WillReturnRows(pgxmock.NewRows(cols).AddRow("internship", "INR", "stipend", int64(1), 600_000.0, 600_000.0, 600_000.0, 600_000.0))
+
+	byCompany, byCategory, err := repo.CompensationStats(context.Background(), "2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(byCompany) != 1 || byCompany[0].Key != "Acme" || byCompany[0].Count != 2 {
+		t.Errorf("byCompany = %+v", byCompany)
+	}
+	if len(byCategory) != 1 || byCategory[0].Kind != compensation.KindStipend {
+		t.Errorf("byCategory = %+v", byCategory)
+	}
+}
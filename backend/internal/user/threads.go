@@ -0,0 +1,118 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/r7rainz/auramail/internal/ai"
+	"github.com/r7rainz/auramail/internal/revision"
+)
+
+// Thread rollup sources.
+const (
+	RollupSourceAI    = "ai"
+	RollupSourceRules = "rules"
+)
+
+// ThreadRollup is the stored "what changed" summary of a thread.
+// MessageCount and LatestGmailID identify the messages it was computed
+// from.
+type ThreadRollup struct {
+	ThreadID      string            `json:"threadId"`
+	MessageCount  int               `json:"messageCount"`
+	LatestGmailID string            `json:"latestGmailId"`
+	Summary       []string          `json:"summary"`
+	Changes       []revision.Change `json:"changes"`
+	Source        string            `json:"source"`
+	UpdatedAt     time.Time         `json:"updatedAt"`
+}
+
+// ThreadSummaries returns the user's summaries in threadID, in no
+// particular order.
+func (r *PostgresRepository) ThreadSummaries(ctx context.Context, userID, threadID string) ([]*ai.AIResult, error) {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT data
+		FROM email_summaries
+		WHERE user_id = $1 AND thread_id = $2`, id, threadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load thread summaries: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]*ai.AIResult, 0)
+	for rows.Next() {
+		var jsonData []byte
+		if err := rows.Scan(&jsonData); err != nil {
+			continue
+		}
+		var res ai.AIResult
+		if err := json.Unmarshal(jsonData, &res); err != nil {
+			continue
+		}
+		results = append(results, &res)
+	}
+	return results, nil
+}
+
+// GetThreadRollup returns the stored rollup for a thread, or pgx.ErrNoRows
+// when none has been computed.
+func (r *PostgresRepository) GetThreadRollup(ctx context.Context, userID, threadID string) (*ThreadRollup, error) {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		jsonData  []byte
+		updatedAt time.Time
+	)
+	err = r.db.QueryRow(ctx, `
+		SELECT data, updated_at
+		FROM thread_rollups
+		WHERE user_id = $1 AND thread_id = $2`, id, threadID).Scan(&jsonData, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	var rollup ThreadRollup
+	if err := json.Unmarshal(jsonData, &rollup); err != nil {
+		return nil, err
+	}
+	rollup.UpdatedAt = updatedAt
+	return &rollup, nil
+}
+
+// SaveThreadRollup creates or replaces the rollup for rollup.ThreadID.
+func (r *PostgresRepository) SaveThreadRollup(ctx context.Context, userID string, rollup *ThreadRollup) error {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	jsonData, err := json.Marshal(rollup)
+	if err != nil {
+		return fmt.Errorf("failed to marshal thread rollup: %w", err)
+	}
+
+	_, err = r.db.Exec(ctx, `
+		INSERT INTO thread_rollups (user_id, thread_id, message_count, latest_gmail_id, data, updated_at)
+		VALUES ($1, $2, $3, $4, $5, now())
+		ON CONFLICT (user_id, thread_id) DO UPDATE SET
+			message_count = EXCLUDED.message_count,
+			latest_gmail_id = EXCLUDED.latest_gmail_id,
+			data = EXCLUDED.data,
+			updated_at = EXCLUDED.updated_at`,
+		id, rollup.ThreadID, rollup.MessageCount, rollup.LatestGmailID, jsonData,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save thread rollup: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+
+	"github.com/r7rainz/auramail/internal/revision"
+)
+
+func TestThreadRollupRoundTrip(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	rollup := &ThreadRollup{
+		ThreadID:      "t1",
+		MessageCount:  2,
+		LatestGmailID: "m2",
+		Summary:       []string{"Deadline extended from 2026-08-12 to 2026-08-15"},
+		Changes:       []revision.Change{{Field: "deadline", From: "2026-08-12", To: "2026-08-15", GmailMessageID: "m2"}},
+		Source:        RollupSourceRules,
+	}
+
+	mock.ExpectExec("INSERT INTO thread_rollups").
+		WithArgs(int64(3), "t1", 2, "m2", pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	if err := repo.SaveThreadRollup(context.Background(), "3", rollup); err != nil {
+		t.Fatalf("SaveThreadRollup: %v", err)
+	}
+
+	data, _ := json.Marshal(rollup)
+	updated := time.Date(2026, 8, 10, 9, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("FROM thread_rollups").
+		WithArgs(int64(3), "t1").
+		WillReturnRows(pgxmock.NewRows([]string{"data", "updated_at"}).AddRow(data, updated))
+
+	got, err := repo.GetThreadRollup(context.Background(), "3", "t1")
+	if err != nil {
+		t.Fatalf("GetThreadRollup: %v", err)
+	}
+	if got.LatestGmailID != "m2" || len(got.Changes) != 1 || !got.UpdatedAt.Equal(updated) {
+		t.Errorf("unexpected rollup: %+v", got)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
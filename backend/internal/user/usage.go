@@ -0,0 +1,176 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/r7rainz/auramail/internal/ai"
+)
+
+// AIUsage is one aggregated ai_usage row: everything a user spent on a given
+// day for one operation, Gmail query and model.
+type AIUsage struct {
+	UserID           string
+	Email            string
+	Day              time.Time
+	Operation        string
+	Query            string
+	Model            string
+	Requests         int64
+	PromptTokens     int64
+	CompletionTokens int64
+	CostUSD          float64
+}
+
+// AIUsageTotals is a user's usage summed over a single day.
+type AIUsageTotals struct {
+	Requests         int64
+	PromptTokens     int64
+	CompletionTokens int64
+	CostUSD          float64
+}
+
+// TotalTokens returns prompt plus completion tokens.
+func (t AIUsageTotals) TotalTokens() int64 {
+	return t.PromptTokens + t.CompletionTokens
+}
+
+// AIBudget is a per-user override of the configured daily budgets. A nil
+// field falls back to the configured default; zero means unlimited.
+type AIBudget struct {
+	DailyTokenLimit   *int64
+	DailyCostLimitUSD *float64
+}
+
+// usageDay truncates t to the UTC calendar day usage is bucketed by.
+func usageDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// RecordAIUsage adds one completion's token counts to the user's bucket for
+// the current day.
+func (r *PostgresRepository) RecordAIUsage(ctx context.Context, userID string, query string, usage ai.Usage) error {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	q := `
+		INSERT INTO ai_usage (user_id, usage_date, operation, query, model, requests, prompt_tokens, completion_tokens, cost_usd)
+		VALUES ($1, $2, $3, $4, $5, 1, $6, $7, $8)
+		ON CONFLICT (user_id, usage_date, operation, query, model) DO UPDATE SET
+			requests = ai_usage.requests + 1,
+			prompt_tokens = ai_usage.prompt_tokens + EXCLUDED.prompt_tokens,
+			completion_tokens = ai_usage.completion_tokens + EXCLUDED.completion_tokens,
+			cost_usd = ai_usage.cost_usd + EXCLUDED.cost_usd,
+			updated_at = CURRENT_TIMESTAMP`
+
+	_, err = r.db.Exec(ctx, q,
+		id,
+		usageDay(time.Now()),
+		usage.Operation,
+		query,
+		usage.Model,
+		usage.PromptTokens,
+		usage.CompletionTokens,
+		usage.CostUSD,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record ai usage: %w", err)
+	}
+	return nil
+}
+
+// GetDailyAIUsage sums the user's usage for the UTC day containing day.
+func (r *PostgresRepository) GetDailyAIUsage(ctx context.Context, userID string, day time.Time) (*AIUsageTotals, error) {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	q := `
+		SELECT COALESCE(SUM(requests), 0), COALESCE(SUM(prompt_tokens), 0),
+		       COALESCE(SUM(completion_tokens), 0), COALESCE(SUM(cost_usd), 0)
+		FROM ai_usage
+		WHERE user_id = $1 AND usage_date = $2`
+
+	var totals AIUsageTotals
+	err = r.db.QueryRow(ctx, q, id, usageDay(day)).Scan(
+		&totals.Requests,
+		&totals.PromptTokens,
+		&totals.CompletionTokens,
+		&totals.CostUSD,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load daily ai usage: %w", err)
+	}
+	return &totals, nil
+}
+
+// GetAIBudget returns the user's budget override, or pgx.ErrNoRows when the
+// configured defaults apply.
+func (r *PostgresRepository) GetAIBudget(ctx context.Context, userID string) (*AIBudget, error) {
+	id, err := parseUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var budget AIBudget
+	err = r.db.QueryRow(ctx,
+		`SELECT daily_token_limit, daily_cost_limit_usd FROM ai_budgets WHERE user_id = $1`,
+		id,
+	).Scan(&budget.DailyTokenLimit, &budget.DailyCostLimitUSD)
+	if err != nil {
+		return nil, err
+	}
+	return &budget, nil
+}
+
+// ListAIUsage returns every usage row between from and to (inclusive UTC
+// days), joined with the owning user's email.
+func (r *PostgresRepository) ListAIUsage(ctx context.Context, from, to time.Time) ([]AIUsage, error) {
+	q := `
+		SELECT u.user_id, COALESCE(users.email, ''), u.usage_date, u.operation, u.query, u.model,
+		       u.requests, u.prompt_tokens, u.completion_tokens, u.cost_usd
+		FROM ai_usage u
+		LEFT JOIN users ON users.id = u.user_id
+		WHERE u.usage_date BETWEEN $1 AND $2
+		ORDER BY u.usage_date DESC, u.cost_usd DESC`
+
+	rows, err := r.db.Query(ctx, q, usageDay(from), usageDay(to))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ai usage: %w", err)
+	}
+	defer rows.Close()
+
+	usage := make([]AIUsage, 0)
+	for rows.Next() {
+		var (
+			row AIUsage
+			id  int64
+		)
+		if err := rows.Scan(
+			&id,
+			&row.Email,
+			&row.Day,
+			&row.Operation,
+			&row.Query,
+			&row.Model,
+			&row.Requests,
+			&row.PromptTokens,
+			&row.CompletionTokens,
+			&row.CostUSD,
+		); err != nil {
+			return nil, err
+		}
+		row.UserID = strconv.FormatInt(id, 10)
+		usage = append(usage, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
@@ -0,0 +1,109 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+
+	"github.com/r7rainz/auramail/internal/ai"
+)
+
+func TestRecordAIUsage(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	usage := ai.Usage{
+		Operation:        ai.OperationAnalyzeEmail,
+		Model:            "gpt-4o-mini",
+		PromptTokens:     1200,
+		CompletionTokens: 300,
+		CostUSD:          0.00036,
+	}
+	mock.ExpectExec("INSERT INTO ai_usage").
+		WithArgs(int64(3), pgxmock.AnyArg(), usage.Operation, "subject:placement", usage.Model, 1200, 300, 0.00036).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	if err := repo.RecordAIUsage(context.Background(), "3", "subject:placement", usage); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestGetDailyAIUsage(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	day := time.Date(2026, 8, 3, 17, 30, 0, 0, time.UTC)
+	rows := pgxmock.NewRows([]string{"requests", "prompt", "completion", "cost"}).
+		AddRow(int64(4), int64(4000), int64(1000), 0.0012)
+	mock.ExpectQuery("FROM ai_usage").
+		WithArgs(int64(3), time.Date(2026, 8, 3, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(rows)
+
+	totals, err := repo.GetDailyAIUsage(context.Background(), "3", day)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if totals.TotalTokens() != 5000 || totals.Requests != 4 {
+		t.Errorf("unexpected totals: %+v", totals)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestGetAIBudget(t *testing.T) {
+	t.Run("override", func(t *testing.T) {
+		repo, mock := newMockRepo(t)
+		limit := int64(50000)
+		rows := pgxmock.NewRows([]string{"daily_token_limit", "daily_cost_limit_usd"}).AddRow(&limit, nil)
+		mock.ExpectQuery("SELECT daily_token_limit, daily_cost_limit_usd FROM ai_budgets").
+			WithArgs(int64(3)).
+			WillReturnRows(rows)
+
+		budget, err := repo.GetAIBudget(context.Background(), "3")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if budget.DailyTokenLimit == nil || *budget.DailyTokenLimit != limit {
+			t.Errorf("DailyTokenLimit = %v, want %d", budget.DailyTokenLimit, limit)
+		}
+		if budget.DailyCostLimitUSD != nil {
+			t.Errorf("DailyCostLimitUSD = %v, want nil", *budget.DailyCostLimitUSD)
+		}
+	})
+
+	t.Run("no override", func(t *testing.T) {
+		repo, mock := newMockRepo(t)
+		mock.ExpectQuery("FROM ai_budgets").
+			WithArgs(int64(3)).
+			WillReturnError(pgx.ErrNoRows)
+
+		if _, err := repo.GetAIBudget(context.Background(), "3"); !errors.Is(err, pgx.ErrNoRows) {
+			t.Fatalf("expected pgx.ErrNoRows, got %v", err)
+		}
+	})
+}
+
+func TestListAIUsage(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	day := time.Date(2026, 8, 3, 0, 0, 0, 0, time.UTC)
+	rows := pgxmock.NewRows([]string{"user_id", "email", "usage_date", "operation", "query", "model", "requests", "prompt", "completion", "cost"}).
+		AddRow(int64(1), "a@x.com", day, ai.OperationAnalyzeEmail, "q", "gpt-4o-mini", int64(2), int64(100), int64(20), 0.5)
+	mock.ExpectQuery("FROM ai_usage u").
+		WithArgs(day, day).
+		WillReturnRows(rows)
+
+	usage, err := repo.ListAIUsage(context.Background(), day, day.Add(3*time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(usage) != 1 || usage[0].UserID != "1" || usage[0].Email != "a@x.com" {
+		t.Fatalf("unexpected usage rows: %+v", usage)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS ai_usage (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    usage_date DATE NOT NULL,
+    operation TEXT NOT NULL,
+    query TEXT NOT NULL DEFAULT '',
+    model TEXT NOT NULL,
+    requests INTEGER NOT NULL DEFAULT 0,
+    prompt_tokens BIGINT NOT NULL DEFAULT 0,
+    completion_tokens BIGINT NOT NULL DEFAULT 0,
+    cost_usd DOUBLE PRECISION NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, usage_date, operation, query, model)
+);
+
+CREATE INDEX idx_ai_usage_date ON ai_usage(usage_date);
+
+-- Per-user overrides of the configured daily budgets. NULL means "use the
+-- configured default"; 0 means unlimited.
+CREATE TABLE IF NOT EXISTS ai_budgets (
+    user_id INTEGER PRIMARY KEY,
+    daily_token_limit BIGINT,
+    daily_cost_limit_usd DOUBLE PRECISION,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS ai_budgets;
+DROP TABLE IF EXISTS ai_usage;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- One academic profile per user, matched against the eligibility criteria
+-- parsed from placement emails. NULL columns are "not provided".
+CREATE TABLE IF NOT EXISTS academic_profiles (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    degree TEXT NOT NULL DEFAULT '',
+    branch TEXT NOT NULL DEFAULT '',
+    passout_year INTEGER,
+    cgpa DOUBLE PRECISION,
+    tenth_percent DOUBLE PRECISION,
+    twelfth_percent DOUBLE PRECISION,
+    active_backlogs INTEGER,
+    backlog_history BOOLEAN,
+    gender TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS academic_profiles;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Normalized compensation parsed from data->'salary'. comp_raw holds the
+-- salary text whenever there is one; the amount columns stay NULL when no
+-- figure could be recognised. Amounts are annualized, in comp_currency units.
+ALTER TABLE email_summaries
+    ADD COLUMN IF NOT EXISTS comp_currency TEXT,
+    ADD COLUMN IF NOT EXISTS comp_min_annual DOUBLE PRECISION,
+    ADD COLUMN IF NOT EXISTS comp_max_annual DOUBLE PRECISION,
+    ADD COLUMN IF NOT EXISTS comp_kind TEXT,
+    ADD COLUMN IF NOT EXISTS comp_raw TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_summaries_user_comp_max ON email_summaries(user_id, comp_max_annual);
+CREATE INDEX IF NOT EXISTS idx_summaries_user_comp_min ON email_summaries(user_id, comp_min_annual);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_summaries_user_comp_min;
+DROP INDEX IF EXISTS idx_summaries_user_comp_max;
+ALTER TABLE email_summaries
+    DROP COLUMN IF EXISTS comp_raw,
+    DROP COLUMN IF EXISTS comp_kind,
+    DROP COLUMN IF EXISTS comp_max_annual,
+    DROP COLUMN IF EXISTS comp_min_annual,
+    DROP COLUMN IF EXISTS comp_currency;
+-- +goose StatementEnd
@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Canonical recruiters shared by all users. name_key is company.Key(name)
+-- and keeps the resolver from creating the same company twice; aliases
+-- collect the raw names analysis has returned for it.
+CREATE TABLE IF NOT EXISTS companies (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    name_key TEXT NOT NULL UNIQUE,
+    aliases TEXT[] NOT NULL DEFAULT '{}',
+    domains TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE email_summaries
+    ADD COLUMN IF NOT EXISTS company_id INTEGER REFERENCES companies(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_summaries_user_company ON email_summaries(user_id, company_id);
+
+INSERT INTO companies (name, name_key, aliases, domains) VALUES
+    ('Tata Consultancy Services', 'tata consultancy services', '{TCS}', '{tcs.com}'),
+    ('Infosys', 'infosys', '{}', '{infosys.com}'),
+    ('Wipro', 'wipro', '{}', '{wipro.com}'),
+    ('Accenture', 'accenture', '{}', '{accenture.com}'),
+    ('Cognizant', 'cognizant', '{CTS,Cognizant Technology Solutions}', '{cognizant.com}'),
+    ('HCLTech', 'hcltech', '{HCL,HCL Technologies}', '{hcltech.com,hcl.com}'),
+    ('Capgemini', 'capgemini', '{}', '{capgemini.com}'),
+    ('Tech Mahindra', 'tech mahindra', '{}', '{techmahindra.com}'),
+    ('Deloitte', 'deloitte', '{}', '{deloitte.com}'),
+    ('IBM', 'ibm', '{International Business Machines}', '{ibm.com}'),
+    ('Amazon', 'amazon', '{}', '{amazon.com,amazon.jobs}'),
+    ('Microsoft', 'microsoft', '{}', '{microsoft.com}'),
+    ('Google', 'google', '{}', '{google.com}')
+ON CONFLICT (name_key) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_summaries_user_company;
+ALTER TABLE email_summaries DROP COLUMN IF EXISTS company_id;
+DROP TABLE IF EXISTS companies;
+-- +goose StatementEnd
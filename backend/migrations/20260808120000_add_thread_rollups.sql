@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- One "what changed" rollup per user thread. message_count and
+-- latest_gmail_id record which messages the rollup covers so a stale one
+-- can be detected without comparing the JSON.
+CREATE TABLE IF NOT EXISTS thread_rollups (
+    user_id INTEGER NOT NULL,
+    thread_id TEXT NOT NULL,
+    message_count INTEGER NOT NULL,
+    latest_gmail_id TEXT NOT NULL,
+    data JSONB NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, thread_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_summaries_user_thread ON email_summaries(user_id, thread_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_summaries_user_thread;
+DROP TABLE IF EXISTS thread_rollups;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Near-duplicate detection. opportunity_id is the gmail_id of the first
+-- email seen for an opportunity; reminders, extensions and corrigenda that
+-- arrive in new threads share it. subject_key and simhash are the stored
+-- parts of the dedupe fingerprint.
+ALTER TABLE email_summaries
+    ADD COLUMN IF NOT EXISTS opportunity_id TEXT,
+    ADD COLUMN IF NOT EXISTS subject_key TEXT,
+    ADD COLUMN IF NOT EXISTS simhash BIGINT;
+
+CREATE INDEX IF NOT EXISTS idx_summaries_user_opportunity ON email_summaries(user_id, opportunity_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_summaries_user_opportunity;
+ALTER TABLE email_summaries
+    DROP COLUMN IF EXISTS simhash,
+    DROP COLUMN IF EXISTS subject_key,
+    DROP COLUMN IF EXISTS opportunity_id;
+-- +goose StatementEnd
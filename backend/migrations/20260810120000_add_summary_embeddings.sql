@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Summary embeddings for semantic search, one row per summary and
+-- embedding provider. embedding is always populated; vec mirrors it as a
+-- pgvector column when the extension is available, and search falls back
+-- to ranking the real[] vectors in the application otherwise.
+CREATE TABLE IF NOT EXISTS summary_embeddings (
+    gmail_id TEXT NOT NULL REFERENCES email_summaries(gmail_id) ON DELETE CASCADE,
+    provider TEXT NOT NULL,
+    user_id INTEGER NOT NULL,
+    embedding REAL[] NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (gmail_id, provider)
+);
+
+CREATE INDEX IF NOT EXISTS idx_summary_embeddings_user_provider ON summary_embeddings(user_id, provider);
+
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_available_extensions WHERE name = 'vector') THEN
+        CREATE EXTENSION IF NOT EXISTS vector;
+        ALTER TABLE summary_embeddings ADD COLUMN IF NOT EXISTS vec vector;
+    END IF;
+END
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS summary_embeddings;
+-- +goose StatementEnd
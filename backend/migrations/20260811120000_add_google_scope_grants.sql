@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Optional Google scopes a user granted on top of the login scopes (e.g.
+-- gmail.compose for saving reply drafts). The stored refresh token covers
+-- every scope listed here.
+CREATE TABLE IF NOT EXISTS google_scope_grants (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    scope TEXT NOT NULL,
+    granted_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, scope)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS google_scope_grants;
+-- +goose StatementEnd
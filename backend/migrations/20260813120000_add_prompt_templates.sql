@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Prompt templates saved by admins. The newest active row for a name
+-- overrides the template on disk and the one embedded in the binary.
+CREATE TABLE IF NOT EXISTS prompt_templates (
+    name TEXT NOT NULL,
+    version TEXT NOT NULL,
+    template TEXT NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (name, version)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS prompt_templates;
+-- +goose StatementEnd
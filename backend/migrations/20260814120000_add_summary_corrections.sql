@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- User corrections to extracted fields, keyed by JSON field name. SaveSummary
+-- reapplies them over every re-analysis.
+ALTER TABLE email_summaries ADD COLUMN IF NOT EXISTS overrides JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+-- One evaluation case per corrected email: the anonymized email with the
+-- corrected values as expectations, and what the analyzer predicted.
+-- Rows outlive the summary retention window so the dataset keeps growing.
+CREATE TABLE IF NOT EXISTS summary_corrections (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    gmail_id TEXT NOT NULL,
+    analysis_version TEXT NOT NULL DEFAULT '',
+    predicted JSONB NOT NULL DEFAULT '{}'::jsonb,
+    eval_case JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, gmail_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS summary_corrections;
+ALTER TABLE email_summaries DROP COLUMN IF EXISTS overrides;
+-- +goose StatementEnd
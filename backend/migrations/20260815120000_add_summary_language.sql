@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Language summary bullets are written in (ISO 639-1). Extracted fields
+-- stay in English whatever the email or summary language.
+ALTER TABLE user_preferences
+    ADD COLUMN IF NOT EXISTS summary_language TEXT NOT NULL DEFAULT 'en';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE user_preferences DROP COLUMN IF EXISTS summary_language;
+-- +goose StatementEnd
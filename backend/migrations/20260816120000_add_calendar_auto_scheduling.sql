@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Per-user rules for putting deadlines, tests and interviews on the user's
+-- Google Calendar after each sync. Users without a row are not scheduled.
+CREATE TABLE IF NOT EXISTS calendar_rules (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    auto_schedule BOOLEAN NOT NULL DEFAULT FALSE,
+    categories TEXT[] NOT NULL DEFAULT '{internship,job offer,exam,interview,registration}',
+    reminder_minutes INTEGER[] NOT NULL DEFAULT '{60,1440}',
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Calendar events created automatically. event_key is the opportunity the
+-- email belongs to (or its gmail ID), so a reminder mail about the same
+-- opportunity updates the event instead of adding another one.
+CREATE TABLE IF NOT EXISTS calendar_links (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    event_key TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    gmail_id TEXT NOT NULL,
+    event_id TEXT NOT NULL,
+    title TEXT NOT NULL,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, event_key, kind)
+);
+
+CREATE INDEX IF NOT EXISTS idx_calendar_links_gmail ON calendar_links(user_id, gmail_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS calendar_links;
+DROP TABLE IF EXISTS calendar_rules;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- How AuraMail creates calendar events: the time zone times without an
+-- offset are read in, how long events last, whether date-only deadlines
+-- are all-day events, and reminders per event type.
+ALTER TABLE calendar_rules
+    ADD COLUMN IF NOT EXISTS reminder_sets JSONB NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS time_zone TEXT NOT NULL DEFAULT 'Asia/Kolkata',
+    ADD COLUMN IF NOT EXISTS event_minutes INTEGER NOT NULL DEFAULT 60,
+    ADD COLUMN IF NOT EXISTS all_day_deadlines BOOLEAN NOT NULL DEFAULT TRUE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE calendar_rules
+    DROP COLUMN IF EXISTS all_day_deadlines,
+    DROP COLUMN IF EXISTS event_minutes,
+    DROP COLUMN IF EXISTS time_zone,
+    DROP COLUMN IF EXISTS reminder_sets;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Secret iCalendar feed URLs. Only a SHA-256 hash of the token is kept;
+-- regenerating replaces it, which revokes the old URL.
+CREATE TABLE IF NOT EXISTS calendar_feed_tokens (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS calendar_feed_tokens;
+-- +goose StatementEnd
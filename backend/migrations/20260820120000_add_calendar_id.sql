@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The Google calendar AuraMail adds events to, e.g. a dedicated
+-- "Placements" calendar instead of the user's primary one.
+ALTER TABLE calendar_rules
+    ADD COLUMN IF NOT EXISTS calendar_id TEXT NOT NULL DEFAULT 'primary';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE calendar_rules
+    DROP COLUMN IF EXISTS calendar_id;
+-- +goose StatementEnd
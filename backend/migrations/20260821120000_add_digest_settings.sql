@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- When each user gets their digest email: daily or weekly (or off) at a
+-- local time, and when the last one was sent. Digests are opt-in: users
+-- without a row get none, and the defaults shown to them live in
+-- user.DefaultDigestSettings.
+CREATE TABLE IF NOT EXISTS digest_settings (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    frequency TEXT NOT NULL,
+    send_at TEXT NOT NULL,
+    weekday TEXT NOT NULL,
+    time_zone TEXT NOT NULL,
+    last_sent_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS digest_settings;
+-- +goose StatementEnd
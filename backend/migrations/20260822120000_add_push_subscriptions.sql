@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Browsers registered for Web Push. endpoint is the push service URL the
+-- browser handed out; p256dh and auth are its base64url keys for payload
+-- encryption (RFC 8291). A browser can only be subscribed by one user.
+CREATE TABLE IF NOT EXISTS push_subscriptions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    endpoint TEXT NOT NULL UNIQUE,
+    p256dh TEXT NOT NULL,
+    auth TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_push_subscriptions_user_id ON push_subscriptions(user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS push_subscriptions;
+-- +goose StatementEnd